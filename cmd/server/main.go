@@ -3,9 +3,11 @@ package main
 import (
 	"context"
 	"go-one/internal/api"
+	"go-one/internal/cache"
 	"go-one/internal/conf"
 	"go-one/internal/model"
 	"go-one/internal/server"
+	"go-one/internal/service"
 	"go-one/util"
 	"net/http"
 	"os"
@@ -27,6 +29,11 @@ func main() {
 	// 初始化Handler
 	api.HandlerApi = api.NewHandler(model.DB)
 
+	// 启动后台任务（数据导出、清理过期归档与彻底删除注销账号）
+	if err := service.StartAccountJobs(api.HandlerApi.ServiceManager()); err != nil {
+		util.Log().Panic("启动后台任务失败: %v", err)
+	}
+
 	// 创建路由
 	router := server.NewRouter()
 
@@ -66,6 +73,10 @@ func main() {
 		util.Log().Error("服务器关闭错误: %v", err)
 	}
 
+	// 停止后台任务
+	service.StopAccountJobs()
+	cache.ShutdownStreams()
+
 	// Flush sentry events on shutdown
 	sentry.Flush(2 * time.Second)
 
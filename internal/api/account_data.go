@@ -0,0 +1,82 @@
+package api
+
+import (
+	"go-one/internal/serializer"
+	"go-one/internal/service"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// DeleteAccountRequest 注销账号请求
+type DeleteAccountRequest struct {
+	Password string `json:"password" binding:"required"`
+}
+
+// RequestDataExport 申请导出当前用户的数据（后台异步生成归档）
+func (h *Handler) RequestDataExport(c *gin.Context) {
+	bizCtx := GetBusinessContext(c)
+
+	accountService := h.services(bizCtx).NewAccountDataService()
+	export, serviceErr := accountService.RequestExport(bizCtx)
+	if serviceErr != nil {
+		HandleServiceError(c, serviceErr)
+		return
+	}
+
+	c.JSON(http.StatusOK, serializer.Success("已提交导出申请，生成后可在导出记录中下载", serializer.BuildDataExportVTO(export, "")))
+}
+
+// ListDataExports 获取当前用户最近的数据导出记录
+func (h *Handler) ListDataExports(c *gin.Context) {
+	bizCtx := GetBusinessContext(c)
+
+	accountService := h.services(bizCtx).NewAccountDataService()
+	exports, serviceErr := accountService.ListExports(bizCtx)
+	if serviceErr != nil {
+		HandleServiceError(c, serviceErr)
+		return
+	}
+
+	list := make([]*serializer.DataExportVTO, len(exports))
+	for i, e := range exports {
+		list[i] = serializer.BuildDataExportVTO(e.Export, e.DownloadURL)
+	}
+	c.JSON(http.StatusOK, serializer.Success("获取成功", list))
+}
+
+// DownloadDataExport 凭下载链接中的令牌下载数据导出归档
+// 令牌自带所属租户，不依赖请求解析的租户
+func (h *Handler) DownloadDataExport(c *gin.Context) {
+	accountService := h.serviceManager.NewAccountDataService()
+	export, serviceErr := accountService.OpenExport(c.Query("token"))
+	if serviceErr != nil {
+		HandleServiceError(c, serviceErr)
+		return
+	}
+
+	c.FileAttachment(export.FilePath, "data-export-"+export.CreatedAt.Format("20060102")+".zip")
+}
+
+// DeleteAccount 验证当前密码并注销账号
+func (h *Handler) DeleteAccount(c *gin.Context) {
+	bizCtx := GetBusinessContext(c)
+
+	var req DeleteAccountRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, serializer.ParamErr("参数错误", err))
+		return
+	}
+
+	accountService := h.services(bizCtx).NewAccountDataService()
+	purgeAt, serviceErr := accountService.DeleteAccount(bizCtx, &service.DeleteAccountDTO{Password: req.Password})
+	if serviceErr != nil {
+		HandleServiceError(c, serviceErr)
+		return
+	}
+	if cookieMode(c) {
+		clearAuthCookies(c)
+	}
+
+	c.JSON(http.StatusOK, serializer.Success("账号已注销", &serializer.AccountDeletionVTO{PurgeAt: *purgeAt}))
+}
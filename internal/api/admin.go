@@ -0,0 +1,247 @@
+package api
+
+import (
+	"go-one/internal/serializer"
+	"go-one/internal/service"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// AssignRoleRequest 分配角色请求
+type AssignRoleRequest struct {
+	Role string `json:"role" binding:"required"`
+}
+
+// ListRoles 获取全部角色
+func (h *Handler) ListRoles(c *gin.Context) {
+	bizCtx := GetBusinessContext(c)
+
+	rbacService := h.services(bizCtx).NewRBACService()
+	roles, serviceErr := rbacService.ListRoles(bizCtx)
+	if serviceErr != nil {
+		HandleServiceError(c, serviceErr)
+		return
+	}
+
+	c.JSON(http.StatusOK, serializer.Success("获取成功", serializer.BuildRoleVTOs(roles)))
+}
+
+// GetUserRoles 获取指定用户的角色
+func (h *Handler) GetUserRoles(c *gin.Context) {
+	bizCtx := GetBusinessContext(c)
+
+	userID, ok := parseIDParam(c, "id")
+	if !ok {
+		return
+	}
+
+	rbacService := h.services(bizCtx).NewRBACService()
+	roles, serviceErr := rbacService.UserRoles(bizCtx, userID)
+	if serviceErr != nil {
+		HandleServiceError(c, serviceErr)
+		return
+	}
+
+	c.JSON(http.StatusOK, serializer.Success("获取成功", serializer.BuildRoleVTOs(roles)))
+}
+
+// AssignUserRole 为用户分配角色
+func (h *Handler) AssignUserRole(c *gin.Context) {
+	bizCtx := GetBusinessContext(c)
+
+	userID, ok := parseIDParam(c, "id")
+	if !ok {
+		return
+	}
+	var req AssignRoleRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, serializer.ParamErr("参数错误", err))
+		return
+	}
+
+	rbacService := h.services(bizCtx).NewRBACService()
+	if serviceErr := rbacService.AssignRole(bizCtx, userID, req.Role); serviceErr != nil {
+		HandleServiceError(c, serviceErr)
+		return
+	}
+
+	c.JSON(http.StatusOK, serializer.Success("角色已分配", nil))
+}
+
+// RevokeUserRole 撤销用户的角色
+func (h *Handler) RevokeUserRole(c *gin.Context) {
+	bizCtx := GetBusinessContext(c)
+
+	userID, ok := parseIDParam(c, "id")
+	if !ok {
+		return
+	}
+
+	rbacService := h.services(bizCtx).NewRBACService()
+	if serviceErr := rbacService.RevokeRole(bizCtx, userID, c.Param("role")); serviceErr != nil {
+		HandleServiceError(c, serviceErr)
+		return
+	}
+
+	c.JSON(http.StatusOK, serializer.Success("角色已撤销", nil))
+}
+
+// UnlockUser 解除用户的登录锁定
+func (h *Handler) UnlockUser(c *gin.Context) {
+	bizCtx := GetBusinessContext(c)
+
+	userID, ok := parseIDParam(c, "id")
+	if !ok {
+		return
+	}
+
+	userService := h.services(bizCtx).NewUserService()
+	if serviceErr := userService.UnlockUser(bizCtx, userID); serviceErr != nil {
+		HandleServiceError(c, serviceErr)
+		return
+	}
+
+	c.JSON(http.StatusOK, serializer.Success("登录锁定已解除", nil))
+}
+
+// UserStatusRequest 用户状态变更请求
+type UserStatusRequest struct {
+	Reason string     `json:"reason"`
+	Until  *time.Time `json:"until"` // 仅暂停时有效（RFC 3339），为空表示无限期
+}
+
+// SearchUsers 搜索用户
+func (h *Handler) SearchUsers(c *gin.Context) {
+	bizCtx := GetBusinessContext(c)
+
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	pageSize, _ := strconv.Atoi(c.DefaultQuery("page_size", "20"))
+	query := &service.SearchUsersQuery{
+		Keyword:  c.Query("keyword"),
+		Page:     page,
+		PageSize: pageSize,
+	}
+	if v := c.Query("status"); v != "" {
+		status, err := strconv.Atoi(v)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, serializer.ParamErr("无效的状态", err))
+			return
+		}
+		query.Status = &status
+	}
+
+	adminService := h.services(bizCtx).NewAdminUserService()
+	result, serviceErr := adminService.SearchUsers(bizCtx, query)
+	if serviceErr != nil {
+		HandleServiceError(c, serviceErr)
+		return
+	}
+
+	list := make([]*serializer.AdminUserVTO, len(result.List))
+	for i := range result.List {
+		list[i] = serializer.BuildAdminUserVTO(&result.List[i])
+	}
+	c.JSON(http.StatusOK, serializer.Success("获取成功", &serializer.AdminUserListVTO{
+		List:     list,
+		Total:    result.Total,
+		Page:     result.Page,
+		PageSize: result.PageSize,
+	}))
+}
+
+// GetUser 获取用户详情
+func (h *Handler) GetUser(c *gin.Context) {
+	bizCtx := GetBusinessContext(c)
+
+	userID, ok := parseIDParam(c, "id")
+	if !ok {
+		return
+	}
+
+	adminService := h.services(bizCtx).NewAdminUserService()
+	detail, serviceErr := adminService.GetUser(bizCtx, userID)
+	if serviceErr != nil {
+		HandleServiceError(c, serviceErr)
+		return
+	}
+
+	changes := make([]*serializer.UserStatusChangeVTO, len(detail.StatusChanges))
+	for i := range detail.StatusChanges {
+		changes[i] = serializer.BuildUserStatusChangeVTO(&detail.StatusChanges[i])
+	}
+	c.JSON(http.StatusOK, serializer.Success("获取成功", &serializer.AdminUserDetailVTO{
+		User:          serializer.BuildAdminUserVTO(detail.User),
+		StatusChanges: changes,
+	}))
+}
+
+// SuspendUser 暂停用户
+func (h *Handler) SuspendUser(c *gin.Context) {
+	h.changeUserStatus(c, "账号已暂停", (*service.AdminUserService).SuspendUser)
+}
+
+// UnsuspendUser 解除暂停
+func (h *Handler) UnsuspendUser(c *gin.Context) {
+	h.changeUserStatus(c, "账号已恢复", (*service.AdminUserService).UnsuspendUser)
+}
+
+// BanUser 封禁用户
+func (h *Handler) BanUser(c *gin.Context) {
+	h.changeUserStatus(c, "账号已封禁", (*service.AdminUserService).BanUser)
+}
+
+// DeleteUser 删除用户
+func (h *Handler) DeleteUser(c *gin.Context) {
+	h.changeUserStatus(c, "账号已删除", (*service.AdminUserService).DeleteUser)
+}
+
+// ForceLogoutUser 强制用户下线
+func (h *Handler) ForceLogoutUser(c *gin.Context) {
+	bizCtx := GetBusinessContext(c)
+
+	userID, ok := parseIDParam(c, "id")
+	if !ok {
+		return
+	}
+
+	adminService := h.services(bizCtx).NewAdminUserService()
+	if serviceErr := adminService.ForceLogout(bizCtx, userID); serviceErr != nil {
+		HandleServiceError(c, serviceErr)
+		return
+	}
+
+	c.JSON(http.StatusOK, serializer.Success("用户已下线", nil))
+}
+
+// changeUserStatus 状态变更接口的公共流程
+func (h *Handler) changeUserStatus(c *gin.Context, successMsg string,
+	change func(*service.AdminUserService, *service.BusinessContext, uint, *service.UserStatusDTO) service.ServiceError) {
+	bizCtx := GetBusinessContext(c)
+
+	userID, ok := parseIDParam(c, "id")
+	if !ok {
+		return
+	}
+	var req UserStatusRequest
+	if c.Request.ContentLength > 0 {
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, serializer.ParamErr("参数错误", err))
+			return
+		}
+	}
+
+	adminService := h.services(bizCtx).NewAdminUserService()
+	dto := &service.UserStatusDTO{
+		Reason: req.Reason,
+		Until:  req.Until,
+	}
+	if serviceErr := change(adminService, bizCtx, userID, dto); serviceErr != nil {
+		HandleServiceError(c, serviceErr)
+		return
+	}
+
+	c.JSON(http.StatusOK, serializer.Success(successMsg, nil))
+}
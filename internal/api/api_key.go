@@ -0,0 +1,88 @@
+package api
+
+import (
+	"go-one/internal/serializer"
+	"go-one/internal/service"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// CreateAPIKeyRequest 创建 API 密钥请求
+type CreateAPIKeyRequest struct {
+	Name          string   `json:"name" binding:"required,max=100"`
+	Scopes        []string `json:"scopes" binding:"required,min=1"`
+	AllowedIPs    []string `json:"allowed_ips"`
+	ExpiresInDays int      `json:"expires_in_days" binding:"omitempty,min=1,max=3650"` // 为空表示不过期
+}
+
+// ListAPIKeys 获取当前用户的 API 密钥
+func (h *Handler) ListAPIKeys(c *gin.Context) {
+	bizCtx := GetBusinessContext(c)
+
+	apiKeyService := h.services(bizCtx).NewAPIKeyService()
+	keys, serviceErr := apiKeyService.ListAPIKeys(bizCtx)
+	if serviceErr != nil {
+		HandleServiceError(c, serviceErr)
+		return
+	}
+
+	list := make([]*serializer.APIKeyVTO, len(keys))
+	for i := range keys {
+		list[i] = serializer.BuildAPIKeyVTO(&keys[i])
+	}
+	c.JSON(http.StatusOK, serializer.Success("获取成功", list))
+}
+
+// CreateAPIKey 创建 API 密钥
+func (h *Handler) CreateAPIKey(c *gin.Context) {
+	bizCtx := GetBusinessContext(c)
+
+	var req CreateAPIKeyRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, serializer.ParamErr("参数错误", err))
+		return
+	}
+
+	dto := &service.CreateAPIKeyDTO{
+		Name:       req.Name,
+		Scopes:     req.Scopes,
+		AllowedIPs: req.AllowedIPs,
+	}
+	if req.ExpiresInDays > 0 {
+		expiresAt := time.Now().AddDate(0, 0, req.ExpiresInDays)
+		dto.ExpiresAt = &expiresAt
+	}
+
+	apiKeyService := h.services(bizCtx).NewAPIKeyService()
+	result, serviceErr := apiKeyService.CreateAPIKey(bizCtx, dto)
+	if serviceErr != nil {
+		HandleServiceError(c, serviceErr)
+		return
+	}
+
+	vto := &serializer.CreatedAPIKeyVTO{
+		APIKeyVTO: serializer.BuildAPIKeyVTO(result.APIKey),
+		Key:       result.Key,
+	}
+	c.JSON(http.StatusOK, serializer.Success("创建成功，请妥善保存密钥，之后将无法再次查看", vto))
+}
+
+// RevokeAPIKey 撤销 API 密钥
+func (h *Handler) RevokeAPIKey(c *gin.Context) {
+	bizCtx := GetBusinessContext(c)
+
+	id, ok := parseIDParam(c, "id")
+	if !ok {
+		return
+	}
+
+	apiKeyService := h.services(bizCtx).NewAPIKeyService()
+	if serviceErr := apiKeyService.RevokeAPIKey(bizCtx, id); serviceErr != nil {
+		HandleServiceError(c, serviceErr)
+		return
+	}
+
+	c.JSON(http.StatusOK, serializer.Success("API 密钥已撤销", nil))
+}
@@ -0,0 +1,118 @@
+package api
+
+import (
+	"errors"
+	"go-one/internal/serializer"
+	"go-one/internal/service"
+	"go-one/util"
+	"io"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// authModeHeader 客户端选择令牌传输方式的请求头，值为 cookie 时启用 Cookie 模式
+const authModeHeader = "X-Auth-Mode"
+
+// cookieMode 请求是否选择了 Cookie 模式
+func cookieMode(c *gin.Context) bool {
+	return strings.EqualFold(c.GetHeader(authModeHeader), "cookie")
+}
+
+// setCookie 按统一的 Domain、Secure 与 SameSite 配置写入 Cookie
+func setCookie(c *gin.Context, name, value string, maxAge int, path string, httpOnly bool) {
+	cfg := service.AuthCookie
+	http.SetCookie(c.Writer, &http.Cookie{
+		Name:     name,
+		Value:    value,
+		MaxAge:   maxAge,
+		Path:     path,
+		Domain:   cfg.Domain,
+		Secure:   cfg.Secure,
+		HttpOnly: httpOnly,
+		SameSite: cfg.SameSite,
+	})
+}
+
+// setAuthCookies Cookie 模式下写入 refresh token 与新的 CSRF 令牌，返回 CSRF 令牌供响应体携带
+// CSRF Cookie 不设 HttpOnly 且路径为 /，同站前端可直接读取；跨站前端使用响应体中的值
+func setAuthCookies(c *gin.Context, refreshToken string) (string, error) {
+	csrfToken, err := util.RandomToken(32)
+	if err != nil {
+		return "", err
+	}
+	maxAge := int(service.JWT.RefreshTokenExpire.Seconds())
+	setCookie(c, service.AuthCookie.RefreshCookieName, refreshToken, maxAge, service.AuthCookie.Path, true)
+	setCookie(c, service.AuthCookie.CSRFCookieName, csrfToken, maxAge, "/", false)
+	return csrfToken, nil
+}
+
+// clearAuthCookies 清除 refresh token 与 CSRF Cookie
+func clearAuthCookies(c *gin.Context) {
+	setCookie(c, service.AuthCookie.RefreshCookieName, "", -1, service.AuthCookie.Path, true)
+	setCookie(c, service.AuthCookie.CSRFCookieName, "", -1, "/", false)
+}
+
+// refreshTokenFromCookie 读取 Cookie 中的 refresh token
+func refreshTokenFromCookie(c *gin.Context) string {
+	token, _ := c.Cookie(service.AuthCookie.RefreshCookieName)
+	return token
+}
+
+// issueRefreshToken 按认证模式下发 refresh token
+// Cookie 模式下写入 Cookie，返回空的 bodyToken（响应体不再携带）与新的 CSRF 令牌；失败时已写入响应
+func issueRefreshToken(c *gin.Context, refreshToken string, useCookie bool) (bodyToken, csrfToken string, ok bool) {
+	if !useCookie {
+		return refreshToken, "", true
+	}
+	csrfToken, err := setAuthCookies(c, refreshToken)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, serializer.Err(serializer.CodeError, "生成CSRF令牌失败", err))
+		return "", "", false
+	}
+	return "", csrfToken, true
+}
+
+// bindRefreshToken 读取 refresh token：优先取请求体，缺省时取 Cookie；fromCookie 表示取自 Cookie
+// 请求体可以为空，失败时已写入响应
+func bindRefreshToken(c *gin.Context) (token string, fromCookie bool, ok bool) {
+	var req RefreshTokenRequest
+	if err := c.ShouldBindJSON(&req); err != nil && !errors.Is(err, io.EOF) {
+		c.JSON(http.StatusBadRequest, serializer.ParamErr("参数错误", err))
+		return "", false, false
+	}
+	if req.RefreshToken != "" {
+		return req.RefreshToken, false, true
+	}
+	if token = refreshTokenFromCookie(c); token != "" {
+		return token, true, true
+	}
+	c.JSON(http.StatusBadRequest, serializer.ParamErr("缺少refresh_token", nil))
+	return "", false, false
+}
+
+// respondLoginResult 输出登录结果：需要二次验证时返回挑战，否则按认证模式下发令牌对
+func respondLoginResult(c *gin.Context, result *service.LoginResult) {
+	if result.MFARequired {
+		c.JSON(http.StatusOK, serializer.Success("需要二次验证", &serializer.MFAChallengeVTO{
+			MFARequired: true,
+			MFAToken:    result.MFAToken,
+			ExpiresIn:   int64(service.MFA.PendingTokenExpire.Seconds()),
+		}))
+		return
+	}
+
+	refreshToken, csrfToken, ok := issueRefreshToken(c, result.RefreshToken, cookieMode(c))
+	if !ok {
+		return
+	}
+	vto := &serializer.AuthTokenVTO{
+		User:         serializer.BuildUserVTO(result.User),
+		AccessToken:  result.AccessToken,
+		TokenType:    GetBusinessContext(c).TokenScheme(),
+		RefreshToken: refreshToken,
+		CSRFToken:    csrfToken,
+	}
+	c.JSON(http.StatusOK, serializer.Success("登录成功", vto))
+}
@@ -0,0 +1,126 @@
+package api
+
+import (
+	"go-one/internal/serializer"
+	"go-one/internal/service"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// AcceptConsentsRequest 同意协议请求
+type AcceptConsentsRequest struct {
+	PolicyVersionIDs []uint `json:"policy_version_ids" binding:"required,min=1,max=10"`
+}
+
+// PublishPolicyVersionRequest 发布协议版本请求
+type PublishPolicyVersionRequest struct {
+	Type        string     `json:"type" binding:"required,oneof=terms privacy"`
+	Version     string     `json:"version" binding:"required,max=50"`
+	URL         string     `json:"url" binding:"omitempty,url,max=512"`
+	Mandatory   *bool      `json:"mandatory"`    // 为空表示 true，已有用户须重新同意
+	EffectiveAt *time.Time `json:"effective_at"` // 为空表示立即生效
+}
+
+// ListCurrentPolicies 获取当前生效的协议版本（注册页面展示，注册时须全部同意）
+func (h *Handler) ListCurrentPolicies(c *gin.Context) {
+	consentService := h.serviceManager.NewConsentService()
+	versions, serviceErr := consentService.CurrentVersions()
+	if serviceErr != nil {
+		HandleServiceError(c, serviceErr)
+		return
+	}
+
+	c.JSON(http.StatusOK, serializer.Success("获取成功", serializer.BuildPolicyVersionVTOs(versions)))
+}
+
+// ListConsents 获取当前用户的协议同意状态与同意记录
+func (h *Handler) ListConsents(c *gin.Context) {
+	bizCtx := GetBusinessContext(c)
+
+	consentService := h.services(bizCtx).NewConsentService()
+	status, serviceErr := consentService.ListConsents(bizCtx)
+	if serviceErr != nil {
+		HandleServiceError(c, serviceErr)
+		return
+	}
+
+	vto := &serializer.ConsentStatusVTO{
+		Current: make([]*serializer.CurrentPolicyVTO, len(status.Current)),
+		Pending: serializer.BuildPolicyVersionVTOs(status.Missing),
+		History: make([]*serializer.UserConsentVTO, len(status.History)),
+	}
+	for i, current := range status.Current {
+		vto.Current[i] = &serializer.CurrentPolicyVTO{
+			PolicyVersionVTO: serializer.BuildPolicyVersionVTO(current.Version),
+			Accepted:         current.Accepted,
+		}
+	}
+	for i := range status.History {
+		detail := &status.History[i]
+		vto.History[i] = serializer.BuildUserConsentVTO(&detail.UserConsent, detail.Type, detail.Version, detail.URL)
+	}
+	c.JSON(http.StatusOK, serializer.Success("获取成功", vto))
+}
+
+// AcceptConsents 同意当前生效的协议版本
+func (h *Handler) AcceptConsents(c *gin.Context) {
+	bizCtx := GetBusinessContext(c)
+
+	var req AcceptConsentsRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, serializer.ParamErr("参数错误", err))
+		return
+	}
+
+	consentService := h.services(bizCtx).NewConsentService()
+	if serviceErr := consentService.Accept(bizCtx, &service.AcceptConsentsDTO{PolicyVersionIDs: req.PolicyVersionIDs}); serviceErr != nil {
+		HandleServiceError(c, serviceErr)
+		return
+	}
+
+	c.JSON(http.StatusOK, serializer.Success("已同意", nil))
+}
+
+// ListPolicyVersions 获取全部协议版本
+func (h *Handler) ListPolicyVersions(c *gin.Context) {
+	bizCtx := GetBusinessContext(c)
+
+	consentService := h.services(bizCtx).NewConsentService()
+	versions, serviceErr := consentService.ListVersions(bizCtx)
+	if serviceErr != nil {
+		HandleServiceError(c, serviceErr)
+		return
+	}
+
+	c.JSON(http.StatusOK, serializer.Success("获取成功", serializer.BuildPolicyVersionVTOs(versions)))
+}
+
+// PublishPolicyVersion 发布协议版本
+func (h *Handler) PublishPolicyVersion(c *gin.Context) {
+	bizCtx := GetBusinessContext(c)
+
+	var req PublishPolicyVersionRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, serializer.ParamErr("参数错误", err))
+		return
+	}
+
+	dto := &service.PublishPolicyDTO{
+		Type:        req.Type,
+		Version:     req.Version,
+		URL:         req.URL,
+		Mandatory:   req.Mandatory == nil || *req.Mandatory,
+		EffectiveAt: req.EffectiveAt,
+	}
+
+	consentService := h.services(bizCtx).NewConsentService()
+	version, serviceErr := consentService.PublishVersion(bizCtx, dto)
+	if serviceErr != nil {
+		HandleServiceError(c, serviceErr)
+		return
+	}
+
+	c.JSON(http.StatusOK, serializer.Success("发布成功", serializer.BuildPolicyVersionVTO(version)))
+}
@@ -4,6 +4,7 @@ import (
 	"go-one/internal/serializer"
 	"go-one/internal/service"
 	"net/http"
+	"strconv"
 
 	"github.com/gin-gonic/gin"
 )
@@ -17,8 +18,20 @@ func GetBusinessContext(c *gin.Context) *service.BusinessContext {
 	}
 	// 如果没有从中间件获取到，创建一个新的
 	return service.NewBusinessContext(c.Request.Context()).
+		WithTenant(c.GetUint("tenant_id")).
 		WithClientIP(c.ClientIP()).
-		WithUserAgent(c.GetHeader("User-Agent"))
+		WithUserAgent(c.GetHeader("User-Agent")).
+		WithDeviceName(c.GetHeader("X-Device-Name"))
+}
+
+// parseIDParam 解析路径中的数字ID，非法时直接返回 400
+func parseIDParam(c *gin.Context, name string) (uint, bool) {
+	id, err := strconv.ParseUint(c.Param(name), 10, 64)
+	if err != nil || id == 0 {
+		c.JSON(http.StatusBadRequest, serializer.ParamErr("无效的ID", err))
+		return 0, false
+	}
+	return uint(id), true
 }
 
 // HandleServiceError 处理ServiceError并转换为HTTP响应
@@ -32,18 +45,18 @@ func HandleServiceError(c *gin.Context, err service.ServiceError) {
 	message := err.GetMessage()
 
 	// 根据错误码范围判断HTTP状态码
+	// 400xx 通用错误码（含 40001/40003/40004/40009）沿用 400，保持与既有客户端兼容；
+	// 新增的细分错误码 401xx、403xx、429xx 按前三位映射
 	var httpStatus int
 	switch {
-	case code >= 40000 && code < 40100: // 参数验证错误
+	case code >= 40000 && code < 40100: // 参数验证等通用错误
 		httpStatus = http.StatusBadRequest
-	case code >= 40001 && code < 40002: // 认证错误
+	case code >= 40100 && code < 40200: // 认证错误
 		httpStatus = http.StatusUnauthorized
-	case code >= 40003 && code < 40004: // 权限错误
+	case code >= 40300 && code < 40400: // 权限错误
 		httpStatus = http.StatusForbidden
-	case code >= 40004 && code < 40005: // 未找到错误
-		httpStatus = http.StatusNotFound
-	case code >= 40009 && code < 40010: // 冲突错误（如重复）
-		httpStatus = http.StatusConflict
+	case code >= 42900 && code < 43000: // 请求过于频繁
+		httpStatus = http.StatusTooManyRequests
 	case code >= 50000: // 服务器错误
 		httpStatus = http.StatusInternalServerError
 	default:
@@ -51,7 +64,7 @@ func HandleServiceError(c *gin.Context, err service.ServiceError) {
 	}
 
 	// 根据错误类型选择序列化器
-	switch err.(type) {
+	switch e := err.(type) {
 	case *service.ValidationError:
 		c.JSON(httpStatus, serializer.ParamErr(message, err))
 	case *service.DatabaseError:
@@ -60,6 +73,23 @@ func HandleServiceError(c *gin.Context, err service.ServiceError) {
 		c.JSON(httpStatus, serializer.Err(serializer.CodeNotFound, message, nil))
 	case *service.AuthError:
 		c.JSON(httpStatus, serializer.Err(serializer.CodeUnauthorized, message, nil))
+	case *service.PasswordPolicyError:
+		// 返回全部违规项，便于客户端逐条提示
+		violations := make([]serializer.PasswordViolationVTO, len(e.Violations))
+		for i, v := range e.Violations {
+			violations[i] = serializer.PasswordViolationVTO{Rule: v.Rule, Message: v.Message}
+		}
+		res := serializer.Err(code, message, nil)
+		res.Data = &serializer.PasswordPolicyErrorVTO{Violations: violations}
+		c.JSON(httpStatus, res)
+	case *service.RegistrationError:
+		// 返回拒绝原因，便于客户端引导（如提示输入邀请码）
+		res := serializer.Err(code, message, nil)
+		res.Data = &serializer.RegistrationErrorVTO{Reason: e.Reason}
+		c.JSON(httpStatus, res)
+	case *service.RateLimitError:
+		c.Header("Retry-After", strconv.FormatInt(e.RetryAfterSeconds(), 10))
+		c.JSON(httpStatus, serializer.Err(code, message, nil))
 	default:
 		c.JSON(httpStatus, serializer.Err(code, message, nil))
 	}
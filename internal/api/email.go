@@ -0,0 +1,57 @@
+package api
+
+import (
+	"go-one/internal/serializer"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// VerifyEmailRequest 邮箱验证请求
+type VerifyEmailRequest struct {
+	Token string `json:"token" binding:"required"`
+}
+
+// ResendVerificationRequest 重发验证邮件请求
+type ResendVerificationRequest struct {
+	Email string `json:"email" binding:"required,email"`
+}
+
+// VerifyEmail 校验邮箱验证令牌
+func (h *Handler) VerifyEmail(c *gin.Context) {
+	bizCtx := GetBusinessContext(c)
+
+	var req VerifyEmailRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, serializer.ParamErr("参数错误", err))
+		return
+	}
+
+	emailService := h.services(bizCtx).NewEmailVerificationService()
+	user, serviceErr := emailService.VerifyEmail(bizCtx, req.Token)
+	if serviceErr != nil {
+		HandleServiceError(c, serviceErr)
+		return
+	}
+
+	c.JSON(http.StatusOK, serializer.Success("邮箱验证成功", serializer.BuildUserVTO(user)))
+}
+
+// ResendVerification 重发验证邮件
+func (h *Handler) ResendVerification(c *gin.Context) {
+	bizCtx := GetBusinessContext(c)
+
+	var req ResendVerificationRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, serializer.ParamErr("参数错误", err))
+		return
+	}
+
+	emailService := h.services(bizCtx).NewEmailVerificationService()
+	if serviceErr := emailService.ResendVerification(bizCtx, req.Email); serviceErr != nil {
+		HandleServiceError(c, serviceErr)
+		return
+	}
+
+	c.JSON(http.StatusOK, serializer.Success("如果该邮箱已注册且未验证，验证邮件已发送", nil))
+}
@@ -24,3 +24,13 @@ func NewHandler(db *gorm.DB) *Handler {
 	HandlerApi = handler
 	return handler
 }
+
+// services 返回限定在请求所属租户的服务管理器
+func (h *Handler) services(bizCtx *service.BusinessContext) *service.ServiceManager {
+	return h.serviceManager.ForTenant(bizCtx.TenantID)
+}
+
+// ServiceManager 返回服务管理器（供需要访问服务层的中间件使用）
+func (h *Handler) ServiceManager() *service.ServiceManager {
+	return h.serviceManager
+}
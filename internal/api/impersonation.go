@@ -0,0 +1,84 @@
+package api
+
+import (
+	"go-one/internal/serializer"
+	"go-one/internal/service"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ImpersonateRequest 模拟登录请求
+type ImpersonateRequest struct {
+	Reason string `json:"reason" binding:"required,max=255"`
+}
+
+// ImpersonateUser 以指定用户的身份签发短期访问令牌
+func (h *Handler) ImpersonateUser(c *gin.Context) {
+	// 1. 获取BusinessContext
+	bizCtx := GetBusinessContext(c)
+
+	// 2. 绑定并验证请求参数
+	userID, ok := parseIDParam(c, "id")
+	if !ok {
+		return
+	}
+	var req ImpersonateRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, serializer.ParamErr("参数错误", err))
+		return
+	}
+
+	// 3. 调用Service层
+	impersonationService := h.services(bizCtx).NewImpersonationService()
+	result, serviceErr := impersonationService.Start(bizCtx, userID, &service.ImpersonateDTO{Reason: req.Reason})
+	if serviceErr != nil {
+		HandleServiceError(c, serviceErr)
+		return
+	}
+
+	// 4. 返回成功响应
+	c.JSON(http.StatusOK, serializer.Success("模拟登录已开始", &serializer.ImpersonationVTO{
+		User:            serializer.BuildUserVTO(result.User),
+		AccessToken:     result.AccessToken,
+		TokenType:       "Bearer",
+		ExpiresIn:       result.ExpiresIn,
+		ImpersonationID: result.ImpersonationID,
+	}))
+}
+
+// SearchAuditLogs 搜索审计日志
+func (h *Handler) SearchAuditLogs(c *gin.Context) {
+	bizCtx := GetBusinessContext(c)
+
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	pageSize, _ := strconv.Atoi(c.DefaultQuery("page_size", "20"))
+	actorID, _ := strconv.ParseUint(c.Query("actor_id"), 10, 64)
+	userID, _ := strconv.ParseUint(c.Query("user_id"), 10, 64)
+	query := &service.SearchAuditLogsQuery{
+		ActorID:         uint(actorID),
+		UserID:          uint(userID),
+		ImpersonationID: c.Query("impersonation_id"),
+		Page:            page,
+		PageSize:        pageSize,
+	}
+
+	impersonationService := h.services(bizCtx).NewImpersonationService()
+	result, serviceErr := impersonationService.SearchAuditLogs(bizCtx, query)
+	if serviceErr != nil {
+		HandleServiceError(c, serviceErr)
+		return
+	}
+
+	list := make([]*serializer.AuditLogVTO, len(result.List))
+	for i := range result.List {
+		list[i] = serializer.BuildAuditLogVTO(&result.List[i])
+	}
+	c.JSON(http.StatusOK, serializer.Success("获取成功", &serializer.AuditLogListVTO{
+		List:     list,
+		Total:    result.Total,
+		Page:     result.Page,
+		PageSize: result.PageSize,
+	}))
+}
@@ -0,0 +1,123 @@
+package api
+
+import (
+	"go-one/internal/serializer"
+	"go-one/internal/service"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// CreateInviteRequest 签发邀请码请求
+type CreateInviteRequest struct {
+	Note          string `json:"note" binding:"max=255"`
+	MaxUses       int    `json:"max_uses" binding:"omitempty,min=1,max=1000"`       // 为空表示单次邀请码
+	ExpiresInDays int    `json:"expires_in_days" binding:"omitempty,min=1,max=365"` // 为空表示不过期
+}
+
+// ListInvites 获取注册邀请码
+func (h *Handler) ListInvites(c *gin.Context) {
+	bizCtx := GetBusinessContext(c)
+
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	pageSize, _ := strconv.Atoi(c.DefaultQuery("page_size", "20"))
+
+	inviteService := h.services(bizCtx).NewInviteService()
+	result, serviceErr := inviteService.ListInvites(bizCtx, &service.ListInvitesQuery{Page: page, PageSize: pageSize})
+	if serviceErr != nil {
+		HandleServiceError(c, serviceErr)
+		return
+	}
+
+	list := make([]*serializer.InviteVTO, len(result.List))
+	for i := range result.List {
+		list[i] = serializer.BuildInviteVTO(&result.List[i])
+	}
+	c.JSON(http.StatusOK, serializer.Success("获取成功", &serializer.InviteListVTO{
+		List:     list,
+		Total:    result.Total,
+		Page:     result.Page,
+		PageSize: result.PageSize,
+	}))
+}
+
+// CreateInvite 签发注册邀请码
+func (h *Handler) CreateInvite(c *gin.Context) {
+	bizCtx := GetBusinessContext(c)
+
+	var req CreateInviteRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, serializer.ParamErr("参数错误", err))
+		return
+	}
+
+	dto := &service.CreateInviteDTO{
+		Note:    req.Note,
+		MaxUses: req.MaxUses,
+	}
+	if req.ExpiresInDays > 0 {
+		expiresAt := time.Now().AddDate(0, 0, req.ExpiresInDays)
+		dto.ExpiresAt = &expiresAt
+	}
+
+	inviteService := h.services(bizCtx).NewInviteService()
+	result, serviceErr := inviteService.CreateInvite(bizCtx, dto)
+	if serviceErr != nil {
+		HandleServiceError(c, serviceErr)
+		return
+	}
+
+	vto := &serializer.CreatedInviteVTO{
+		InviteVTO: serializer.BuildInviteVTO(result.Invite),
+		Code:      result.Code,
+	}
+	c.JSON(http.StatusOK, serializer.Success("签发成功，请妥善保存邀请码，之后将无法再次查看", vto))
+}
+
+// RevokeInvite 撤销注册邀请码
+func (h *Handler) RevokeInvite(c *gin.Context) {
+	bizCtx := GetBusinessContext(c)
+
+	id, ok := parseIDParam(c, "id")
+	if !ok {
+		return
+	}
+
+	inviteService := h.services(bizCtx).NewInviteService()
+	if serviceErr := inviteService.RevokeInvite(bizCtx, id); serviceErr != nil {
+		HandleServiceError(c, serviceErr)
+		return
+	}
+
+	c.JSON(http.StatusOK, serializer.Success("邀请码已撤销", nil))
+}
+
+// ListInviteRedemptions 获取邀请码的使用记录
+func (h *Handler) ListInviteRedemptions(c *gin.Context) {
+	bizCtx := GetBusinessContext(c)
+
+	id, ok := parseIDParam(c, "id")
+	if !ok {
+		return
+	}
+
+	inviteService := h.services(bizCtx).NewInviteService()
+	redemptions, serviceErr := inviteService.ListRedemptions(bizCtx, id)
+	if serviceErr != nil {
+		HandleServiceError(c, serviceErr)
+		return
+	}
+
+	list := make([]*serializer.InviteRedemptionVTO, len(redemptions))
+	for i, r := range redemptions {
+		list[i] = &serializer.InviteRedemptionVTO{
+			UserID:     r.UserID,
+			Username:   r.Username,
+			Email:      r.Email,
+			RedeemedAt: r.CreatedAt,
+		}
+	}
+	c.JSON(http.StatusOK, serializer.Success("获取成功", list))
+}
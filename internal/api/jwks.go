@@ -0,0 +1,15 @@
+package api
+
+import (
+	"go-one/internal/service"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// JWKS 发布JWT验签公钥（RFC 7517 JWK Set）
+// 按标准格式直接返回，不包裹统一响应结构
+func JWKS(c *gin.Context) {
+	c.Header("Cache-Control", "public, max-age=300")
+	c.JSON(http.StatusOK, service.JWT.Keys.PublicJWKS())
+}
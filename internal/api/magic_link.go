@@ -0,0 +1,88 @@
+package api
+
+import (
+	"go-one/internal/serializer"
+	"go-one/internal/service"
+	"go-one/util"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// magicLinkNonceCookie 登录链接绑定的客户端 Nonce Cookie 名称
+const magicLinkNonceCookie = "magic_link_nonce"
+
+// MagicLinkRequest 申请登录链接请求
+type MagicLinkRequest struct {
+	Email string `json:"email" binding:"required,email"`
+}
+
+// MagicLinkConsumeRequest 使用登录链接请求
+type MagicLinkConsumeRequest struct {
+	Token string `json:"token" binding:"required"`
+}
+
+// RequestMagicLink 申请邮件登录链接
+func (h *Handler) RequestMagicLink(c *gin.Context) {
+	// 1. 获取BusinessContext
+	bizCtx := GetBusinessContext(c)
+
+	// 2. 绑定并验证请求参数
+	var req MagicLinkRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, serializer.ParamErr("参数错误", err))
+		return
+	}
+
+	// 3. 生成客户端 Nonce，链接只能在持有该 Cookie 的客户端上使用
+	nonce, err := util.RandomToken(32)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, serializer.Err(serializer.CodeError, "生成登录链接失败", err))
+		return
+	}
+
+	// 4. 调用Service层
+	userService := h.services(bizCtx).NewUserService()
+	if serviceErr := userService.RequestMagicLink(bizCtx, &service.MagicLinkDTO{Email: req.Email, Nonce: nonce}); serviceErr != nil {
+		HandleServiceError(c, serviceErr)
+		return
+	}
+
+	// 5. 无论邮箱是否存在都写入 Cookie，避免通过响应差异枚举邮箱
+	setCookie(c, magicLinkNonceCookie, nonce, int(service.MagicLink.Expire.Seconds()), service.AuthCookie.Path, true)
+	c.JSON(http.StatusOK, serializer.Success("如果该邮箱已注册并验证，登录链接已发送", nil))
+}
+
+// ConsumeMagicLink 使用邮件登录链接换取令牌
+func (h *Handler) ConsumeMagicLink(c *gin.Context) {
+	// 1. 获取BusinessContext
+	bizCtx := GetBusinessContext(c)
+
+	// 2. 绑定并验证请求参数
+	var req MagicLinkConsumeRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, serializer.ParamErr("参数错误", err))
+		return
+	}
+
+	// 3. 转换为Service层DTO
+	nonce, _ := c.Cookie(magicLinkNonceCookie)
+	trustedDevice, _ := c.Cookie(trustedDeviceCookie)
+	dto := &service.MagicLinkConsumeDTO{
+		Token:              req.Token,
+		Nonce:              nonce,
+		TrustedDeviceToken: trustedDevice,
+	}
+
+	// 4. 调用Service层
+	userService := h.services(bizCtx).NewUserService()
+	result, serviceErr := userService.ConsumeMagicLink(bizCtx, dto)
+	if serviceErr != nil {
+		HandleServiceError(c, serviceErr)
+		return
+	}
+	setCookie(c, magicLinkNonceCookie, "", -1, service.AuthCookie.Path, true)
+
+	// 5. 返回登录结果（与密码登录一致）
+	respondLoginResult(c, result)
+}
@@ -0,0 +1,138 @@
+package api
+
+import (
+	"go-one/internal/serializer"
+	"go-one/internal/service"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// trustedDeviceCookie “记住此设备”令牌的 Cookie 名称
+const trustedDeviceCookie = "mfa_trusted_device"
+
+// MFACodeRequest 二次验证码请求（验证码与恢复码二选一）
+type MFACodeRequest struct {
+	Code         string `json:"code"`
+	RecoveryCode string `json:"recovery_code"`
+}
+
+// MFAVerifyRequest 登录二次验证请求
+type MFAVerifyRequest struct {
+	MFAToken       string `json:"mfa_token" binding:"required"`
+	Code           string `json:"code"`
+	RecoveryCode   string `json:"recovery_code"`
+	RememberDevice bool   `json:"remember_device"`
+}
+
+// EnrollTOTP 生成 TOTP 密钥与二维码
+func (h *Handler) EnrollTOTP(c *gin.Context) {
+	bizCtx := GetBusinessContext(c)
+
+	mfaService := h.services(bizCtx).NewMFAService()
+	result, serviceErr := mfaService.EnrollTOTP(bizCtx)
+	if serviceErr != nil {
+		HandleServiceError(c, serviceErr)
+		return
+	}
+
+	c.JSON(http.StatusOK, serializer.Success("请使用认证器扫描二维码", serializer.BuildTOTPEnrollVTO(result.Secret, result.URI, result.QRCodePNG)))
+}
+
+// ConfirmTOTP 确认绑定并启用二次验证
+func (h *Handler) ConfirmTOTP(c *gin.Context) {
+	bizCtx := GetBusinessContext(c)
+
+	var req MFACodeRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, serializer.ParamErr("参数错误", err))
+		return
+	}
+
+	mfaService := h.services(bizCtx).NewMFAService()
+	codes, serviceErr := mfaService.ConfirmTOTP(bizCtx, req.Code)
+	if serviceErr != nil {
+		HandleServiceError(c, serviceErr)
+		return
+	}
+
+	c.JSON(http.StatusOK, serializer.Success("二次验证已启用，请妥善保存恢复码", &serializer.RecoveryCodesVTO{RecoveryCodes: codes}))
+}
+
+// DisableTOTP 关闭二次验证
+func (h *Handler) DisableTOTP(c *gin.Context) {
+	bizCtx := GetBusinessContext(c)
+
+	var req MFACodeRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, serializer.ParamErr("参数错误", err))
+		return
+	}
+
+	mfaService := h.services(bizCtx).NewMFAService()
+	dto := &service.MFACodeDTO{
+		Code:         req.Code,
+		RecoveryCode: req.RecoveryCode,
+	}
+	if serviceErr := mfaService.DisableTOTP(bizCtx, dto); serviceErr != nil {
+		HandleServiceError(c, serviceErr)
+		return
+	}
+
+	c.JSON(http.StatusOK, serializer.Success("二次验证已关闭", nil))
+}
+
+// RegenerateRecoveryCodes 重新生成恢复码
+func (h *Handler) RegenerateRecoveryCodes(c *gin.Context) {
+	bizCtx := GetBusinessContext(c)
+
+	var req MFACodeRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, serializer.ParamErr("参数错误", err))
+		return
+	}
+
+	mfaService := h.services(bizCtx).NewMFAService()
+	codes, serviceErr := mfaService.RegenerateRecoveryCodes(bizCtx, req.Code)
+	if serviceErr != nil {
+		HandleServiceError(c, serviceErr)
+		return
+	}
+
+	c.JSON(http.StatusOK, serializer.Success("恢复码已重新生成", &serializer.RecoveryCodesVTO{RecoveryCodes: codes}))
+}
+
+// MFAVerify 登录二次验证：以 mfa_token 与验证码换取令牌对
+func (h *Handler) MFAVerify(c *gin.Context) {
+	// 1. 获取BusinessContext
+	bizCtx := GetBusinessContext(c)
+
+	// 2. 绑定并验证请求参数
+	var req MFAVerifyRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, serializer.ParamErr("参数错误", err))
+		return
+	}
+
+	// 3. 调用Service层
+	mfaService := h.services(bizCtx).NewMFAService()
+	result, serviceErr := mfaService.VerifyLogin(bizCtx, &service.MFAVerifyDTO{
+		MFAToken:       req.MFAToken,
+		Code:           req.Code,
+		RecoveryCode:   req.RecoveryCode,
+		RememberDevice: req.RememberDevice,
+	})
+	if serviceErr != nil {
+		HandleServiceError(c, serviceErr)
+		return
+	}
+
+	// 4. 记住此设备：以 HttpOnly Cookie 下发设备令牌，仅在认证接口携带
+	if result.TrustedDeviceToken != "" {
+		setCookie(c, trustedDeviceCookie, result.TrustedDeviceToken,
+			int(service.MFA.TrustedDeviceExpire.Seconds()), service.AuthCookie.Path, true)
+	}
+
+	// 5. 返回登录结果（Cookie 模式下 refresh token 写入 Cookie）
+	respondLoginResult(c, result)
+}
@@ -0,0 +1,85 @@
+package api
+
+import (
+	"go-one/internal/serializer"
+	"go-one/internal/service"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// oauthStateCookie 第三方登录 state 绑定 Cookie 名称
+const oauthStateCookie = "oauth_state"
+
+// OAuthCallbackRequest 第三方登录回调请求（前端从回调地址中取出 code 与 state 后提交）
+type OAuthCallbackRequest struct {
+	Code  string `json:"code" binding:"required"`
+	State string `json:"state" binding:"required"`
+}
+
+// ListOAuthProviders 获取可用的第三方登录方式
+func (h *Handler) ListOAuthProviders(c *gin.Context) {
+	oauthService := h.serviceManager.NewOAuthService()
+	c.JSON(http.StatusOK, serializer.Success("获取成功", &serializer.OAuthProvidersVTO{
+		Providers: oauthService.Providers(),
+	}))
+}
+
+// OAuthAuthorize 发起第三方登录，返回身份提供方授权地址
+func (h *Handler) OAuthAuthorize(c *gin.Context) {
+	// 1. 获取BusinessContext
+	bizCtx := GetBusinessContext(c)
+
+	// 2. 调用Service层
+	oauthService := h.services(bizCtx).NewOAuthService()
+	result, serviceErr := oauthService.StartLogin(bizCtx, c.Param("provider"))
+	if serviceErr != nil {
+		HandleServiceError(c, serviceErr)
+		return
+	}
+
+	// 3. 将 state 绑定到当前客户端，回调时校验
+	setCookie(c, oauthStateCookie, result.State, int(service.OAuth.StateExpire.Seconds()), service.AuthCookie.Path, true)
+
+	// 4. 返回成功响应
+	c.JSON(http.StatusOK, serializer.Success("获取成功", &serializer.OAuthAuthorizeVTO{
+		AuthorizationURL: result.AuthorizationURL,
+		State:            result.State,
+	}))
+}
+
+// OAuthCallback 使用授权码完成第三方登录
+func (h *Handler) OAuthCallback(c *gin.Context) {
+	// 1. 获取BusinessContext
+	bizCtx := GetBusinessContext(c)
+
+	// 2. 绑定并验证请求参数
+	var req OAuthCallbackRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, serializer.ParamErr("参数错误", err))
+		return
+	}
+
+	// 3. 转换为Service层DTO
+	boundState, _ := c.Cookie(oauthStateCookie)
+	trustedDevice, _ := c.Cookie(trustedDeviceCookie)
+	dto := &service.OAuthCallbackDTO{
+		Provider:           c.Param("provider"),
+		Code:               req.Code,
+		State:              req.State,
+		BoundState:         boundState,
+		TrustedDeviceToken: trustedDevice,
+	}
+
+	// 4. 调用Service层
+	oauthService := h.services(bizCtx).NewOAuthService()
+	result, serviceErr := oauthService.CompleteLogin(bizCtx, dto)
+	setCookie(c, oauthStateCookie, "", -1, service.AuthCookie.Path, true)
+	if serviceErr != nil {
+		HandleServiceError(c, serviceErr)
+		return
+	}
+
+	// 5. 返回登录结果（与密码登录一致）
+	respondLoginResult(c, result)
+}
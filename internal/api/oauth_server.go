@@ -0,0 +1,310 @@
+package api
+
+import (
+	"go-one/internal/serializer"
+	"go-one/internal/service"
+	"net/http"
+	"net/url"
+
+	"github.com/gin-gonic/gin"
+)
+
+// OAuthAuthorizeRequest 授权请求参数（GET 使用查询参数，POST 使用 JSON）
+type OAuthAuthorizeRequest struct {
+	ResponseType        string `form:"response_type" json:"response_type" binding:"required"`
+	ClientID            string `form:"client_id" json:"client_id" binding:"required"`
+	RedirectURI         string `form:"redirect_uri" json:"redirect_uri" binding:"required"`
+	Scope               string `form:"scope" json:"scope"`
+	State               string `form:"state" json:"state"`
+	CodeChallenge       string `form:"code_challenge" json:"code_challenge" binding:"required"`
+	CodeChallengeMethod string `form:"code_challenge_method" json:"code_challenge_method" binding:"required"`
+	Approve             bool   `form:"-" json:"approve"`
+}
+
+// CreateOAuthClientRequest 注册 OAuth 应用请求
+type CreateOAuthClientRequest struct {
+	Name         string   `json:"name" binding:"required,max=100"`
+	RedirectURIs []string `json:"redirect_uris"`
+	Scopes       []string `json:"scopes" binding:"required,min=1"`
+	GrantTypes   []string `json:"grant_types"` // 为空时默认 authorization_code + refresh_token
+	Public       bool     `json:"public"`      // 公开应用（SPA、移动端）没有密钥
+}
+
+// toAuthorizeDTO 转换为Service层DTO
+func (r *OAuthAuthorizeRequest) toAuthorizeDTO() *service.AuthorizeDTO {
+	return &service.AuthorizeDTO{
+		ResponseType:        r.ResponseType,
+		ClientID:            r.ClientID,
+		RedirectURI:         r.RedirectURI,
+		Scope:               r.Scope,
+		State:               r.State,
+		CodeChallenge:       r.CodeChallenge,
+		CodeChallengeMethod: r.CodeChallengeMethod,
+		Approve:             r.Approve,
+	}
+}
+
+// GetOAuthAuthorization 校验授权请求并返回授权确认页信息（需已登录）
+func (h *Handler) GetOAuthAuthorization(c *gin.Context) {
+	// 1. 获取BusinessContext
+	bizCtx := GetBusinessContext(c)
+
+	// 2. 绑定并验证请求参数
+	var req OAuthAuthorizeRequest
+	if err := c.ShouldBindQuery(&req); err != nil {
+		c.JSON(http.StatusBadRequest, serializer.ParamErr("参数错误", err))
+		return
+	}
+
+	// 3. 调用Service层
+	oauthServer := h.services(bizCtx).NewOAuthServerService()
+	info, serviceErr := oauthServer.PrepareAuthorization(bizCtx, req.toAuthorizeDTO())
+	if serviceErr != nil {
+		HandleServiceError(c, serviceErr)
+		return
+	}
+
+	// 4. 返回成功响应
+	c.JSON(http.StatusOK, serializer.Success("获取成功", &serializer.OAuthAuthorizationVTO{
+		ClientID:        info.Client.ClientID,
+		ClientName:      info.Client.Name,
+		Scopes:          info.Scopes,
+		ConsentRequired: info.ConsentRequired,
+	}))
+}
+
+// OAuthServerAuthorize 提交用户的授权决定，返回携带 code 或 error 的回调地址
+func (h *Handler) OAuthServerAuthorize(c *gin.Context) {
+	// 1. 获取BusinessContext
+	bizCtx := GetBusinessContext(c)
+
+	// 2. 绑定并验证请求参数
+	var req OAuthAuthorizeRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, serializer.ParamErr("参数错误", err))
+		return
+	}
+
+	// 3. 调用Service层
+	oauthServer := h.services(bizCtx).NewOAuthServerService()
+	result, serviceErr := oauthServer.Authorize(bizCtx, req.toAuthorizeDTO())
+	if serviceErr != nil {
+		HandleServiceError(c, serviceErr)
+		return
+	}
+
+	// 4. 返回成功响应
+	c.JSON(http.StatusOK, serializer.Success("授权完成", &serializer.OAuthRedirectVTO{RedirectURI: result.RedirectURI}))
+}
+
+// OAuthToken 令牌端点（application/x-www-form-urlencoded，响应遵循 RFC 6749）
+func (h *Handler) OAuthToken(c *gin.Context) {
+	bizCtx := GetBusinessContext(c)
+
+	dto := &service.OAuthTokenDTO{
+		OAuthClientCredentials: oauthClientCredentials(c),
+		GrantType:              c.PostForm("grant_type"),
+		Code:                   c.PostForm("code"),
+		RedirectURI:            c.PostForm("redirect_uri"),
+		CodeVerifier:           c.PostForm("code_verifier"),
+		RefreshToken:           c.PostForm("refresh_token"),
+		Scope:                  c.PostForm("scope"),
+	}
+
+	oauthServer := h.services(bizCtx).NewOAuthServerService()
+	result, serviceErr := oauthServer.Token(bizCtx, dto)
+	c.Header("Cache-Control", "no-store")
+	c.Header("Pragma", "no-cache")
+	if serviceErr != nil {
+		respondOAuthError(c, serviceErr)
+		return
+	}
+
+	c.JSON(http.StatusOK, &serializer.OAuthTokenVTO{
+		AccessToken:  result.AccessToken,
+		TokenType:    result.TokenType,
+		ExpiresIn:    result.ExpiresIn,
+		RefreshToken: result.RefreshToken,
+		Scope:        result.Scope,
+	})
+}
+
+// OAuthIntrospect 令牌内省端点（RFC 7662）
+func (h *Handler) OAuthIntrospect(c *gin.Context) {
+	bizCtx := GetBusinessContext(c)
+
+	oauthServer := h.services(bizCtx).NewOAuthServerService()
+	result, serviceErr := oauthServer.Introspect(bizCtx, oauthTokenRequest(c))
+	c.Header("Cache-Control", "no-store")
+	if serviceErr != nil {
+		respondOAuthError(c, serviceErr)
+		return
+	}
+
+	vto := &serializer.OAuthIntrospectionVTO{
+		Active:    result.Active,
+		Scope:     result.Scope,
+		ClientID:  result.ClientID,
+		Sub:       result.Subject,
+		TokenType: result.TokenType,
+		Exp:       result.ExpiresAt,
+		Iat:       result.IssuedAt,
+		JTI:       result.JTI,
+	}
+	if result.DPoPKey != "" {
+		vto.Cnf = &serializer.OAuthConfirmationVTO{JKT: result.DPoPKey}
+	}
+	c.JSON(http.StatusOK, vto)
+}
+
+// OAuthRevoke 令牌吊销端点（RFC 7009），无效令牌同样返回 200
+func (h *Handler) OAuthRevoke(c *gin.Context) {
+	bizCtx := GetBusinessContext(c)
+
+	oauthServer := h.services(bizCtx).NewOAuthServerService()
+	if serviceErr := oauthServer.Revoke(bizCtx, oauthTokenRequest(c)); serviceErr != nil {
+		respondOAuthError(c, serviceErr)
+		return
+	}
+
+	c.Status(http.StatusOK)
+}
+
+// ListOAuthConsents 获取当前用户已授权的应用
+func (h *Handler) ListOAuthConsents(c *gin.Context) {
+	bizCtx := GetBusinessContext(c)
+
+	oauthServer := h.services(bizCtx).NewOAuthServerService()
+	consents, serviceErr := oauthServer.ListConsents(bizCtx)
+	if serviceErr != nil {
+		HandleServiceError(c, serviceErr)
+		return
+	}
+
+	c.JSON(http.StatusOK, serializer.Success("获取成功", serializer.BuildOAuthConsentVTOs(consents)))
+}
+
+// RevokeOAuthConsent 撤销对应用的授权
+func (h *Handler) RevokeOAuthConsent(c *gin.Context) {
+	bizCtx := GetBusinessContext(c)
+
+	oauthServer := h.services(bizCtx).NewOAuthServerService()
+	if serviceErr := oauthServer.RevokeConsent(bizCtx, c.Param("client_id")); serviceErr != nil {
+		HandleServiceError(c, serviceErr)
+		return
+	}
+
+	c.JSON(http.StatusOK, serializer.Success("授权已撤销", nil))
+}
+
+// ListOAuthClients 获取全部 OAuth 应用
+func (h *Handler) ListOAuthClients(c *gin.Context) {
+	bizCtx := GetBusinessContext(c)
+
+	oauthServer := h.services(bizCtx).NewOAuthServerService()
+	clients, serviceErr := oauthServer.ListClients(bizCtx)
+	if serviceErr != nil {
+		HandleServiceError(c, serviceErr)
+		return
+	}
+
+	list := make([]*serializer.OAuthClientVTO, len(clients))
+	for i := range clients {
+		list[i] = serializer.BuildOAuthClientVTO(&clients[i])
+	}
+	c.JSON(http.StatusOK, serializer.Success("获取成功", list))
+}
+
+// CreateOAuthClient 注册 OAuth 应用
+func (h *Handler) CreateOAuthClient(c *gin.Context) {
+	bizCtx := GetBusinessContext(c)
+
+	var req CreateOAuthClientRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, serializer.ParamErr("参数错误", err))
+		return
+	}
+
+	oauthServer := h.services(bizCtx).NewOAuthServerService()
+	result, serviceErr := oauthServer.CreateClient(bizCtx, &service.CreateOAuthClientDTO{
+		Name:         req.Name,
+		RedirectURIs: req.RedirectURIs,
+		Scopes:       req.Scopes,
+		GrantTypes:   req.GrantTypes,
+		Public:       req.Public,
+	})
+	if serviceErr != nil {
+		HandleServiceError(c, serviceErr)
+		return
+	}
+
+	vto := &serializer.CreatedOAuthClientVTO{
+		OAuthClientVTO: serializer.BuildOAuthClientVTO(result.Client),
+		ClientSecret:   result.Secret,
+	}
+	c.JSON(http.StatusOK, serializer.Success("创建成功，请妥善保存应用密钥，之后将无法再次查看", vto))
+}
+
+// RevokeOAuthClient 吊销 OAuth 应用
+func (h *Handler) RevokeOAuthClient(c *gin.Context) {
+	bizCtx := GetBusinessContext(c)
+
+	id, ok := parseIDParam(c, "id")
+	if !ok {
+		return
+	}
+
+	oauthServer := h.services(bizCtx).NewOAuthServerService()
+	if serviceErr := oauthServer.RevokeClient(bizCtx, id); serviceErr != nil {
+		HandleServiceError(c, serviceErr)
+		return
+	}
+
+	c.JSON(http.StatusOK, serializer.Success("OAuth 应用已吊销", nil))
+}
+
+// oauthClientCredentials 读取应用凭证：优先 HTTP Basic（RFC 6749 2.3.1，值为表单编码），其次表单参数
+func oauthClientCredentials(c *gin.Context) service.OAuthClientCredentials {
+	if id, secret, ok := c.Request.BasicAuth(); ok {
+		if unescaped, err := url.QueryUnescape(id); err == nil {
+			id = unescaped
+		}
+		if unescaped, err := url.QueryUnescape(secret); err == nil {
+			secret = unescaped
+		}
+		return service.OAuthClientCredentials{ClientID: id, ClientSecret: secret}
+	}
+	return service.OAuthClientCredentials{
+		ClientID:     c.PostForm("client_id"),
+		ClientSecret: c.PostForm("client_secret"),
+	}
+}
+
+// oauthTokenRequest 读取内省与吊销请求参数
+func oauthTokenRequest(c *gin.Context) *service.OAuthTokenRequestDTO {
+	return &service.OAuthTokenRequestDTO{
+		OAuthClientCredentials: oauthClientCredentials(c),
+		Token:                  c.PostForm("token"),
+		TokenTypeHint:          c.PostForm("token_type_hint"),
+	}
+}
+
+// respondOAuthError 以 RFC 6749 5.2 格式返回错误
+func respondOAuthError(c *gin.Context, err service.ServiceError) {
+	vto := &serializer.OAuthErrorVTO{Error: "invalid_request", ErrorDescription: err.GetMessage()}
+	status := http.StatusBadRequest
+	switch e := err.(type) {
+	case *service.OAuthProtocolError:
+		vto.Error = e.ErrorCode
+		if e.ErrorCode == "invalid_client" {
+			status = http.StatusUnauthorized
+			c.Header("WWW-Authenticate", `Basic realm="oauth"`)
+		}
+	default:
+		if err.GetCode() >= 50000 {
+			vto.Error = "server_error"
+			status = http.StatusInternalServerError
+		}
+	}
+	c.JSON(status, vto)
+}
@@ -0,0 +1,62 @@
+package api
+
+import (
+	"go-one/internal/serializer"
+	"go-one/internal/service"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ForgotPasswordRequest 找回密码请求
+type ForgotPasswordRequest struct {
+	Email string `json:"email" binding:"required,email"`
+}
+
+// ResetPasswordRequest 重置密码请求
+type ResetPasswordRequest struct {
+	Token       string `json:"token" binding:"required"`
+	NewPassword string `json:"new_password" binding:"required"`
+}
+
+// ForgotPassword 申请找回密码
+func (h *Handler) ForgotPassword(c *gin.Context) {
+	bizCtx := GetBusinessContext(c)
+
+	var req ForgotPasswordRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, serializer.ParamErr("参数错误", err))
+		return
+	}
+
+	resetService := h.services(bizCtx).NewPasswordResetService()
+	if serviceErr := resetService.ForgotPassword(bizCtx, req.Email); serviceErr != nil {
+		HandleServiceError(c, serviceErr)
+		return
+	}
+
+	c.JSON(http.StatusOK, serializer.Success("如果该邮箱已注册，重置链接已发送", nil))
+}
+
+// ResetPassword 使用重置令牌设置新密码
+func (h *Handler) ResetPassword(c *gin.Context) {
+	bizCtx := GetBusinessContext(c)
+
+	var req ResetPasswordRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, serializer.ParamErr("参数错误", err))
+		return
+	}
+
+	resetService := h.services(bizCtx).NewPasswordResetService()
+	dto := &service.ResetPasswordDTO{
+		Token:       req.Token,
+		NewPassword: req.NewPassword,
+	}
+	if serviceErr := resetService.ResetPassword(bizCtx, dto); serviceErr != nil {
+		HandleServiceError(c, serviceErr)
+		return
+	}
+
+	c.JSON(http.StatusOK, serializer.Success("密码已重置，请重新登录", nil))
+}
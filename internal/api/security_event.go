@@ -0,0 +1,64 @@
+package api
+
+import (
+	"go-one/internal/serializer"
+	"go-one/internal/service"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ListSecurityEvents 获取当前用户的安全事件时间线（登录、刷新、登出、修改密码、锁定等）
+func (h *Handler) ListSecurityEvents(c *gin.Context) {
+	bizCtx := GetBusinessContext(c)
+
+	eventService := h.services(bizCtx).NewSecurityEventService()
+	result, serviceErr := eventService.ListMine(bizCtx, securityEventsQuery(c))
+	if serviceErr != nil {
+		HandleServiceError(c, serviceErr)
+		return
+	}
+
+	c.JSON(http.StatusOK, serializer.Success("获取成功", buildSecurityEventList(result)))
+}
+
+// ListUserSecurityEvents 获取指定用户的安全事件时间线（管理接口）
+func (h *Handler) ListUserSecurityEvents(c *gin.Context) {
+	bizCtx := GetBusinessContext(c)
+
+	userID, ok := parseIDParam(c, "id")
+	if !ok {
+		return
+	}
+
+	eventService := h.services(bizCtx).NewSecurityEventService()
+	result, serviceErr := eventService.ListUserEvents(bizCtx, userID, securityEventsQuery(c))
+	if serviceErr != nil {
+		HandleServiceError(c, serviceErr)
+		return
+	}
+
+	c.JSON(http.StatusOK, serializer.Success("获取成功", buildSecurityEventList(result)))
+}
+
+// securityEventsQuery 解析安全事件的分页与类型筛选参数
+func securityEventsQuery(c *gin.Context) *service.ListSecurityEventsQuery {
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	pageSize, _ := strconv.Atoi(c.DefaultQuery("page_size", "20"))
+	return &service.ListSecurityEventsQuery{
+		Type:     c.Query("type"),
+		Page:     page,
+		PageSize: pageSize,
+	}
+}
+
+// buildSecurityEventList 构建安全事件列表响应
+func buildSecurityEventList(result *service.ListSecurityEventsResult) *serializer.SecurityEventListVTO {
+	return &serializer.SecurityEventListVTO{
+		List:     serializer.BuildSecurityEventVTOs(result.List),
+		Total:    result.Total,
+		Page:     result.Page,
+		PageSize: result.PageSize,
+	}
+}
@@ -0,0 +1,53 @@
+package api
+
+import (
+	"go-one/internal/serializer"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ListSessions 获取当前用户的活跃会话
+func (h *Handler) ListSessions(c *gin.Context) {
+	bizCtx := GetBusinessContext(c)
+
+	sessionService := h.services(bizCtx).NewSessionService()
+	result, serviceErr := sessionService.ListSessions(bizCtx)
+	if serviceErr != nil {
+		HandleServiceError(c, serviceErr)
+		return
+	}
+
+	list := make([]*serializer.SessionVTO, len(result.List))
+	for i := range result.List {
+		list[i] = serializer.BuildSessionVTO(&result.List[i], result.CurrentSessionID)
+	}
+	c.JSON(http.StatusOK, serializer.Success("获取成功", list))
+}
+
+// RevokeSession 撤销指定会话
+func (h *Handler) RevokeSession(c *gin.Context) {
+	bizCtx := GetBusinessContext(c)
+
+	sessionService := h.services(bizCtx).NewSessionService()
+	if serviceErr := sessionService.RevokeSession(bizCtx, c.Param("id")); serviceErr != nil {
+		HandleServiceError(c, serviceErr)
+		return
+	}
+
+	c.JSON(http.StatusOK, serializer.Success("会话已撤销", nil))
+}
+
+// RevokeOtherSessions 撤销当前会话以外的全部会话
+func (h *Handler) RevokeOtherSessions(c *gin.Context) {
+	bizCtx := GetBusinessContext(c)
+
+	sessionService := h.services(bizCtx).NewSessionService()
+	revoked, serviceErr := sessionService.RevokeOtherSessions(bizCtx)
+	if serviceErr != nil {
+		HandleServiceError(c, serviceErr)
+		return
+	}
+
+	c.JSON(http.StatusOK, serializer.Success("已退出其他设备", gin.H{"revoked": revoked}))
+}
@@ -0,0 +1,157 @@
+package api
+
+import (
+	"go-one/internal/serializer"
+	"go-one/internal/service"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// SmsSendRequest 发送短信验证码请求
+type SmsSendRequest struct {
+	Phone string `json:"phone" binding:"required"`
+}
+
+// SmsLoginRequest 短信验证码登录请求
+type SmsLoginRequest struct {
+	Phone string `json:"phone" binding:"required"`
+	Code  string `json:"code" binding:"required"`
+}
+
+// PhoneBindCodeRequest 发送绑定手机号验证码请求（需验证当前密码）
+type PhoneBindCodeRequest struct {
+	Phone    string `json:"phone" binding:"required"`
+	Password string `json:"password" binding:"required"`
+}
+
+// PhoneBindRequest 绑定手机号请求
+type PhoneBindRequest struct {
+	Phone string `json:"phone" binding:"required"`
+	Code  string `json:"code" binding:"required"`
+}
+
+// PhoneUnbindRequest 解绑手机号请求
+type PhoneUnbindRequest struct {
+	Password string `json:"password" binding:"required"`
+}
+
+// SendSmsLoginCode 发送短信登录验证码
+func (h *Handler) SendSmsLoginCode(c *gin.Context) {
+	// 1. 获取BusinessContext
+	bizCtx := GetBusinessContext(c)
+
+	// 2. 绑定并验证请求参数
+	var req SmsSendRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, serializer.ParamErr("参数错误", err))
+		return
+	}
+
+	// 3. 调用Service层
+	userService := h.services(bizCtx).NewUserService()
+	if serviceErr := userService.SendSmsLoginCode(bizCtx, &service.SmsSendDTO{Phone: req.Phone}); serviceErr != nil {
+		HandleServiceError(c, serviceErr)
+		return
+	}
+
+	// 4. 无论手机号是否已绑定都返回相同结果，避免枚举手机号
+	c.JSON(http.StatusOK, serializer.Success("如果该手机号已绑定账号，验证码已发送", nil))
+}
+
+// SmsLogin 使用短信验证码登录
+func (h *Handler) SmsLogin(c *gin.Context) {
+	// 1. 获取BusinessContext
+	bizCtx := GetBusinessContext(c)
+
+	// 2. 绑定并验证请求参数
+	var req SmsLoginRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, serializer.ParamErr("参数错误", err))
+		return
+	}
+
+	// 3. 转换为Service层DTO
+	trustedDevice, _ := c.Cookie(trustedDeviceCookie)
+	dto := &service.SmsLoginDTO{
+		Phone:              req.Phone,
+		Code:               req.Code,
+		TrustedDeviceToken: trustedDevice,
+	}
+
+	// 4. 调用Service层
+	userService := h.services(bizCtx).NewUserService()
+	result, serviceErr := userService.LoginBySms(bizCtx, dto)
+	if serviceErr != nil {
+		HandleServiceError(c, serviceErr)
+		return
+	}
+
+	// 5. 返回登录结果（与密码登录一致）
+	respondLoginResult(c, result)
+}
+
+// SendPhoneBindCode 验证当前密码并向待绑定的手机号发送验证码
+func (h *Handler) SendPhoneBindCode(c *gin.Context) {
+	bizCtx := GetBusinessContext(c)
+
+	var req PhoneBindCodeRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, serializer.ParamErr("参数错误", err))
+		return
+	}
+
+	userService := h.services(bizCtx).NewUserService()
+	dto := &service.PhoneBindDTO{
+		Phone:    req.Phone,
+		Password: req.Password,
+	}
+	if serviceErr := userService.SendPhoneBindCode(bizCtx, dto); serviceErr != nil {
+		HandleServiceError(c, serviceErr)
+		return
+	}
+
+	c.JSON(http.StatusOK, serializer.Success("验证码已发送", nil))
+}
+
+// BindPhone 校验验证码并绑定手机号
+func (h *Handler) BindPhone(c *gin.Context) {
+	bizCtx := GetBusinessContext(c)
+
+	var req PhoneBindRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, serializer.ParamErr("参数错误", err))
+		return
+	}
+
+	userService := h.services(bizCtx).NewUserService()
+	dto := &service.PhoneBindDTO{
+		Phone: req.Phone,
+		Code:  req.Code,
+	}
+	if serviceErr := userService.BindPhone(bizCtx, dto); serviceErr != nil {
+		HandleServiceError(c, serviceErr)
+		return
+	}
+
+	c.JSON(http.StatusOK, serializer.Success("手机号已绑定", nil))
+}
+
+// UnbindPhone 验证当前密码并解绑手机号
+func (h *Handler) UnbindPhone(c *gin.Context) {
+	bizCtx := GetBusinessContext(c)
+
+	var req PhoneUnbindRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, serializer.ParamErr("参数错误", err))
+		return
+	}
+
+	userService := h.services(bizCtx).NewUserService()
+	if serviceErr := userService.UnbindPhone(bizCtx, &service.PhoneUnbindDTO{Password: req.Password}); serviceErr != nil {
+		HandleServiceError(c, serviceErr)
+		return
+	}
+
+	c.JSON(http.StatusOK, serializer.Success("手机号已解绑", nil))
+}
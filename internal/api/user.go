@@ -11,9 +11,11 @@ import (
 
 // RegisterRequest 注册请求
 type RegisterRequest struct {
-	Username string `json:"username" binding:"required,min=3,max=50"`
-	Email    string `json:"email" binding:"omitempty,email"`
-	Password string `json:"password" binding:"required,min=6"`
+	Username               string `json:"username" binding:"required,min=3,max=50"`
+	Email                  string `json:"email" binding:"omitempty,email"`
+	Password               string `json:"password" binding:"required"`
+	InviteCode             string `json:"invite_code"`              // 邀请注册模式下必填
+	AcceptedPolicyVersions []uint `json:"accepted_policy_versions"` // 同意的当前协议版本ID，须覆盖 GET /policies 返回的全部版本
 }
 
 // LoginRequest 登录请求
@@ -31,12 +33,12 @@ type UpdateProfileRequest struct {
 // ChangePasswordRequest 修改密码请求
 type ChangePasswordRequest struct {
 	OldPassword string `json:"old_password" binding:"required"`
-	NewPassword string `json:"new_password" binding:"required,min=6"`
+	NewPassword string `json:"new_password" binding:"required"`
 }
 
-// RefreshTokenRequest 刷新令牌请求
+// RefreshTokenRequest 刷新令牌请求（Cookie 模式下可省略，从 Cookie 读取）
 type RefreshTokenRequest struct {
-	RefreshToken string `json:"refresh_token" binding:"required"`
+	RefreshToken string `json:"refresh_token"`
 }
 
 // UserRegister 用户注册
@@ -53,24 +55,36 @@ func (h *Handler) UserRegister(c *gin.Context) {
 
 	// 3. 转换为Service层DTO
 	dto := &service.RegisterDTO{
-		Username: req.Username,
-		Email:    req.Email,
-		Password: req.Password,
+		Username:               req.Username,
+		Email:                  req.Email,
+		Password:               req.Password,
+		InviteCode:             req.InviteCode,
+		AcceptedPolicyVersions: req.AcceptedPolicyVersions,
 	}
 
 	// 4. 调用Service层（传入BusinessContext）
-	userService := h.serviceManager.NewUserService()
+	userService := h.services(bizCtx).NewUserService()
 	result, serviceErr := userService.Register(bizCtx, dto)
 	if serviceErr != nil {
 		HandleServiceError(c, serviceErr)
 		return
 	}
 
-	// 5. 返回成功响应
+	// 5. 返回成功响应（需要邮箱验证时不下发令牌）
+	if result.VerificationRequired {
+		c.JSON(http.StatusOK, serializer.Success("注册成功，请前往邮箱完成验证", serializer.BuildUserVTO(result.User)))
+		return
+	}
+	refreshToken, csrfToken, ok := issueRefreshToken(c, result.RefreshToken, cookieMode(c))
+	if !ok {
+		return
+	}
 	vto := &serializer.AuthTokenVTO{
 		User:         serializer.BuildUserVTO(result.User),
 		AccessToken:  result.AccessToken,
-		RefreshToken: result.RefreshToken,
+		TokenType:    bizCtx.TokenScheme(),
+		RefreshToken: refreshToken,
+		CSRFToken:    csrfToken,
 	}
 	c.JSON(http.StatusOK, serializer.Success("注册成功", vto))
 }
@@ -88,26 +102,23 @@ func (h *Handler) UserLogin(c *gin.Context) {
 	}
 
 	// 3. 转换为Service层DTO
+	trustedDevice, _ := c.Cookie(trustedDeviceCookie)
 	dto := &service.LoginDTO{
-		Username: req.Username,
-		Password: req.Password,
+		Username:           req.Username,
+		Password:           req.Password,
+		TrustedDeviceToken: trustedDevice,
 	}
 
 	// 4. 调用Service层
-	userService := h.serviceManager.NewUserService()
+	userService := h.services(bizCtx).NewUserService()
 	result, serviceErr := userService.Login(bizCtx, dto)
 	if serviceErr != nil {
 		HandleServiceError(c, serviceErr)
 		return
 	}
 
-	// 5. 返回成功响应
-	vto := &serializer.AuthTokenVTO{
-		User:         serializer.BuildUserVTO(result.User),
-		AccessToken:  result.AccessToken,
-		RefreshToken: result.RefreshToken,
-	}
-	c.JSON(http.StatusOK, serializer.Success("登录成功", vto))
+	// 5. 返回登录结果（Cookie 模式下 refresh token 写入 Cookie）
+	respondLoginResult(c, result)
 }
 
 // GetUserProfile 获取用户资料
@@ -122,7 +133,7 @@ func (h *Handler) GetUserProfile(c *gin.Context) {
     }
 
 	// 3. 调用Service层
-	userService := h.serviceManager.NewUserService()
+	userService := h.services(bizCtx).NewUserService()
 	user, serviceErr := userService.GetUserByID(bizCtx)
 	if serviceErr != nil {
 		HandleServiceError(c, serviceErr)
@@ -158,7 +169,7 @@ func (h *Handler) UpdateUserProfile(c *gin.Context) {
 	}
 
 	// 5. 调用Service层
-	userService := h.serviceManager.NewUserService()
+	userService := h.services(bizCtx).NewUserService()
 	serviceErr := userService.UpdateProfile(bizCtx, dto)
 	if serviceErr != nil {
 		HandleServiceError(c, serviceErr)
@@ -194,7 +205,7 @@ func (h *Handler) ChangePassword(c *gin.Context) {
 	}
 
 	// 5. 调用Service层
-	userService := h.serviceManager.NewUserService()
+	userService := h.services(bizCtx).NewUserService()
 	serviceErr := userService.ChangePassword(bizCtx, dto)
 	if serviceErr != nil {
 		HandleServiceError(c, serviceErr)
@@ -202,7 +213,7 @@ func (h *Handler) ChangePassword(c *gin.Context) {
 	}
 
 	// 6. 返回成功响应
-	c.JSON(http.StatusOK, serializer.Success("密码修改成功", nil))
+	c.JSON(http.StatusOK, serializer.Success("密码修改成功，请重新登录", nil))
 }
 
 // ListUsers 获取用户列表
@@ -221,7 +232,7 @@ func (h *Handler) ListUsers(c *gin.Context) {
 	}
 
 	// 4. 调用Service层
-	userService := h.serviceManager.NewUserService()
+	userService := h.services(bizCtx).NewUserService()
 	result, serviceErr := userService.ListUsers(bizCtx, query)
 	if serviceErr != nil {
 		HandleServiceError(c, serviceErr)
@@ -247,30 +258,35 @@ func (h *Handler) RefreshToken(c *gin.Context) {
 	// 1. 获取BusinessContext
 	bizCtx := GetBusinessContext(c)
 
-	// 2. 绑定请求参数
-	var req RefreshTokenRequest
-	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, serializer.ParamErr("参数错误", err))
+	// 2. 读取refresh token（请求体或Cookie）
+	token, fromCookie, ok := bindRefreshToken(c)
+	if !ok {
 		return
 	}
 
 	// 3. 转换为Service层DTO
 	dto := &service.RefreshTokenDTO{
-		RefreshToken: req.RefreshToken,
+		RefreshToken: token,
 	}
 
 	// 4. 调用Service层
-	userService := h.serviceManager.NewUserService()
+	userService := h.services(bizCtx).NewUserService()
 	result, serviceErr := userService.RefreshToken(bizCtx, dto)
 	if serviceErr != nil {
 		HandleServiceError(c, serviceErr)
 		return
 	}
 
-	// 5. 返回成功响应
+	// 5. 返回成功响应（令牌取自Cookie时新令牌同样写回Cookie，并轮换CSRF令牌）
+	refreshToken, csrfToken, ok := issueRefreshToken(c, result.RefreshToken, fromCookie || cookieMode(c))
+	if !ok {
+		return
+	}
 	vto := &serializer.TokenPairVTO{
 		AccessToken:  result.AccessToken,
-		RefreshToken: result.RefreshToken,
+		TokenType:    bizCtx.TokenScheme(),
+		RefreshToken: refreshToken,
+		CSRFToken:    csrfToken,
 	}
 	c.JSON(http.StatusOK, serializer.Success("刷新成功", vto))
 }
@@ -279,14 +295,17 @@ func (h *Handler) RefreshToken(c *gin.Context) {
 func (h *Handler) UserLogout(c *gin.Context) {
     bizCtx := GetBusinessContext(c)
 
-    var req RefreshTokenRequest
-    if err := c.ShouldBindJSON(&req); err != nil {
-        c.JSON(http.StatusBadRequest, serializer.ParamErr("参数错误", err))
+    token, fromCookie, ok := bindRefreshToken(c)
+    if !ok {
         return
     }
+    // Cookie 模式下无论撤销结果如何都清除 Cookie
+    if fromCookie || cookieMode(c) {
+        clearAuthCookies(c)
+    }
 
-    userService := h.serviceManager.NewUserService()
-    if serviceErr := userService.Logout(bizCtx, &service.LogoutDTO{RefreshToken: req.RefreshToken}); serviceErr != nil {
+    userService := h.services(bizCtx).NewUserService()
+    if serviceErr := userService.Logout(bizCtx, &service.LogoutDTO{RefreshToken: token}); serviceErr != nil {
         HandleServiceError(c, serviceErr)
         return
     }
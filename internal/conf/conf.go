@@ -49,6 +49,11 @@ func InitWithEnvFile(envFile string) {
 		util.Log().Panic("初始化Redis失败: %v", err)
 	}
 
+	// 初始化 Redis Stream（数据导出等后台任务使用）
+	if err := cache.InitStreams(); err != nil {
+		util.Log().Panic("初始化Redis Stream失败: %v", err)
+	}
+
 	// 连接数据库
 	// 从环境变量获取数据库连接信息
 	url := os.Getenv("POSTGRES_URL")
@@ -61,8 +66,62 @@ func InitWithEnvFile(envFile string) {
 
 	model.Init(dsn, tz)
 
+	// 初始化多租户配置
+	service.InitTenant()
+
+	// 按配置初始化管理员角色
+	service.InitRBAC()
+
 	// 初始化JWT配置
 	service.InitJWT()
+	service.InitDPoP()
+
+	// 初始化密码哈希与密码策略配置
+	service.InitPasswordHasher()
+	service.InitPasswordPolicy()
+
+	// 初始化会话配置
+	service.InitSession()
+
+	// 初始化管理员模拟登录配置
+	service.InitImpersonation()
+
+	// 初始化 Cookie 认证模式配置
+	service.InitAuthCookie()
+
+	// 初始化二次验证配置
+	service.InitMFA()
+
+	// 初始化邮件发送与邮箱验证配置
+	service.InitMailer()
+	service.InitEmail()
+
+	// 初始化注册策略配置
+	service.InitRegistration()
+
+	// 初始化找回密码配置
+	service.InitPasswordReset()
+
+	// 初始化邮件链接登录配置
+	service.InitMagicLink()
+
+	// 初始化短信发送与短信验证码配置
+	service.InitSMS()
+
+	// 初始化第三方登录配置
+	service.InitOAuth()
+
+	// 初始化 OAuth 授权服务配置
+	service.InitOAuthServer()
+
+	// 初始化登录防爆破配置
+	service.InitLoginGuard()
+
+	// 初始化数据导出与账号注销配置
+	service.InitAccountData()
+
+	// 初始化安全事件通知
+	service.InitSecurityEvents()
 
 	// 初始化 Sentry（可选）
 	if dsn := os.Getenv("SENTRY_DSN"); dsn != "" {
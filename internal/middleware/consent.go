@@ -0,0 +1,41 @@
+package middleware
+
+import (
+	"go-one/internal/serializer"
+	"go-one/internal/service"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// consentExemptPaths 未同意最新协议时仍可访问的接口（查看与同意协议，以及不同意时导出数据、注销账号）
+var consentExemptPaths = map[string]bool{
+	"/api/v1/user/consents": true,
+	"/api/v1/user/export":   true,
+	"/api/v1/user/account":  true,
+}
+
+// RequireConsent 要求交互式登录的用户已同意每种协议最新的须重新同意版本，否则返回 40308 及待同意的版本，需在 JWTMiddleware 之后使用
+// API 密钥、第三方应用令牌与模拟登录的请求不受限制：协议须由用户本人在交互界面中同意
+func RequireConsent(sm *service.ServiceManager) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		bizCtx, ok := businessContext(c)
+		if !ok || bizCtx.IsDelegated() || bizCtx.IsImpersonated() || consentExemptPaths[c.FullPath()] {
+			c.Next()
+			return
+		}
+		missing, serviceErr := sm.NewConsentService().Pending(bizCtx)
+		if serviceErr != nil {
+			abortWithServiceError(c, serviceErr)
+			return
+		}
+		if len(missing) > 0 {
+			res := serializer.Err(40308, "请阅读并同意最新的服务条款与隐私政策", nil)
+			res.Data = serializer.BuildPolicyVersionVTOs(missing)
+			c.JSON(http.StatusForbidden, res)
+			c.Abort()
+			return
+		}
+		c.Next()
+	}
+}
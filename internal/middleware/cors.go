@@ -1,6 +1,7 @@
 package middleware
 
 import (
+	"go-one/internal/service"
 	"regexp"
 
 	"github.com/gin-contrib/cors"
@@ -11,7 +12,7 @@ import (
 func Cors() gin.HandlerFunc {
 	config := cors.DefaultConfig()
 	config.AllowMethods = []string{"GET", "POST", "PUT", "PATCH", "DELETE", "HEAD", "OPTIONS"}
-	config.AllowHeaders = []string{"Origin", "Content-Length", "Content-Type", "Cookie", "Authorization"}
+	config.AllowHeaders = []string{"Origin", "Content-Length", "Content-Type", "Cookie", "Authorization", "X-Device-Name", "X-Auth-Mode", "X-CSRF-Token", "DPoP", service.Tenancy.Header}
 
 	if gin.Mode() == gin.ReleaseMode {
 		// 生产环境需要配置跨域域名，否则403
@@ -0,0 +1,33 @@
+package middleware
+
+import (
+	"crypto/subtle"
+	"go-one/internal/serializer"
+	"go-one/internal/service"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// CSRFMiddleware 双重提交 CSRF 校验
+// 仅当请求携带 refresh token Cookie（即 Cookie 模式）时生效：要求请求头中的 CSRF 令牌与 CSRF Cookie 一致
+func CSRFMiddleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		cfg := service.AuthCookie
+		if _, err := c.Cookie(cfg.RefreshCookieName); err != nil {
+			c.Next()
+			return
+		}
+
+		cookieToken, _ := c.Cookie(cfg.CSRFCookieName)
+		headerToken := c.GetHeader(cfg.CSRFHeaderName)
+		if cookieToken == "" || headerToken == "" ||
+			subtle.ConstantTimeCompare([]byte(cookieToken), []byte(headerToken)) != 1 {
+			c.JSON(http.StatusForbidden, serializer.Err(serializer.CodeForbidden, "CSRF 校验失败", nil))
+			c.Abort()
+			return
+		}
+
+		c.Next()
+	}
+}
@@ -0,0 +1,85 @@
+package middleware
+
+import (
+	"errors"
+	"go-one/internal/serializer"
+	"go-one/internal/service"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// dpopHeader DPoP 证明请求头（RFC 9449）
+const dpopHeader = "DPoP"
+
+// errInvalidDPoPProof 作为响应的 error 字段，与 RFC 9449 的错误码一致
+var errInvalidDPoPProof = errors.New("invalid_dpop_proof")
+
+// DPoPMiddleware 用于签发令牌的接口：请求携带 DPoP 证明时校验，并将公钥指纹写入 BusinessContext，签发的令牌随之绑定
+// 未携带证明的请求照常签发 Bearer 令牌
+func DPoPMiddleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		proofs := c.Request.Header.Values(dpopHeader)
+		if len(proofs) == 0 {
+			c.Next()
+			return
+		}
+		if len(proofs) > 1 {
+			c.JSON(http.StatusBadRequest, serializer.Err(serializer.CodeBadRequest, "只能携带一个 DPoP 证明", errInvalidDPoPProof))
+			c.Abort()
+			return
+		}
+
+		jkt, serviceErr := service.VerifyDPoPProof(&service.DPoPProofDTO{
+			Proof:  proofs[0],
+			Method: c.Request.Method,
+			URL:    requestURL(c),
+		})
+		if serviceErr != nil {
+			if serviceErr.GetCode() >= 50000 {
+				c.JSON(http.StatusInternalServerError, serializer.Err(serializer.CodeError, serviceErr.GetMessage(), nil))
+			} else {
+				c.JSON(http.StatusBadRequest, serializer.Err(serializer.CodeBadRequest, serviceErr.GetMessage(), errInvalidDPoPProof))
+			}
+			c.Abort()
+			return
+		}
+
+		c.Set("business_context", newBusinessContext(c).WithDPoPKey(jkt))
+		c.Next()
+	}
+}
+
+// verifyDPoPAccess 校验以 DPoP 方案出示的访问令牌：证明须携带该令牌的 ath，且公钥与令牌的 cnf.jkt 一致
+func verifyDPoPAccess(c *gin.Context, accessToken, boundKey string) (string, service.ServiceError) {
+	proofs := c.Request.Header.Values(dpopHeader)
+	if len(proofs) != 1 {
+		return "", &service.AuthError{Message: "缺少 DPoP 证明"}
+	}
+	jkt, serviceErr := service.VerifyDPoPProof(&service.DPoPProofDTO{
+		Proof:       proofs[0],
+		Method:      c.Request.Method,
+		URL:         requestURL(c),
+		AccessToken: accessToken,
+	})
+	if serviceErr != nil {
+		return "", serviceErr
+	}
+	if jkt != boundKey {
+		return "", &service.AuthError{Message: "DPoP 证明的密钥与访问令牌不一致"}
+	}
+	return jkt, nil
+}
+
+// requestURL 当前请求的完整地址（不含查询串），用于比对证明的 htu
+// 配置了 DPOP_BASE_URL 时以其为准，否则按 TLS 与 X-Forwarded-Proto 推断协议
+func requestURL(c *gin.Context) string {
+	if service.DPoP.BaseURL != "" {
+		return service.DPoP.BaseURL + c.Request.URL.Path
+	}
+	scheme := "http"
+	if c.Request.TLS != nil || c.GetHeader("X-Forwarded-Proto") == "https" {
+		scheme = "https"
+	}
+	return scheme + "://" + c.Request.Host + c.Request.URL.Path
+}
@@ -0,0 +1,45 @@
+package middleware
+
+import (
+	"go-one/internal/serializer"
+	"go-one/internal/service"
+	"go-one/util"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ImpersonationAudit 将模拟登录期间的每个请求（方法、路径与响应状态）写入审计日志，需在 JWTMiddleware 之后使用
+func ImpersonationAudit(sm *service.ServiceManager) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		bizCtx, ok := businessContext(c)
+		if !ok || !bizCtx.IsImpersonated() {
+			c.Next()
+			return
+		}
+
+		c.Next()
+
+		serviceErr := sm.ForTenant(bizCtx.TenantID).NewImpersonationService().RecordRequest(bizCtx, &service.AuditRequestDTO{
+			Method: c.Request.Method,
+			Path:   c.Request.URL.Path,
+			Status: c.Writer.Status(),
+		})
+		if serviceErr != nil {
+			util.Log().Error("记录模拟登录审计日志失败 user_id=%s actor=%s path=%s: %v",
+				bizCtx.UserUUID, bizCtx.ActorUUID, c.Request.URL.Path, serviceErr)
+		}
+	}
+}
+
+// DenyImpersonation 拒绝模拟登录的请求（用于修改密码、会话管理等账号安全接口）
+func DenyImpersonation() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if bizCtx, ok := businessContext(c); ok && bizCtx.IsImpersonated() {
+			c.JSON(http.StatusForbidden, serializer.Err(40307, "模拟登录期间不能访问该接口", nil))
+			c.Abort()
+			return
+		}
+		c.Next()
+	}
+}
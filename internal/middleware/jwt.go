@@ -3,14 +3,20 @@ package middleware
 import (
 	"go-one/internal/serializer"
 	"go-one/internal/service"
+	"go-one/util"
 	"net/http"
+	"strconv"
 	"strings"
 
 	"github.com/gin-gonic/gin"
 )
 
 // JWTMiddleware JWT认证中间件
-func JWTMiddleware() gin.HandlerFunc {
+// 除签名与有效期外，还会校验令牌版本，确保禁用、改密等操作后旧令牌立即失效
+// 同时接受 Authorization: ApiKey <key>，API 密钥认证与 JWT 填充相同的 BusinessContext
+// 绑定 DPoP 密钥的令牌须以 Authorization: DPoP <token> 出示并附带证明（RFC 9449）
+// 请求所属租户以令牌（API 密钥为其所属用户）为准，请求显式指定了其他租户时拒绝
+func JWTMiddleware(sm *service.ServiceManager) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		// 从请求头获取token
 		authHeader := c.GetHeader("Authorization")
@@ -20,9 +26,27 @@ func JWTMiddleware() gin.HandlerFunc {
 			return
 		}
 
-		// Bearer token格式
 		parts := strings.SplitN(authHeader, " ", 2)
-		if len(parts) != 2 || parts[0] != "Bearer" {
+
+		// API 密钥格式
+		if len(parts) == 2 && parts[0] == "ApiKey" {
+			// 未显式指定租户时按密钥查找用户，认证成功后以用户所属租户为准
+			services := sm
+			if c.GetBool("tenant_explicit") {
+				services = sm.ForTenant(c.GetUint("tenant_id"))
+			}
+			bizCtx := newBusinessContext(c)
+			if serviceErr := services.NewAPIKeyService().Authenticate(bizCtx, strings.TrimSpace(parts[1])); serviceErr != nil {
+				abortWithServiceError(c, serviceErr)
+				return
+			}
+			c.Set("business_context", bizCtx)
+			c.Next()
+			return
+		}
+
+		// Bearer 或 DPoP token格式
+		if len(parts) != 2 || (parts[0] != "Bearer" && parts[0] != "DPoP") {
 			c.JSON(http.StatusUnauthorized, serializer.Err(serializer.CodeUnauthorized, "认证令牌格式错误", nil))
 			c.Abort()
 			return
@@ -38,13 +62,102 @@ func JWTMiddleware() gin.HandlerFunc {
 			return
 		}
 
+		// 令牌所属租户须与请求显式指定的租户一致
+		tenantID := claims.Tenant()
+		if c.GetBool("tenant_explicit") && c.GetUint("tenant_id") != tenantID {
+			c.JSON(http.StatusUnauthorized, serializer.Err(serializer.CodeUnauthorized, "认证令牌不属于当前租户", nil))
+			c.Abort()
+			return
+		}
+		services := sm.ForTenant(tenantID)
+
+		// 校验令牌版本（Redis 缓存）
+		if serviceErr := services.NewTokenVersionService().Verify(claims); serviceErr != nil {
+			c.JSON(http.StatusUnauthorized, serializer.Err(serializer.CodeUnauthorized, serviceErr.GetMessage(), nil))
+			c.Abort()
+			return
+		}
+
+		// 所属会话已被撤销（退出指定设备、会话数淘汰等）的令牌立即失效
+		if serviceErr := services.NewSessionService().CheckRevoked(claims); serviceErr != nil {
+			c.JSON(http.StatusUnauthorized, serializer.Err(serializer.CodeUnauthorized, serviceErr.GetMessage(), nil))
+			c.Abort()
+			return
+		}
+
+		// 持有证明：DPoP 绑定的令牌不能作为 Bearer 令牌使用，未绑定的令牌也不能以 DPoP 方案出示
+		var dpopKey string
+		if boundKey := claims.BoundKey(); parts[0] == "DPoP" || boundKey != "" {
+			if parts[0] != "DPoP" || boundKey == "" {
+				c.Header("WWW-Authenticate", `DPoP error="invalid_token"`)
+				c.JSON(http.StatusUnauthorized, serializer.Err(serializer.CodeUnauthorized, "认证方案与令牌类型不匹配", nil))
+				c.Abort()
+				return
+			}
+			jkt, serviceErr := verifyDPoPAccess(c, tokenString, boundKey)
+			if serviceErr != nil {
+				if serviceErr.GetCode() >= 50000 {
+					abortWithServiceError(c, serviceErr)
+					return
+				}
+				c.Header("WWW-Authenticate", `DPoP error="invalid_dpop_proof"`)
+				c.JSON(http.StatusUnauthorized, serializer.Err(serializer.CodeUnauthorized, serviceErr.GetMessage(), nil))
+				c.Abort()
+				return
+			}
+			dpopKey = jkt
+		}
+
+		// 签发给 OAuth 应用的令牌：检查是否已被吊销
+		if claims.ClientID != "" {
+			if serviceErr := services.NewOAuthServerService().CheckRevoked(claims); serviceErr != nil {
+				c.JSON(http.StatusUnauthorized, serializer.Err(serializer.CodeUnauthorized, serviceErr.GetMessage(), nil))
+				c.Abort()
+				return
+			}
+		}
+
+		// 模拟令牌：实际操作的管理员须仍拥有模拟权限
+		if claims.Actor != nil {
+			if serviceErr := services.NewImpersonationService().VerifyActor(claims); serviceErr != nil {
+				abortWithServiceError(c, serviceErr)
+				return
+			}
+		}
+
 		// 获取claims并创建BusinessContext
 		if claims != nil {
 			// 创建BusinessContext并注入上下文
-			bizCtx := service.NewBusinessContext(c.Request.Context()).
+			bizCtx := newBusinessContext(c).
+				WithTenant(tenantID).
 				WithClaims(claims).
-				WithClientIP(c.ClientIP()).
-				WithUserAgent(c.GetHeader("User-Agent"))
+				WithDPoPKey(dpopKey)
+			if claims.Actor != nil {
+				bizCtx.WithActor(claims.Actor.Subject)
+			}
+
+			// 解析用户角色与权限（Redis 缓存）
+			if uid64, err := strconv.ParseUint(claims.UserID, 10, 64); err == nil {
+				access, err := services.NewRBACService().Access(uint(uid64))
+				if err != nil {
+					c.JSON(http.StatusInternalServerError, serializer.Err(serializer.CodeError, "获取用户权限失败", nil))
+					c.Abort()
+					return
+				}
+				permissions := access.Permissions
+				// OAuth 应用令牌的有效权限为用户当前权限与授权范围的交集
+				if claims.ClientID != "" {
+					scopes := strings.Fields(claims.Scope)
+					permissions = make([]string, 0, len(access.Permissions))
+					for _, p := range access.Permissions {
+						if util.ContainsString(scopes, p) {
+							permissions = append(permissions, p)
+						}
+					}
+					bizCtx.WithOAuthClient(claims.ClientID, scopes)
+				}
+				bizCtx.WithAccess(access.Roles, permissions)
+			}
 
 			c.Set("business_context", bizCtx)
 			c.Next()
@@ -55,3 +168,16 @@ func JWTMiddleware() gin.HandlerFunc {
 		}
 	}
 }
+
+// abortWithServiceError 将认证阶段的 ServiceError 转换为响应并中止请求
+func abortWithServiceError(c *gin.Context, err service.ServiceError) {
+	switch code := err.GetCode(); {
+	case code == 40001:
+		c.JSON(http.StatusUnauthorized, serializer.Err(serializer.CodeUnauthorized, err.GetMessage(), nil))
+	case code == 40003 || (code >= 40300 && code < 40400):
+		c.JSON(http.StatusForbidden, serializer.Err(code, err.GetMessage(), nil))
+	default:
+		c.JSON(http.StatusInternalServerError, serializer.Err(serializer.CodeError, err.GetMessage(), nil))
+	}
+	c.Abort()
+}
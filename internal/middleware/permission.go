@@ -0,0 +1,30 @@
+package middleware
+
+import (
+	"go-one/internal/serializer"
+	"go-one/internal/service"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// RequirePermission 权限校验中间件，需在 JWTMiddleware 之后使用
+func RequirePermission(permission string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		bizCtxVal, exists := c.Get("business_context")
+		bizCtx, ok := bizCtxVal.(*service.BusinessContext)
+		if !exists || !ok || !bizCtx.IsAuthenticated() {
+			c.JSON(http.StatusUnauthorized, serializer.Err(serializer.CodeUnauthorized, "未认证", nil))
+			c.Abort()
+			return
+		}
+
+		if !bizCtx.HasPermission(permission) {
+			c.JSON(http.StatusForbidden, serializer.Err(serializer.CodeForbidden, "无权限访问", nil))
+			c.Abort()
+			return
+		}
+
+		c.Next()
+	}
+}
@@ -16,7 +16,8 @@ import (
 // RateLimitMiddleware 创建一个基于令牌桶算法的限流中间件
 // limit: 桶容量（最大令牌数）
 // period: 补充令牌的周期
-// identifierType: 限流标识类型（"user" 或 "ip"）
+// identifierType: 限流标识类型（"user"、"api_key" 或 "ip"）
+// "api_key"：API 密钥请求按密钥独立计数，JWT 请求按用户计数
 func RateLimitMiddleware(limit int64, period time.Duration, identifierType string) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		var identifier string
@@ -36,6 +37,18 @@ func RateLimitMiddleware(limit int64, period time.Duration, identifierType strin
 				c.Abort()
 				return
 			}
+		case "api_key":
+			bizCtx, ok := businessContext(c)
+			if !ok || !bizCtx.IsAuthenticated() {
+				c.JSON(http.StatusUnauthorized, serializer.Err(serializer.CodeUnauthorized, "用户未认证，无法进行限流", nil))
+				c.Abort()
+				return
+			}
+			if bizCtx.IsAPIKey() {
+				identifier = "key:" + strconv.FormatUint(uint64(bizCtx.APIKeyID), 10)
+			} else {
+				identifier = "user:" + bizCtx.UserUUID
+			}
 		case "ip":
 			identifier = c.ClientIP()
 		default:
@@ -44,11 +57,14 @@ func RateLimitMiddleware(limit int64, period time.Duration, identifierType strin
 			return
 		}
 
-		bucketKey := fmt.Sprintf("token_bucket:%s:%s:%s:%s",
+		// 键中包含容量与周期：同一路由叠加多个限流器时各自独立计数，互不覆盖
+		bucketKey := fmt.Sprintf("token_bucket:%s:%s:%s:%s:%d/%d",
 			identifierType,
 			identifier,
 			c.Request.Method,
 			c.FullPath(),
+			limit,
+			int64(period/time.Second),
 		)
 
 		ctx := context.Background()
@@ -0,0 +1,43 @@
+package middleware
+
+import (
+	"go-one/internal/serializer"
+	"go-one/internal/service"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// RequireScope API 密钥与 OAuth 应用令牌的作用域校验，交互式登录的请求直接放行
+func RequireScope(scope string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if bizCtx, ok := businessContext(c); ok && !bizCtx.HasScope(scope) {
+			c.JSON(http.StatusForbidden, serializer.Err(serializer.CodeForbidden, "凭证缺少作用域: "+scope, nil))
+			c.Abort()
+			return
+		}
+		c.Next()
+	}
+}
+
+// DenyAPIKey 拒绝 API 密钥与 OAuth 应用令牌访问（用于修改密码、会话、二次验证、密钥管理等账号安全接口）
+func DenyAPIKey() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if bizCtx, ok := businessContext(c); ok && bizCtx.IsDelegated() {
+			c.JSON(http.StatusForbidden, serializer.Err(serializer.CodeForbidden, "该接口不支持 API 密钥或第三方应用访问", nil))
+			c.Abort()
+			return
+		}
+		c.Next()
+	}
+}
+
+// businessContext 从 gin.Context 读取认证中间件注入的 BusinessContext
+func businessContext(c *gin.Context) (*service.BusinessContext, bool) {
+	val, exists := c.Get("business_context")
+	if !exists {
+		return nil, false
+	}
+	bizCtx, ok := val.(*service.BusinessContext)
+	return bizCtx, ok
+}
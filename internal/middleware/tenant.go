@@ -0,0 +1,49 @@
+package middleware
+
+import (
+	"go-one/internal/serializer"
+	"go-one/internal/service"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// TenantMiddleware 解析请求所属租户：优先读取租户请求头，其次按子域名解析，均未指定时使用默认租户
+// 受保护接口以令牌中的 tid 为准，请求显式指定的租户与令牌不一致时由 JWTMiddleware 拒绝
+func TenantMiddleware(sm *service.ServiceManager) gin.HandlerFunc {
+	tenants := sm.NewTenantService()
+	return func(c *gin.Context) {
+		slug := strings.TrimSpace(c.GetHeader(service.Tenancy.Header))
+		if slug == "" {
+			slug = service.TenantFromHost(c.Request.Host)
+		}
+
+		tenantID := service.Tenancy.DefaultID
+		if slug != "" {
+			id, serviceErr := tenants.Resolve(slug)
+			if serviceErr != nil {
+				if serviceErr.GetCode() == 40004 {
+					c.JSON(http.StatusNotFound, serializer.Err(serializer.CodeNotFound, serviceErr.GetMessage(), nil))
+					c.Abort()
+					return
+				}
+				abortWithServiceError(c, serviceErr)
+				return
+			}
+			tenantID = id
+			c.Set("tenant_explicit", true)
+		}
+		c.Set("tenant_id", tenantID)
+		c.Next()
+	}
+}
+
+// newBusinessContext 创建携带请求元数据与所属租户的 BusinessContext
+func newBusinessContext(c *gin.Context) *service.BusinessContext {
+	return service.NewBusinessContext(c.Request.Context()).
+		WithTenant(c.GetUint("tenant_id")).
+		WithClientIP(c.ClientIP()).
+		WithUserAgent(c.GetHeader("User-Agent")).
+		WithDeviceName(c.GetHeader("X-Device-Name"))
+}
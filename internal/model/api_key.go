@@ -0,0 +1,23 @@
+package model
+
+import "time"
+
+// APIKey 个人访问令牌（供 CI、第三方集成等非交互式客户端使用）
+// 完整密钥形如 go1_<prefix>_<secret>，库中仅保存 secret 的哈希
+type APIKey struct {
+	ID         uint       `gorm:"primaryKey" json:"id"`
+	UserID     uint       `gorm:"index;not null" json:"user_id"`
+	Name       string     `gorm:"size:100;not null" json:"name"`
+	Prefix     string     `gorm:"uniqueIndex;size:16;not null" json:"prefix"`
+	SecretHash string     `gorm:"size:64;not null" json:"-"`
+	Scopes     string     `gorm:"size:1024" json:"scopes"`      // 逗号分隔
+	AllowedIPs string     `gorm:"size:1024" json:"allowed_ips"` // 逗号分隔的 IP/CIDR，空表示不限制
+	ExpiresAt  *time.Time `json:"expires_at"`
+	LastUsedAt *time.Time `json:"last_used_at"`
+	LastUsedIP string     `gorm:"size:64" json:"last_used_ip"`
+	RevokedAt  *time.Time `json:"revoked_at"`
+	CreatedAt  time.Time  `json:"created_at"`
+	UpdatedAt  time.Time  `json:"updated_at"`
+}
+
+func (APIKey) TableName() string { return "api_keys" }
@@ -0,0 +1,29 @@
+package model
+
+import "time"
+
+// 审计动作
+const (
+	AuditActionImpersonationStart   = "impersonation.start"   // 管理员开始模拟用户
+	AuditActionImpersonationRequest = "impersonation.request" // 模拟期间发起的请求
+)
+
+// AuditLog 审计日志（只增不改）
+// 模拟登录期间 ActorID 为实际操作的管理员，UserID 为被模拟的用户，ImpersonationID 为模拟令牌的 jti
+type AuditLog struct {
+	ID              uint      `gorm:"primaryKey" json:"id"`
+	TenantID        uint      `gorm:"<-:create;not null;default:0;index" json:"tenant_id"`
+	ActorID         uint      `gorm:"index;not null" json:"actor_id"`
+	UserID          uint      `gorm:"index" json:"user_id"`
+	ImpersonationID string    `gorm:"size:64;index" json:"impersonation_id"`
+	Action          string    `gorm:"size:64;not null" json:"action"`
+	Method          string    `gorm:"size:10" json:"method"`
+	Path            string    `gorm:"size:512" json:"path"`
+	Status          int       `json:"status"`
+	Detail          string    `gorm:"size:1024" json:"detail"` // 如开始模拟时填写的原因
+	ClientIP        string    `gorm:"size:64" json:"client_ip"`
+	UserAgent       string    `gorm:"size:512" json:"user_agent"`
+	CreatedAt       time.Time `gorm:"index" json:"created_at"`
+}
+
+func (AuditLog) TableName() string { return "audit_logs" }
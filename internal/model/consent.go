@@ -0,0 +1,35 @@
+package model
+
+import "time"
+
+// 协议类型
+const (
+	PolicyTypeTerms   = "terms"   // 服务条款
+	PolicyTypePrivacy = "privacy" // 隐私政策
+)
+
+// PolicyVersion 协议版本（全部租户共用），同一类型以生效时间最新的版本为当前版本
+type PolicyVersion struct {
+	ID          uint      `gorm:"primaryKey" json:"id"`
+	Type        string    `gorm:"uniqueIndex:idx_policy_versions_type_version,priority:1;size:20;not null" json:"type"` // 见 PolicyType* 常量
+	Version     string    `gorm:"uniqueIndex:idx_policy_versions_type_version,priority:2;size:50;not null" json:"version"`
+	URL         string    `gorm:"size:512" json:"url"`                // 协议全文地址
+	Mandatory   bool      `gorm:"not null" json:"mandatory"`          // 为 true 时已有用户须重新同意，否则仅新注册用户同意
+	EffectiveAt time.Time `gorm:"index;not null" json:"effective_at"` // 生效时间，可提前发布
+	CreatedBy   uint      `json:"created_by"`
+	CreatedAt   time.Time `json:"created_at"`
+}
+
+func (PolicyVersion) TableName() string { return "policy_versions" }
+
+// UserConsent 用户同意协议版本的记录（只增不改）
+type UserConsent struct {
+	ID              uint      `gorm:"primaryKey" json:"id"`
+	UserID          uint      `gorm:"uniqueIndex:idx_user_consents_user_version,priority:1;not null" json:"user_id"`
+	PolicyVersionID uint      `gorm:"uniqueIndex:idx_user_consents_user_version,priority:2;not null" json:"policy_version_id"`
+	AcceptedAt      time.Time `gorm:"not null" json:"accepted_at"`
+	ClientIP        string    `gorm:"size:64" json:"client_ip"`
+	UserAgent       string    `gorm:"size:255" json:"user_agent"`
+}
+
+func (UserConsent) TableName() string { return "user_consents" }
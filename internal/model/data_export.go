@@ -0,0 +1,27 @@
+package model
+
+import "time"
+
+// 数据导出状态
+const (
+	DataExportPending = "pending" // 排队中
+	DataExportReady   = "ready"   // 已生成，可下载
+	DataExportFailed  = "failed"  // 生成失败
+)
+
+// DataExport 用户数据导出任务，归档文件生成后在 ExpiresAt 前可通过下载链接获取
+type DataExport struct {
+	ID          uint       `gorm:"primaryKey" json:"id"`
+	TenantID    uint       `gorm:"<-:create;not null;default:0;index" json:"tenant_id"`
+	UserID      uint       `gorm:"index;not null" json:"user_id"`
+	Status      string     `gorm:"size:20;not null" json:"status"` // 见 DataExport* 常量
+	FilePath    string     `gorm:"size:512" json:"-"`              // 归档文件在服务器上的路径
+	FileSize    int64      `json:"file_size"`
+	Error       string     `gorm:"size:255" json:"-"` // 生成失败的原因，仅记录在服务端
+	CompletedAt *time.Time `json:"completed_at"`
+	ExpiresAt   *time.Time `gorm:"index" json:"expires_at"` // 下载链接与归档文件的过期时间
+	CreatedAt   time.Time  `json:"created_at"`
+	UpdatedAt   time.Time  `json:"updated_at"`
+}
+
+func (DataExport) TableName() string { return "data_exports" }
@@ -55,6 +55,11 @@ func database(connString, tz string) {
 	sqlDB.SetMaxIdleConns(10)
 	// 打开
 	sqlDB.SetMaxOpenConns(20)
+	// 注册租户隔离回调（见 TenantScope）
+	if err := registerTenantCallbacks(db); err != nil {
+		util.Log().Error("注册租户回调失败: %v", err)
+		panic(err)
+	}
 	DB = db
 
 	// 设置数据库会话时区（默认 Asia/Shanghai，可通过 DB_TIMEZONE 配置）
@@ -0,0 +1,31 @@
+package model
+
+import "time"
+
+// Invite 注册邀请码（邀请注册模式下使用），库中仅保存邀请码的哈希
+type Invite struct {
+	ID        uint       `gorm:"primaryKey" json:"id"`
+	TenantID  uint       `gorm:"<-:create;not null;default:0;index" json:"tenant_id"` // 所属租户，只能用于该租户的注册
+	Prefix    string     `gorm:"size:8;not null" json:"prefix"`                       // 邀请码前几位，便于管理员辨认
+	CodeHash  string     `gorm:"uniqueIndex;size:64;not null" json:"-"`
+	Note      string     `gorm:"size:255" json:"note"`
+	MaxUses   int        `gorm:"not null;default:1" json:"max_uses"` // 可使用次数，1 为单次邀请码
+	UsedCount int        `gorm:"not null;default:0" json:"used_count"`
+	ExpiresAt *time.Time `json:"expires_at"` // 为空表示不过期
+	CreatedBy uint       `gorm:"index" json:"created_by"`
+	RevokedAt *time.Time `json:"revoked_at"`
+	CreatedAt time.Time  `json:"created_at"`
+	UpdatedAt time.Time  `json:"updated_at"`
+}
+
+func (Invite) TableName() string { return "invites" }
+
+// InviteRedemption 邀请码使用记录
+type InviteRedemption struct {
+	ID        uint      `gorm:"primaryKey" json:"id"`
+	InviteID  uint      `gorm:"index;not null" json:"invite_id"`
+	UserID    uint      `gorm:"uniqueIndex;not null" json:"user_id"` // 通过该邀请码注册的用户
+	CreatedAt time.Time `json:"created_at"`
+}
+
+func (InviteRedemption) TableName() string { return "invite_redemptions" }
@@ -0,0 +1,14 @@
+package model
+
+import "time"
+
+// MFARecoveryCode 二次验证恢复码（仅保存哈希，每个码只能使用一次）
+type MFARecoveryCode struct {
+	ID        uint       `gorm:"primaryKey" json:"id"`
+	UserID    uint       `gorm:"index;not null" json:"user_id"`
+	CodeHash  string     `gorm:"size:64;not null" json:"-"`
+	UsedAt    *time.Time `json:"used_at"`
+	CreatedAt time.Time  `json:"created_at"`
+}
+
+func (MFARecoveryCode) TableName() string { return "mfa_recovery_codes" }
@@ -3,6 +3,35 @@ package model
 //执行数据迁移
 
 func migration() {
+    _ = DB.AutoMigrate(&Tenant{})
+    // 用户名与已验证邮箱改为租户内唯一，移除旧的全局唯一索引（含早期的全量邮箱唯一索引）
+    for _, index := range []string{"idx_users_email", "idx_users_username", "idx_users_email_verified"} {
+        if DB.Migrator().HasIndex(&User{}, index) {
+            _ = DB.Migrator().DropIndex(&User{}, index)
+        }
+    }
     _ = DB.AutoMigrate(&User{})
+    _ = DB.Exec("CREATE UNIQUE INDEX IF NOT EXISTS idx_users_tenant_email_verified ON users (tenant_id, email) WHERE email_verified").Error
+    _ = DB.Exec("CREATE UNIQUE INDEX IF NOT EXISTS idx_users_tenant_phone_verified ON users (tenant_id, phone) WHERE phone_verified").Error
     _ = DB.AutoMigrate(&RefreshToken{})
+    // 会话管理上线前签发的刷新令牌没有会话ID，以 JTI 补齐，使其可被列出与撤销（旋转时沿用）
+    _ = DB.Exec("UPDATE refresh_tokens SET session_id = jti WHERE session_id IS NULL OR session_id = ''").Error
+    _ = DB.AutoMigrate(&MFARecoveryCode{})
+    _ = DB.AutoMigrate(&PasswordResetToken{})
+    _ = DB.AutoMigrate(&Permission{}, &Role{}, &UserRole{})
+    _ = DB.AutoMigrate(&APIKey{})
+    _ = DB.AutoMigrate(&UserStatusChange{})
+    if DB.Migrator().HasIndex(&UserIdentity{}, "idx_user_identities_provider_subject") {
+        _ = DB.Migrator().DropIndex(&UserIdentity{}, "idx_user_identities_provider_subject")
+    }
+    _ = DB.AutoMigrate(&UserIdentity{})
+    _ = DB.AutoMigrate(&OAuthClient{}, &OAuthConsent{})
+    _ = DB.AutoMigrate(&AuditLog{})
+    _ = DB.AutoMigrate(&Invite{}, &InviteRedemption{})
+    _ = DB.AutoMigrate(&PolicyVersion{}, &UserConsent{})
+    _ = DB.AutoMigrate(&DataExport{})
+    _ = DB.AutoMigrate(&SecurityEvent{})
+
+    seedDefaultTenant()
+    seedRBAC()
 }
@@ -0,0 +1,35 @@
+package model
+
+import "time"
+
+// OAuthClient 在本服务注册的 OAuth 应用（内部第一方应用）
+// 机密应用库中仅保存 client_secret 的哈希；公开应用（SPA、移动端）没有密钥，必须使用 PKCE
+type OAuthClient struct {
+	ID           uint       `gorm:"primaryKey" json:"id"`
+	ClientID     string     `gorm:"uniqueIndex;size:64;not null" json:"client_id"`
+	SecretHash   string     `gorm:"size:64" json:"-"`
+	Name         string     `gorm:"size:100;not null" json:"name"`
+	RedirectURIs string     `gorm:"size:2048" json:"redirect_uris"` // 逗号分隔，回调地址须完全匹配
+	Scopes       string     `gorm:"size:1024" json:"scopes"`        // 允许申请的作用域，逗号分隔
+	GrantTypes   string     `gorm:"size:255" json:"grant_types"`    // 允许的授权类型，逗号分隔
+	Public       bool       `gorm:"default:false" json:"public"`
+	CreatedBy    uint       `json:"created_by"`
+	RevokedAt    *time.Time `json:"revoked_at"`
+	CreatedAt    time.Time  `json:"created_at"`
+	UpdatedAt    time.Time  `json:"updated_at"`
+}
+
+func (OAuthClient) TableName() string { return "oauth_clients" }
+
+// OAuthConsent 用户对 OAuth 应用的授权同意记录
+// 再次授权时若申请的作用域已全部同意过则无需重复确认
+type OAuthConsent struct {
+	ID        uint      `gorm:"primaryKey" json:"id"`
+	UserID    uint      `gorm:"not null;uniqueIndex:idx_oauth_consents_user_client" json:"user_id"`
+	ClientID  string    `gorm:"size:64;not null;uniqueIndex:idx_oauth_consents_user_client" json:"client_id"`
+	Scopes    string    `gorm:"size:1024" json:"scopes"` // 已同意的作用域，逗号分隔
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+func (OAuthConsent) TableName() string { return "oauth_consents" }
@@ -0,0 +1,16 @@
+package model
+
+import "time"
+
+// PasswordResetToken 找回密码令牌（仅保存哈希，单次有效）
+type PasswordResetToken struct {
+	ID        uint       `gorm:"primaryKey" json:"id"`
+	UserID    uint       `gorm:"index;not null" json:"user_id"`
+	TokenHash string     `gorm:"uniqueIndex;size:64;not null" json:"-"`
+	ExpiresAt time.Time  `gorm:"index;not null" json:"expires_at"`
+	UsedAt    *time.Time `json:"used_at"`
+	RequestIP string     `gorm:"size:64" json:"request_ip"`
+	CreatedAt time.Time  `json:"created_at"`
+}
+
+func (PasswordResetToken) TableName() string { return "password_reset_tokens" }
@@ -0,0 +1,73 @@
+package model
+
+import "time"
+
+// 内置角色与权限
+const (
+	RoleAdmin = "admin"
+
+	PermissionUsersList        = "users:list"           // 查看用户列表
+	PermissionUsersManage      = "users:manage"         // 管理用户（解锁、禁用等）
+	PermissionUsersImpersonate = "users:impersonate"    // 模拟用户登录
+	PermissionRolesManage      = "roles:manage"         // 分配与撤销角色
+	PermissionOAuthClients     = "oauth_clients:manage" // 注册与吊销 OAuth 应用
+	PermissionInvitesManage    = "invites:manage"       // 签发与撤销注册邀请码
+	PermissionPoliciesManage   = "policies:manage"      // 发布服务条款与隐私政策版本
+)
+
+// Role 角色
+type Role struct {
+	ID          uint         `gorm:"primaryKey" json:"id"`
+	Name        string       `gorm:"uniqueIndex;size:50;not null" json:"name"`
+	Description string       `gorm:"size:255" json:"description"`
+	Permissions []Permission `gorm:"many2many:role_permissions;" json:"permissions"`
+	CreatedAt   time.Time    `json:"created_at"`
+	UpdatedAt   time.Time    `json:"updated_at"`
+}
+
+func (Role) TableName() string { return "roles" }
+
+// Permission 权限（以 资源:操作 形式命名）
+type Permission struct {
+	ID          uint      `gorm:"primaryKey" json:"id"`
+	Code        string    `gorm:"uniqueIndex;size:100;not null" json:"code"`
+	Description string    `gorm:"size:255" json:"description"`
+	CreatedAt   time.Time `json:"created_at"`
+}
+
+func (Permission) TableName() string { return "permissions" }
+
+// UserRole 用户与角色的关联
+type UserRole struct {
+	UserID    uint      `gorm:"primaryKey" json:"user_id"`
+	RoleID    uint      `gorm:"primaryKey;index" json:"role_id"`
+	GrantedBy uint      `json:"granted_by"` // 授权的管理员，0 表示系统初始化
+	CreatedAt time.Time `json:"created_at"`
+}
+
+func (UserRole) TableName() string { return "user_roles" }
+
+// seedRBAC 初始化内置权限与 admin 角色（幂等）
+func seedRBAC() {
+	permissions := []Permission{
+		{Code: PermissionUsersList, Description: "查看用户列表"},
+		{Code: PermissionUsersManage, Description: "管理用户"},
+		{Code: PermissionUsersImpersonate, Description: "模拟用户登录"},
+		{Code: PermissionRolesManage, Description: "分配与撤销角色"},
+		{Code: PermissionOAuthClients, Description: "管理 OAuth 应用"},
+		{Code: PermissionInvitesManage, Description: "管理注册邀请码"},
+		{Code: PermissionPoliciesManage, Description: "发布协议版本"},
+	}
+	for i := range permissions {
+		_ = DB.Where(Permission{Code: permissions[i].Code}).
+			Attrs(Permission{Description: permissions[i].Description}).
+			FirstOrCreate(&permissions[i]).Error
+	}
+
+	admin := Role{Name: RoleAdmin}
+	if err := DB.Where(Role{Name: RoleAdmin}).Attrs(Role{Description: "系统管理员"}).FirstOrCreate(&admin).Error; err != nil {
+		return
+	}
+	// admin 始终拥有全部内置权限
+	_ = DB.Model(&admin).Association("Permissions").Append(permissions)
+}
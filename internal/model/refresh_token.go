@@ -3,16 +3,26 @@ package model
 import "time"
 
 // RefreshToken 用于持久化和旋转的刷新令牌记录
+// 同一次登录（或一次 OAuth 授权）产生的旋转链共享 SessionID，即一个“会话”
 type RefreshToken struct {
-    ID          uint      `gorm:"primaryKey" json:"id"`
-    JTI         string    `gorm:"uniqueIndex;size:64;not null" json:"jti"`
-    UserID      uint      `gorm:"index;not null" json:"user_id"`
-    ExpiresAt   time.Time `json:"expires_at"`
-    Revoked     bool      `gorm:"default:false" json:"revoked"`
-    RotatedFrom string    `gorm:"size:64" json:"rotated_from"`
-    CreatedAt   time.Time `json:"created_at"`
-    UpdatedAt   time.Time `json:"updated_at"`
+	ID              uint      `gorm:"primaryKey" json:"id"`
+	TenantID        uint      `gorm:"<-:create;not null;default:0;index" json:"tenant_id"`
+	JTI             string    `gorm:"uniqueIndex;size:64;not null" json:"jti"`
+	UserID          uint      `gorm:"index;not null" json:"user_id"`
+	SessionID       string    `gorm:"index;size:64" json:"session_id"`
+	ExpiresAt       time.Time `json:"expires_at"`
+	Revoked         bool      `gorm:"default:false" json:"revoked"`
+	RotatedFrom     string    `gorm:"size:64" json:"rotated_from"`
+	DeviceName      string    `gorm:"size:100" json:"device_name"`
+	ClientIP        string    `gorm:"size:64" json:"client_ip"`
+	UserAgent       string    `gorm:"size:512" json:"user_agent"`
+	LastUsedAt      time.Time `json:"last_used_at"`
+	AuthenticatedAt time.Time `json:"authenticated_at"`                 // 会话首次认证时间，旋转时沿用
+	ClientID        string    `gorm:"size:64;index" json:"client_id"`   // 签发给 OAuth 应用时的 client_id，自有登录为空
+	Scope           string    `gorm:"size:1024" json:"scope"`           // OAuth 授权范围（空格分隔），旋转时沿用
+	DPoPKey         string    `gorm:"column:dpop_jkt;size:64" json:"-"` // DPoP 绑定的公钥指纹，刷新时须出示同一密钥的证明
+	CreatedAt       time.Time `json:"created_at"`
+	UpdatedAt       time.Time `json:"updated_at"`
 }
 
 func (RefreshToken) TableName() string { return "refresh_tokens" }
-
@@ -0,0 +1,19 @@
+package model
+
+import "time"
+
+// SecurityEvent 用户的安全事件记录（登录、刷新、登出、修改密码、锁定等，只增不改）
+// NewDevice 为 true 表示该次登录来自此前未出现过的设备（User-Agent）或 IP
+type SecurityEvent struct {
+	ID        uint      `gorm:"primaryKey" json:"id"`
+	TenantID  uint      `gorm:"<-:create;not null;default:0;index" json:"tenant_id"`
+	UserID    uint      `gorm:"index:idx_security_events_user_created,priority:1;not null" json:"user_id"`
+	Type      string    `gorm:"size:40;not null;index" json:"type"`
+	ClientIP  string    `gorm:"size:64" json:"client_ip"`
+	UserAgent string    `gorm:"size:512" json:"user_agent"`
+	NewDevice bool      `gorm:"not null;default:false" json:"new_device"`
+	Detail    string    `gorm:"size:1024" json:"detail"` // JSON 格式的附加信息，如会话ID、锁定时长
+	CreatedAt time.Time `gorm:"index:idx_security_events_user_created,priority:2" json:"created_at"`
+}
+
+func (SecurityEvent) TableName() string { return "security_events" }
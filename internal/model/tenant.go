@@ -0,0 +1,97 @@
+package model
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// 租户状态
+const (
+	TenantStatusDisabled = 0 // 已停用，拒绝该租户的全部请求
+	TenantStatusActive   = 1 // 正常
+)
+
+// DefaultTenantSlug 默认租户标识，单租户部署与升级前的存量数据归属该租户
+const DefaultTenantSlug = "default"
+
+// tenantSettingKey 会话中记录所属租户的 Settings 键，由 TenantScope 写入、租户回调读取
+const tenantSettingKey = "tenant:id"
+
+// Tenant 租户，通过子域名、请求头或令牌中的 tid 解析
+type Tenant struct {
+	ID        uint      `gorm:"primaryKey" json:"id"`
+	Slug      string    `gorm:"uniqueIndex;size:63;not null" json:"slug"` // 子域名与 X-Tenant 请求头使用的标识
+	Name      string    `gorm:"size:100;not null" json:"name"`
+	Status    int       `gorm:"default:1" json:"status"` // 见 TenantStatus* 常量
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+func (Tenant) TableName() string { return "tenants" }
+
+// TenantScope GORM 作用域：将会话标记为所属租户
+// 对含 TenantID 字段的模型，查询、更新与删除自动附加 tenant_id 条件，创建时自动填充 TenantID
+// 原生 SQL（Raw/Exec）不经过该处理，租户隔离的表只能通过查询构造器访问
+func TenantScope(tenantID uint) func(*gorm.DB) *gorm.DB {
+	return func(db *gorm.DB) *gorm.DB {
+		return db.Set(tenantSettingKey, tenantID)
+	}
+}
+
+// registerTenantCallbacks 注册租户隔离回调
+func registerTenantCallbacks(db *gorm.DB) error {
+	if err := db.Callback().Create().Before("gorm:create").Register("tenant:assign", assignTenant); err != nil {
+		return err
+	}
+	if err := db.Callback().Query().Before("gorm:query").Register("tenant:query", filterTenant); err != nil {
+		return err
+	}
+	if err := db.Callback().Update().Before("gorm:update").Register("tenant:update", filterTenant); err != nil {
+		return err
+	}
+	return db.Callback().Delete().Before("gorm:delete").Register("tenant:delete", filterTenant)
+}
+
+// statementTenant 返回会话所属租户；未标记租户、模型不含 TenantID 字段时返回 false
+func statementTenant(db *gorm.DB) (uint, bool) {
+	value, ok := db.Get(tenantSettingKey)
+	if !ok || db.Statement.Schema == nil || db.Statement.Schema.LookUpField("TenantID") == nil {
+		return 0, false
+	}
+	tenantID, ok := value.(uint)
+	return tenantID, ok
+}
+
+// assignTenant 创建记录时写入会话所属租户，覆盖调用方传入的值
+func assignTenant(db *gorm.DB) {
+	if tenantID, ok := statementTenant(db); ok {
+		db.Statement.SetColumn("TenantID", tenantID, true)
+	}
+}
+
+// filterTenant 为查询、更新与删除附加 tenant_id 条件
+func filterTenant(db *gorm.DB) {
+	if db.Statement.SQL.Len() > 0 {
+		return
+	}
+	if tenantID, ok := statementTenant(db); ok {
+		db.Statement.AddClause(clause.Where{Exprs: []clause.Expression{
+			clause.Eq{Column: clause.Column{Table: clause.CurrentTable, Name: "tenant_id"}, Value: tenantID},
+		}})
+	}
+}
+
+// seedDefaultTenant 创建默认租户并将升级前的存量数据归属到该租户（幂等，需在相关表迁移之后调用）
+func seedDefaultTenant() {
+	tenant := Tenant{Slug: DefaultTenantSlug}
+	if err := DB.Where(Tenant{Slug: DefaultTenantSlug}).
+		Attrs(Tenant{Name: "Default", Status: TenantStatusActive}).
+		FirstOrCreate(&tenant).Error; err != nil {
+		return
+	}
+	_ = DB.Exec("UPDATE users SET tenant_id = ? WHERE tenant_id = 0", tenant.ID).Error
+	_ = DB.Exec("UPDATE refresh_tokens SET tenant_id = ? WHERE tenant_id = 0", tenant.ID).Error
+	_ = DB.Exec("UPDATE user_identities SET tenant_id = ? WHERE tenant_id = 0", tenant.ID).Error
+}
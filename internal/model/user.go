@@ -4,17 +4,38 @@ import (
 	"time"
 )
 
+// 用户状态
+const (
+	UserStatusSuspended = 0 // 已暂停（可设置截止时间，兼容原“禁用”）
+	UserStatusActive    = 1 // 正常
+	UserStatusPending   = 2 // 待激活（等待邮箱验证）
+	UserStatusBanned    = 3 // 已封禁
+	UserStatusDeleted   = 4 // 已删除
+)
+
 // User 用户模型（示例）
 type User struct {
-	ID        uint      `gorm:"primaryKey" json:"id"`
-	Username  string    `gorm:"uniqueIndex;size:50;not null" json:"username"`
-	Email     string    `gorm:"uniqueIndex;size:100" json:"email"`
-	Password  string    `gorm:"size:255;not null" json:"-"` // 不在JSON中显示
-	Nickname  string    `gorm:"size:50" json:"nickname"`
-	Avatar    string    `gorm:"size:255" json:"avatar"`
-	Status    int       `gorm:"default:1" json:"status"` // 1-正常 0-禁用
-	CreatedAt time.Time `json:"created_at"`
-	UpdatedAt time.Time `json:"updated_at"`
+	ID              uint       `gorm:"primaryKey" json:"id"`
+	TenantID        uint       `gorm:"<-:create;not null;default:0;uniqueIndex:idx_users_tenant_username,priority:1" json:"tenant_id"` // 所属租户，创建后不可变更
+	Username        string     `gorm:"uniqueIndex:idx_users_tenant_username,priority:2;size:50;not null" json:"username"`              // 租户内唯一
+	Email           string     `gorm:"index:idx_users_email_lookup;size:100" json:"email"`                                             // 仅已验证邮箱在租户内唯一，见 migration
+	EmailVerified   bool       `gorm:"default:false" json:"email_verified"`
+	EmailVerifiedAt *time.Time `json:"email_verified_at,omitempty"`
+	Phone           string     `gorm:"index:idx_users_phone_lookup;size:20" json:"phone"` // 仅已验证手机号在租户内唯一，见 migration
+	PhoneVerified   bool       `gorm:"default:false" json:"phone_verified"`
+	Password        string     `gorm:"size:255;not null" json:"-"` // 不在JSON中显示
+	Nickname        string     `gorm:"size:50" json:"nickname"`
+	Avatar          string     `gorm:"size:255" json:"avatar"`
+	Status          int        `gorm:"default:1;index" json:"status"`         // 见 UserStatus* 常量
+	StatusReason    string     `gorm:"size:255" json:"status_reason"`         // 最近一次状态变更的原因
+	SuspendedUntil  *time.Time `json:"suspended_until,omitempty"`             // 暂停截止时间，为空表示无限期
+	TokenVersion    int        `gorm:"<-:create;not null;default:0" json:"-"` // 令牌版本，递增后已签发令牌全部失效
+	MFAEnabled      bool       `gorm:"default:false" json:"mfa_enabled"`
+	TOTPSecret      string     `gorm:"size:255" json:"-"`  // AES-GCM 加密后的 TOTP 密钥（启用前为待确认密钥）
+	TOTPLastStep    int64      `gorm:"default:0" json:"-"` // 最近一次通过校验的时间步，防止验证码重放
+	PurgeAt         *time.Time `gorm:"index" json:"-"`     // 用户注销账号后计划彻底删除的时间
+	CreatedAt       time.Time  `json:"created_at"`
+	UpdatedAt       time.Time  `json:"updated_at"`
 }
 
 // TableName 指定表名
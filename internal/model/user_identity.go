@@ -0,0 +1,18 @@
+package model
+
+import "time"
+
+// UserIdentity 外部身份（OIDC 提供方 + subject）与本地用户的关联，同一外部身份可在每个租户各关联一个用户
+type UserIdentity struct {
+	ID          uint       `gorm:"primaryKey" json:"id"`
+	TenantID    uint       `gorm:"<-:create;not null;default:0;uniqueIndex:idx_user_identities_tenant_subject,priority:1" json:"tenant_id"`
+	UserID      uint       `gorm:"index;not null" json:"user_id"`
+	Provider    string     `gorm:"size:64;not null;uniqueIndex:idx_user_identities_tenant_subject,priority:2" json:"provider"`
+	Subject     string     `gorm:"size:255;not null;uniqueIndex:idx_user_identities_tenant_subject,priority:3" json:"subject"`
+	Email       string     `gorm:"size:100" json:"email"` // 最近一次登录时提供方返回的邮箱
+	LastLoginAt *time.Time `json:"last_login_at"`
+	CreatedAt   time.Time  `json:"created_at"`
+	UpdatedAt   time.Time  `json:"updated_at"`
+}
+
+func (UserIdentity) TableName() string { return "user_identities" }
@@ -0,0 +1,17 @@
+package model
+
+import "time"
+
+// UserStatusChange 用户状态变更记录
+type UserStatusChange struct {
+	ID         uint       `gorm:"primaryKey" json:"id"`
+	UserID     uint       `gorm:"index;not null" json:"user_id"`
+	FromStatus int        `json:"from_status"`
+	ToStatus   int        `json:"to_status"`
+	Reason     string     `gorm:"size:255" json:"reason"`
+	ExpiresAt  *time.Time `json:"expires_at,omitempty"`     // 暂停截止时间
+	OperatorID uint       `gorm:"index" json:"operator_id"` // 操作人，0 表示系统
+	CreatedAt  time.Time  `json:"created_at"`
+}
+
+func (UserStatusChange) TableName() string { return "user_status_changes" }
@@ -0,0 +1,231 @@
+package oauth
+
+import (
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"go-one/util"
+	"math/big"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// keysRefreshInterval 遇到未知 kid 时重新拉取 JWKS 的最小间隔，防止伪造的 kid 放大请求
+const keysRefreshInterval = time.Minute
+
+// defaultSigningAlgs 发现文档未声明时接受的 ID Token 签名算法（仅非对称算法）
+var defaultSigningAlgs = []string{"RS256", "RS384", "RS512", "PS256", "PS384", "PS512", "ES256", "ES384", "ES512", "EdDSA"}
+
+// IDTokenClaims ID Token 声明
+type IDTokenClaims struct {
+	Nonce             string       `json:"nonce"`
+	Email             string       `json:"email"`
+	EmailVerified     flexibleBool `json:"email_verified"`
+	Name              string       `json:"name"`
+	PreferredUsername string       `json:"preferred_username"`
+	Picture           string       `json:"picture"`
+	AuthorizedParty   string       `json:"azp"`
+	jwt.RegisteredClaims
+}
+
+// flexibleBool 兼容部分提供方以字符串 "true"/"false" 表示布尔值
+type flexibleBool bool
+
+func (b *flexibleBool) UnmarshalJSON(data []byte) error {
+	var value interface{}
+	if err := json.Unmarshal(data, &value); err != nil {
+		return err
+	}
+	switch v := value.(type) {
+	case bool:
+		*b = flexibleBool(v)
+	case string:
+		*b = flexibleBool(v == "true")
+	default:
+		*b = false
+	}
+	return nil
+}
+
+// jsonWebKey JWKS 中的单个公钥
+type jsonWebKey struct {
+	Kty string `json:"kty"`
+	Kid string `json:"kid"`
+	Use string `json:"use"`
+	Crv string `json:"crv"`
+	N   string `json:"n"`
+	E   string `json:"e"`
+	X   string `json:"x"`
+	Y   string `json:"y"`
+}
+
+// keySet 已解析的 JWKS 缓存
+type keySet struct {
+	keys      map[string]crypto.PublicKey
+	fetchedAt time.Time
+}
+
+// VerifyIDToken 校验 ID Token 的签名（提供方 JWKS）、发行方、受众、有效期与 nonce
+func (p *Provider) VerifyIDToken(ctx context.Context, rawIDToken, nonce string) (*IDTokenClaims, error) {
+	metadata, err := p.Metadata(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	algs := asymmetricAlgs(metadata.IDTokenSigningAlgs)
+	claims := &IDTokenClaims{}
+	_, err = jwt.ParseWithClaims(rawIDToken, claims, func(token *jwt.Token) (interface{}, error) {
+		kid, _ := token.Header["kid"].(string)
+		return p.publicKey(ctx, metadata.JWKSURI, kid)
+	},
+		jwt.WithValidMethods(algs),
+		jwt.WithIssuer(metadata.Issuer),
+		jwt.WithAudience(p.cfg.ClientID),
+		jwt.WithExpirationRequired(),
+		jwt.WithIssuedAt(),
+		jwt.WithLeeway(time.Minute),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("ID Token 校验失败: %w", err)
+	}
+
+	if claims.Subject == "" {
+		return nil, errors.New("ID Token 缺少 sub")
+	}
+	// 多受众时 azp 必须为本客户端（OIDC Core 3.1.3.7）
+	if len(claims.Audience) > 1 && claims.AuthorizedParty != p.cfg.ClientID {
+		return nil, errors.New("ID Token 的 azp 与客户端不一致")
+	}
+	if nonce == "" || subtle.ConstantTimeCompare([]byte(claims.Nonce), []byte(nonce)) != 1 {
+		return nil, errors.New("ID Token 的 nonce 不匹配")
+	}
+	return claims, nil
+}
+
+// publicKey 按 kid 查找验签公钥；未命中时（受最小间隔限制）重新拉取 JWKS，以支持提供方轮换密钥
+func (p *Provider) publicKey(ctx context.Context, jwksURI, kid string) (crypto.PublicKey, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if key := p.keys.lookup(kid); key != nil {
+		return key, nil
+	}
+	if p.keys != nil && time.Since(p.keys.fetchedAt) < keysRefreshInterval {
+		return nil, fmt.Errorf("未找到 kid=%q 的验签公钥", kid)
+	}
+
+	var set struct {
+		Keys []jsonWebKey `json:"keys"`
+	}
+	if err := p.getJSON(ctx, jwksURI, &set); err != nil {
+		return nil, fmt.Errorf("获取 JWKS 失败: %w", err)
+	}
+	keys := &keySet{keys: make(map[string]crypto.PublicKey, len(set.Keys)), fetchedAt: time.Now()}
+	for _, jwk := range set.Keys {
+		if jwk.Use != "" && jwk.Use != "sig" {
+			continue
+		}
+		// 无法解析的密钥（如不支持的类型）直接跳过，不影响其余密钥
+		if key, err := jwk.publicKey(); err == nil {
+			keys.keys[jwk.Kid] = key
+		}
+	}
+	p.keys = keys
+
+	if key := p.keys.lookup(kid); key != nil {
+		return key, nil
+	}
+	return nil, fmt.Errorf("未找到 kid=%q 的验签公钥", kid)
+}
+
+// lookup 按 kid 查找公钥；令牌未携带 kid 且 JWKS 只有一把密钥时使用该密钥
+func (s *keySet) lookup(kid string) crypto.PublicKey {
+	if s == nil {
+		return nil
+	}
+	if key, ok := s.keys[kid]; ok {
+		return key
+	}
+	if kid == "" && len(s.keys) == 1 {
+		for _, key := range s.keys {
+			return key
+		}
+	}
+	return nil
+}
+
+// publicKey 将 JWK 还原为公钥（RSA、EC P-256/384/521、Ed25519）
+func (k jsonWebKey) publicKey() (crypto.PublicKey, error) {
+	b64 := base64.RawURLEncoding
+	switch k.Kty {
+	case "RSA":
+		n, err := b64.DecodeString(k.N)
+		if err != nil {
+			return nil, err
+		}
+		e, err := b64.DecodeString(k.E)
+		if err != nil {
+			return nil, err
+		}
+		return &rsa.PublicKey{N: new(big.Int).SetBytes(n), E: int(new(big.Int).SetBytes(e).Int64())}, nil
+	case "EC":
+		var curve elliptic.Curve
+		switch k.Crv {
+		case "P-256":
+			curve = elliptic.P256()
+		case "P-384":
+			curve = elliptic.P384()
+		case "P-521":
+			curve = elliptic.P521()
+		default:
+			return nil, fmt.Errorf("不支持的椭圆曲线 %s", k.Crv)
+		}
+		x, err := b64.DecodeString(k.X)
+		if err != nil {
+			return nil, err
+		}
+		y, err := b64.DecodeString(k.Y)
+		if err != nil {
+			return nil, err
+		}
+		pub := &ecdsa.PublicKey{Curve: curve, X: new(big.Int).SetBytes(x), Y: new(big.Int).SetBytes(y)}
+		if !curve.IsOnCurve(pub.X, pub.Y) {
+			return nil, errors.New("EC 公钥不在曲线上")
+		}
+		return pub, nil
+	case "OKP":
+		x, err := b64.DecodeString(k.X)
+		if err != nil || k.Crv != "Ed25519" || len(x) != ed25519.PublicKeySize {
+			return nil, errors.New("无效的 Ed25519 公钥")
+		}
+		return ed25519.PublicKey(x), nil
+	default:
+		return nil, fmt.Errorf("不支持的密钥类型 %s", k.Kty)
+	}
+}
+
+// asymmetricAlgs 过滤出允许的非对称签名算法，拒绝 none 与 HMAC（客户端密钥不应作为验签密钥）
+func asymmetricAlgs(declared []string) []string {
+	if len(declared) == 0 {
+		return defaultSigningAlgs
+	}
+	var algs []string
+	for _, alg := range declared {
+		if util.ContainsString(defaultSigningAlgs, alg) {
+			algs = append(algs, alg)
+		}
+	}
+	if len(algs) == 0 {
+		return defaultSigningAlgs
+	}
+	return algs
+}
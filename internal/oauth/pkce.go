@@ -0,0 +1,22 @@
+package oauth
+
+import (
+	"crypto/sha256"
+	"encoding/base64"
+	"go-one/util"
+)
+
+// NewPKCE 生成 PKCE code_verifier 及其 S256 code_challenge（RFC 7636）
+func NewPKCE() (verifier, challenge string, err error) {
+	verifier, err = util.RandomToken(32)
+	if err != nil {
+		return "", "", err
+	}
+	return verifier, CodeChallengeS256(verifier), nil
+}
+
+// CodeChallengeS256 计算 code_verifier 的 S256 challenge
+func CodeChallengeS256(verifier string) string {
+	sum := sha256.Sum256([]byte(verifier))
+	return base64.RawURLEncoding.EncodeToString(sum[:])
+}
@@ -0,0 +1,240 @@
+// Package oauth 实现面向通用 OIDC 身份提供方的授权码 + PKCE 登录流程：
+// 发现文档、授权地址构造、授权码换取令牌与 ID Token 校验。
+// 所有网络请求经由注入的 *http.Client 发出，便于替换为本地桩服务。
+package oauth
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"go-one/util"
+	"io"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// maxResponseBytes 身份提供方响应体的读取上限
+const maxResponseBytes = 1 << 20
+
+// ProviderConfig OIDC 身份提供方配置
+type ProviderConfig struct {
+	Name         string
+	Issuer       string
+	ClientID     string
+	ClientSecret string
+	Scopes       []string
+	RedirectURL  string // 在身份提供方登记的回调地址（通常为前端页面，由前端把 code/state 提交给后端）
+	TrustEmail   bool   // 信任提供方的 email_verified 声明，可据此关联已验证同一邮箱的本地账号
+}
+
+// Metadata OIDC 发现文档中用到的字段
+type Metadata struct {
+	Issuer                   string   `json:"issuer"`
+	AuthorizationEndpoint    string   `json:"authorization_endpoint"`
+	TokenEndpoint            string   `json:"token_endpoint"`
+	JWKSURI                  string   `json:"jwks_uri"`
+	TokenEndpointAuthMethods []string `json:"token_endpoint_auth_methods_supported"`
+	IDTokenSigningAlgs       []string `json:"id_token_signing_alg_values_supported"`
+}
+
+// TokenResponse 令牌端点响应
+type TokenResponse struct {
+	AccessToken  string `json:"access_token"`
+	TokenType    string `json:"token_type"`
+	IDToken      string `json:"id_token"`
+	RefreshToken string `json:"refresh_token"`
+	ExpiresIn    int64  `json:"expires_in"`
+}
+
+// tokenError 令牌端点错误响应（RFC 6749 5.2）
+type tokenError struct {
+	Error            string `json:"error"`
+	ErrorDescription string `json:"error_description"`
+}
+
+// Provider 单个 OIDC 身份提供方，发现文档与 JWKS 按需获取并缓存
+type Provider struct {
+	cfg    ProviderConfig
+	client *http.Client
+
+	mu       sync.Mutex
+	metadata *Metadata
+	keys     *keySet
+}
+
+// NewProvider 创建身份提供方，client 为 nil 时使用 http.DefaultClient
+func NewProvider(cfg ProviderConfig, client *http.Client) *Provider {
+	if client == nil {
+		client = http.DefaultClient
+	}
+	cfg.Issuer = strings.TrimRight(cfg.Issuer, "/")
+	if !util.ContainsString(cfg.Scopes, "openid") {
+		cfg.Scopes = append([]string{"openid"}, cfg.Scopes...)
+	}
+	return &Provider{cfg: cfg, client: client}
+}
+
+// Name 身份提供方名称
+func (p *Provider) Name() string {
+	return p.cfg.Name
+}
+
+// Config 身份提供方配置
+func (p *Provider) Config() ProviderConfig {
+	return p.cfg
+}
+
+// Metadata 获取（并缓存）发现文档，发行方与配置不一致时拒绝使用
+func (p *Provider) Metadata(ctx context.Context) (*Metadata, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.metadata != nil {
+		return p.metadata, nil
+	}
+
+	var metadata Metadata
+	if err := p.getJSON(ctx, p.cfg.Issuer+"/.well-known/openid-configuration", &metadata); err != nil {
+		return nil, fmt.Errorf("获取 OIDC 发现文档失败: %w", err)
+	}
+	if strings.TrimRight(metadata.Issuer, "/") != p.cfg.Issuer {
+		return nil, fmt.Errorf("发现文档中的 issuer 与配置不一致: %s", metadata.Issuer)
+	}
+	if metadata.AuthorizationEndpoint == "" || metadata.TokenEndpoint == "" || metadata.JWKSURI == "" {
+		return nil, errors.New("发现文档缺少必要的端点")
+	}
+	p.metadata = &metadata
+	return p.metadata, nil
+}
+
+// AuthCodeURL 构造授权地址（授权码模式 + PKCE S256）
+func (p *Provider) AuthCodeURL(ctx context.Context, state, nonce, codeChallenge string) (string, error) {
+	metadata, err := p.Metadata(ctx)
+	if err != nil {
+		return "", err
+	}
+	authURL, err := url.Parse(metadata.AuthorizationEndpoint)
+	if err != nil {
+		return "", fmt.Errorf("无效的授权端点: %w", err)
+	}
+	query := authURL.Query()
+	query.Set("response_type", "code")
+	query.Set("client_id", p.cfg.ClientID)
+	query.Set("redirect_uri", p.cfg.RedirectURL)
+	query.Set("scope", strings.Join(p.cfg.Scopes, " "))
+	query.Set("state", state)
+	query.Set("nonce", nonce)
+	query.Set("code_challenge", codeChallenge)
+	query.Set("code_challenge_method", "S256")
+	authURL.RawQuery = query.Encode()
+	return authURL.String(), nil
+}
+
+// Exchange 使用授权码与 PKCE code_verifier 换取令牌
+func (p *Provider) Exchange(ctx context.Context, code, codeVerifier string) (*TokenResponse, error) {
+	metadata, err := p.Metadata(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	form := url.Values{}
+	form.Set("grant_type", "authorization_code")
+	form.Set("code", code)
+	form.Set("redirect_uri", p.cfg.RedirectURL)
+	form.Set("code_verifier", codeVerifier)
+	form.Set("client_id", p.cfg.ClientID)
+	// 未声明支持 client_secret_basic 而支持 client_secret_post 时改用表单传递密钥
+	useBasic := p.cfg.ClientSecret != "" &&
+		(len(metadata.TokenEndpointAuthMethods) == 0 || util.ContainsString(metadata.TokenEndpointAuthMethods, "client_secret_basic") ||
+			!util.ContainsString(metadata.TokenEndpointAuthMethods, "client_secret_post"))
+	if p.cfg.ClientSecret != "" && !useBasic {
+		form.Set("client_secret", p.cfg.ClientSecret)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, metadata.TokenEndpoint, strings.NewReader(form.Encode()))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("Accept", "application/json")
+	if useBasic {
+		req.SetBasicAuth(url.QueryEscape(p.cfg.ClientID), url.QueryEscape(p.cfg.ClientSecret))
+	}
+
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("请求令牌端点失败: %w", err)
+	}
+	defer resp.Body.Close()
+	body, err := io.ReadAll(io.LimitReader(resp.Body, maxResponseBytes))
+	if err != nil {
+		return nil, fmt.Errorf("读取令牌端点响应失败: %w", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		var tokenErr tokenError
+		if json.Unmarshal(body, &tokenErr) == nil && tokenErr.Error != "" {
+			return nil, fmt.Errorf("令牌端点返回错误: %s %s", tokenErr.Error, tokenErr.ErrorDescription)
+		}
+		return nil, fmt.Errorf("令牌端点返回状态码 %d", resp.StatusCode)
+	}
+
+	var token TokenResponse
+	if err := json.Unmarshal(body, &token); err != nil {
+		return nil, fmt.Errorf("解析令牌端点响应失败: %w", err)
+	}
+	if token.IDToken == "" {
+		return nil, errors.New("令牌端点未返回 id_token")
+	}
+	return &token, nil
+}
+
+// getJSON 发起 GET 请求并解析 JSON 响应
+func (p *Provider) getJSON(ctx context.Context, target string, out interface{}) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, target, nil)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Accept", "application/json")
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("%s 返回状态码 %d", target, resp.StatusCode)
+	}
+	return json.NewDecoder(io.LimitReader(resp.Body, maxResponseBytes)).Decode(out)
+}
+
+// Registry 已配置的身份提供方集合
+type Registry struct {
+	providers map[string]*Provider
+}
+
+// NewRegistry 按配置创建身份提供方集合，所有提供方共用同一个 http.Client
+func NewRegistry(configs []ProviderConfig, client *http.Client) *Registry {
+	registry := &Registry{providers: make(map[string]*Provider, len(configs))}
+	for _, cfg := range configs {
+		registry.providers[cfg.Name] = NewProvider(cfg, client)
+	}
+	return registry
+}
+
+// Get 按名称获取身份提供方
+func (r *Registry) Get(name string) (*Provider, bool) {
+	provider, ok := r.providers[name]
+	return provider, ok
+}
+
+// Names 已配置的身份提供方名称（按字母排序）
+func (r *Registry) Names() []string {
+	names := make([]string, 0, len(r.providers))
+	for name := range r.providers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
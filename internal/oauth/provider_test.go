@@ -0,0 +1,377 @@
+package oauth
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+const (
+	testClientID     = "go-one"
+	testClientSecret = "client-secret"
+	testRedirectURL  = "https://app.example.com/oauth/callback"
+)
+
+// stubIdP 基于 httptest 的本地 OIDC 身份提供方：发现文档、JWKS 与令牌端点
+type stubIdP struct {
+	server *httptest.Server
+
+	mu         sync.Mutex
+	key        *rsa.PrivateKey
+	kid        string
+	challenges map[string]string // 授权码 -> code_challenge
+	idTokens   map[string]string // 授权码 -> 令牌端点返回的 id_token
+	jwksHits   atomic.Int32
+}
+
+func newStubIdP(t *testing.T) *stubIdP {
+	t.Helper()
+	idp := &stubIdP{
+		challenges: map[string]string{},
+		idTokens:   map[string]string{},
+	}
+	idp.rotateKey(t, "key-1")
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/.well-known/openid-configuration", func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, http.StatusOK, Metadata{
+			Issuer:                   idp.server.URL,
+			AuthorizationEndpoint:    idp.server.URL + "/authorize",
+			TokenEndpoint:            idp.server.URL + "/token",
+			JWKSURI:                  idp.server.URL + "/jwks",
+			TokenEndpointAuthMethods: []string{"client_secret_basic"},
+			IDTokenSigningAlgs:       []string{"RS256", "HS256", "none"},
+		})
+	})
+	mux.HandleFunc("/jwks", func(w http.ResponseWriter, r *http.Request) {
+		idp.jwksHits.Add(1)
+		idp.mu.Lock()
+		defer idp.mu.Unlock()
+		writeJSON(w, http.StatusOK, map[string]interface{}{"keys": []jsonWebKey{{
+			Kty: "RSA",
+			Kid: idp.kid,
+			Use: "sig",
+			N:   base64.RawURLEncoding.EncodeToString(idp.key.N.Bytes()),
+			E:   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(idp.key.E)).Bytes()),
+		}}})
+	})
+	mux.HandleFunc("/token", idp.handleToken)
+	idp.server = httptest.NewServer(mux)
+	t.Cleanup(idp.server.Close)
+	return idp
+}
+
+// handleToken 令牌端点：校验客户端凭证、授权码、回调地址与 PKCE code_verifier
+func (idp *stubIdP) handleToken(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+	if id, secret, ok := r.BasicAuth(); !ok || id != testClientID || secret != testClientSecret {
+		writeJSON(w, http.StatusUnauthorized, tokenError{Error: "invalid_client"})
+		return
+	}
+	_ = r.ParseForm()
+	code := r.PostForm.Get("code")
+	idp.mu.Lock()
+	challenge, known := idp.challenges[code]
+	idToken := idp.idTokens[code]
+	delete(idp.challenges, code)
+	idp.mu.Unlock()
+	switch {
+	case r.PostForm.Get("grant_type") != "authorization_code" || r.PostForm.Get("redirect_uri") != testRedirectURL:
+		writeJSON(w, http.StatusBadRequest, tokenError{Error: "invalid_request"})
+	case !known || CodeChallengeS256(r.PostForm.Get("code_verifier")) != challenge:
+		writeJSON(w, http.StatusBadRequest, tokenError{Error: "invalid_grant", ErrorDescription: "PKCE verification failed"})
+	default:
+		writeJSON(w, http.StatusOK, TokenResponse{AccessToken: "idp-access-token", TokenType: "Bearer", IDToken: idToken, ExpiresIn: 3600})
+	}
+}
+
+// authorize 模拟用户在授权页同意：记录 code_challenge 并为授权码准备 id_token
+func (idp *stubIdP) authorize(t *testing.T, authURL, code, idToken string) {
+	t.Helper()
+	u, err := url.Parse(authURL)
+	if err != nil {
+		t.Fatalf("解析授权地址失败: %v", err)
+	}
+	query := u.Query()
+	if query.Get("code_challenge_method") != "S256" || query.Get("code_challenge") == "" {
+		t.Fatalf("授权地址缺少 PKCE 参数: %s", authURL)
+	}
+	idp.mu.Lock()
+	defer idp.mu.Unlock()
+	idp.challenges[code] = query.Get("code_challenge")
+	idp.idTokens[code] = idToken
+}
+
+// rotateKey 更换签名密钥（模拟提供方轮换）
+func (idp *stubIdP) rotateKey(t *testing.T, kid string) {
+	t.Helper()
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatalf("生成 RSA 密钥失败: %v", err)
+	}
+	idp.mu.Lock()
+	defer idp.mu.Unlock()
+	idp.key, idp.kid = key, kid
+}
+
+// claims 默认的有效 ID Token 声明
+func (idp *stubIdP) claims(nonce string) jwt.MapClaims {
+	now := time.Now()
+	return jwt.MapClaims{
+		"iss":            idp.server.URL,
+		"sub":            "idp-user-1",
+		"aud":            testClientID,
+		"exp":            now.Add(5 * time.Minute).Unix(),
+		"iat":            now.Unix(),
+		"nonce":          nonce,
+		"email":          "alice@example.com",
+		"email_verified": "true",
+	}
+}
+
+// sign 使用当前密钥以 RS256 签发 ID Token
+func (idp *stubIdP) sign(t *testing.T, claims jwt.MapClaims) string {
+	t.Helper()
+	idp.mu.Lock()
+	defer idp.mu.Unlock()
+	token := jwt.NewWithClaims(jwt.SigningMethodRS256, claims)
+	token.Header["kid"] = idp.kid
+	signed, err := token.SignedString(idp.key)
+	if err != nil {
+		t.Fatalf("签发 ID Token 失败: %v", err)
+	}
+	return signed
+}
+
+func (idp *stubIdP) provider() *Provider {
+	return NewProvider(ProviderConfig{
+		Name:         "stub",
+		Issuer:       idp.server.URL,
+		ClientID:     testClientID,
+		ClientSecret: testClientSecret,
+		Scopes:       []string{"email", "profile"},
+		RedirectURL:  testRedirectURL,
+	}, idp.server.Client())
+}
+
+func writeJSON(w http.ResponseWriter, status int, body interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(body)
+}
+
+func TestProviderCodeExchangeWithPKCE(t *testing.T) {
+	idp := newStubIdP(t)
+	provider := idp.provider()
+	ctx := context.Background()
+
+	verifier, challenge, err := NewPKCE()
+	if err != nil {
+		t.Fatalf("NewPKCE: %v", err)
+	}
+	authURL, err := provider.AuthCodeURL(ctx, "state-1", "nonce-1", challenge)
+	if err != nil {
+		t.Fatalf("AuthCodeURL: %v", err)
+	}
+	if !strings.HasPrefix(authURL, idp.server.URL+"/authorize?") {
+		t.Fatalf("授权地址 = %s", authURL)
+	}
+	query, _ := url.ParseQuery(authURL[strings.Index(authURL, "?")+1:])
+	if query.Get("scope") != "openid email profile" || query.Get("state") != "state-1" || query.Get("nonce") != "nonce-1" {
+		t.Errorf("授权参数不正确: %v", query)
+	}
+
+	idp.authorize(t, authURL, "code-1", idp.sign(t, idp.claims("nonce-1")))
+	token, err := provider.Exchange(ctx, "code-1", verifier)
+	if err != nil {
+		t.Fatalf("Exchange: %v", err)
+	}
+	claims, err := provider.VerifyIDToken(ctx, token.IDToken, "nonce-1")
+	if err != nil {
+		t.Fatalf("VerifyIDToken: %v", err)
+	}
+	if claims.Subject != "idp-user-1" || claims.Email != "alice@example.com" || !bool(claims.EmailVerified) {
+		t.Errorf("claims = %+v", claims)
+	}
+
+	// 授权码只能使用一次
+	if _, err := provider.Exchange(ctx, "code-1", verifier); err == nil {
+		t.Error("重复使用授权码应失败")
+	}
+}
+
+func TestProviderExchangeRejectsWrongVerifier(t *testing.T) {
+	idp := newStubIdP(t)
+	provider := idp.provider()
+	ctx := context.Background()
+
+	_, challenge, _ := NewPKCE()
+	authURL, err := provider.AuthCodeURL(ctx, "state", "nonce", challenge)
+	if err != nil {
+		t.Fatalf("AuthCodeURL: %v", err)
+	}
+	idp.authorize(t, authURL, "code-2", idp.sign(t, idp.claims("nonce")))
+
+	otherVerifier, _, _ := NewPKCE()
+	if _, err := provider.Exchange(ctx, "code-2", otherVerifier); err == nil || !strings.Contains(err.Error(), "invalid_grant") {
+		t.Errorf("code_verifier 不匹配时应返回 invalid_grant, err = %v", err)
+	}
+}
+
+func TestProviderMetadataRejectsIssuerMismatch(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, http.StatusOK, Metadata{
+			Issuer:                "https://evil.example.com",
+			AuthorizationEndpoint: "https://evil.example.com/authorize",
+			TokenEndpoint:         "https://evil.example.com/token",
+			JWKSURI:               "https://evil.example.com/jwks",
+		})
+	}))
+	defer server.Close()
+
+	provider := NewProvider(ProviderConfig{Name: "stub", Issuer: server.URL, ClientID: testClientID}, server.Client())
+	if _, err := provider.Metadata(context.Background()); err == nil {
+		t.Error("发现文档 issuer 与配置不一致时应拒绝")
+	}
+}
+
+func TestVerifyIDTokenRejects(t *testing.T) {
+	idp := newStubIdP(t)
+	const nonce = "nonce-ok"
+
+	withClaims := func(edit func(jwt.MapClaims)) string {
+		claims := idp.claims(nonce)
+		edit(claims)
+		return idp.sign(t, claims)
+	}
+	hs256 := func() string {
+		// 以客户端密钥作为 HMAC 密钥伪造的令牌
+		token := jwt.NewWithClaims(jwt.SigningMethodHS256, idp.claims(nonce))
+		token.Header["kid"] = "key-1"
+		signed, err := token.SignedString([]byte(testClientSecret))
+		if err != nil {
+			t.Fatalf("签发 HS256 令牌失败: %v", err)
+		}
+		return signed
+	}
+	none := func() string {
+		signed, err := jwt.NewWithClaims(jwt.SigningMethodNone, idp.claims(nonce)).SignedString(jwt.UnsafeAllowNoneSignatureType)
+		if err != nil {
+			t.Fatalf("签发 none 令牌失败: %v", err)
+		}
+		return signed
+	}
+
+	tests := []struct {
+		name    string
+		token   string
+		nonce   string
+		wantErr bool
+	}{
+		{name: "有效令牌", token: idp.sign(t, idp.claims(nonce)), nonce: nonce},
+		{name: "受众不是本客户端", token: withClaims(func(c jwt.MapClaims) { c["aud"] = "another-client" }), nonce: nonce, wantErr: true},
+		{name: "发行方不一致", token: withClaims(func(c jwt.MapClaims) { c["iss"] = "https://evil.example.com" }), nonce: nonce, wantErr: true},
+		{name: "nonce 不匹配", token: idp.sign(t, idp.claims(nonce)), nonce: "another-nonce", wantErr: true},
+		{name: "缺少 nonce", token: withClaims(func(c jwt.MapClaims) { delete(c, "nonce") }), nonce: nonce, wantErr: true},
+		{name: "已过期", token: withClaims(func(c jwt.MapClaims) { c["exp"] = time.Now().Add(-time.Hour).Unix() }), nonce: nonce, wantErr: true},
+		{name: "缺少 exp", token: withClaims(func(c jwt.MapClaims) { delete(c, "exp") }), nonce: nonce, wantErr: true},
+		{name: "缺少 sub", token: withClaims(func(c jwt.MapClaims) { delete(c, "sub") }), nonce: nonce, wantErr: true},
+		{name: "alg HS256", token: hs256(), nonce: nonce, wantErr: true},
+		{name: "alg none", token: none(), nonce: nonce, wantErr: true},
+		{
+			name:    "多受众缺少 azp",
+			token:   withClaims(func(c jwt.MapClaims) { c["aud"] = []string{testClientID, "another-client"} }),
+			nonce:   nonce,
+			wantErr: true,
+		},
+		{
+			name: "多受众 azp 为其他客户端",
+			token: withClaims(func(c jwt.MapClaims) {
+				c["aud"] = []string{testClientID, "another-client"}
+				c["azp"] = "another-client"
+			}),
+			nonce:   nonce,
+			wantErr: true,
+		},
+		{
+			name: "多受众 azp 为本客户端",
+			token: withClaims(func(c jwt.MapClaims) {
+				c["aud"] = []string{testClientID, "another-client"}
+				c["azp"] = testClientID
+			}),
+			nonce: nonce,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := idp.provider().VerifyIDToken(context.Background(), tt.token, tt.nonce)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("err = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestVerifyIDTokenUnknownKidThrottlesRefresh(t *testing.T) {
+	idp := newStubIdP(t)
+	provider := idp.provider()
+	ctx := context.Background()
+	const nonce = "nonce"
+
+	if _, err := provider.VerifyIDToken(ctx, idp.sign(t, idp.claims(nonce)), nonce); err != nil {
+		t.Fatalf("VerifyIDToken: %v", err)
+	}
+	if hits := idp.jwksHits.Load(); hits != 1 {
+		t.Fatalf("JWKS 请求次数 = %d, want 1", hits)
+	}
+
+	// 伪造的 kid 在刷新间隔内不会触发重新拉取 JWKS
+	forged := jwt.NewWithClaims(jwt.SigningMethodRS256, idp.claims(nonce))
+	forged.Header["kid"] = "forged"
+	idp.mu.Lock()
+	forgedToken, err := forged.SignedString(idp.key)
+	idp.mu.Unlock()
+	if err != nil {
+		t.Fatalf("签发令牌失败: %v", err)
+	}
+	for i := 0; i < 3; i++ {
+		if _, err := provider.VerifyIDToken(ctx, forgedToken, nonce); err == nil {
+			t.Fatal("未知 kid 应校验失败")
+		}
+	}
+	if hits := idp.jwksHits.Load(); hits != 1 {
+		t.Errorf("刷新间隔内 JWKS 请求次数 = %d, want 1", hits)
+	}
+
+	// 提供方轮换密钥：间隔过后遇到新 kid 重新拉取并校验通过
+	idp.rotateKey(t, "key-2")
+	rotated := idp.sign(t, idp.claims(nonce))
+	if _, err := provider.VerifyIDToken(ctx, rotated, nonce); err == nil {
+		t.Fatal("刷新间隔内不应拉取新密钥")
+	}
+	provider.mu.Lock()
+	provider.keys.fetchedAt = time.Now().Add(-2 * keysRefreshInterval)
+	provider.mu.Unlock()
+	if _, err := provider.VerifyIDToken(ctx, rotated, nonce); err != nil {
+		t.Fatalf("轮换后的密钥应可校验: %v", err)
+	}
+	if hits := idp.jwksHits.Load(); hits != 2 {
+		t.Errorf("JWKS 请求次数 = %d, want 2", hits)
+	}
+}
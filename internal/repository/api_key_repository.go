@@ -0,0 +1,73 @@
+package repository
+
+import (
+	"time"
+
+	"go-one/internal/model"
+
+	"gorm.io/gorm"
+)
+
+// APIKeyRepository API 密钥数据访问接口
+type APIKeyRepository interface {
+	Create(key *model.APIKey) error
+	FindByPrefix(prefix string) (*model.APIKey, error)
+	ListActiveByUser(userID uint) ([]model.APIKey, error)
+	CountActiveByUser(userID uint) (int64, error)
+	Revoke(userID, id uint) (int64, error)
+	TouchLastUsed(id uint, ip string) error
+}
+
+type apiKeyRepository struct {
+	db *gorm.DB
+}
+
+// NewAPIKeyRepository 创建 API 密钥仓储实例
+func NewAPIKeyRepository(db *gorm.DB) APIKeyRepository {
+	return &apiKeyRepository{db: db}
+}
+
+// Create 保存 API 密钥
+func (r *apiKeyRepository) Create(key *model.APIKey) error {
+	return r.db.Create(key).Error
+}
+
+// FindByPrefix 根据前缀查找 API 密钥
+func (r *apiKeyRepository) FindByPrefix(prefix string) (*model.APIKey, error) {
+	var key model.APIKey
+	if err := r.db.Where("prefix = ?", prefix).First(&key).Error; err != nil {
+		return nil, err
+	}
+	return &key, nil
+}
+
+// ListActiveByUser 查询用户未撤销的 API 密钥（含已过期），按创建时间倒序
+func (r *apiKeyRepository) ListActiveByUser(userID uint) ([]model.APIKey, error) {
+	var keys []model.APIKey
+	err := r.db.Where("user_id = ? AND revoked_at IS NULL", userID).Order("created_at DESC").Find(&keys).Error
+	if err != nil {
+		return nil, err
+	}
+	return keys, nil
+}
+
+// CountActiveByUser 统计用户未撤销的 API 密钥数量
+func (r *apiKeyRepository) CountActiveByUser(userID uint) (int64, error) {
+	var count int64
+	err := r.db.Model(&model.APIKey{}).Where("user_id = ? AND revoked_at IS NULL", userID).Count(&count).Error
+	return count, err
+}
+
+// Revoke 撤销用户的 API 密钥，返回撤销数量
+func (r *apiKeyRepository) Revoke(userID, id uint) (int64, error) {
+	res := r.db.Model(&model.APIKey{}).
+		Where("id = ? AND user_id = ? AND revoked_at IS NULL", id, userID).
+		Update("revoked_at", time.Now())
+	return res.RowsAffected, res.Error
+}
+
+// TouchLastUsed 更新最近使用时间与 IP
+func (r *apiKeyRepository) TouchLastUsed(id uint, ip string) error {
+	return r.db.Model(&model.APIKey{}).Where("id = ?", id).
+		Updates(map[string]interface{}{"last_used_at": time.Now(), "last_used_ip": ip}).Error
+}
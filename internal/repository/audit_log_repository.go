@@ -0,0 +1,62 @@
+package repository
+
+import (
+	"go-one/internal/model"
+
+	"gorm.io/gorm"
+)
+
+// AuditLogRepository 审计日志数据访问接口
+type AuditLogRepository interface {
+	Create(log *model.AuditLog) error
+	Search(query AuditLogSearch) ([]model.AuditLog, int64, error)
+}
+
+// AuditLogSearch 审计日志搜索条件，零值表示不限
+type AuditLogSearch struct {
+	ActorID         uint
+	UserID          uint
+	ImpersonationID string
+	Page            int
+	PageSize        int
+}
+
+type auditLogRepository struct {
+	db *gorm.DB
+}
+
+// NewAuditLogRepository 创建限定在 tenantID 租户内的审计日志仓储实例，tenantID 为 0 时不限定租户
+func NewAuditLogRepository(db *gorm.DB, tenantID uint) AuditLogRepository {
+	return &auditLogRepository{db: tenantDB(db, tenantID)}
+}
+
+// Create 写入审计日志
+func (r *auditLogRepository) Create(log *model.AuditLog) error {
+	return r.db.Create(log).Error
+}
+
+// Search 按操作人、用户与模拟会话搜索审计日志（分页，按时间倒序）
+func (r *auditLogRepository) Search(query AuditLogSearch) ([]model.AuditLog, int64, error) {
+	var logs []model.AuditLog
+	var total int64
+
+	db := r.db.Model(&model.AuditLog{})
+	if query.ActorID != 0 {
+		db = db.Where("actor_id = ?", query.ActorID)
+	}
+	if query.UserID != 0 {
+		db = db.Where("user_id = ?", query.UserID)
+	}
+	if query.ImpersonationID != "" {
+		db = db.Where("impersonation_id = ?", query.ImpersonationID)
+	}
+
+	if err := db.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+	offset := (query.Page - 1) * query.PageSize
+	if err := db.Order("id DESC").Offset(offset).Limit(query.PageSize).Find(&logs).Error; err != nil {
+		return nil, 0, err
+	}
+	return logs, total, nil
+}
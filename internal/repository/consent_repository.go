@@ -0,0 +1,119 @@
+package repository
+
+import (
+	"time"
+
+	"go-one/internal/model"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// ConsentRepository 协议版本与用户同意记录数据访问接口
+type ConsentRepository interface {
+	CreateVersion(version *model.PolicyVersion) error
+	FindVersion(policyType, version string) (*model.PolicyVersion, error)
+	ListVersions() ([]model.PolicyVersion, error)
+	ListCurrentVersions(now time.Time, mandatoryOnly bool) ([]model.PolicyVersion, error)
+	Accept(consents []model.UserConsent) error
+	ListByUser(userID uint) ([]UserConsentDetail, error)
+	LatestAccepted(userID uint) (map[string]time.Time, error)
+}
+
+// UserConsentDetail 用户同意记录及对应的协议版本
+type UserConsentDetail struct {
+	model.UserConsent
+	Type    string `json:"type"`
+	Version string `json:"version"`
+	URL     string `json:"url"`
+}
+
+type consentRepository struct {
+	db *gorm.DB
+}
+
+// NewConsentRepository 创建协议同意仓储实例
+func NewConsentRepository(db *gorm.DB) ConsentRepository {
+	return &consentRepository{db: db}
+}
+
+// CreateVersion 发布协议版本
+func (r *consentRepository) CreateVersion(version *model.PolicyVersion) error {
+	return r.db.Create(version).Error
+}
+
+// FindVersion 按类型与版本号查找协议版本
+func (r *consentRepository) FindVersion(policyType, version string) (*model.PolicyVersion, error) {
+	var v model.PolicyVersion
+	if err := r.db.Where("type = ? AND version = ?", policyType, version).First(&v).Error; err != nil {
+		return nil, err
+	}
+	return &v, nil
+}
+
+// ListVersions 查询全部协议版本，按生效时间倒序
+func (r *consentRepository) ListVersions() ([]model.PolicyVersion, error) {
+	var versions []model.PolicyVersion
+	if err := r.db.Order("effective_at DESC, id DESC").Find(&versions).Error; err != nil {
+		return nil, err
+	}
+	return versions, nil
+}
+
+// ListCurrentVersions 查询每种协议在 now 时已生效的最新版本，mandatoryOnly 为 true 时只考虑须重新同意的版本
+func (r *consentRepository) ListCurrentVersions(now time.Time, mandatoryOnly bool) ([]model.PolicyVersion, error) {
+	var versions []model.PolicyVersion
+	db := r.db.Select("DISTINCT ON (type) *").Where("effective_at <= ?", now)
+	if mandatoryOnly {
+		db = db.Where("mandatory = ?", true)
+	}
+	if err := db.Order("type, effective_at DESC, id DESC").Find(&versions).Error; err != nil {
+		return nil, err
+	}
+	return versions, nil
+}
+
+// Accept 保存同意记录，已同意过的版本忽略
+func (r *consentRepository) Accept(consents []model.UserConsent) error {
+	if len(consents) == 0 {
+		return nil
+	}
+	return r.db.Clauses(clause.OnConflict{DoNothing: true}).Create(&consents).Error
+}
+
+// ListByUser 查询用户的全部同意记录，按同意时间倒序
+func (r *consentRepository) ListByUser(userID uint) ([]UserConsentDetail, error) {
+	var details []UserConsentDetail
+	err := r.db.Model(&model.UserConsent{}).
+		Select("user_consents.*, policy_versions.type, policy_versions.version, policy_versions.url").
+		Joins("JOIN policy_versions ON policy_versions.id = user_consents.policy_version_id").
+		Where("user_consents.user_id = ?", userID).
+		Order("user_consents.accepted_at DESC, user_consents.id DESC").
+		Scan(&details).Error
+	if err != nil {
+		return nil, err
+	}
+	return details, nil
+}
+
+// LatestAccepted 返回用户已同意的每种协议中生效时间最新的版本的生效时间
+func (r *consentRepository) LatestAccepted(userID uint) (map[string]time.Time, error) {
+	var rows []struct {
+		Type        string
+		EffectiveAt time.Time
+	}
+	err := r.db.Model(&model.UserConsent{}).
+		Select("policy_versions.type, MAX(policy_versions.effective_at) AS effective_at").
+		Joins("JOIN policy_versions ON policy_versions.id = user_consents.policy_version_id").
+		Where("user_consents.user_id = ?", userID).
+		Group("policy_versions.type").
+		Scan(&rows).Error
+	if err != nil {
+		return nil, err
+	}
+	latest := make(map[string]time.Time, len(rows))
+	for _, row := range rows {
+		latest[row.Type] = row.EffectiveAt
+	}
+	return latest, nil
+}
@@ -0,0 +1,159 @@
+package repository
+
+import (
+	"time"
+
+	"go-one/internal/model"
+
+	"gorm.io/gorm"
+)
+
+// DataExportRepository 用户数据导出数据访问接口
+type DataExportRepository interface {
+	Create(export *model.DataExport) error
+	FindByID(id uint) (*model.DataExport, error)
+	ListByUser(userID uint, limit int) ([]model.DataExport, error)
+	Complete(id uint, filePath string, fileSize int64, expiresAt time.Time) error
+	Fail(id uint, reason string) error
+	Delete(id uint) error
+	DeleteByUser(userID uint) ([]model.DataExport, error)
+	ListExpired(now, staleBefore time.Time, limit int) ([]model.DataExport, error)
+	CollectUserData(userID uint) (*UserData, error)
+}
+
+// UserData 导出归档中包含的用户数据
+type UserData struct {
+	Profile        *model.User
+	Sessions       []model.RefreshToken
+	AuditLogs      []model.AuditLog
+	Consents       []UserConsentDetail
+	Identities     []model.UserIdentity
+	APIKeys        []model.APIKey
+	StatusChanges  []model.UserStatusChange
+	SecurityEvents []model.SecurityEvent
+}
+
+type dataExportRepository struct {
+	db       *gorm.DB
+	tenantID uint
+}
+
+// NewDataExportRepository 创建限定在 tenantID 租户内的数据导出仓储实例，tenantID 为 0 时不限定租户
+func NewDataExportRepository(db *gorm.DB, tenantID uint) DataExportRepository {
+	return &dataExportRepository{db: tenantDB(db, tenantID), tenantID: tenantID}
+}
+
+// Create 创建导出任务
+func (r *dataExportRepository) Create(export *model.DataExport) error {
+	return r.db.Create(export).Error
+}
+
+// FindByID 根据ID查找导出任务
+func (r *dataExportRepository) FindByID(id uint) (*model.DataExport, error) {
+	var export model.DataExport
+	if err := r.db.Where("id = ?", id).First(&export).Error; err != nil {
+		return nil, err
+	}
+	return &export, nil
+}
+
+// ListByUser 查询用户最近的导出任务，按创建时间倒序
+func (r *dataExportRepository) ListByUser(userID uint, limit int) ([]model.DataExport, error) {
+	var exports []model.DataExport
+	err := r.db.Where("user_id = ?", userID).Order("id DESC").Limit(limit).Find(&exports).Error
+	if err != nil {
+		return nil, err
+	}
+	return exports, nil
+}
+
+// Complete 标记导出任务已生成（仅处理排队中的任务）
+func (r *dataExportRepository) Complete(id uint, filePath string, fileSize int64, expiresAt time.Time) error {
+	now := time.Now()
+	return r.db.Model(&model.DataExport{}).
+		Where("id = ? AND status = ?", id, model.DataExportPending).
+		Updates(map[string]interface{}{
+			"status":       model.DataExportReady,
+			"file_path":    filePath,
+			"file_size":    fileSize,
+			"completed_at": now,
+			"expires_at":   expiresAt,
+		}).Error
+}
+
+// Fail 标记导出任务生成失败
+func (r *dataExportRepository) Fail(id uint, reason string) error {
+	return r.db.Model(&model.DataExport{}).
+		Where("id = ? AND status = ?", id, model.DataExportPending).
+		Updates(map[string]interface{}{"status": model.DataExportFailed, "error": reason, "completed_at": time.Now()}).Error
+}
+
+// Delete 删除导出任务记录
+func (r *dataExportRepository) Delete(id uint) error {
+	return r.db.Delete(&model.DataExport{}, id).Error
+}
+
+// DeleteByUser 删除用户的全部导出任务，返回被删除的记录以便清理归档文件
+func (r *dataExportRepository) DeleteByUser(userID uint) ([]model.DataExport, error) {
+	var exports []model.DataExport
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		tx = tenantDB(tx, r.tenantID)
+		if err := tx.Where("user_id = ?", userID).Find(&exports).Error; err != nil {
+			return err
+		}
+		return tx.Where("user_id = ?", userID).Delete(&model.DataExport{}).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+	return exports, nil
+}
+
+// ListExpired 查询下载已过期的导出任务，以及在 staleBefore 前创建仍未生成的任务
+func (r *dataExportRepository) ListExpired(now, staleBefore time.Time, limit int) ([]model.DataExport, error) {
+	var exports []model.DataExport
+	err := r.db.Where("(expires_at IS NOT NULL AND expires_at <= ?) OR (expires_at IS NULL AND created_at <= ?)", now, staleBefore).
+		Order("id").
+		Limit(limit).
+		Find(&exports).Error
+	if err != nil {
+		return nil, err
+	}
+	return exports, nil
+}
+
+// CollectUserData 查询用户的个人资料、会话、审计日志（作为被操作用户或操作人）、协议同意、外部身份、API 密钥、状态变更与安全事件记录
+func (r *dataExportRepository) CollectUserData(userID uint) (*UserData, error) {
+	data := &UserData{Profile: &model.User{}}
+	if err := r.db.Where("id = ?", userID).First(data.Profile).Error; err != nil {
+		return nil, err
+	}
+	if err := r.db.Where("user_id = ?", userID).Order("id").Find(&data.Sessions).Error; err != nil {
+		return nil, err
+	}
+	if err := r.db.Where("user_id = ? OR actor_id = ?", userID, userID).Order("id").Find(&data.AuditLogs).Error; err != nil {
+		return nil, err
+	}
+	err := r.db.Model(&model.UserConsent{}).
+		Select("user_consents.*, policy_versions.type, policy_versions.version, policy_versions.url").
+		Joins("JOIN policy_versions ON policy_versions.id = user_consents.policy_version_id").
+		Where("user_consents.user_id = ?", userID).
+		Order("user_consents.id").
+		Scan(&data.Consents).Error
+	if err != nil {
+		return nil, err
+	}
+	if err := r.db.Where("user_id = ?", userID).Order("id").Find(&data.Identities).Error; err != nil {
+		return nil, err
+	}
+	if err := r.db.Where("user_id = ?", userID).Order("id").Find(&data.APIKeys).Error; err != nil {
+		return nil, err
+	}
+	if err := r.db.Where("user_id = ?", userID).Order("id").Find(&data.StatusChanges).Error; err != nil {
+		return nil, err
+	}
+	if err := r.db.Where("user_id = ?", userID).Order("id").Find(&data.SecurityEvents).Error; err != nil {
+		return nil, err
+	}
+	return data, nil
+}
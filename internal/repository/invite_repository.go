@@ -0,0 +1,120 @@
+package repository
+
+import (
+	"time"
+
+	"go-one/internal/model"
+
+	"gorm.io/gorm"
+)
+
+// InviteRepository 注册邀请码数据访问接口
+type InviteRepository interface {
+	Create(invite *model.Invite) error
+	FindByID(id uint) (*model.Invite, error)
+	FindByCodeHash(codeHash string) (*model.Invite, error)
+	List(page, pageSize int) ([]model.Invite, int64, error)
+	Revoke(id uint) (int64, error)
+	CreateUserWithInvite(user *model.User, inviteID uint) error
+	ListRedemptions(inviteID uint) ([]InviteRedemptionDetail, error)
+}
+
+// InviteRedemptionDetail 邀请码使用记录及注册用户信息
+type InviteRedemptionDetail struct {
+	model.InviteRedemption
+	Username string
+	Email    string
+}
+
+type inviteRepository struct {
+	db       *gorm.DB
+	tenantID uint
+}
+
+// NewInviteRepository 创建限定在 tenantID 租户内的邀请码仓储实例，tenantID 为 0 时不限定租户
+func NewInviteRepository(db *gorm.DB, tenantID uint) InviteRepository {
+	return &inviteRepository{db: tenantDB(db, tenantID), tenantID: tenantID}
+}
+
+// Create 保存邀请码
+func (r *inviteRepository) Create(invite *model.Invite) error {
+	return r.db.Create(invite).Error
+}
+
+// FindByID 根据ID查找邀请码
+func (r *inviteRepository) FindByID(id uint) (*model.Invite, error) {
+	var invite model.Invite
+	if err := r.db.Where("id = ?", id).First(&invite).Error; err != nil {
+		return nil, err
+	}
+	return &invite, nil
+}
+
+// FindByCodeHash 根据邀请码哈希查找邀请码
+func (r *inviteRepository) FindByCodeHash(codeHash string) (*model.Invite, error) {
+	var invite model.Invite
+	if err := r.db.Where("code_hash = ?", codeHash).First(&invite).Error; err != nil {
+		return nil, err
+	}
+	return &invite, nil
+}
+
+// List 分页查询邀请码，按创建时间倒序
+func (r *inviteRepository) List(page, pageSize int) ([]model.Invite, int64, error) {
+	var invites []model.Invite
+	var total int64
+
+	db := r.db.Model(&model.Invite{})
+	if err := db.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+	offset := (page - 1) * pageSize
+	if err := db.Order("id DESC").Offset(offset).Limit(pageSize).Find(&invites).Error; err != nil {
+		return nil, 0, err
+	}
+	return invites, total, nil
+}
+
+// Revoke 撤销邀请码，返回撤销数量
+func (r *inviteRepository) Revoke(id uint) (int64, error) {
+	res := r.db.Model(&model.Invite{}).
+		Where("id = ? AND revoked_at IS NULL", id).
+		Update("revoked_at", time.Now())
+	return res.RowsAffected, res.Error
+}
+
+// CreateUserWithInvite 在同一事务中占用一次邀请码、创建用户并记录使用
+// 邀请码已撤销、过期或次数用尽时返回 gorm.ErrRecordNotFound
+func (r *inviteRepository) CreateUserWithInvite(user *model.User, inviteID uint) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		tx = tenantDB(tx, r.tenantID)
+		res := tx.Model(&model.Invite{}).
+			Where("id = ? AND revoked_at IS NULL AND used_count < max_uses AND (expires_at IS NULL OR expires_at > ?)", inviteID, time.Now()).
+			Update("used_count", gorm.Expr("used_count + 1"))
+		if res.Error != nil {
+			return res.Error
+		}
+		if res.RowsAffected == 0 {
+			return gorm.ErrRecordNotFound
+		}
+		if err := tx.Create(user).Error; err != nil {
+			return err
+		}
+		return tx.Create(&model.InviteRedemption{InviteID: inviteID, UserID: user.ID}).Error
+	})
+}
+
+// ListRedemptions 查询邀请码的使用记录，按使用时间倒序
+func (r *inviteRepository) ListRedemptions(inviteID uint) ([]InviteRedemptionDetail, error) {
+	var details []InviteRedemptionDetail
+	err := r.db.Model(&model.InviteRedemption{}).
+		Select("invite_redemptions.*, users.username, users.email").
+		Joins("LEFT JOIN users ON users.id = invite_redemptions.user_id").
+		Where("invite_redemptions.invite_id = ?", inviteID).
+		Order("invite_redemptions.id DESC").
+		Scan(&details).Error
+	if err != nil {
+		return nil, err
+	}
+	return details, nil
+}
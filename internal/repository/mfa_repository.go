@@ -0,0 +1,60 @@
+package repository
+
+import (
+	"time"
+
+	"go-one/internal/model"
+
+	"gorm.io/gorm"
+)
+
+// RecoveryCodeRepository 二次验证恢复码数据访问接口
+type RecoveryCodeRepository interface {
+	ReplaceForUser(userID uint, codeHashes []string) error
+	Consume(userID uint, codeHash string) (bool, error)
+	CountUnused(userID uint) (int64, error)
+	DeleteByUser(userID uint) error
+}
+
+type recoveryCodeRepository struct {
+	db *gorm.DB
+}
+
+// NewRecoveryCodeRepository 创建恢复码仓储实例
+func NewRecoveryCodeRepository(db *gorm.DB) RecoveryCodeRepository {
+	return &recoveryCodeRepository{db: db}
+}
+
+// ReplaceForUser 用新的一组恢复码替换用户现有的全部恢复码
+func (r *recoveryCodeRepository) ReplaceForUser(userID uint, codeHashes []string) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("user_id = ?", userID).Delete(&model.MFARecoveryCode{}).Error; err != nil {
+			return err
+		}
+		codes := make([]model.MFARecoveryCode, len(codeHashes))
+		for i, hash := range codeHashes {
+			codes[i] = model.MFARecoveryCode{UserID: userID, CodeHash: hash}
+		}
+		return tx.Create(&codes).Error
+	})
+}
+
+// Consume 将未使用的恢复码标记为已使用，返回是否命中
+func (r *recoveryCodeRepository) Consume(userID uint, codeHash string) (bool, error) {
+	res := r.db.Model(&model.MFARecoveryCode{}).
+		Where("user_id = ? AND code_hash = ? AND used_at IS NULL", userID, codeHash).
+		Update("used_at", time.Now())
+	return res.RowsAffected == 1, res.Error
+}
+
+// CountUnused 统计用户剩余可用的恢复码数量
+func (r *recoveryCodeRepository) CountUnused(userID uint) (int64, error) {
+	var count int64
+	err := r.db.Model(&model.MFARecoveryCode{}).Where("user_id = ? AND used_at IS NULL", userID).Count(&count).Error
+	return count, err
+}
+
+// DeleteByUser 删除用户的全部恢复码
+func (r *recoveryCodeRepository) DeleteByUser(userID uint) error {
+	return r.db.Where("user_id = ?", userID).Delete(&model.MFARecoveryCode{}).Error
+}
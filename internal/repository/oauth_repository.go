@@ -0,0 +1,100 @@
+package repository
+
+import (
+	"time"
+
+	"go-one/internal/model"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// OAuthRepository OAuth 应用与授权同意数据访问接口
+type OAuthRepository interface {
+	CreateClient(client *model.OAuthClient) error
+	FindClient(clientID string) (*model.OAuthClient, error)
+	FindClientByID(id uint) (*model.OAuthClient, error)
+	ListClients() ([]model.OAuthClient, error)
+	RevokeClient(id uint) (int64, error)
+	FindConsent(userID uint, clientID string) (*model.OAuthConsent, error)
+	SaveConsent(consent *model.OAuthConsent) error
+	ListConsents(userID uint) ([]model.OAuthConsent, error)
+	DeleteConsent(userID uint, clientID string) (int64, error)
+}
+
+type oauthRepository struct {
+	db *gorm.DB
+}
+
+// NewOAuthRepository 创建 OAuth 仓储实例
+func NewOAuthRepository(db *gorm.DB) OAuthRepository {
+	return &oauthRepository{db: db}
+}
+
+// CreateClient 保存 OAuth 应用
+func (r *oauthRepository) CreateClient(client *model.OAuthClient) error {
+	return r.db.Create(client).Error
+}
+
+// FindClient 按 client_id 查找未吊销的 OAuth 应用
+func (r *oauthRepository) FindClient(clientID string) (*model.OAuthClient, error) {
+	var client model.OAuthClient
+	if err := r.db.Where("client_id = ? AND revoked_at IS NULL", clientID).First(&client).Error; err != nil {
+		return nil, err
+	}
+	return &client, nil
+}
+
+// FindClientByID 按主键查找 OAuth 应用（含已吊销）
+func (r *oauthRepository) FindClientByID(id uint) (*model.OAuthClient, error) {
+	var client model.OAuthClient
+	if err := r.db.Where("id = ?", id).First(&client).Error; err != nil {
+		return nil, err
+	}
+	return &client, nil
+}
+
+// ListClients 获取全部 OAuth 应用（含已吊销）
+func (r *oauthRepository) ListClients() ([]model.OAuthClient, error) {
+	var clients []model.OAuthClient
+	err := r.db.Order("id DESC").Find(&clients).Error
+	return clients, err
+}
+
+// RevokeClient 吊销 OAuth 应用，返回受影响行数
+func (r *oauthRepository) RevokeClient(id uint) (int64, error) {
+	res := r.db.Model(&model.OAuthClient{}).
+		Where("id = ? AND revoked_at IS NULL", id).
+		Update("revoked_at", time.Now())
+	return res.RowsAffected, res.Error
+}
+
+// FindConsent 查找用户对应用的授权同意记录
+func (r *oauthRepository) FindConsent(userID uint, clientID string) (*model.OAuthConsent, error) {
+	var consent model.OAuthConsent
+	if err := r.db.Where("user_id = ? AND client_id = ?", userID, clientID).First(&consent).Error; err != nil {
+		return nil, err
+	}
+	return &consent, nil
+}
+
+// SaveConsent 新增或更新授权同意记录（按用户 + 应用唯一）
+func (r *oauthRepository) SaveConsent(consent *model.OAuthConsent) error {
+	return r.db.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "user_id"}, {Name: "client_id"}},
+		DoUpdates: clause.AssignmentColumns([]string{"scopes", "updated_at"}),
+	}).Create(consent).Error
+}
+
+// ListConsents 获取用户的全部授权同意记录
+func (r *oauthRepository) ListConsents(userID uint) ([]model.OAuthConsent, error) {
+	var consents []model.OAuthConsent
+	err := r.db.Where("user_id = ?", userID).Order("updated_at DESC").Find(&consents).Error
+	return consents, err
+}
+
+// DeleteConsent 删除用户对应用的授权同意记录，返回受影响行数
+func (r *oauthRepository) DeleteConsent(userID uint, clientID string) (int64, error) {
+	res := r.db.Where("user_id = ? AND client_id = ?", userID, clientID).Delete(&model.OAuthConsent{})
+	return res.RowsAffected, res.Error
+}
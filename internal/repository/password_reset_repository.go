@@ -0,0 +1,64 @@
+package repository
+
+import (
+	"time"
+
+	"go-one/internal/model"
+
+	"gorm.io/gorm"
+)
+
+// PasswordResetRepository 找回密码令牌数据访问接口
+type PasswordResetRepository interface {
+	Create(token *model.PasswordResetToken) error
+	FindValid(tokenHash string) (*model.PasswordResetToken, error)
+	Consume(tokenHash string) (*model.PasswordResetToken, error)
+	InvalidateByUser(userID uint) error
+}
+
+type passwordResetRepository struct {
+	db *gorm.DB
+}
+
+// NewPasswordResetRepository 创建找回密码令牌仓储实例
+func NewPasswordResetRepository(db *gorm.DB) PasswordResetRepository {
+	return &passwordResetRepository{db: db}
+}
+
+// Create 保存找回密码令牌
+func (r *passwordResetRepository) Create(token *model.PasswordResetToken) error {
+	return r.db.Create(token).Error
+}
+
+// FindValid 查找未使用且未过期的令牌（不消耗）
+func (r *passwordResetRepository) FindValid(tokenHash string) (*model.PasswordResetToken, error) {
+	var token model.PasswordResetToken
+	err := r.db.Where("token_hash = ? AND used_at IS NULL AND expires_at > ?", tokenHash, time.Now()).
+		First(&token).Error
+	if err != nil {
+		return nil, err
+	}
+	return &token, nil
+}
+
+// Consume 原子地将未使用且未过期的令牌标记为已使用，未命中时返回 gorm.ErrRecordNotFound
+func (r *passwordResetRepository) Consume(tokenHash string) (*model.PasswordResetToken, error) {
+	var tokens []model.PasswordResetToken
+	now := time.Now()
+	err := r.db.Raw("UPDATE password_reset_tokens SET used_at = ? WHERE token_hash = ? AND used_at IS NULL AND expires_at > ? RETURNING *",
+		now, tokenHash, now).Scan(&tokens).Error
+	if err != nil {
+		return nil, err
+	}
+	if len(tokens) == 0 {
+		return nil, gorm.ErrRecordNotFound
+	}
+	return &tokens[0], nil
+}
+
+// InvalidateByUser 作废用户全部未使用的找回密码令牌
+func (r *passwordResetRepository) InvalidateByUser(userID uint) error {
+	return r.db.Model(&model.PasswordResetToken{}).
+		Where("user_id = ? AND used_at IS NULL", userID).
+		Update("used_at", time.Now()).Error
+}
@@ -0,0 +1,94 @@
+package repository
+
+import (
+	"go-one/internal/model"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// RoleRepository 角色与权限数据访问接口
+type RoleRepository interface {
+	List() ([]model.Role, error)
+	FindByName(name string) (*model.Role, error)
+	RolesOfUser(userID uint) ([]model.Role, error)
+	PermissionsOfUser(userID uint) ([]string, error)
+	Assign(userID, roleID, grantedBy uint) error
+	Revoke(userID, roleID uint) (int64, error)
+	CountUsers(roleID uint) (int64, error)
+}
+
+type roleRepository struct {
+	db *gorm.DB
+}
+
+// NewRoleRepository 创建角色仓储实例
+func NewRoleRepository(db *gorm.DB) RoleRepository {
+	return &roleRepository{db: db}
+}
+
+// List 获取全部角色（含权限）
+func (r *roleRepository) List() ([]model.Role, error) {
+	var roles []model.Role
+	if err := r.db.Preload("Permissions").Order("id").Find(&roles).Error; err != nil {
+		return nil, err
+	}
+	return roles, nil
+}
+
+// FindByName 根据名称查找角色
+func (r *roleRepository) FindByName(name string) (*model.Role, error) {
+	var role model.Role
+	if err := r.db.Where("name = ?", name).First(&role).Error; err != nil {
+		return nil, err
+	}
+	return &role, nil
+}
+
+// RolesOfUser 获取用户拥有的角色
+func (r *roleRepository) RolesOfUser(userID uint) ([]model.Role, error) {
+	var roles []model.Role
+	err := r.db.Joins("JOIN user_roles ON user_roles.role_id = roles.id").
+		Where("user_roles.user_id = ?", userID).
+		Order("roles.id").
+		Find(&roles).Error
+	if err != nil {
+		return nil, err
+	}
+	return roles, nil
+}
+
+// PermissionsOfUser 获取用户通过角色获得的全部权限编码（去重）
+func (r *roleRepository) PermissionsOfUser(userID uint) ([]string, error) {
+	var codes []string
+	err := r.db.Model(&model.Permission{}).
+		Distinct("permissions.code").
+		Joins("JOIN role_permissions ON role_permissions.permission_id = permissions.id").
+		Joins("JOIN user_roles ON user_roles.role_id = role_permissions.role_id").
+		Where("user_roles.user_id = ?", userID).
+		Order("permissions.code").
+		Pluck("permissions.code", &codes).Error
+	if err != nil {
+		return nil, err
+	}
+	return codes, nil
+}
+
+// Assign 为用户分配角色（已拥有时忽略）
+func (r *roleRepository) Assign(userID, roleID, grantedBy uint) error {
+	return r.db.Clauses(clause.OnConflict{DoNothing: true}).
+		Create(&model.UserRole{UserID: userID, RoleID: roleID, GrantedBy: grantedBy}).Error
+}
+
+// Revoke 撤销用户的角色，返回删除数量
+func (r *roleRepository) Revoke(userID, roleID uint) (int64, error) {
+	res := r.db.Where("user_id = ? AND role_id = ?", userID, roleID).Delete(&model.UserRole{})
+	return res.RowsAffected, res.Error
+}
+
+// CountUsers 统计拥有该角色的用户数
+func (r *roleRepository) CountUsers(roleID uint) (int64, error) {
+	var count int64
+	err := r.db.Model(&model.UserRole{}).Where("role_id = ?", roleID).Count(&count).Error
+	return count, err
+}
@@ -0,0 +1,81 @@
+package repository
+
+import (
+	"go-one/internal/model"
+
+	"gorm.io/gorm"
+)
+
+// SecurityEventRepository 安全事件数据访问接口
+type SecurityEventRepository interface {
+	Create(event *model.SecurityEvent) error
+	Search(query SecurityEventSearch) ([]model.SecurityEvent, int64, error)
+	Footprint(userID uint, eventType, clientIP, userAgent string) (*SecurityEventFootprint, error)
+}
+
+// SecurityEventSearch 安全事件搜索条件，零值表示不限
+type SecurityEventSearch struct {
+	UserID   uint
+	Type     string
+	Page     int
+	PageSize int
+}
+
+// SecurityEventFootprint 用户某类事件的历史次数，以及其中来自指定 IP 与 User-Agent 的次数
+type SecurityEventFootprint struct {
+	Total      int64
+	IPCount    int64
+	AgentCount int64
+}
+
+type securityEventRepository struct {
+	db *gorm.DB
+}
+
+// NewSecurityEventRepository 创建限定在 tenantID 租户内的安全事件仓储实例，tenantID 为 0 时不限定租户
+func NewSecurityEventRepository(db *gorm.DB, tenantID uint) SecurityEventRepository {
+	return &securityEventRepository{db: tenantDB(db, tenantID)}
+}
+
+// Create 写入安全事件
+func (r *securityEventRepository) Create(event *model.SecurityEvent) error {
+	return r.db.Create(event).Error
+}
+
+// Search 按用户与事件类型搜索安全事件（分页，按时间倒序）
+func (r *securityEventRepository) Search(query SecurityEventSearch) ([]model.SecurityEvent, int64, error) {
+	var events []model.SecurityEvent
+	var total int64
+
+	db := r.db.Model(&model.SecurityEvent{})
+	if query.UserID != 0 {
+		db = db.Where("user_id = ?", query.UserID)
+	}
+	if query.Type != "" {
+		db = db.Where("type = ?", query.Type)
+	}
+
+	if err := db.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+	offset := (query.Page - 1) * query.PageSize
+	if err := db.Order("id DESC").Offset(offset).Limit(query.PageSize).Find(&events).Error; err != nil {
+		return nil, 0, err
+	}
+	return events, total, nil
+}
+
+// Footprint 统计用户此前 eventType 类事件的次数，以及其中来自 clientIP 与 userAgent 的次数
+func (r *securityEventRepository) Footprint(userID uint, eventType, clientIP, userAgent string) (*SecurityEventFootprint, error) {
+	var footprint SecurityEventFootprint
+	err := r.db.Model(&model.SecurityEvent{}).
+		Select("COUNT(*) AS total, "+
+			"COUNT(*) FILTER (WHERE client_ip = ?) AS ip_count, "+
+			"COUNT(*) FILTER (WHERE user_agent = ?) AS agent_count", clientIP, userAgent).
+		Where("user_id = ? AND type = ?", userID, eventType).
+		Find(&footprint).Error
+	if err != nil {
+		return nil, err
+	}
+	return &footprint, nil
+}
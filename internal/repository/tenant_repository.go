@@ -0,0 +1,38 @@
+package repository
+
+import (
+	"go-one/internal/model"
+
+	"gorm.io/gorm"
+)
+
+// TenantRepository 租户数据访问接口
+type TenantRepository interface {
+	FindBySlug(slug string) (*model.Tenant, error)
+}
+
+type tenantRepository struct {
+	db *gorm.DB
+}
+
+// NewTenantRepository 创建租户仓储实例
+func NewTenantRepository(db *gorm.DB) TenantRepository {
+	return &tenantRepository{db: db}
+}
+
+// FindBySlug 根据标识查找租户
+func (r *tenantRepository) FindBySlug(slug string) (*model.Tenant, error) {
+	var tenant model.Tenant
+	if err := r.db.Where("slug = ?", slug).First(&tenant).Error; err != nil {
+		return nil, err
+	}
+	return &tenant, nil
+}
+
+// tenantDB 返回限定在指定租户的会话（见 model.TenantScope），tenantID 为 0 时原样返回
+func tenantDB(db *gorm.DB, tenantID uint) *gorm.DB {
+	if tenantID == 0 {
+		return db
+	}
+	return db.Scopes(model.TenantScope(tenantID)).Session(&gorm.Session{})
+}
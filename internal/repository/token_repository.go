@@ -1,46 +1,146 @@
 package repository
 
 import (
-    "time"
+	"time"
 
-    "go-one/internal/model"
-    "gorm.io/gorm"
+	"go-one/internal/model"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
+// familyCTE 从指定 JTI 出发，沿 rotated_from 向下遍历整条旋转链（包含自身），作为子查询使用
+// 子查询为原生 SQL，不附加租户条件；外层查询经 TenantScope 过滤，结果仍限定在当前租户
+const familyCTE = `WITH RECURSIVE family AS (
+	SELECT jti FROM refresh_tokens WHERE jti = ?
+	UNION
+	SELECT rt.jti FROM refresh_tokens rt JOIN family f ON rt.rotated_from = f.jti
+) SELECT jti FROM family`
+
 type RefreshTokenRepository interface {
-    Create(jti string, userID uint, expiresAt time.Time, rotatedFrom string) error
-    FindByJTI(jti string) (*model.RefreshToken, error)
-    RevokeByJTI(jti string) error
+	Create(rt *model.RefreshToken) error
+	FindByJTI(jti string) (*model.RefreshToken, error)
+	FindByJTIForUpdate(jti string) (*model.RefreshToken, error)
+	RevokeByJTI(jti string) error
+	FindFamily(jti string) ([]model.RefreshToken, error)
+	RevokeFamily(jti string) (int64, error)
+	ListActiveByUser(userID uint) ([]model.RefreshToken, error)
+	RevokeSession(userID uint, sessionID string) (int64, error)
+	RevokeAllByUser(userID uint, exceptSessionID string) (int64, error)
+	RevokeByClient(userID uint, clientID string) (int64, error)
+	ListSessionIDsByClient(userID uint, clientID string) ([]string, error)
+	Transaction(fn func(repo RefreshTokenRepository) error) error
 }
 
 type refreshTokenRepository struct {
-    db *gorm.DB
+	db       *gorm.DB
+	tenantID uint
 }
 
-func NewRefreshTokenRepository(db *gorm.DB) RefreshTokenRepository {
-    return &refreshTokenRepository{db: db}
+// NewRefreshTokenRepository 创建限定在 tenantID 租户内的刷新令牌仓储实例，tenantID 为 0 时不限定租户
+func NewRefreshTokenRepository(db *gorm.DB, tenantID uint) RefreshTokenRepository {
+	return &refreshTokenRepository{db: tenantDB(db, tenantID), tenantID: tenantID}
 }
 
-func (r *refreshTokenRepository) Create(jti string, userID uint, expiresAt time.Time, rotatedFrom string) error {
-    rt := &model.RefreshToken{
-        JTI:         jti,
-        UserID:      userID,
-        ExpiresAt:   expiresAt,
-        Revoked:     false,
-        RotatedFrom: rotatedFrom,
-    }
-    return r.db.Create(rt).Error
+func (r *refreshTokenRepository) Create(rt *model.RefreshToken) error {
+	return r.db.Create(rt).Error
 }
 
 func (r *refreshTokenRepository) FindByJTI(jti string) (*model.RefreshToken, error) {
-    var rt model.RefreshToken
-    if err := r.db.Where("jti = ?", jti).First(&rt).Error; err != nil {
-        return nil, err
-    }
-    return &rt, nil
+	var rt model.RefreshToken
+	if err := r.db.Where("jti = ?", jti).First(&rt).Error; err != nil {
+		return nil, err
+	}
+	return &rt, nil
+}
+
+// FindByJTIForUpdate 查询并对记录加行锁（SELECT ... FOR UPDATE），需在事务中调用
+func (r *refreshTokenRepository) FindByJTIForUpdate(jti string) (*model.RefreshToken, error) {
+	var rt model.RefreshToken
+	if err := r.db.Clauses(clause.Locking{Strength: "UPDATE"}).Where("jti = ?", jti).First(&rt).Error; err != nil {
+		return nil, err
+	}
+	return &rt, nil
 }
 
 func (r *refreshTokenRepository) RevokeByJTI(jti string) error {
-    return r.db.Model(&model.RefreshToken{}).Where("jti = ?", jti).Update("revoked", true).Error
+	return r.db.Model(&model.RefreshToken{}).Where("jti = ?", jti).Update("revoked", true).Error
+}
+
+// FindFamily 查询指定 JTI 及其所有旋转后代
+func (r *refreshTokenRepository) FindFamily(jti string) ([]model.RefreshToken, error) {
+	var tokens []model.RefreshToken
+	err := r.db.Where("jti IN (?)", r.db.Raw(familyCTE, jti)).Order("id").Find(&tokens).Error
+	if err != nil {
+		return nil, err
+	}
+	return tokens, nil
+}
+
+// RevokeFamily 撤销指定 JTI 及其所有旋转后代，返回本次新撤销的数量
+func (r *refreshTokenRepository) RevokeFamily(jti string) (int64, error) {
+	res := r.db.Model(&model.RefreshToken{}).
+		Where("revoked = ? AND jti IN (?)", false, r.db.Raw(familyCTE, jti)).
+		Update("revoked", true)
+	return res.RowsAffected, res.Error
+}
+
+// ListActiveByUser 查询用户所有未撤销且未过期的刷新令牌（每个会话一条），按认证时间倒序
+func (r *refreshTokenRepository) ListActiveByUser(userID uint) ([]model.RefreshToken, error) {
+	var tokens []model.RefreshToken
+	err := r.db.Where("user_id = ? AND revoked = ? AND expires_at > ?", userID, false, time.Now()).
+		Order("authenticated_at DESC").
+		Find(&tokens).Error
+	if err != nil {
+		return nil, err
+	}
+	return tokens, nil
 }
 
+// RevokeSession 撤销用户指定会话下的全部刷新令牌
+func (r *refreshTokenRepository) RevokeSession(userID uint, sessionID string) (int64, error) {
+	res := r.db.Model(&model.RefreshToken{}).
+		Where("user_id = ? AND session_id = ? AND revoked = ?", userID, sessionID, false).
+		Update("revoked", true)
+	return res.RowsAffected, res.Error
+}
+
+// RevokeAllByUser 撤销用户的全部刷新令牌，exceptSessionID 非空时保留该会话
+func (r *refreshTokenRepository) RevokeAllByUser(userID uint, exceptSessionID string) (int64, error) {
+	query := r.db.Model(&model.RefreshToken{}).Where("user_id = ? AND revoked = ?", userID, false)
+	if exceptSessionID != "" {
+		query = query.Where("session_id <> ?", exceptSessionID)
+	}
+	res := query.Update("revoked", true)
+	return res.RowsAffected, res.Error
+}
+
+// RevokeByClient 撤销签发给指定 OAuth 应用的刷新令牌，userID 为 0 时撤销该应用的全部授权
+func (r *refreshTokenRepository) RevokeByClient(userID uint, clientID string) (int64, error) {
+	query := r.db.Model(&model.RefreshToken{}).Where("client_id = ? AND revoked = ?", clientID, false)
+	if userID != 0 {
+		query = query.Where("user_id = ?", userID)
+	}
+	res := query.Update("revoked", true)
+	return res.RowsAffected, res.Error
+}
+
+// ListSessionIDsByClient 查询签发给指定 OAuth 应用的未撤销会话ID，userID 为 0 时不限定用户
+func (r *refreshTokenRepository) ListSessionIDsByClient(userID uint, clientID string) ([]string, error) {
+	query := r.db.Model(&model.RefreshToken{}).Where("client_id = ? AND revoked = ?", clientID, false)
+	if userID != 0 {
+		query = query.Where("user_id = ?", userID)
+	}
+	var sessionIDs []string
+	if err := query.Distinct().Pluck("session_id", &sessionIDs).Error; err != nil {
+		return nil, err
+	}
+	return sessionIDs, nil
+}
+
+// Transaction 在数据库事务中执行 fn，fn 内应使用传入的 repo 进行读写
+func (r *refreshTokenRepository) Transaction(fn func(repo RefreshTokenRepository) error) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		return fn(&refreshTokenRepository{db: tenantDB(tx, r.tenantID), tenantID: r.tenantID})
+	})
+}
@@ -0,0 +1,95 @@
+package repository
+
+import (
+	"regexp"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+)
+
+// newMockDB 创建基于 sqlmock 的 PostgreSQL 方言 gorm 连接，按生成的 SQL 断言查询
+func newMockDB(t *testing.T) (*gorm.DB, sqlmock.Sqlmock) {
+	t.Helper()
+	conn, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("sqlmock.New: %v", err)
+	}
+	t.Cleanup(func() {
+		conn.Close()
+		if err := mock.ExpectationsWereMet(); err != nil {
+			t.Error(err)
+		}
+	})
+	db, err := gorm.Open(postgres.New(postgres.Config{Conn: conn}), &gorm.Config{SkipDefaultTransaction: true})
+	if err != nil {
+		t.Fatalf("gorm.Open: %v", err)
+	}
+	return db, mock
+}
+
+func TestRevokeFamilyUsesRecursiveCTE(t *testing.T) {
+	db, mock := newMockDB(t)
+	// 旋转链以子查询方式撤销：起点 JTI 绑定到 CTE 内，外层只撤销仍有效的令牌
+	mock.ExpectExec(regexp.QuoteMeta(`UPDATE "refresh_tokens" SET "revoked"=$1,"updated_at"=$2 WHERE revoked = $3 AND jti IN (WITH RECURSIVE family AS (`)+
+		`\s+SELECT jti FROM refresh_tokens WHERE jti = \$4\s+UNION\s+`+
+		regexp.QuoteMeta(`SELECT rt.jti FROM refresh_tokens rt JOIN family f ON rt.rotated_from = f.jti`)+
+		`\s+`+regexp.QuoteMeta(`) SELECT jti FROM family)`)+`$`).
+		WithArgs(true, sqlmock.AnyArg(), false, "root-jti").
+		WillReturnResult(sqlmock.NewResult(0, 2))
+
+	revoked, err := NewRefreshTokenRepository(db, 0).RevokeFamily("root-jti")
+	if err != nil {
+		t.Fatalf("RevokeFamily: %v", err)
+	}
+	if revoked != 2 {
+		t.Errorf("revoked = %d, want 2", revoked)
+	}
+}
+
+func TestFindFamilyUsesRecursiveCTE(t *testing.T) {
+	db, mock := newMockDB(t)
+	mock.ExpectQuery(regexp.QuoteMeta(`SELECT * FROM "refresh_tokens" WHERE jti IN (WITH RECURSIVE family AS (`) +
+		`[\s\S]+` + regexp.QuoteMeta(`) SELECT jti FROM family) ORDER BY id`)).
+		WithArgs("root-jti").
+		WillReturnRows(sqlmock.NewRows([]string{"id", "jti", "rotated_from"}).
+			AddRow(1, "root-jti", "").
+			AddRow(2, "child-jti", "root-jti"))
+
+	family, err := NewRefreshTokenRepository(db, 0).FindFamily("root-jti")
+	if err != nil {
+		t.Fatalf("FindFamily: %v", err)
+	}
+	if len(family) != 2 || family[1].RotatedFrom != "root-jti" {
+		t.Errorf("family = %+v", family)
+	}
+}
+
+func TestFindByJTIForUpdateLocksRowInTransaction(t *testing.T) {
+	db, mock := newMockDB(t)
+	mock.ExpectBegin()
+	mock.ExpectQuery(regexp.QuoteMeta(`SELECT * FROM "refresh_tokens" WHERE jti = $1 ORDER BY "refresh_tokens"."id" LIMIT $2 FOR UPDATE`)).
+		WithArgs("current-jti", 1).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "jti", "revoked"}).AddRow(1, "current-jti", true))
+	mock.ExpectExec(regexp.QuoteMeta(`UPDATE "refresh_tokens" SET "revoked"=$1,"updated_at"=$2 WHERE revoked = $3 AND jti IN (WITH RECURSIVE family AS (`)).
+		WithArgs(true, sqlmock.AnyArg(), false, "current-jti").
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+
+	// 重放检测路径：加锁读到已撤销的令牌后在同一事务内撤销整条旋转链并提交
+	err := NewRefreshTokenRepository(db, 0).Transaction(func(repo RefreshTokenRepository) error {
+		record, err := repo.FindByJTIForUpdate("current-jti")
+		if err != nil {
+			return err
+		}
+		if !record.Revoked {
+			t.Errorf("record = %+v, want revoked", record)
+		}
+		_, err = repo.RevokeFamily(record.JTI)
+		return err
+	})
+	if err != nil {
+		t.Fatalf("Transaction: %v", err)
+	}
+}
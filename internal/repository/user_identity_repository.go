@@ -0,0 +1,59 @@
+package repository
+
+import (
+	"time"
+
+	"go-one/internal/model"
+
+	"gorm.io/gorm"
+)
+
+// UserIdentityRepository 外部身份数据访问接口
+type UserIdentityRepository interface {
+	Find(provider, subject string) (*model.UserIdentity, error)
+	Create(identity *model.UserIdentity) error
+	CreateWithUser(user *model.User, identity *model.UserIdentity) error
+	TouchLogin(id uint, email string) error
+}
+
+type userIdentityRepository struct {
+	db       *gorm.DB
+	tenantID uint
+}
+
+// NewUserIdentityRepository 创建限定在 tenantID 租户内的外部身份仓储实例，tenantID 为 0 时不限定租户
+func NewUserIdentityRepository(db *gorm.DB, tenantID uint) UserIdentityRepository {
+	return &userIdentityRepository{db: tenantDB(db, tenantID), tenantID: tenantID}
+}
+
+// Find 按提供方与 subject 查找外部身份
+func (r *userIdentityRepository) Find(provider, subject string) (*model.UserIdentity, error) {
+	var identity model.UserIdentity
+	if err := r.db.Where("provider = ? AND subject = ?", provider, subject).First(&identity).Error; err != nil {
+		return nil, err
+	}
+	return &identity, nil
+}
+
+// Create 保存外部身份（关联到已有用户）
+func (r *userIdentityRepository) Create(identity *model.UserIdentity) error {
+	return r.db.Create(identity).Error
+}
+
+// CreateWithUser 在同一事务中创建用户及其外部身份
+func (r *userIdentityRepository) CreateWithUser(user *model.User, identity *model.UserIdentity) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		tx = tenantDB(tx, r.tenantID)
+		if err := tx.Create(user).Error; err != nil {
+			return err
+		}
+		identity.UserID = user.ID
+		return tx.Create(identity).Error
+	})
+}
+
+// TouchLogin 更新最近登录时间与提供方返回的邮箱
+func (r *userIdentityRepository) TouchLogin(id uint, email string) error {
+	return r.db.Model(&model.UserIdentity{}).Where("id = ?", id).
+		Updates(map[string]interface{}{"last_login_at": time.Now(), "email": email}).Error
+}
@@ -2,8 +2,11 @@ package repository
 
 import (
 	"go-one/internal/model"
+	"strings"
+	"time"
 
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
 // UserRepository 用户数据访问接口
@@ -12,18 +15,41 @@ type UserRepository interface {
 	FindByID(id uint) (*model.User, error)
 	FindByUsername(username string) (*model.User, error)
 	FindByEmail(email string) (*model.User, error)
+	FindByVerifiedEmail(email string) (*model.User, error)
+	FindUnverifiedByEmail(email string) ([]model.User, error)
+	FindByVerifiedPhone(phone string) (*model.User, error)
+	UpdatePhone(id uint, phone string) error
+	MarkEmailVerified(id uint, email string) (bool, error)
 	Update(user *model.User) error
+	UpdatePasswordHash(id uint, oldHash, newHash string) (bool, error)
 	Delete(id uint) error
+	IncrementTokenVersion(id uint) (int, error)
+	AdvanceTOTPStep(id uint, step int64) (bool, error)
 	List(page, pageSize int) ([]model.User, int64, error)
+	Search(query UserSearch) ([]model.User, int64, error)
+	ChangeStatus(user *model.User, change *model.UserStatusChange) error
+	ListStatusChanges(userID uint) ([]model.UserStatusChange, error)
+	Anonymize(user *model.User, change *model.UserStatusChange) error
+	ListPurgeable(before time.Time, limit int) ([]model.User, error)
+}
+
+// UserSearch 用户搜索条件
+type UserSearch struct {
+	Keyword  string // 匹配用户名、邮箱、昵称
+	Status   *int
+	Page     int
+	PageSize int
 }
 
 type userRepository struct {
-	db *gorm.DB
+	db       *gorm.DB
+	tenantID uint
 }
 
-// NewUserRepository 创建用户仓储实例
-func NewUserRepository(db *gorm.DB) UserRepository {
-	return &userRepository{db: db}
+// NewUserRepository 创建限定在 tenantID 租户内的用户仓储实例，所有读写自动附加租户条件
+// tenantID 为 0 时不限定租户，仅供跨租户的系统任务（如 API 密钥认证）使用
+func NewUserRepository(db *gorm.DB, tenantID uint) UserRepository {
+	return &userRepository{db: tenantDB(db, tenantID), tenantID: tenantID}
 }
 
 // Create 创建用户
@@ -61,14 +87,130 @@ func (r *userRepository) FindByEmail(email string) (*model.User, error) {
 	return &user, nil
 }
 
+// FindByVerifiedEmail 根据已验证的邮箱查找用户
+func (r *userRepository) FindByVerifiedEmail(email string) (*model.User, error) {
+	var user model.User
+	err := r.db.Where("email = ? AND email_verified = ?", email, true).First(&user).Error
+	if err != nil {
+		return nil, err
+	}
+	return &user, nil
+}
+
+// FindUnverifiedByEmail 查找填写了该邮箱但尚未验证的用户
+func (r *userRepository) FindUnverifiedByEmail(email string) ([]model.User, error) {
+	var users []model.User
+	err := r.db.Where("email = ? AND email_verified = ?", email, false).Order("id").Find(&users).Error
+	if err != nil {
+		return nil, err
+	}
+	return users, nil
+}
+
+// FindByVerifiedPhone 根据已验证的手机号查找用户
+func (r *userRepository) FindByVerifiedPhone(phone string) (*model.User, error) {
+	var user model.User
+	err := r.db.Where("phone = ? AND phone_verified = ?", phone, true).First(&user).Error
+	if err != nil {
+		return nil, err
+	}
+	return &user, nil
+}
+
+// UpdatePhone 设置用户已验证的手机号，phone 为空时解除绑定
+func (r *userRepository) UpdatePhone(id uint, phone string) error {
+	res := r.db.Model(&model.User{}).
+		Where("id = ?", id).
+		Updates(map[string]interface{}{"phone": phone, "phone_verified": phone != ""})
+	if res.Error != nil {
+		return res.Error
+	}
+	if res.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
+	return nil
+}
+
+// MarkEmailVerified 将用户当前邮箱标记为已验证，邮箱已变更或已验证时返回 false
+func (r *userRepository) MarkEmailVerified(id uint, email string) (bool, error) {
+	now := time.Now()
+	res := r.db.Model(&model.User{}).
+		Where("id = ? AND email = ? AND email_verified = ?", id, email, false).
+		Updates(map[string]interface{}{"email_verified": true, "email_verified_at": now})
+	return res.RowsAffected > 0, res.Error
+}
+
 // Update 更新用户
 func (r *userRepository) Update(user *model.User) error {
 	return r.db.Save(user).Error
 }
 
-// Delete 删除用户
+// UpdatePasswordHash 仅当当前哈希仍为 oldHash 时替换为 newHash，返回是否更新成功（避免覆盖并发的改密）
+func (r *userRepository) UpdatePasswordHash(id uint, oldHash, newHash string) (bool, error) {
+	res := r.db.Model(&model.User{}).
+		Where("id = ? AND password = ?", id, oldHash).
+		Update("password", newHash)
+	return res.RowsAffected > 0, res.Error
+}
+
+// userOwnedModels 随用户一并彻底删除的关联数据（审计日志保留，仅以用户ID关联）
+var userOwnedModels = []interface{}{
+	&model.RefreshToken{},
+	&model.MFARecoveryCode{},
+	&model.PasswordResetToken{},
+	&model.UserRole{},
+	&model.APIKey{},
+	&model.UserStatusChange{},
+	&model.UserIdentity{},
+	&model.OAuthConsent{},
+	&model.UserConsent{},
+	&model.InviteRedemption{},
+	&model.DataExport{},
+	&model.SecurityEvent{},
+}
+
+// Delete 在事务中彻底删除用户及其关联数据
 func (r *userRepository) Delete(id uint) error {
-	return r.db.Delete(&model.User{}, id).Error
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		tx = tenantDB(tx, r.tenantID)
+		for _, owned := range userOwnedModels {
+			if err := tx.Where("user_id = ?", id).Delete(owned).Error; err != nil {
+				return err
+			}
+		}
+		return tx.Delete(&model.User{}, id).Error
+	})
+}
+
+// IncrementTokenVersion 原子递增用户令牌版本，返回递增后的版本号
+func (r *userRepository) IncrementTokenVersion(id uint) (int, error) {
+	// token_version 仅允许在创建时写入（防止 Save 覆盖），此处显式构造 SET 子句
+	var user model.User
+	res := r.db.Model(&user).
+		Clauses(
+			clause.Set{
+				{Column: clause.Column{Name: "token_version"}, Value: gorm.Expr("token_version + 1")},
+				{Column: clause.Column{Name: "updated_at"}, Value: time.Now()},
+			},
+			clause.Returning{Columns: []clause.Column{{Name: "token_version"}}},
+		).
+		Where("id = ?", id).
+		Updates(map[string]interface{}{})
+	if res.Error != nil {
+		return 0, res.Error
+	}
+	if res.RowsAffected == 0 {
+		return 0, gorm.ErrRecordNotFound
+	}
+	return user.TokenVersion, nil
+}
+
+// AdvanceTOTPStep 仅当 step 大于已使用的时间步时更新，返回是否更新成功（防止验证码重放）
+func (r *userRepository) AdvanceTOTPStep(id uint, step int64) (bool, error) {
+	res := r.db.Model(&model.User{}).
+		Where("id = ? AND totp_last_step < ?", id, step).
+		Update("totp_last_step", step)
+	return res.RowsAffected > 0, res.Error
 }
 
 // List 获取用户列表（分页）
@@ -88,3 +230,110 @@ func (r *userRepository) List(page, pageSize int) ([]model.User, int64, error) {
 
 	return users, total, nil
 }
+
+// likeEscaper 转义 LIKE 模式中的通配符，使关键字按字面匹配
+var likeEscaper = strings.NewReplacer(`\`, `\\`, "%", `\%`, "_", `\_`)
+
+// Search 按关键字与状态搜索用户（分页）
+func (r *userRepository) Search(query UserSearch) ([]model.User, int64, error) {
+	var users []model.User
+	var total int64
+
+	db := r.db.Model(&model.User{})
+	if query.Keyword != "" {
+		like := "%" + likeEscaper.Replace(query.Keyword) + "%"
+		db = db.Where(`username ILIKE ? ESCAPE '\' OR email ILIKE ? ESCAPE '\' OR nickname ILIKE ? ESCAPE '\'`, like, like, like)
+	}
+	if query.Status != nil {
+		db = db.Where("status = ?", *query.Status)
+	}
+
+	if err := db.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+	offset := (query.Page - 1) * query.PageSize
+	if err := db.Order("id DESC").Offset(offset).Limit(query.PageSize).Find(&users).Error; err != nil {
+		return nil, 0, err
+	}
+	return users, total, nil
+}
+
+// ChangeStatus 在事务中更新用户状态并写入变更记录
+func (r *userRepository) ChangeStatus(user *model.User, change *model.UserStatusChange) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		tx = tenantDB(tx, r.tenantID)
+		err := tx.Model(&model.User{}).Where("id = ?", user.ID).Updates(map[string]interface{}{
+			"status":          user.Status,
+			"status_reason":   user.StatusReason,
+			"suspended_until": user.SuspendedUntil,
+			"updated_at":      time.Now(),
+		}).Error
+		if err != nil {
+			return err
+		}
+		return tx.Create(change).Error
+	})
+}
+
+// ListStatusChanges 查询用户状态变更记录，按时间倒序
+func (r *userRepository) ListStatusChanges(userID uint) ([]model.UserStatusChange, error) {
+	var changes []model.UserStatusChange
+	err := r.db.Where("user_id = ?", userID).Order("id DESC").Find(&changes).Error
+	if err != nil {
+		return nil, err
+	}
+	return changes, nil
+}
+
+// Anonymize 在事务中清除用户的个人信息并标记为已注销，同时删除外部身份、恢复码、找回密码令牌与应用授权，撤销 API 密钥
+// user 中的个人信息字段应已由调用方清除，change 为对应的状态变更记录
+func (r *userRepository) Anonymize(user *model.User, change *model.UserStatusChange) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		tx = tenantDB(tx, r.tenantID)
+		err := tx.Model(&model.User{}).Where("id = ?", user.ID).Updates(map[string]interface{}{
+			"username":          user.Username,
+			"email":             user.Email,
+			"email_verified":    user.EmailVerified,
+			"email_verified_at": user.EmailVerifiedAt,
+			"phone":             user.Phone,
+			"phone_verified":    user.PhoneVerified,
+			"password":          user.Password,
+			"nickname":          user.Nickname,
+			"avatar":            user.Avatar,
+			"status":            user.Status,
+			"status_reason":     user.StatusReason,
+			"suspended_until":   user.SuspendedUntil,
+			"mfa_enabled":       user.MFAEnabled,
+			"totp_secret":       user.TOTPSecret,
+			"purge_at":          user.PurgeAt,
+			"updated_at":        time.Now(),
+		}).Error
+		if err != nil {
+			return err
+		}
+		if err := tx.Create(change).Error; err != nil {
+			return err
+		}
+		for _, owned := range []interface{}{&model.UserIdentity{}, &model.MFARecoveryCode{}, &model.PasswordResetToken{}, &model.OAuthConsent{}} {
+			if err := tx.Where("user_id = ?", user.ID).Delete(owned).Error; err != nil {
+				return err
+			}
+		}
+		return tx.Model(&model.APIKey{}).
+			Where("user_id = ? AND revoked_at IS NULL", user.ID).
+			Update("revoked_at", time.Now()).Error
+	})
+}
+
+// ListPurgeable 查询已注销且到达彻底删除时间的用户
+func (r *userRepository) ListPurgeable(before time.Time, limit int) ([]model.User, error) {
+	var users []model.User
+	err := r.db.Where("status = ? AND purge_at IS NOT NULL AND purge_at <= ?", model.UserStatusDeleted, before).
+		Order("purge_at").
+		Limit(limit).
+		Find(&users).Error
+	if err != nil {
+		return nil, err
+	}
+	return users, nil
+}
@@ -0,0 +1,61 @@
+package serializer
+
+import (
+	"go-one/internal/model"
+	"time"
+)
+
+// AdminUserVTO 管理端用户信息 VTO
+type AdminUserVTO struct {
+	*UserVTO
+	StatusReason   string     `json:"status_reason,omitempty"`
+	SuspendedUntil *time.Time `json:"suspended_until,omitempty"`
+}
+
+// UserStatusChangeVTO 用户状态变更记录 VTO
+type UserStatusChangeVTO struct {
+	FromStatus int        `json:"from_status"`
+	ToStatus   int        `json:"to_status"`
+	Reason     string     `json:"reason"`
+	ExpiresAt  *time.Time `json:"expires_at,omitempty"`
+	OperatorID uint       `json:"operator_id"`
+	CreatedAt  time.Time  `json:"created_at"`
+}
+
+// AdminUserDetailVTO 管理端用户详情 VTO
+type AdminUserDetailVTO struct {
+	User          *AdminUserVTO          `json:"user"`
+	StatusChanges []*UserStatusChangeVTO `json:"status_changes"`
+}
+
+// AdminUserListVTO 管理端用户列表 VTO
+type AdminUserListVTO struct {
+	List     []*AdminUserVTO `json:"list"`
+	Total    int64           `json:"total"`
+	Page     int             `json:"page"`
+	PageSize int             `json:"page_size"`
+}
+
+// BuildAdminUserVTO 将 model.User 转换为 AdminUserVTO
+func BuildAdminUserVTO(user *model.User) *AdminUserVTO {
+	if user == nil {
+		return nil
+	}
+	return &AdminUserVTO{
+		UserVTO:        BuildUserVTO(user),
+		StatusReason:   user.StatusReason,
+		SuspendedUntil: user.SuspendedUntil,
+	}
+}
+
+// BuildUserStatusChangeVTO 将 model.UserStatusChange 转换为 VTO
+func BuildUserStatusChangeVTO(change *model.UserStatusChange) *UserStatusChangeVTO {
+	return &UserStatusChangeVTO{
+		FromStatus: change.FromStatus,
+		ToStatus:   change.ToStatus,
+		Reason:     change.Reason,
+		ExpiresAt:  change.ExpiresAt,
+		OperatorID: change.OperatorID,
+		CreatedAt:  change.CreatedAt,
+	}
+}
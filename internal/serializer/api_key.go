@@ -0,0 +1,52 @@
+package serializer
+
+import (
+	"go-one/internal/model"
+	"strings"
+	"time"
+)
+
+// APIKeyVTO API 密钥 VTO（不含密钥明文）
+type APIKeyVTO struct {
+	ID         uint       `json:"id"`
+	Name       string     `json:"name"`
+	Prefix     string     `json:"prefix"`
+	Scopes     []string   `json:"scopes"`
+	AllowedIPs []string   `json:"allowed_ips"`
+	ExpiresAt  *time.Time `json:"expires_at"`
+	LastUsedAt *time.Time `json:"last_used_at"`
+	LastUsedIP string     `json:"last_used_ip,omitempty"`
+	CreatedAt  time.Time  `json:"created_at"`
+}
+
+// CreatedAPIKeyVTO 创建 API 密钥响应 VTO（Key 仅返回一次）
+type CreatedAPIKeyVTO struct {
+	*APIKeyVTO
+	Key string `json:"key"`
+}
+
+// BuildAPIKeyVTO 将 model.APIKey 转换为 APIKeyVTO
+func BuildAPIKeyVTO(key *model.APIKey) *APIKeyVTO {
+	if key == nil {
+		return nil
+	}
+	return &APIKeyVTO{
+		ID:         key.ID,
+		Name:       key.Name,
+		Prefix:     key.Prefix,
+		Scopes:     splitComma(key.Scopes),
+		AllowedIPs: splitComma(key.AllowedIPs),
+		ExpiresAt:  key.ExpiresAt,
+		LastUsedAt: key.LastUsedAt,
+		LastUsedIP: key.LastUsedIP,
+		CreatedAt:  key.CreatedAt,
+	}
+}
+
+// splitComma 拆分逗号分隔的字段，空字符串返回空切片
+func splitComma(s string) []string {
+	if s == "" {
+		return []string{}
+	}
+	return strings.Split(s, ",")
+}
@@ -0,0 +1,75 @@
+package serializer
+
+import (
+	"go-one/internal/model"
+	"time"
+)
+
+// PolicyVersionVTO 协议版本 VTO
+type PolicyVersionVTO struct {
+	ID          uint      `json:"id"`
+	Type        string    `json:"type"`
+	Version     string    `json:"version"`
+	URL         string    `json:"url"`
+	Mandatory   bool      `json:"mandatory"`
+	EffectiveAt time.Time `json:"effective_at"`
+}
+
+// CurrentPolicyVTO 当前生效的协议版本及用户的同意状态
+type CurrentPolicyVTO struct {
+	*PolicyVersionVTO
+	Accepted bool `json:"accepted"`
+}
+
+// UserConsentVTO 用户同意记录 VTO
+type UserConsentVTO struct {
+	PolicyVersionID uint      `json:"policy_version_id"`
+	Type            string    `json:"type"`
+	Version         string    `json:"version"`
+	URL             string    `json:"url"`
+	AcceptedAt      time.Time `json:"accepted_at"`
+	ClientIP        string    `json:"client_ip"`
+}
+
+// ConsentStatusVTO 用户的协议同意状态 VTO
+type ConsentStatusVTO struct {
+	Current []*CurrentPolicyVTO `json:"current"`
+	Pending []*PolicyVersionVTO `json:"pending"` // 须重新同意才能继续使用的版本
+	History []*UserConsentVTO   `json:"history"`
+}
+
+// BuildPolicyVersionVTO 将 model.PolicyVersion 转换为 PolicyVersionVTO
+func BuildPolicyVersionVTO(version *model.PolicyVersion) *PolicyVersionVTO {
+	if version == nil {
+		return nil
+	}
+	return &PolicyVersionVTO{
+		ID:          version.ID,
+		Type:        version.Type,
+		Version:     version.Version,
+		URL:         version.URL,
+		Mandatory:   version.Mandatory,
+		EffectiveAt: version.EffectiveAt,
+	}
+}
+
+// BuildPolicyVersionVTOs 批量转换协议版本
+func BuildPolicyVersionVTOs(versions []model.PolicyVersion) []*PolicyVersionVTO {
+	list := make([]*PolicyVersionVTO, len(versions))
+	for i := range versions {
+		list[i] = BuildPolicyVersionVTO(&versions[i])
+	}
+	return list
+}
+
+// BuildUserConsentVTO 将同意记录转换为 UserConsentVTO
+func BuildUserConsentVTO(consent *model.UserConsent, policyType, version, url string) *UserConsentVTO {
+	return &UserConsentVTO{
+		PolicyVersionID: consent.PolicyVersionID,
+		Type:            policyType,
+		Version:         version,
+		URL:             url,
+		AcceptedAt:      consent.AcceptedAt,
+		ClientIP:        consent.ClientIP,
+	}
+}
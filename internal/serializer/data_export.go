@@ -0,0 +1,38 @@
+package serializer
+
+import (
+	"go-one/internal/model"
+	"time"
+)
+
+// DataExportVTO 数据导出任务 VTO
+type DataExportVTO struct {
+	ID          uint       `json:"id"`
+	Status      string     `json:"status"` // pending、ready 或 failed
+	FileSize    int64      `json:"file_size,omitempty"`
+	CreatedAt   time.Time  `json:"created_at"`
+	CompletedAt *time.Time `json:"completed_at,omitempty"`
+	ExpiresAt   *time.Time `json:"expires_at,omitempty"`
+	DownloadURL string     `json:"download_url,omitempty"` // 已生成且未过期时提供
+}
+
+// AccountDeletionVTO 注销账号结果 VTO
+type AccountDeletionVTO struct {
+	PurgeAt time.Time `json:"purge_at"` // 账号记录彻底删除的时间
+}
+
+// BuildDataExportVTO 将 model.DataExport 转换为 DataExportVTO
+func BuildDataExportVTO(export *model.DataExport, downloadURL string) *DataExportVTO {
+	if export == nil {
+		return nil
+	}
+	return &DataExportVTO{
+		ID:          export.ID,
+		Status:      export.Status,
+		FileSize:    export.FileSize,
+		CreatedAt:   export.CreatedAt,
+		CompletedAt: export.CompletedAt,
+		ExpiresAt:   export.ExpiresAt,
+		DownloadURL: downloadURL,
+	}
+}
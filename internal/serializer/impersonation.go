@@ -0,0 +1,57 @@
+package serializer
+
+import (
+	"go-one/internal/model"
+	"time"
+)
+
+// ImpersonationVTO 模拟登录令牌 VTO（不含刷新令牌，到期需重新发起）
+type ImpersonationVTO struct {
+	User            *UserVTO `json:"user"`
+	AccessToken     string   `json:"access_token"`
+	TokenType       string   `json:"token_type"`
+	ExpiresIn       int64    `json:"expires_in"`
+	ImpersonationID string   `json:"impersonation_id"`
+}
+
+// AuditLogVTO 审计日志 VTO
+type AuditLogVTO struct {
+	ID              uint      `json:"id"`
+	ActorID         uint      `json:"actor_id"`
+	UserID          uint      `json:"user_id"`
+	ImpersonationID string    `json:"impersonation_id,omitempty"`
+	Action          string    `json:"action"`
+	Method          string    `json:"method,omitempty"`
+	Path            string    `json:"path,omitempty"`
+	Status          int       `json:"status,omitempty"`
+	Detail          string    `json:"detail,omitempty"`
+	ClientIP        string    `json:"client_ip"`
+	UserAgent       string    `json:"user_agent"`
+	CreatedAt       time.Time `json:"created_at"`
+}
+
+// AuditLogListVTO 审计日志列表 VTO
+type AuditLogListVTO struct {
+	List     []*AuditLogVTO `json:"list"`
+	Total    int64          `json:"total"`
+	Page     int            `json:"page"`
+	PageSize int            `json:"page_size"`
+}
+
+// BuildAuditLogVTO 将 model.AuditLog 转换为 AuditLogVTO
+func BuildAuditLogVTO(log *model.AuditLog) *AuditLogVTO {
+	return &AuditLogVTO{
+		ID:              log.ID,
+		ActorID:         log.ActorID,
+		UserID:          log.UserID,
+		ImpersonationID: log.ImpersonationID,
+		Action:          log.Action,
+		Method:          log.Method,
+		Path:            log.Path,
+		Status:          log.Status,
+		Detail:          log.Detail,
+		ClientIP:        log.ClientIP,
+		UserAgent:       log.UserAgent,
+		CreatedAt:       log.CreatedAt,
+	}
+}
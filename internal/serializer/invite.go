@@ -0,0 +1,59 @@
+package serializer
+
+import (
+	"go-one/internal/model"
+	"time"
+)
+
+// InviteVTO 注册邀请码 VTO（不含邀请码明文）
+type InviteVTO struct {
+	ID        uint       `json:"id"`
+	Prefix    string     `json:"prefix"`
+	Note      string     `json:"note"`
+	MaxUses   int        `json:"max_uses"`
+	UsedCount int        `json:"used_count"`
+	ExpiresAt *time.Time `json:"expires_at"`
+	CreatedBy uint       `json:"created_by"`
+	RevokedAt *time.Time `json:"revoked_at"`
+	CreatedAt time.Time  `json:"created_at"`
+}
+
+// CreatedInviteVTO 签发邀请码响应 VTO（Code 仅返回一次）
+type CreatedInviteVTO struct {
+	*InviteVTO
+	Code string `json:"code"`
+}
+
+// InviteListVTO 邀请码列表 VTO
+type InviteListVTO struct {
+	List     []*InviteVTO `json:"list"`
+	Total    int64        `json:"total"`
+	Page     int          `json:"page"`
+	PageSize int          `json:"page_size"`
+}
+
+// InviteRedemptionVTO 邀请码使用记录 VTO
+type InviteRedemptionVTO struct {
+	UserID     uint      `json:"user_id"`
+	Username   string    `json:"username"`
+	Email      string    `json:"email,omitempty"`
+	RedeemedAt time.Time `json:"redeemed_at"`
+}
+
+// BuildInviteVTO 将 model.Invite 转换为 InviteVTO
+func BuildInviteVTO(invite *model.Invite) *InviteVTO {
+	if invite == nil {
+		return nil
+	}
+	return &InviteVTO{
+		ID:        invite.ID,
+		Prefix:    invite.Prefix,
+		Note:      invite.Note,
+		MaxUses:   invite.MaxUses,
+		UsedCount: invite.UsedCount,
+		ExpiresAt: invite.ExpiresAt,
+		CreatedBy: invite.CreatedBy,
+		RevokedAt: invite.RevokedAt,
+		CreatedAt: invite.CreatedAt,
+	}
+}
@@ -0,0 +1,31 @@
+package serializer
+
+import "encoding/base64"
+
+// TOTPEnrollVTO TOTP 绑定信息 VTO
+type TOTPEnrollVTO struct {
+	Secret    string `json:"secret"`
+	URI       string `json:"otpauth_uri"`
+	QRCodePNG string `json:"qr_code"` // data:image/png;base64,...
+}
+
+// RecoveryCodesVTO 恢复码 VTO（仅在生成时返回一次）
+type RecoveryCodesVTO struct {
+	RecoveryCodes []string `json:"recovery_codes"`
+}
+
+// MFAChallengeVTO 登录需要二次验证时的响应 VTO
+type MFAChallengeVTO struct {
+	MFARequired bool   `json:"mfa_required"`
+	MFAToken    string `json:"mfa_token"`
+	ExpiresIn   int64  `json:"expires_in"`
+}
+
+// BuildTOTPEnrollVTO 构建 TOTP 绑定信息 VTO，二维码以 data URI 形式返回
+func BuildTOTPEnrollVTO(secret, uri string, qrCodePNG []byte) *TOTPEnrollVTO {
+	return &TOTPEnrollVTO{
+		Secret:    secret,
+		URI:       uri,
+		QRCodePNG: "data:image/png;base64," + base64.StdEncoding.EncodeToString(qrCodePNG),
+	}
+}
@@ -0,0 +1,12 @@
+package serializer
+
+// OAuthAuthorizeVTO 发起第三方登录响应 VTO
+type OAuthAuthorizeVTO struct {
+	AuthorizationURL string `json:"authorization_url"`
+	State            string `json:"state"`
+}
+
+// OAuthProvidersVTO 可用的第三方登录方式
+type OAuthProvidersVTO struct {
+	Providers []string `json:"providers"`
+}
@@ -0,0 +1,109 @@
+package serializer
+
+import (
+	"go-one/internal/model"
+	"time"
+)
+
+// OAuthClientVTO OAuth 应用 VTO（不含密钥）
+type OAuthClientVTO struct {
+	ID           uint       `json:"id"`
+	ClientID     string     `json:"client_id"`
+	Name         string     `json:"name"`
+	RedirectURIs []string   `json:"redirect_uris"`
+	Scopes       []string   `json:"scopes"`
+	GrantTypes   []string   `json:"grant_types"`
+	Public       bool       `json:"public"`
+	RevokedAt    *time.Time `json:"revoked_at"`
+	CreatedAt    time.Time  `json:"created_at"`
+}
+
+// CreatedOAuthClientVTO 注册 OAuth 应用响应 VTO（ClientSecret 仅返回一次）
+type CreatedOAuthClientVTO struct {
+	*OAuthClientVTO
+	ClientSecret string `json:"client_secret,omitempty"`
+}
+
+// BuildOAuthClientVTO 将 model.OAuthClient 转换为 OAuthClientVTO
+func BuildOAuthClientVTO(client *model.OAuthClient) *OAuthClientVTO {
+	if client == nil {
+		return nil
+	}
+	return &OAuthClientVTO{
+		ID:           client.ID,
+		ClientID:     client.ClientID,
+		Name:         client.Name,
+		RedirectURIs: splitComma(client.RedirectURIs),
+		Scopes:       splitComma(client.Scopes),
+		GrantTypes:   splitComma(client.GrantTypes),
+		Public:       client.Public,
+		RevokedAt:    client.RevokedAt,
+		CreatedAt:    client.CreatedAt,
+	}
+}
+
+// OAuthAuthorizationVTO 授权确认页信息
+type OAuthAuthorizationVTO struct {
+	ClientID        string   `json:"client_id"`
+	ClientName      string   `json:"client_name"`
+	Scopes          []string `json:"scopes"`
+	ConsentRequired bool     `json:"consent_required"`
+}
+
+// OAuthRedirectVTO 授权结果，前端跳转到 redirect_uri
+type OAuthRedirectVTO struct {
+	RedirectURI string `json:"redirect_uri"`
+}
+
+// OAuthConsentVTO 用户授权记录 VTO
+type OAuthConsentVTO struct {
+	ClientID  string    `json:"client_id"`
+	Scopes    []string  `json:"scopes"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// BuildOAuthConsentVTOs 转换用户授权记录列表
+func BuildOAuthConsentVTOs(consents []model.OAuthConsent) []*OAuthConsentVTO {
+	list := make([]*OAuthConsentVTO, len(consents))
+	for i, consent := range consents {
+		list[i] = &OAuthConsentVTO{
+			ClientID:  consent.ClientID,
+			Scopes:    splitComma(consent.Scopes),
+			UpdatedAt: consent.UpdatedAt,
+		}
+	}
+	return list
+}
+
+// OAuthTokenVTO 令牌端点响应（RFC 6749 5.1，不使用统一响应格式）
+type OAuthTokenVTO struct {
+	AccessToken  string `json:"access_token"`
+	TokenType    string `json:"token_type"`
+	ExpiresIn    int64  `json:"expires_in"`
+	RefreshToken string `json:"refresh_token,omitempty"`
+	Scope        string `json:"scope,omitempty"`
+}
+
+// OAuthErrorVTO OAuth 协议错误响应（RFC 6749 5.2）
+type OAuthErrorVTO struct {
+	Error            string `json:"error"`
+	ErrorDescription string `json:"error_description,omitempty"`
+}
+
+// OAuthIntrospectionVTO 令牌内省响应（RFC 7662 2.2）
+type OAuthIntrospectionVTO struct {
+	Active    bool                  `json:"active"`
+	Scope     string                `json:"scope,omitempty"`
+	ClientID  string                `json:"client_id,omitempty"`
+	Sub       string                `json:"sub,omitempty"`
+	TokenType string                `json:"token_type,omitempty"`
+	Exp       int64                 `json:"exp,omitempty"`
+	Iat       int64                 `json:"iat,omitempty"`
+	JTI       string                `json:"jti,omitempty"`
+	Cnf       *OAuthConfirmationVTO `json:"cnf,omitempty"`
+}
+
+// OAuthConfirmationVTO 令牌持有证明声明（DPoP 绑定的公钥指纹）
+type OAuthConfirmationVTO struct {
+	JKT string `json:"jkt"`
+}
@@ -0,0 +1,36 @@
+package serializer
+
+import "go-one/internal/model"
+
+// RoleVTO 角色 VTO
+type RoleVTO struct {
+	ID          uint     `json:"id"`
+	Name        string   `json:"name"`
+	Description string   `json:"description"`
+	Permissions []string `json:"permissions,omitempty"`
+}
+
+// BuildRoleVTO 将 model.Role 转换为 RoleVTO
+func BuildRoleVTO(role *model.Role) *RoleVTO {
+	if role == nil {
+		return nil
+	}
+	vto := &RoleVTO{
+		ID:          role.ID,
+		Name:        role.Name,
+		Description: role.Description,
+	}
+	for _, p := range role.Permissions {
+		vto.Permissions = append(vto.Permissions, p.Code)
+	}
+	return vto
+}
+
+// BuildRoleVTOs 批量转换角色
+func BuildRoleVTOs(roles []model.Role) []*RoleVTO {
+	list := make([]*RoleVTO, len(roles))
+	for i := range roles {
+		list[i] = BuildRoleVTO(&roles[i])
+	}
+	return list
+}
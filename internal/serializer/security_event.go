@@ -0,0 +1,53 @@
+package serializer
+
+import (
+	"encoding/json"
+	"go-one/internal/model"
+	"time"
+)
+
+// SecurityEventVTO 安全事件 VTO
+type SecurityEventVTO struct {
+	ID        uint            `json:"id"`
+	UserID    uint            `json:"user_id"`
+	Type      string          `json:"type"`
+	ClientIP  string          `json:"client_ip"`
+	UserAgent string          `json:"user_agent"`
+	NewDevice bool            `json:"new_device"` // 来自此前未出现过的设备或 IP 的登录
+	Detail    json.RawMessage `json:"detail,omitempty"`
+	CreatedAt time.Time       `json:"created_at"`
+}
+
+// SecurityEventListVTO 安全事件列表 VTO
+type SecurityEventListVTO struct {
+	List     []*SecurityEventVTO `json:"list"`
+	Total    int64               `json:"total"`
+	Page     int                 `json:"page"`
+	PageSize int                 `json:"page_size"`
+}
+
+// BuildSecurityEventVTO 将 model.SecurityEvent 转换为 SecurityEventVTO
+func BuildSecurityEventVTO(event *model.SecurityEvent) *SecurityEventVTO {
+	vto := &SecurityEventVTO{
+		ID:        event.ID,
+		UserID:    event.UserID,
+		Type:      event.Type,
+		ClientIP:  event.ClientIP,
+		UserAgent: event.UserAgent,
+		NewDevice: event.NewDevice,
+		CreatedAt: event.CreatedAt,
+	}
+	if event.Detail != "" && json.Valid([]byte(event.Detail)) {
+		vto.Detail = json.RawMessage(event.Detail)
+	}
+	return vto
+}
+
+// BuildSecurityEventVTOs 批量转换安全事件
+func BuildSecurityEventVTOs(events []model.SecurityEvent) []*SecurityEventVTO {
+	list := make([]*SecurityEventVTO, len(events))
+	for i := range events {
+		list[i] = BuildSecurityEventVTO(&events[i])
+	}
+	return list
+}
@@ -0,0 +1,35 @@
+package serializer
+
+import (
+	"go-one/internal/model"
+	"time"
+)
+
+// SessionVTO 登录会话 VTO
+type SessionVTO struct {
+	ID              string    `json:"id"`
+	DeviceName      string    `json:"device_name"`
+	ClientIP        string    `json:"client_ip"`
+	UserAgent       string    `json:"user_agent"`
+	Current         bool      `json:"current"`
+	AuthenticatedAt time.Time `json:"authenticated_at"`
+	LastUsedAt      time.Time `json:"last_used_at"`
+	ExpiresAt       time.Time `json:"expires_at"`
+}
+
+// BuildSessionVTO 将刷新令牌记录转换为 SessionVTO
+func BuildSessionVTO(token *model.RefreshToken, currentSessionID string) *SessionVTO {
+	if token == nil {
+		return nil
+	}
+	return &SessionVTO{
+		ID:              token.SessionID,
+		DeviceName:      token.DeviceName,
+		ClientIP:        token.ClientIP,
+		UserAgent:       token.UserAgent,
+		Current:         token.SessionID != "" && token.SessionID == currentSessionID,
+		AuthenticatedAt: token.AuthenticatedAt,
+		LastUsedAt:      token.LastUsedAt,
+		ExpiresAt:       token.ExpiresAt,
+	}
+}
@@ -7,27 +7,36 @@ import (
 
 // UserVTO 用户信息 VTO
 type UserVTO struct {
-	ID        uint      `json:"id"`
-	Username  string    `json:"username"`
-	Email     string    `json:"email,omitempty"`
-	Nickname  string    `json:"nickname"`
-	Avatar    string    `json:"avatar"`
-	Status    int       `json:"status"`
-	CreatedAt time.Time `json:"created_at"`
-	UpdatedAt time.Time `json:"updated_at"`
+	ID            uint      `json:"id"`
+	Username      string    `json:"username"`
+	Email         string    `json:"email,omitempty"`
+	EmailVerified bool      `json:"email_verified"`
+	Phone         string    `json:"phone,omitempty"`
+	PhoneVerified bool      `json:"phone_verified"`
+	Nickname      string    `json:"nickname"`
+	Avatar        string    `json:"avatar"`
+	Status        int       `json:"status"`
+	MFAEnabled    bool      `json:"mfa_enabled"`
+	CreatedAt     time.Time `json:"created_at"`
+	UpdatedAt     time.Time `json:"updated_at"`
 }
 
 // AuthTokenVTO 认证令牌响应 VTO（用于注册和登录）
+// Cookie 模式下 refresh token 只写入 HttpOnly Cookie，响应体改为携带 CSRF 令牌
 type AuthTokenVTO struct {
 	User         *UserVTO `json:"user"`
 	AccessToken  string   `json:"access_token"`
-	RefreshToken string   `json:"refresh_token"`
+	TokenType    string   `json:"token_type"` // Bearer 或 DPoP（令牌已绑定 DPoP 密钥）
+	RefreshToken string   `json:"refresh_token,omitempty"`
+	CSRFToken    string   `json:"csrf_token,omitempty"`
 }
 
 // TokenPairVTO 令牌对 VTO（用于刷新令牌）
 type TokenPairVTO struct {
 	AccessToken  string `json:"access_token"`
-	RefreshToken string `json:"refresh_token"`
+	TokenType    string `json:"token_type"`
+	RefreshToken string `json:"refresh_token,omitempty"`
+	CSRFToken    string `json:"csrf_token,omitempty"`
 }
 
 // UserListVTO 用户列表 VTO
@@ -38,20 +47,39 @@ type UserListVTO struct {
 	PageSize int        `json:"page_size"`
 }
 
-
 // BuildUserVTO 将 model.User 转换为 UserVTO
 func BuildUserVTO(user *model.User) *UserVTO {
 	if user == nil {
 		return nil
 	}
 	return &UserVTO{
-		ID:        user.ID,
-		Username:  user.Username,
-		Email:     user.Email,
-		Nickname:  user.Nickname,
-		Avatar:    user.Avatar,
-		Status:    user.Status,
-		CreatedAt: user.CreatedAt,
-		UpdatedAt: user.UpdatedAt,
+		ID:            user.ID,
+		Username:      user.Username,
+		Email:         user.Email,
+		EmailVerified: user.EmailVerified,
+		Phone:         user.Phone,
+		PhoneVerified: user.PhoneVerified,
+		Nickname:      user.Nickname,
+		Avatar:        user.Avatar,
+		Status:        user.Status,
+		MFAEnabled:    user.MFAEnabled,
+		CreatedAt:     user.CreatedAt,
+		UpdatedAt:     user.UpdatedAt,
 	}
 }
+
+// PasswordViolationVTO 密码策略违规项 VTO
+type PasswordViolationVTO struct {
+	Rule    string `json:"rule"`
+	Message string `json:"message"`
+}
+
+// PasswordPolicyErrorVTO 密码不符合策略时的错误详情
+type PasswordPolicyErrorVTO struct {
+	Violations []PasswordViolationVTO `json:"violations"`
+}
+
+// RegistrationErrorVTO 注册被注册策略拒绝时的错误详情
+type RegistrationErrorVTO struct {
+	Reason string `json:"reason"`
+}
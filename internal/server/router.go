@@ -3,6 +3,8 @@ package server
 import (
 	"go-one/internal/api"
 	"go-one/internal/middleware"
+	"go-one/internal/model"
+	"go-one/internal/service"
 	"time"
 
 	sentrygin "github.com/getsentry/sentry-go/gin"
@@ -17,6 +19,8 @@ func NewRouter() *gin.Engine {
 	// 应用中间件
 	r.Use(middleware.Cors())
 	r.Use(middleware.SecurityMiddleware())
+	// 解析请求所属租户（请求头、子域名，受保护接口以令牌为准）
+	r.Use(middleware.TenantMiddleware(h.ServiceManager()))
 
 	// Sentry middleware 捕获请求中的 panic 与错误
 	r.Use(sentrygin.New(sentrygin.Options{
@@ -25,6 +29,18 @@ func NewRouter() *gin.Engine {
 		Timeout:         2 * time.Second,
 	}))
 
+	// JWKS：供其他服务获取验签公钥
+	r.GET("/.well-known/jwks.json", api.JWKS)
+
+	// OAuth 授权服务：令牌、内省与吊销端点（应用凭证认证，表单参数）
+	oauthServer := r.Group("/oauth")
+	oauthServer.Use(middleware.RateLimitMiddleware(60, 1*time.Minute, "ip"), middleware.DPoPMiddleware())
+	{
+		oauthServer.POST("/token", h.OAuthToken)
+		oauthServer.POST("/introspect", h.OAuthIntrospect)
+		oauthServer.POST("/revoke", h.OAuthRevoke)
+	}
+
 	// API版本1
 	v1 := r.Group("/api/v1")
 
@@ -36,28 +52,146 @@ func NewRouter() *gin.Engine {
 		{
 			// 对登录和注册接口应用IP限流（1分钟内最多6次）
 			auth.Use(middleware.RateLimitMiddleware(6, 10*time.Second, "ip"))
+			// 携带 DPoP 证明时，签发的令牌绑定到证明的密钥
+			auth.Use(middleware.DPoPMiddleware())
 			auth.POST("/register", h.UserRegister)
 			auth.POST("/login", h.UserLogin)
-			auth.POST("/refresh", h.RefreshToken) // 刷新令牌
-			auth.POST("/logout", h.UserLogout)
+			// 刷新与登出可从 Cookie 读取 refresh token，需校验 CSRF 令牌
+			auth.POST("/refresh", middleware.CSRFMiddleware(), h.RefreshToken)
+			auth.POST("/logout", middleware.CSRFMiddleware(), h.UserLogout)
+			auth.POST("/mfa/verify", h.MFAVerify) // 登录二次验证
+			auth.POST("/verify-email", h.VerifyEmail)
+			// 重发验证邮件额外限流（5分钟内最多3次）
+			auth.POST("/resend-verification", middleware.RateLimitMiddleware(3, 100*time.Second, "ip"), h.ResendVerification)
+			// 找回密码
+			auth.POST("/password/forgot", middleware.RateLimitMiddleware(3, 100*time.Second, "ip"), h.ForgotPassword)
+			auth.POST("/password/reset", h.ResetPassword)
+			// 邮件链接免密登录
+			auth.POST("/magic-link", middleware.RateLimitMiddleware(3, 100*time.Second, "ip"), h.RequestMagicLink)
+			auth.POST("/magic-link/consume", h.ConsumeMagicLink)
+			// 短信验证码登录
+			auth.POST("/sms/send", middleware.RateLimitMiddleware(3, 100*time.Second, "ip"), h.SendSmsLoginCode)
+			auth.POST("/sms/login", h.SmsLogin)
+			// 第三方（OIDC）登录
+			auth.GET("/oauth/providers", h.ListOAuthProviders)
+			auth.POST("/oauth/:provider/authorize", h.OAuthAuthorize)
+			auth.POST("/oauth/:provider/callback", h.OAuthCallback)
 		}
 
+		// 数据导出归档下载（凭链接中的令牌）
+		public.GET("/exports/download", h.DownloadDataExport)
+
+		// 当前生效的协议版本（注册时须同意）
+		public.GET("/policies", h.ListCurrentPolicies)
+
 		// 健康检查
 		public.GET("/ping", api.Ping)
 	}
 
-	// 受保护的路由（需要JWT认证）
+	// 受保护的路由（需要JWT或API密钥认证）
 	protected := v1.Group("")
-	protected.Use(middleware.JWTMiddleware())
-	protected.Use(middleware.RateLimitMiddleware(60, 1*time.Minute, "user"))
+	protected.Use(middleware.JWTMiddleware(h.ServiceManager()))
+	protected.Use(middleware.RateLimitMiddleware(60, 1*time.Minute, "api_key"))
+	// 模拟登录期间的每个请求写入审计日志
+	protected.Use(middleware.ImpersonationAudit(h.ServiceManager()))
+	// 未同意最新的须重新同意协议版本时拒绝访问（查看与同意协议的接口除外）
+	protected.Use(middleware.RequireConsent(h.ServiceManager()))
 	{
 		// 用户相关
 		user := protected.Group("/user")
 		{
-			user.GET("/profile", h.GetUserProfile)
-			user.PUT("/profile", h.UpdateUserProfile)
-			user.POST("/change-password", h.ChangePassword)
-			user.GET("/list", h.ListUsers)
+			user.GET("/profile", middleware.RequireScope(service.ScopeProfileRead), h.GetUserProfile)
+			user.PUT("/profile", middleware.RequireScope(service.ScopeProfileWrite), h.UpdateUserProfile)
+			user.GET("/list", middleware.RequirePermission(model.PermissionUsersList), h.ListUsers)
+			user.GET("/consents", middleware.RequireScope(service.ScopeProfileRead), h.ListConsents)
+		}
+
+		// 账号安全相关（仅限交互式登录，不接受API密钥与模拟登录）
+		account := protected.Group("/user", middleware.DenyAPIKey(), middleware.DenyImpersonation())
+		{
+			account.POST("/change-password", h.ChangePassword)
+
+			// 会话管理
+			account.GET("/sessions", h.ListSessions)
+			account.DELETE("/sessions/:id", h.RevokeSession)
+			account.DELETE("/sessions", h.RevokeOtherSessions)
+
+			// 安全事件时间线（登录历史）
+			account.GET("/security-events", h.ListSecurityEvents)
+
+			// 二次验证
+			account.POST("/mfa/totp/enroll", h.EnrollTOTP)
+			account.POST("/mfa/totp/confirm", h.ConfirmTOTP)
+			account.POST("/mfa/totp/disable", h.DisableTOTP)
+			account.POST("/mfa/recovery-codes", h.RegenerateRecoveryCodes)
+
+			// 手机号绑定（需验证当前密码）
+			account.POST("/phone/code", middleware.RateLimitMiddleware(3, 100*time.Second, "ip"), h.SendPhoneBindCode)
+			account.POST("/phone", h.BindPhone)
+			account.DELETE("/phone", h.UnbindPhone)
+
+			// API 密钥
+			account.GET("/api-keys", h.ListAPIKeys)
+			account.POST("/api-keys", h.CreateAPIKey)
+			account.DELETE("/api-keys/:id", h.RevokeAPIKey)
+
+			// 已授权的 OAuth 应用
+			account.GET("/oauth/consents", h.ListOAuthConsents)
+			account.DELETE("/oauth/consents/:client_id", h.RevokeOAuthConsent)
+
+			// 服务条款与隐私政策
+			account.POST("/consents", h.AcceptConsents)
+
+			// 数据导出与注销账号
+			account.GET("/export", h.ListDataExports)
+			account.POST("/export", h.RequestDataExport)
+			account.DELETE("/account", h.DeleteAccount)
+		}
+
+		// OAuth 授权确认（仅限交互式登录，不接受模拟登录）
+		authorize := protected.Group("/oauth", middleware.DenyAPIKey(), middleware.DenyImpersonation())
+		{
+			authorize.GET("/authorize", h.GetOAuthAuthorization)
+			authorize.POST("/authorize", h.OAuthServerAuthorize)
+		}
+
+		// 管理接口（仅限交互式登录，不接受API密钥与模拟登录）
+		admin := protected.Group("/admin", middleware.DenyAPIKey(), middleware.DenyImpersonation())
+		{
+			admin.GET("/roles", middleware.RequirePermission(model.PermissionRolesManage), h.ListRoles)
+			admin.GET("/users/:id/roles", middleware.RequirePermission(model.PermissionRolesManage), h.GetUserRoles)
+			admin.POST("/users/:id/roles", middleware.RequirePermission(model.PermissionRolesManage), h.AssignUserRole)
+			admin.DELETE("/users/:id/roles/:role", middleware.RequirePermission(model.PermissionRolesManage), h.RevokeUserRole)
+			admin.POST("/users/:id/unlock", middleware.RequirePermission(model.PermissionUsersManage), h.UnlockUser)
+
+			// 用户管理
+			admin.GET("/users", middleware.RequirePermission(model.PermissionUsersList), h.SearchUsers)
+			admin.GET("/users/:id", middleware.RequirePermission(model.PermissionUsersList), h.GetUser)
+			admin.POST("/users/:id/suspend", middleware.RequirePermission(model.PermissionUsersManage), h.SuspendUser)
+			admin.POST("/users/:id/unsuspend", middleware.RequirePermission(model.PermissionUsersManage), h.UnsuspendUser)
+			admin.POST("/users/:id/ban", middleware.RequirePermission(model.PermissionUsersManage), h.BanUser)
+			admin.POST("/users/:id/logout", middleware.RequirePermission(model.PermissionUsersManage), h.ForceLogoutUser)
+			admin.DELETE("/users/:id", middleware.RequirePermission(model.PermissionUsersManage), h.DeleteUser)
+			admin.GET("/users/:id/security-events", middleware.RequirePermission(model.PermissionUsersManage), h.ListUserSecurityEvents)
+
+			// 模拟登录与审计日志
+			admin.POST("/users/:id/impersonate", middleware.RequirePermission(model.PermissionUsersImpersonate), h.ImpersonateUser)
+			admin.GET("/audit-logs", middleware.RequirePermission(model.PermissionUsersManage), h.SearchAuditLogs)
+
+			// 注册邀请码
+			admin.GET("/invites", middleware.RequirePermission(model.PermissionInvitesManage), h.ListInvites)
+			admin.POST("/invites", middleware.RequirePermission(model.PermissionInvitesManage), h.CreateInvite)
+			admin.DELETE("/invites/:id", middleware.RequirePermission(model.PermissionInvitesManage), h.RevokeInvite)
+			admin.GET("/invites/:id/redemptions", middleware.RequirePermission(model.PermissionInvitesManage), h.ListInviteRedemptions)
+
+			// 协议版本
+			admin.GET("/policy-versions", middleware.RequirePermission(model.PermissionPoliciesManage), h.ListPolicyVersions)
+			admin.POST("/policy-versions", middleware.RequirePermission(model.PermissionPoliciesManage), h.PublishPolicyVersion)
+
+			// OAuth 应用管理
+			admin.GET("/oauth/clients", middleware.RequirePermission(model.PermissionOAuthClients), h.ListOAuthClients)
+			admin.POST("/oauth/clients", middleware.RequirePermission(model.PermissionOAuthClients), h.CreateOAuthClient)
+			admin.DELETE("/oauth/clients/:id", middleware.RequirePermission(model.PermissionOAuthClients), h.RevokeOAuthClient)
 		}
 	}
 
@@ -0,0 +1,362 @@
+package service
+
+import (
+	"archive/zip"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"go-one/internal/cache"
+	"go-one/internal/model"
+	"go-one/internal/repository"
+	"go-one/util"
+	"os"
+	"path/filepath"
+	"strconv"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+const (
+	// dataExportStream 数据导出任务队列（Redis Stream），生产者与消费者同名
+	dataExportStream = "data_export"
+	// accountJobLockKey 定期清理任务的执行锁，多实例部署时同一周期只由一个实例执行
+	accountJobLockKey = "account_jobs:lock"
+	// accountJobBatchSize 每轮清理处理的最大记录数
+	accountJobBatchSize = 100
+	// dataExportListLimit 导出记录列表返回的最大数量
+	dataExportListLimit = 5
+)
+
+// AccountDataConfig 用户数据导出与账号注销配置
+type AccountDataConfig struct {
+	ExportDir      string        // 导出归档的存放目录
+	ExportExpire   time.Duration // 归档生成后下载链接的有效期，过期后删除归档
+	ExportCooldown time.Duration // 同一用户两次申请导出的最小间隔
+	DownloadURL    string        // 下载地址，令牌以 token 查询参数附加
+	DeletionGrace  time.Duration // 注销后保留匿名化账号记录的时间，到期后彻底删除
+	JobInterval    time.Duration // 清理过期归档与彻底删除到期账号的执行间隔
+}
+
+var AccountData *AccountDataConfig
+
+// InitAccountData 初始化用户数据导出与账号注销配置
+func InitAccountData() {
+	dir := os.Getenv("DATA_EXPORT_DIR")
+	if dir == "" {
+		dir = "./storage/exports"
+	}
+	downloadURL := os.Getenv("DATA_EXPORT_DOWNLOAD_URL")
+	if downloadURL == "" {
+		downloadURL = "http://localhost:8080/api/v1/exports/download"
+	}
+
+	AccountData = &AccountDataConfig{
+		ExportDir:      dir,
+		ExportExpire:   time.Duration(envInt64("DATA_EXPORT_EXPIRE", 86400)) * time.Second,
+		ExportCooldown: time.Duration(envInt64("DATA_EXPORT_COOLDOWN", 3600)) * time.Second,
+		DownloadURL:    downloadURL,
+		DeletionGrace:  time.Duration(envInt64("ACCOUNT_DELETION_GRACE_PERIOD", 30*86400)) * time.Second,
+		JobInterval:    time.Duration(envInt64("ACCOUNT_JOB_INTERVAL", 3600)) * time.Second,
+	}
+
+	util.Log().Info("数据导出与账号注销配置初始化完成，注销宽限期: %s", AccountData.DeletionGrace)
+}
+
+// AccountDataService 用户数据导出与账号注销服务
+type AccountDataService struct {
+	userRepo      repository.UserRepository
+	exportRepo    repository.DataExportRepository
+	users         *UserService
+	tokenVersions *TokenVersionService
+	mailer        Mailer
+	config        *AccountDataConfig
+}
+
+// NewAccountDataService 创建用户数据服务实例
+func NewAccountDataService(userRepo repository.UserRepository, exportRepo repository.DataExportRepository,
+	users *UserService, tokenVersions *TokenVersionService, mailer Mailer, config *AccountDataConfig) *AccountDataService {
+	return &AccountDataService{
+		userRepo:      userRepo,
+		exportRepo:    exportRepo,
+		users:         users,
+		tokenVersions: tokenVersions,
+		mailer:        mailer,
+		config:        config,
+	}
+}
+
+// DataExportStatus 导出任务及其下载地址（仅已生成且未过期时提供）
+type DataExportStatus struct {
+	Export      *model.DataExport
+	DownloadURL string
+}
+
+// RequestExport 申请导出当前用户的数据，归档由后台任务异步生成
+func (s *AccountDataService) RequestExport(ctx *BusinessContext) (*model.DataExport, ServiceError) {
+	if serviceErr := denyImpersonation(ctx, "导出用户数据"); serviceErr != nil {
+		return nil, serviceErr
+	}
+	userID, serviceErr := currentUserID(ctx)
+	if serviceErr != nil {
+		return nil, serviceErr
+	}
+
+	recent, err := s.exportRepo.ListByUser(userID, 1)
+	if err != nil {
+		return nil, &DatabaseError{Message: "查询导出记录失败", Err: err}
+	}
+	if len(recent) > 0 && recent[0].Status != model.DataExportFailed {
+		if wait := time.Until(recent[0].CreatedAt.Add(s.config.ExportCooldown)); wait > 0 {
+			return nil, &RateLimitError{Message: "数据导出申请过于频繁，请稍后再试", Code: 42901, RetryAfter: wait}
+		}
+	}
+
+	export := &model.DataExport{UserID: userID, Status: model.DataExportPending}
+	if err := s.exportRepo.Create(export); err != nil {
+		return nil, &DatabaseError{Message: "创建导出任务失败", Err: err}
+	}
+	fields := map[string]interface{}{"export_id": export.ID, "tenant_id": ctx.TenantID}
+	if err := cache.AddMessage(dataExportStream, context.Background(), fields); err != nil {
+		_ = s.exportRepo.Fail(export.ID, "加入导出队列失败")
+		return nil, &ExternalAPIError{Message: "提交导出任务失败", Err: err}
+	}
+
+	util.Log().Info("用户申请导出数据 user_id=%d export_id=%d", userID, export.ID)
+	return export, nil
+}
+
+// ListExports 获取当前用户最近的导出任务
+func (s *AccountDataService) ListExports(ctx *BusinessContext) ([]DataExportStatus, ServiceError) {
+	userID, serviceErr := currentUserID(ctx)
+	if serviceErr != nil {
+		return nil, serviceErr
+	}
+	user, err := s.userRepo.FindByID(userID)
+	if err != nil {
+		return nil, &NotFoundError{Message: "用户不存在"}
+	}
+	exports, err := s.exportRepo.ListByUser(userID, dataExportListLimit)
+	if err != nil {
+		return nil, &DatabaseError{Message: "查询导出记录失败", Err: err}
+	}
+
+	list := make([]DataExportStatus, len(exports))
+	for i := range exports {
+		list[i].Export = &exports[i]
+		if downloadable(&exports[i]) {
+			link, err := s.downloadLink(&exports[i], user.TokenVersion)
+			if err != nil {
+				return nil, &BusinessError{Message: "生成下载链接失败", Code: 50000, Err: err}
+			}
+			list[i].DownloadURL = link
+		}
+	}
+	return list, nil
+}
+
+// OpenExport 校验下载令牌，返回可下载的导出任务
+// 令牌绑定签发时的用户令牌版本，改密、注销等操作后旧链接失效
+func (s *AccountDataService) OpenExport(token string) (*model.DataExport, ServiceError) {
+	if token == "" {
+		return nil, &ValidationError{Message: "下载令牌不能为空", Code: 40000}
+	}
+	claims, err := ValidateToken(token, DataExportToken)
+	if err != nil {
+		return nil, &AuthError{Message: "下载链接无效或已过期"}
+	}
+	exportID, err := strconv.ParseUint(claims.JTI, 10, 64)
+	if err != nil || exportID == 0 {
+		return nil, &AuthError{Message: "下载链接无效或已过期"}
+	}
+
+	export, err := s.exportRepo.FindByID(uint(exportID))
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, &NotFoundError{Message: "导出文件不存在或已过期"}
+		}
+		return nil, &DatabaseError{Message: "查询导出记录失败", Err: err}
+	}
+	if export.TenantID != claims.Tenant() || strconv.FormatUint(uint64(export.UserID), 10) != claims.UserID {
+		return nil, &AuthError{Message: "下载链接无效或已过期"}
+	}
+	if !downloadable(export) {
+		return nil, &NotFoundError{Message: "导出文件不存在或已过期"}
+	}
+
+	user, err := s.userRepo.FindByID(export.UserID)
+	if err != nil || user.Status == model.UserStatusDeleted || user.TokenVersion != claims.TokenVersion {
+		return nil, &AuthError{Message: "下载链接已失效"}
+	}
+	if _, err := os.Stat(export.FilePath); err != nil {
+		return nil, &NotFoundError{Message: "导出文件不存在或已过期"}
+	}
+	return export, nil
+}
+
+// buildExport 生成导出归档（后台任务调用）：打包用户数据为 ZIP，并向已验证邮箱发送下载链接
+// 生成失败时标记任务失败，用户可重新申请
+func (s *AccountDataService) buildExport(exportID uint) {
+	export, err := s.exportRepo.FindByID(exportID)
+	if err != nil {
+		util.Log().Warning("导出任务不存在 export_id=%d: %v", exportID, err)
+		return
+	}
+	if export.Status != model.DataExportPending {
+		return
+	}
+
+	data, err := s.exportRepo.CollectUserData(export.UserID)
+	if err != nil {
+		s.failExport(export, "查询用户数据失败", err)
+		return
+	}
+	path, size, err := writeExportArchive(s.config.ExportDir, export, data)
+	if err != nil {
+		s.failExport(export, "写入导出归档失败", err)
+		return
+	}
+	expiresAt := time.Now().Add(s.config.ExportExpire)
+	if err := s.exportRepo.Complete(export.ID, path, size, expiresAt); err != nil {
+		_ = os.Remove(path)
+		s.failExport(export, "更新导出任务失败", err)
+		return
+	}
+	export.Status = model.DataExportReady
+	export.ExpiresAt = &expiresAt
+	util.Log().Info("数据导出完成 user_id=%d export_id=%d size=%d", export.UserID, export.ID, size)
+
+	user := data.Profile
+	if user.Email == "" || !user.EmailVerified {
+		return
+	}
+	link, err := s.downloadLink(export, user.TokenVersion)
+	if err != nil {
+		util.Log().Error("生成下载链接失败 export_id=%d: %v", export.ID, err)
+		return
+	}
+	msg := &MailMessage{
+		To:      user.Email,
+		Subject: "您的数据导出已完成",
+		Body: fmt.Sprintf("%s，您好：\n\n您申请导出的账号数据已生成，请点击以下链接下载（%d 小时内有效）：\n%s\n\n如果这不是您本人的操作，请立即修改密码。\n",
+			user.Nickname, int(s.config.ExportExpire.Hours()), link),
+	}
+	if err := s.mailer.Send(msg); err != nil {
+		util.Log().Error("发送数据导出邮件失败 export_id=%d: %v", export.ID, err)
+	}
+}
+
+// failExport 标记导出任务失败，原因只记录在服务端
+func (s *AccountDataService) failExport(export *model.DataExport, reason string, err error) {
+	util.Log().Error("数据导出失败 user_id=%d export_id=%d: %s: %v", export.UserID, export.ID, reason, err)
+	if err := s.exportRepo.Fail(export.ID, reason); err != nil {
+		util.Log().Error("更新导出任务失败 export_id=%d: %v", export.ID, err)
+	}
+}
+
+// removeExpiredExports 删除下载已过期的归档及长时间未生成的任务
+func (s *AccountDataService) removeExpiredExports() {
+	now := time.Now()
+	exports, err := s.exportRepo.ListExpired(now, now.Add(-s.config.ExportExpire), accountJobBatchSize)
+	if err != nil {
+		util.Log().Error("查询过期导出任务失败: %v", err)
+		return
+	}
+	for i := range exports {
+		removeExportFile(&exports[i])
+		if err := s.exportRepo.Delete(exports[i].ID); err != nil {
+			util.Log().Error("删除导出任务失败 export_id=%d: %v", exports[i].ID, err)
+		}
+	}
+	if len(exports) > 0 {
+		util.Log().Info("已清理过期导出任务 %d 个", len(exports))
+	}
+}
+
+// downloadLink 生成导出归档的下载链接，有效期至归档过期
+func (s *AccountDataService) downloadLink(export *model.DataExport, tokenVersion int) (string, error) {
+	token, err := GenerateToken(JWTClaims{
+		UserID:       strconv.FormatUint(uint64(export.UserID), 10),
+		TenantID:     export.TenantID,
+		JTI:          strconv.FormatUint(uint64(export.ID), 10),
+		TokenVersion: tokenVersion,
+	}, DataExportToken, time.Until(*export.ExpiresAt))
+	if err != nil {
+		return "", err
+	}
+	return linkWithToken(s.config.DownloadURL, token), nil
+}
+
+// downloadable 导出任务已生成且未过期
+func downloadable(export *model.DataExport) bool {
+	return export.Status == model.DataExportReady && export.ExpiresAt != nil && export.ExpiresAt.After(time.Now())
+}
+
+// removeExportFile 删除导出归档文件
+func removeExportFile(export *model.DataExport) {
+	if export.FilePath == "" {
+		return
+	}
+	if err := os.Remove(export.FilePath); err != nil && !os.IsNotExist(err) {
+		util.Log().Error("删除导出归档失败 %s: %v", export.FilePath, err)
+	}
+}
+
+// writeExportArchive 将用户数据按类别写入 ZIP 归档中的 JSON 文件，返回归档路径与大小
+func writeExportArchive(dir string, export *model.DataExport, data *repository.UserData) (path string, size int64, err error) {
+	if err := os.MkdirAll(dir, 0o700); err != nil {
+		return "", 0, err
+	}
+	path = filepath.Join(dir, fmt.Sprintf("%d_%d_%d.zip", export.TenantID, export.UserID, export.ID))
+	file, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0o600)
+	if err != nil {
+		return "", 0, err
+	}
+	defer func() {
+		if err != nil {
+			_ = os.Remove(path)
+		}
+	}()
+
+	entries := []struct {
+		name  string
+		value interface{}
+	}{
+		{"profile.json", data.Profile},
+		{"sessions.json", data.Sessions},
+		{"audit_logs.json", data.AuditLogs},
+		{"consents.json", data.Consents},
+		{"identities.json", data.Identities},
+		{"api_keys.json", data.APIKeys},
+		{"status_changes.json", data.StatusChanges},
+		{"security_events.json", data.SecurityEvents},
+	}
+	zw := zip.NewWriter(file)
+	for _, entry := range entries {
+		w, err := zw.Create(entry.name)
+		if err != nil {
+			_ = file.Close()
+			return "", 0, err
+		}
+		encoder := json.NewEncoder(w)
+		encoder.SetIndent("", "  ")
+		if err := encoder.Encode(entry.value); err != nil {
+			_ = file.Close()
+			return "", 0, err
+		}
+	}
+	if err := zw.Close(); err != nil {
+		_ = file.Close()
+		return "", 0, err
+	}
+	if err := file.Close(); err != nil {
+		return "", 0, err
+	}
+
+	info, err := os.Stat(path)
+	if err != nil {
+		return "", 0, err
+	}
+	return path, info.Size(), nil
+}
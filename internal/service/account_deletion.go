@@ -0,0 +1,101 @@
+package service
+
+import (
+	"fmt"
+	"go-one/internal/model"
+	"go-one/util"
+	"time"
+)
+
+// DeleteAccountDTO 注销账号请求DTO
+type DeleteAccountDTO struct {
+	Password string
+}
+
+// DeleteAccount 注销当前用户的账号（需验证当前密码）
+// 立即清除个人信息、删除导出归档并使全部令牌失效，匿名化的账号记录在宽限期后由后台任务彻底删除
+func (s *AccountDataService) DeleteAccount(ctx *BusinessContext, dto *DeleteAccountDTO) (*time.Time, ServiceError) {
+	if serviceErr := denyImpersonation(ctx, "注销用户账号"); serviceErr != nil {
+		return nil, serviceErr
+	}
+	user, serviceErr := s.users.reauthenticate(ctx, dto.Password)
+	if serviceErr != nil {
+		return nil, serviceErr
+	}
+
+	randomPassword, err := util.RandomToken(32)
+	if err != nil {
+		return nil, &BusinessError{Message: "注销账号失败", Code: 50000, Err: err}
+	}
+	hashedPassword, err := s.users.hasher.Hash(randomPassword)
+	if err != nil {
+		return nil, &BusinessError{Message: "注销账号失败", Code: 50000, Err: err}
+	}
+	suffix, err := util.RandomToken(4)
+	if err != nil {
+		return nil, &BusinessError{Message: "注销账号失败", Code: 50000, Err: err}
+	}
+
+	exports, err := s.exportRepo.DeleteByUser(user.ID)
+	if err != nil {
+		return nil, &DatabaseError{Message: "删除导出记录失败", Err: err}
+	}
+	for i := range exports {
+		removeExportFile(&exports[i])
+	}
+
+	purgeAt := time.Now().Add(s.config.DeletionGrace)
+	change := &model.UserStatusChange{
+		UserID:     user.ID,
+		FromStatus: user.Status,
+		ToStatus:   model.UserStatusDeleted,
+		Reason:     "用户注销账号",
+		OperatorID: user.ID,
+	}
+	anonymizeUser(user, fmt.Sprintf("deleted_%d_%s", user.ID, suffix), hashedPassword, purgeAt)
+	if err := s.userRepo.Anonymize(user, change); err != nil {
+		return nil, &DatabaseError{Message: "注销账号失败", Err: err}
+	}
+	// 鉴权中间件不检查账号状态，令牌未能失效时不能报告注销成功
+	if err := s.tokenVersions.InvalidateUserTokens(user.ID); err != nil {
+		return nil, &DatabaseError{Message: "注销已登录会话失败", Err: err}
+	}
+
+	util.Log().Info("用户注销账号 user_id=%d purge_at=%s", user.ID, purgeAt.Format(time.RFC3339))
+	return &purgeAt, nil
+}
+
+// purgeDeletedUsers 彻底删除宽限期已过的注销账号及其关联数据（后台任务调用）
+func (s *AccountDataService) purgeDeletedUsers() {
+	users, err := s.userRepo.ListPurgeable(time.Now(), accountJobBatchSize)
+	if err != nil {
+		util.Log().Error("查询待删除账号失败: %v", err)
+		return
+	}
+	for _, user := range users {
+		if err := s.userRepo.Delete(user.ID); err != nil {
+			util.Log().Error("彻底删除账号失败 user_id=%d: %v", user.ID, err)
+			continue
+		}
+		util.Log().Info("已彻底删除注销账号 user_id=%d tenant_id=%d", user.ID, user.TenantID)
+	}
+}
+
+// anonymizeUser 清除用户的个人信息并标记为已注销，用户名替换为占位值，密码替换为不可用的随机值
+func anonymizeUser(user *model.User, username, password string, purgeAt time.Time) {
+	user.Username = username
+	user.Email = ""
+	user.EmailVerified = false
+	user.EmailVerifiedAt = nil
+	user.Phone = ""
+	user.PhoneVerified = false
+	user.Password = password
+	user.Nickname = ""
+	user.Avatar = ""
+	user.Status = model.UserStatusDeleted
+	user.StatusReason = "用户注销账号"
+	user.SuspendedUntil = nil
+	user.MFAEnabled = false
+	user.TOTPSecret = ""
+	user.PurgeAt = &purgeAt
+}
@@ -0,0 +1,82 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"go-one/internal/cache"
+	"go-one/util"
+	"strconv"
+	"time"
+)
+
+// stopAccountJobs 停止定期清理任务
+var stopAccountJobs context.CancelFunc
+
+// StartAccountJobs 启动用户数据相关的后台任务：消费数据导出队列，并定期清理过期归档、彻底删除到期的注销账号
+// sm 应为不限定租户的服务管理器，导出任务按消息中的租户处理
+func StartAccountJobs(sm *ServiceManager) error {
+	if err := cache.CreateSimpleStreamProducer(dataExportStream, dataExportStream); err != nil {
+		return err
+	}
+	if err := cache.InitConsumerWithHandler(dataExportStream, &dataExportHandler{sm: sm}, dataExportStream); err != nil {
+		return err
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	stopAccountJobs = cancel
+	go runAccountJobs(ctx, sm.NewAccountDataService())
+	return nil
+}
+
+// StopAccountJobs 停止定期清理任务（导出队列的消费者随 cache.ShutdownStreams 停止）
+func StopAccountJobs() {
+	if stopAccountJobs != nil {
+		stopAccountJobs()
+	}
+}
+
+// dataExportHandler 数据导出队列的消息处理器
+type dataExportHandler struct {
+	sm *ServiceManager
+}
+
+// HandleMessage 生成消息指定的导出归档；处理结果记录在导出任务中，消息总是确认
+func (h *dataExportHandler) HandleMessage(ctx context.Context, msg cache.XMessage) error {
+	exportID, err := strconv.ParseUint(fmt.Sprint(msg.Values["export_id"]), 10, 64)
+	if err != nil || exportID == 0 {
+		util.Log().Warning("无效的导出任务消息 id=%s values=%v", msg.ID, msg.Values)
+		return nil
+	}
+	tenantID, err := strconv.ParseUint(fmt.Sprint(msg.Values["tenant_id"]), 10, 64)
+	if err != nil {
+		util.Log().Warning("无效的导出任务消息 id=%s values=%v", msg.ID, msg.Values)
+		return nil
+	}
+	h.sm.ForTenant(uint(tenantID)).NewAccountDataService().buildExport(uint(exportID))
+	return nil
+}
+
+// runAccountJobs 按 AccountData.JobInterval 周期执行清理任务，直到 ctx 取消
+func runAccountJobs(ctx context.Context, s *AccountDataService) {
+	ticker := time.NewTicker(s.config.JobInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		// 多实例部署时同一周期只由一个实例执行
+		acquired, err := cache.RedisClient.SetNX(ctx, accountJobLockKey, 1, s.config.JobInterval/2).Result()
+		if err != nil {
+			util.Log().Warning("获取清理任务锁失败: %v", err)
+			continue
+		}
+		if !acquired {
+			continue
+		}
+		s.removeExpiredExports()
+		s.purgeDeletedUsers()
+	}
+}
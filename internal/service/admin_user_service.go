@@ -0,0 +1,197 @@
+package service
+
+import (
+	"go-one/internal/model"
+	"go-one/internal/repository"
+	"go-one/util"
+	"strings"
+	"time"
+)
+
+// AdminUserService 用户管理服务（管理员操作）
+type AdminUserService struct {
+	userRepo      repository.UserRepository
+	tokenVersions *TokenVersionService
+}
+
+// NewAdminUserService 创建用户管理服务实例
+func NewAdminUserService(userRepo repository.UserRepository, tokenVersions *TokenVersionService) *AdminUserService {
+	return &AdminUserService{
+		userRepo:      userRepo,
+		tokenVersions: tokenVersions,
+	}
+}
+
+// SearchUsersQuery 用户搜索参数
+type SearchUsersQuery struct {
+	Keyword  string
+	Status   *int
+	Page     int
+	PageSize int
+}
+
+// SearchUsers 按关键字与状态搜索用户
+func (s *AdminUserService) SearchUsers(ctx *BusinessContext, query *SearchUsersQuery) (*ListUsersResult, ServiceError) {
+	if serviceErr := requirePermission(ctx, model.PermissionUsersList); serviceErr != nil {
+		return nil, serviceErr
+	}
+	if query.Page < 1 {
+		query.Page = 1
+	}
+	if query.PageSize < 1 || query.PageSize > 100 {
+		query.PageSize = 20
+	}
+
+	users, total, err := s.userRepo.Search(repository.UserSearch{
+		Keyword:  strings.TrimSpace(query.Keyword),
+		Status:   query.Status,
+		Page:     query.Page,
+		PageSize: query.PageSize,
+	})
+	if err != nil {
+		return nil, &DatabaseError{Message: "查询用户列表失败", Err: err}
+	}
+
+	return &ListUsersResult{
+		List:     users,
+		Total:    total,
+		Page:     query.Page,
+		PageSize: query.PageSize,
+	}, nil
+}
+
+// UserDetail 用户详情（含状态变更记录）
+type UserDetail struct {
+	User          *model.User
+	StatusChanges []model.UserStatusChange
+}
+
+// GetUser 获取用户详情
+func (s *AdminUserService) GetUser(ctx *BusinessContext, userID uint) (*UserDetail, ServiceError) {
+	if serviceErr := requirePermission(ctx, model.PermissionUsersList); serviceErr != nil {
+		return nil, serviceErr
+	}
+	user, err := s.userRepo.FindByID(userID)
+	if err != nil {
+		return nil, &NotFoundError{Message: "用户不存在"}
+	}
+	changes, err := s.userRepo.ListStatusChanges(userID)
+	if err != nil {
+		return nil, &DatabaseError{Message: "查询状态变更记录失败", Err: err}
+	}
+	return &UserDetail{User: user, StatusChanges: changes}, nil
+}
+
+// UserStatusDTO 状态变更请求DTO
+type UserStatusDTO struct {
+	Reason string
+	Until  *time.Time // 仅暂停时有效，为空表示无限期
+}
+
+// SuspendUser 暂停用户，并使其所有已签发的令牌立即失效
+func (s *AdminUserService) SuspendUser(ctx *BusinessContext, userID uint, dto *UserStatusDTO) ServiceError {
+	if dto.Until != nil && !dto.Until.After(time.Now()) {
+		return &ValidationError{Message: "暂停截止时间必须晚于当前时间", Code: 40000}
+	}
+	return s.changeStatus(ctx, userID, model.UserStatusSuspended, dto, model.UserStatusActive, model.UserStatusPending, model.UserStatusSuspended)
+}
+
+// UnsuspendUser 解除暂停
+func (s *AdminUserService) UnsuspendUser(ctx *BusinessContext, userID uint, dto *UserStatusDTO) ServiceError {
+	return s.changeStatus(ctx, userID, model.UserStatusActive, &UserStatusDTO{Reason: dto.Reason}, model.UserStatusSuspended)
+}
+
+// BanUser 封禁用户
+func (s *AdminUserService) BanUser(ctx *BusinessContext, userID uint, dto *UserStatusDTO) ServiceError {
+	return s.changeStatus(ctx, userID, model.UserStatusBanned, &UserStatusDTO{Reason: dto.Reason},
+		model.UserStatusActive, model.UserStatusPending, model.UserStatusSuspended)
+}
+
+// DeleteUser 删除用户（标记为已删除，保留记录用于审计）
+func (s *AdminUserService) DeleteUser(ctx *BusinessContext, userID uint, dto *UserStatusDTO) ServiceError {
+	return s.changeStatus(ctx, userID, model.UserStatusDeleted, &UserStatusDTO{Reason: dto.Reason},
+		model.UserStatusActive, model.UserStatusPending, model.UserStatusSuspended, model.UserStatusBanned)
+}
+
+// ForceLogout 强制用户下线：撤销全部会话并使已签发的令牌失效
+func (s *AdminUserService) ForceLogout(ctx *BusinessContext, userID uint) ServiceError {
+	if serviceErr := requirePermission(ctx, model.PermissionUsersManage); serviceErr != nil {
+		return serviceErr
+	}
+	if _, err := s.userRepo.FindByID(userID); err != nil {
+		return &NotFoundError{Message: "用户不存在"}
+	}
+	if err := s.tokenVersions.InvalidateUserTokens(userID); err != nil {
+		return &DatabaseError{Message: "注销已登录会话失败", Err: err}
+	}
+
+	util.Log().Info("管理员强制用户下线 user_id=%d operator=%s", userID, ctx.UserUUID)
+	return nil
+}
+
+// changeStatus 校验权限与状态流转后变更用户状态，非正常状态会使已签发令牌失效
+func (s *AdminUserService) changeStatus(ctx *BusinessContext, userID uint, to int, dto *UserStatusDTO, allowedFrom ...int) ServiceError {
+	if serviceErr := requirePermission(ctx, model.PermissionUsersManage); serviceErr != nil {
+		return serviceErr
+	}
+	operatorID, serviceErr := currentUserID(ctx)
+	if serviceErr != nil {
+		return serviceErr
+	}
+	if operatorID == userID {
+		return &BusinessError{Message: "不能变更自己的账号状态", Code: 40003}
+	}
+	dto.Reason = strings.TrimSpace(dto.Reason)
+	if to != model.UserStatusActive && dto.Reason == "" {
+		return &ValidationError{Message: "请填写原因", Code: 40000}
+	}
+
+	user, err := s.userRepo.FindByID(userID)
+	if err != nil {
+		return &NotFoundError{Message: "用户不存在"}
+	}
+	allowed := false
+	for _, from := range allowedFrom {
+		if user.Status == from {
+			allowed = true
+			break
+		}
+	}
+	if !allowed {
+		return &BusinessError{Message: "当前账号状态不允许该操作", Code: 40009}
+	}
+
+	if serviceErr := transitionUserStatus(s.userRepo, user, to, dto.Reason, dto.Until, operatorID); serviceErr != nil {
+		return serviceErr
+	}
+	if to != model.UserStatusActive {
+		if err := s.tokenVersions.InvalidateUserTokens(user.ID); err != nil {
+			return &DatabaseError{Message: "注销已登录会话失败", Err: err}
+		}
+	}
+
+	util.Log().Info("用户状态变更 user_id=%d to=%d operator=%d reason=%q", user.ID, to, operatorID, dto.Reason)
+	return nil
+}
+
+// transitionUserStatus 变更用户状态并记录变更（operatorID 为 0 表示系统操作）
+func transitionUserStatus(repo repository.UserRepository, user *model.User, to int, reason string, until *time.Time, operatorID uint) ServiceError {
+	change := &model.UserStatusChange{
+		UserID:     user.ID,
+		FromStatus: user.Status,
+		ToStatus:   to,
+		Reason:     reason,
+		ExpiresAt:  until,
+		OperatorID: operatorID,
+	}
+	user.Status = to
+	user.StatusReason = reason
+	user.SuspendedUntil = nil
+	if to == model.UserStatusSuspended {
+		user.SuspendedUntil = until
+	}
+	if err := repo.ChangeStatus(user, change); err != nil {
+		return &DatabaseError{Message: "更新用户状态失败", Err: err}
+	}
+	return nil
+}
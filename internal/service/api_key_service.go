@@ -0,0 +1,282 @@
+package service
+
+import (
+	"crypto/rand"
+	"crypto/subtle"
+	"errors"
+	"go-one/internal/model"
+	"go-one/internal/repository"
+	"go-one/util"
+	"net"
+	"strconv"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+const (
+	// apiKeyPrefix API 密钥固定前缀，便于密钥扫描工具识别
+	apiKeyPrefix = "go1"
+	// apiKeyMaxPerUser 每个用户可持有的 API 密钥上限
+	apiKeyMaxPerUser = 20
+	// apiKeyTouchInterval 最近使用时间的最小更新间隔，避免每次请求写库
+	apiKeyTouchInterval = time.Minute
+
+	// ScopeProfileRead 读取个人资料
+	ScopeProfileRead = "profile:read"
+	// ScopeProfileWrite 修改个人资料
+	ScopeProfileWrite = "profile:write"
+)
+
+// APIKeyService API 密钥服务
+type APIKeyService struct {
+	userRepo   repository.UserRepository
+	apiKeyRepo repository.APIKeyRepository
+	rbac       *RBACService
+}
+
+// NewAPIKeyService 创建 API 密钥服务实例
+func NewAPIKeyService(userRepo repository.UserRepository, apiKeyRepo repository.APIKeyRepository, rbac *RBACService) *APIKeyService {
+	return &APIKeyService{
+		userRepo:   userRepo,
+		apiKeyRepo: apiKeyRepo,
+		rbac:       rbac,
+	}
+}
+
+// CreateAPIKeyDTO 创建 API 密钥请求DTO
+type CreateAPIKeyDTO struct {
+	Name       string
+	Scopes     []string
+	AllowedIPs []string
+	ExpiresAt  *time.Time
+}
+
+// CreateAPIKeyResult 创建结果，Key 为完整密钥，仅在创建时返回一次
+type CreateAPIKeyResult struct {
+	APIKey *model.APIKey
+	Key    string
+}
+
+// CreateAPIKey 为当前用户创建 API 密钥
+// 作用域只能是个人资料作用域或当前用户已拥有的权限
+func (s *APIKeyService) CreateAPIKey(ctx *BusinessContext, dto *CreateAPIKeyDTO) (*CreateAPIKeyResult, ServiceError) {
+	userID, serviceErr := currentUserID(ctx)
+	if serviceErr != nil {
+		return nil, serviceErr
+	}
+
+	dto.Name = strings.TrimSpace(dto.Name)
+	if dto.Name == "" {
+		return nil, &ValidationError{Message: "名称不能为空", Code: 40000}
+	}
+	if len(dto.Scopes) == 0 {
+		return nil, &ValidationError{Message: "至少需要一个作用域", Code: 40000}
+	}
+	scopes := make([]string, 0, len(dto.Scopes))
+	for _, scope := range dto.Scopes {
+		scope = strings.TrimSpace(scope)
+		if scope != ScopeProfileRead && scope != ScopeProfileWrite && !ctx.HasPermission(scope) {
+			return nil, &ValidationError{Message: "无效的作用域: " + scope, Code: 40000}
+		}
+		scopes = append(scopes, scope)
+	}
+	for _, ip := range dto.AllowedIPs {
+		if parseIPRule(ip) == nil {
+			return nil, &ValidationError{Message: "无效的 IP 或 CIDR: " + ip, Code: 40000}
+		}
+	}
+	if dto.ExpiresAt != nil && !dto.ExpiresAt.After(time.Now()) {
+		return nil, &ValidationError{Message: "过期时间必须晚于当前时间", Code: 40000}
+	}
+
+	count, err := s.apiKeyRepo.CountActiveByUser(userID)
+	if err != nil {
+		return nil, &DatabaseError{Message: "查询 API 密钥失败", Err: err}
+	}
+	if count >= apiKeyMaxPerUser {
+		return nil, &BusinessError{Message: "API 密钥数量已达上限", Code: 40009}
+	}
+
+	prefix, err := randomKeyPrefix()
+	if err != nil {
+		return nil, &BusinessError{Message: "生成 API 密钥失败", Code: 50000, Err: err}
+	}
+	secret, err := util.RandomToken(32)
+	if err != nil {
+		return nil, &BusinessError{Message: "生成 API 密钥失败", Code: 50000, Err: err}
+	}
+
+	key := &model.APIKey{
+		UserID:     userID,
+		Name:       dto.Name,
+		Prefix:     prefix,
+		SecretHash: util.SHA256Hex(secret),
+		Scopes:     strings.Join(scopes, ","),
+		AllowedIPs: strings.Join(dto.AllowedIPs, ","),
+		ExpiresAt:  dto.ExpiresAt,
+	}
+	if err := s.apiKeyRepo.Create(key); err != nil {
+		return nil, &DatabaseError{Message: "保存 API 密钥失败", Err: err}
+	}
+
+	util.Log().Info("创建 API 密钥 user_id=%d key_id=%d prefix=%s", userID, key.ID, prefix)
+	return &CreateAPIKeyResult{
+		APIKey: key,
+		Key:    apiKeyPrefix + "_" + prefix + "_" + secret,
+	}, nil
+}
+
+// ListAPIKeys 获取当前用户的 API 密钥
+func (s *APIKeyService) ListAPIKeys(ctx *BusinessContext) ([]model.APIKey, ServiceError) {
+	userID, serviceErr := currentUserID(ctx)
+	if serviceErr != nil {
+		return nil, serviceErr
+	}
+	keys, err := s.apiKeyRepo.ListActiveByUser(userID)
+	if err != nil {
+		return nil, &DatabaseError{Message: "查询 API 密钥失败", Err: err}
+	}
+	return keys, nil
+}
+
+// RevokeAPIKey 撤销当前用户的 API 密钥
+func (s *APIKeyService) RevokeAPIKey(ctx *BusinessContext, id uint) ServiceError {
+	userID, serviceErr := currentUserID(ctx)
+	if serviceErr != nil {
+		return serviceErr
+	}
+	affected, err := s.apiKeyRepo.Revoke(userID, id)
+	if err != nil {
+		return &DatabaseError{Message: "撤销 API 密钥失败", Err: err}
+	}
+	if affected == 0 {
+		return &NotFoundError{Message: "API 密钥不存在或已撤销"}
+	}
+	util.Log().Info("撤销 API 密钥 user_id=%d key_id=%d", userID, id)
+	return nil
+}
+
+// Authenticate 校验 API 密钥，返回填充好身份与授权信息的 BusinessContext
+// API 密钥的有效权限为用户当前权限与密钥作用域的交集
+func (s *APIKeyService) Authenticate(bizCtx *BusinessContext, rawKey string) ServiceError {
+	parts := strings.SplitN(rawKey, "_", 3)
+	if len(parts) != 3 || parts[0] != apiKeyPrefix || parts[1] == "" || parts[2] == "" {
+		return &AuthError{Message: "API 密钥格式错误"}
+	}
+
+	key, err := s.apiKeyRepo.FindByPrefix(parts[1])
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return &AuthError{Message: "API 密钥无效"}
+		}
+		return &DatabaseError{Message: "查询 API 密钥失败", Err: err}
+	}
+	if subtle.ConstantTimeCompare([]byte(util.SHA256Hex(parts[2])), []byte(key.SecretHash)) != 1 {
+		return &AuthError{Message: "API 密钥无效"}
+	}
+	now := time.Now()
+	if key.RevokedAt != nil {
+		return &AuthError{Message: "API 密钥已撤销"}
+	}
+	if key.ExpiresAt != nil && now.After(*key.ExpiresAt) {
+		return &AuthError{Message: "API 密钥已过期"}
+	}
+	if !ipAllowed(key.AllowedIPs, bizCtx.ClientIP) {
+		return &BusinessError{Message: "当前 IP 不允许使用该 API 密钥", Code: 40003}
+	}
+
+	user, err := s.userRepo.FindByID(key.UserID)
+	if err != nil {
+		return &AuthError{Message: "API 密钥无效"}
+	}
+	if serviceErr := checkUserStatus(user); serviceErr != nil {
+		return serviceErr
+	}
+
+	access, err := s.rbac.Access(user.ID)
+	if err != nil {
+		return &DatabaseError{Message: "获取用户权限失败", Err: err}
+	}
+	scopes := splitList(key.Scopes)
+	permissions := make([]string, 0, len(access.Permissions))
+	for _, p := range access.Permissions {
+		if util.ContainsString(scopes, p) {
+			permissions = append(permissions, p)
+		}
+	}
+
+	if key.LastUsedAt == nil || now.Sub(*key.LastUsedAt) > apiKeyTouchInterval {
+		if err := s.apiKeyRepo.TouchLastUsed(key.ID, bizCtx.ClientIP); err != nil {
+			util.Log().Warning("更新 API 密钥使用时间失败 key_id=%d: %v", key.ID, err)
+		}
+	}
+
+	bizCtx.WithClaims(&JWTClaims{
+		UserID:    strconv.FormatUint(uint64(user.ID), 10),
+		TenantID:  user.TenantID,
+		TokenType: APIKeyCredential,
+	}).WithTenant(user.TenantID).WithAPIKey(key.ID, scopes).WithAccess(access.Roles, permissions)
+	return nil
+}
+
+// randomKeyPrefix 生成 8 位小写字母数字前缀（不含下划线，便于拆分）
+func randomKeyPrefix() (string, error) {
+	const alphabet = "abcdefghijklmnopqrstuvwxyz0123456789"
+	buf := make([]byte, 8)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+	for i, b := range buf {
+		buf[i] = alphabet[int(b)%len(alphabet)]
+	}
+	return string(buf), nil
+}
+
+// parseIPRule 解析 IP 或 CIDR，单个 IP 视为 /32 或 /128
+func parseIPRule(rule string) *net.IPNet {
+	rule = strings.TrimSpace(rule)
+	if _, ipNet, err := net.ParseCIDR(rule); err == nil {
+		return ipNet
+	}
+	ip := net.ParseIP(rule)
+	if ip == nil {
+		return nil
+	}
+	bits := 128
+	if ip.To4() != nil {
+		ip = ip.To4()
+		bits = 32
+	}
+	return &net.IPNet{IP: ip, Mask: net.CIDRMask(bits, bits)}
+}
+
+// ipAllowed 检查客户端 IP 是否在白名单内，白名单为空时不限制
+func ipAllowed(allowList, clientIP string) bool {
+	rules := splitList(allowList)
+	if len(rules) == 0 {
+		return true
+	}
+	ip := net.ParseIP(clientIP)
+	if ip == nil {
+		return false
+	}
+	for _, rule := range rules {
+		if ipNet := parseIPRule(rule); ipNet != nil && ipNet.Contains(ip) {
+			return true
+		}
+	}
+	return false
+}
+
+// splitList 拆分逗号分隔的列表并去除空白项
+func splitList(s string) []string {
+	var items []string
+	for _, item := range strings.Split(s, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
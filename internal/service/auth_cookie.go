@@ -0,0 +1,47 @@
+package service
+
+import (
+	"go-one/util"
+	"net/http"
+	"os"
+	"strings"
+)
+
+// AuthCookieConfig Cookie 认证模式配置
+// 客户端通过 X-Auth-Mode: cookie 选择该模式：refresh token 写入 HttpOnly Cookie（仅在 /api/v1/auth 下发送），
+// 刷新与登出接口从 Cookie 读取并以双重提交 CSRF 令牌防护
+type AuthCookieConfig struct {
+	RefreshCookieName string
+	CSRFCookieName    string
+	CSRFHeaderName    string
+	Path              string // refresh token Cookie 路径
+	Domain            string
+	Secure            bool
+	SameSite          http.SameSite
+}
+
+var AuthCookie *AuthCookieConfig
+
+// InitAuthCookie 初始化 Cookie 认证模式配置
+func InitAuthCookie() {
+	sameSite := http.SameSiteLaxMode
+	switch strings.ToLower(os.Getenv("AUTH_COOKIE_SAMESITE")) {
+	case "strict":
+		sameSite = http.SameSiteStrictMode
+	case "none":
+		// 跨站 SPA 需要 SameSite=None，且浏览器要求同时设置 Secure
+		sameSite = http.SameSiteNoneMode
+	}
+
+	AuthCookie = &AuthCookieConfig{
+		RefreshCookieName: "refresh_token",
+		CSRFCookieName:    "csrf_token",
+		CSRFHeaderName:    "X-CSRF-Token",
+		Path:              "/api/v1/auth",
+		Domain:            os.Getenv("AUTH_COOKIE_DOMAIN"),
+		Secure:            os.Getenv("AUTH_COOKIE_SECURE") != "false" || sameSite == http.SameSiteNoneMode,
+		SameSite:          sameSite,
+	}
+
+	util.Log().Info("Cookie 认证配置初始化完成")
+}
@@ -0,0 +1,319 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"go-one/internal/cache"
+	"go-one/internal/model"
+	"go-one/internal/repository"
+	"go-one/util"
+	"strconv"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+const (
+	// consentRequiredKey 每种协议须同意的最新版本缓存
+	consentRequiredKey = "consent:required"
+	// consentRequiredTTL 须同意版本的缓存时间（新版本生效后最长在该时间后要求重新同意）
+	consentRequiredTTL = time.Minute
+	// consentOKKey 用户已同意全部须同意版本的标记（用户ID:版本ID列表），版本变化后自然失效
+	consentOKKey = "consent:ok:%d:%s"
+	// consentOKTTL 已同意标记的缓存时间
+	consentOKTTL = 10 * time.Minute
+)
+
+// ConsentService 协议版本与用户同意记录服务
+type ConsentService struct {
+	consentRepo repository.ConsentRepository
+}
+
+// NewConsentService 创建协议同意服务实例
+func NewConsentService(consentRepo repository.ConsentRepository) *ConsentService {
+	return &ConsentService{
+		consentRepo: consentRepo,
+	}
+}
+
+// CurrentVersions 获取每种协议当前生效的版本
+func (s *ConsentService) CurrentVersions() ([]model.PolicyVersion, ServiceError) {
+	versions, err := s.consentRepo.ListCurrentVersions(time.Now(), false)
+	if err != nil {
+		return nil, &DatabaseError{Message: "查询协议版本失败", Err: err}
+	}
+	return versions, nil
+}
+
+// PublishPolicyDTO 发布协议版本请求DTO
+type PublishPolicyDTO struct {
+	Type        string
+	Version     string
+	URL         string
+	Mandatory   bool
+	EffectiveAt *time.Time // 为空表示立即生效
+}
+
+// PublishVersion 发布协议版本，生效后成为该类型的当前版本
+// Mandatory 为 true 时已有用户须重新同意才能继续使用
+func (s *ConsentService) PublishVersion(ctx *BusinessContext, dto *PublishPolicyDTO) (*model.PolicyVersion, ServiceError) {
+	if serviceErr := requirePermission(ctx, model.PermissionPoliciesManage); serviceErr != nil {
+		return nil, serviceErr
+	}
+	userID, serviceErr := currentUserID(ctx)
+	if serviceErr != nil {
+		return nil, serviceErr
+	}
+
+	dto.Type = strings.TrimSpace(dto.Type)
+	dto.Version = strings.TrimSpace(dto.Version)
+	if dto.Type != model.PolicyTypeTerms && dto.Type != model.PolicyTypePrivacy {
+		return nil, &ValidationError{Message: "无效的协议类型: " + dto.Type, Code: 40000}
+	}
+	if dto.Version == "" {
+		return nil, &ValidationError{Message: "版本号不能为空", Code: 40000}
+	}
+	if _, err := s.consentRepo.FindVersion(dto.Type, dto.Version); err == nil {
+		return nil, &BusinessError{Message: "该协议版本已存在", Code: 40009}
+	} else if !errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, &DatabaseError{Message: "查询协议版本失败", Err: err}
+	}
+
+	version := &model.PolicyVersion{
+		Type:        dto.Type,
+		Version:     dto.Version,
+		URL:         strings.TrimSpace(dto.URL),
+		Mandatory:   dto.Mandatory,
+		EffectiveAt: time.Now(),
+		CreatedBy:   userID,
+	}
+	if dto.EffectiveAt != nil {
+		version.EffectiveAt = *dto.EffectiveAt
+	}
+	if err := s.consentRepo.CreateVersion(version); err != nil {
+		return nil, &DatabaseError{Message: "发布协议版本失败", Err: err}
+	}
+	cache.RedisClient.Del(context.Background(), consentRequiredKey)
+
+	util.Log().Info("发布协议版本 type=%s version=%s mandatory=%t effective_at=%s by user_id=%d",
+		version.Type, version.Version, version.Mandatory, version.EffectiveAt.Format(time.RFC3339), userID)
+	return version, nil
+}
+
+// ListVersions 获取全部协议版本
+func (s *ConsentService) ListVersions(ctx *BusinessContext) ([]model.PolicyVersion, ServiceError) {
+	if serviceErr := requirePermission(ctx, model.PermissionPoliciesManage); serviceErr != nil {
+		return nil, serviceErr
+	}
+	versions, err := s.consentRepo.ListVersions()
+	if err != nil {
+		return nil, &DatabaseError{Message: "查询协议版本失败", Err: err}
+	}
+	return versions, nil
+}
+
+// CurrentPolicy 当前生效的协议版本及用户的同意状态
+type CurrentPolicy struct {
+	Version  *model.PolicyVersion
+	Accepted bool // 已同意该版本或更新的版本
+}
+
+// ConsentStatus 用户的协议同意状态
+type ConsentStatus struct {
+	Current []CurrentPolicy
+	Missing []model.PolicyVersion // 须重新同意才能继续使用的版本
+	History []repository.UserConsentDetail
+}
+
+// ListConsents 获取当前用户的协议同意状态与同意记录
+func (s *ConsentService) ListConsents(ctx *BusinessContext) (*ConsentStatus, ServiceError) {
+	userID, serviceErr := currentUserID(ctx)
+	if serviceErr != nil {
+		return nil, serviceErr
+	}
+	current, serviceErr := s.CurrentVersions()
+	if serviceErr != nil {
+		return nil, serviceErr
+	}
+	latest, err := s.consentRepo.LatestAccepted(userID)
+	if err != nil {
+		return nil, &DatabaseError{Message: "查询同意记录失败", Err: err}
+	}
+	missing, serviceErr := s.missingVersions(userID)
+	if serviceErr != nil {
+		return nil, serviceErr
+	}
+	history, err := s.consentRepo.ListByUser(userID)
+	if err != nil {
+		return nil, &DatabaseError{Message: "查询同意记录失败", Err: err}
+	}
+
+	status := &ConsentStatus{Missing: missing, History: history}
+	for i := range current {
+		accepted, ok := latest[current[i].Type]
+		status.Current = append(status.Current, CurrentPolicy{
+			Version:  &current[i],
+			Accepted: ok && !accepted.Before(current[i].EffectiveAt),
+		})
+	}
+	return status, nil
+}
+
+// AcceptConsentsDTO 同意协议请求DTO
+type AcceptConsentsDTO struct {
+	PolicyVersionIDs []uint
+}
+
+// Accept 记录当前用户同意的协议版本，只能同意当前生效的版本
+func (s *ConsentService) Accept(ctx *BusinessContext, dto *AcceptConsentsDTO) ServiceError {
+	if serviceErr := denyImpersonation(ctx, "代替用户同意协议"); serviceErr != nil {
+		return serviceErr
+	}
+	userID, serviceErr := currentUserID(ctx)
+	if serviceErr != nil {
+		return serviceErr
+	}
+	if len(dto.PolicyVersionIDs) == 0 {
+		return &ValidationError{Message: "请选择要同意的协议版本", Code: 40000}
+	}
+	current, serviceErr := s.CurrentVersions()
+	if serviceErr != nil {
+		return serviceErr
+	}
+
+	accepted := make([]model.PolicyVersion, 0, len(dto.PolicyVersionIDs))
+	for _, id := range dto.PolicyVersionIDs {
+		version := findPolicyVersion(current, id)
+		if version == nil {
+			return &ValidationError{Message: "只能同意当前生效的协议版本", Code: 40000}
+		}
+		accepted = append(accepted, *version)
+	}
+	return s.record(ctx, userID, accepted)
+}
+
+// Pending 返回当前用户须重新同意的协议版本，全部已同意时返回空
+func (s *ConsentService) Pending(ctx *BusinessContext) ([]model.PolicyVersion, ServiceError) {
+	userID, serviceErr := currentUserID(ctx)
+	if serviceErr != nil {
+		return nil, serviceErr
+	}
+	return s.missingVersions(userID)
+}
+
+// missingVersions 返回用户须重新同意的协议版本
+// 每种协议以最新的须重新同意版本为准，用户同意过该版本或更新的版本即视为已同意
+func (s *ConsentService) missingVersions(userID uint) ([]model.PolicyVersion, ServiceError) {
+	required, serviceErr := s.requiredVersions()
+	if serviceErr != nil {
+		return nil, serviceErr
+	}
+	if len(required) == 0 {
+		return nil, nil
+	}
+
+	redisCtx := context.Background()
+	okKey := fmt.Sprintf(consentOKKey, userID, policyVersionIDs(required))
+	if exists, _ := cache.RedisClient.Exists(redisCtx, okKey).Result(); exists > 0 {
+		return nil, nil
+	}
+
+	latest, err := s.consentRepo.LatestAccepted(userID)
+	if err != nil {
+		return nil, &DatabaseError{Message: "查询同意记录失败", Err: err}
+	}
+	var missing []model.PolicyVersion
+	for _, version := range required {
+		if accepted, ok := latest[version.Type]; !ok || accepted.Before(version.EffectiveAt) {
+			missing = append(missing, version)
+		}
+	}
+	if len(missing) == 0 {
+		cache.RedisClient.Set(redisCtx, okKey, 1, consentOKTTL)
+	}
+	return missing, nil
+}
+
+// requireCurrent 校验注册时同意的协议版本覆盖全部当前版本，返回待记录的版本
+func (s *ConsentService) requireCurrent(policyVersionIDs []uint) ([]model.PolicyVersion, ServiceError) {
+	current, serviceErr := s.CurrentVersions()
+	if serviceErr != nil {
+		return nil, serviceErr
+	}
+	for _, version := range current {
+		accepted := false
+		for _, id := range policyVersionIDs {
+			if id == version.ID {
+				accepted = true
+				break
+			}
+		}
+		if !accepted {
+			return nil, &RegistrationError{Message: "请阅读并同意最新的服务条款与隐私政策", Code: 40317, Reason: RegistrationRejectPolicyNotAccepted}
+		}
+	}
+	return current, nil
+}
+
+// record 保存同意记录（含 IP 与 UA）
+func (s *ConsentService) record(ctx *BusinessContext, userID uint, versions []model.PolicyVersion) ServiceError {
+	now := time.Now()
+	consents := make([]model.UserConsent, len(versions))
+	for i, version := range versions {
+		consents[i] = model.UserConsent{
+			UserID:          userID,
+			PolicyVersionID: version.ID,
+			AcceptedAt:      now,
+			ClientIP:        ctx.ClientIP,
+			UserAgent:       ctx.UserAgent,
+		}
+	}
+	if err := s.consentRepo.Accept(consents); err != nil {
+		return &DatabaseError{Message: "保存同意记录失败", Err: err}
+	}
+	for _, version := range versions {
+		util.Log().Info("用户同意协议 user_id=%d type=%s version=%s", userID, version.Type, version.Version)
+	}
+	return nil
+}
+
+// requiredVersions 获取每种协议最新的须重新同意版本（Redis 缓存）
+func (s *ConsentService) requiredVersions() ([]model.PolicyVersion, ServiceError) {
+	redisCtx := context.Background()
+	var versions []model.PolicyVersion
+	cached, err := cache.RedisClient.Get(redisCtx, consentRequiredKey).Result()
+	if err == nil && json.Unmarshal([]byte(cached), &versions) == nil {
+		return versions, nil
+	}
+
+	versions, err = s.consentRepo.ListCurrentVersions(time.Now(), true)
+	if err != nil {
+		return nil, &DatabaseError{Message: "查询协议版本失败", Err: err}
+	}
+	if data, err := json.Marshal(versions); err == nil {
+		cache.RedisClient.Set(redisCtx, consentRequiredKey, data, consentRequiredTTL)
+	}
+	return versions, nil
+}
+
+// findPolicyVersion 按ID查找协议版本
+func findPolicyVersion(versions []model.PolicyVersion, id uint) *model.PolicyVersion {
+	for i := range versions {
+		if versions[i].ID == id {
+			return &versions[i]
+		}
+	}
+	return nil
+}
+
+// policyVersionIDs 将协议版本ID拼接为缓存键片段
+func policyVersionIDs(versions []model.PolicyVersion) string {
+	ids := make([]string, len(versions))
+	for i, version := range versions {
+		ids[i] = strconv.FormatUint(uint64(version.ID), 10)
+	}
+	return strings.Join(ids, ",")
+}
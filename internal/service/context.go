@@ -1,25 +1,44 @@
 package service
 
 import (
-    "context"
+	"context"
 )
 
 // BusinessContext 业务上下文，包含业务逻辑需要的上下文信息
 // 替代gin.Context，让service层与HTTP传输层解耦
 type BusinessContext struct {
-    // 请求上下文
-    Context context.Context
+	// 请求上下文
+	Context context.Context
 
-    // 用户身份信息（来自JWT token）
-    UserUUID string     // JWT中的用户ID
-    Claims   *JWTClaims // 完整的JWT claims（最小负载）
+	// 请求所属租户（由子域名、请求头或令牌解析），仓储的读写均限定在该租户内
+	TenantID uint
 
-    // 请求元数据
-    RequestID   string
-    TraceID     string
-    ClientIP    string
-    UserAgent   string
-    RequestTime int64
+	// 用户身份信息（来自JWT token）
+	UserUUID string     // JWT中的用户ID
+	Claims   *JWTClaims // 完整的JWT claims（最小负载）
+
+	// 模拟登录时实际操作的管理员ID（来自令牌的 act 声明），此时 UserUUID 为被模拟的用户
+	ActorUUID string
+
+	// 授权信息（由认证中间件按用户角色解析）
+	Roles       []string
+	Permissions []string
+
+	// API 密钥或 OAuth 应用令牌认证时的凭证标识与作用域（交互式登录时为空）
+	APIKeyID      uint
+	OAuthClientID string
+	Scopes        []string
+
+	// 请求携带的有效 DPoP 证明的公钥指纹（RFC 7638），签发的令牌随之绑定
+	DPoPKey string
+
+	// 请求元数据
+	RequestID   string
+	TraceID     string
+	ClientIP    string
+	UserAgent   string
+	DeviceName  string
+	RequestTime int64
 }
 
 // NewBusinessContext 创建业务上下文
@@ -29,6 +48,12 @@ func NewBusinessContext(ctx context.Context) *BusinessContext {
 	}
 }
 
+// WithTenant 设置租户ID
+func (bc *BusinessContext) WithTenant(tenantID uint) *BusinessContext {
+	bc.TenantID = tenantID
+	return bc
+}
+
 // WithUserUUID 设置用户ID
 func (bc *BusinessContext) WithUserUUID(userID string) *BusinessContext {
 	bc.UserUUID = userID
@@ -37,11 +62,52 @@ func (bc *BusinessContext) WithUserUUID(userID string) *BusinessContext {
 
 // WithClaims 设置JWT claims
 func (bc *BusinessContext) WithClaims(claims *JWTClaims) *BusinessContext {
-    bc.Claims = claims
-    if claims != nil {
-        bc.UserUUID = claims.UserID
-    }
-    return bc
+	bc.Claims = claims
+	if claims != nil {
+		bc.UserUUID = claims.UserID
+	}
+	return bc
+}
+
+// WithActor 设置模拟登录的实际操作者
+func (bc *BusinessContext) WithActor(actorID string) *BusinessContext {
+	bc.ActorUUID = actorID
+	return bc
+}
+
+// WithAccess 设置用户角色与权限
+func (bc *BusinessContext) WithAccess(roles, permissions []string) *BusinessContext {
+	bc.Roles = roles
+	bc.Permissions = permissions
+	return bc
+}
+
+// WithAPIKey 设置 API 密钥信息
+func (bc *BusinessContext) WithAPIKey(keyID uint, scopes []string) *BusinessContext {
+	bc.APIKeyID = keyID
+	bc.Scopes = scopes
+	return bc
+}
+
+// WithOAuthClient 设置 OAuth 应用令牌的应用与授权范围
+func (bc *BusinessContext) WithOAuthClient(clientID string, scopes []string) *BusinessContext {
+	bc.OAuthClientID = clientID
+	bc.Scopes = scopes
+	return bc
+}
+
+// WithDPoPKey 设置 DPoP 公钥指纹
+func (bc *BusinessContext) WithDPoPKey(jkt string) *BusinessContext {
+	bc.DPoPKey = jkt
+	return bc
+}
+
+// TokenScheme 本次签发的访问令牌类型：携带有效 DPoP 证明时为 DPoP，否则为 Bearer
+func (bc *BusinessContext) TokenScheme() string {
+	if bc.DPoPKey != "" {
+		return "DPoP"
+	}
+	return "Bearer"
 }
 
 // WithRequestID 设置请求ID
@@ -68,13 +134,75 @@ func (bc *BusinessContext) WithUserAgent(userAgent string) *BusinessContext {
 	return bc
 }
 
+// WithDeviceName 设置客户端设备名称（用于会话展示）
+func (bc *BusinessContext) WithDeviceName(deviceName string) *BusinessContext {
+	bc.DeviceName = deviceName
+	return bc
+}
+
 // WithRequestTime 设置请求时间
 func (bc *BusinessContext) WithRequestTime(requestTime int64) *BusinessContext {
 	bc.RequestTime = requestTime
 	return bc
 }
 
+// HasPermission 检查当前用户是否拥有指定权限
+func (bc *BusinessContext) HasPermission(permission string) bool {
+	for _, p := range bc.Permissions {
+		if p == permission {
+			return true
+		}
+	}
+	return false
+}
+
+// HasRole 检查当前用户是否拥有指定角色
+func (bc *BusinessContext) HasRole(role string) bool {
+	for _, r := range bc.Roles {
+		if r == role {
+			return true
+		}
+	}
+	return false
+}
+
+// IsAPIKey 是否通过 API 密钥认证
+func (bc *BusinessContext) IsAPIKey() bool {
+	return bc.APIKeyID != 0
+}
+
+// IsImpersonated 是否为管理员模拟登录的请求
+func (bc *BusinessContext) IsImpersonated() bool {
+	return bc.ActorUUID != ""
+}
+
+// Actor 实际发起请求的用户ID：模拟登录时为管理员，否则为当前用户
+func (bc *BusinessContext) Actor() string {
+	if bc.IsImpersonated() {
+		return bc.ActorUUID
+	}
+	return bc.UserUUID
+}
+
+// IsDelegated 是否通过委托凭证（API 密钥或 OAuth 应用令牌）认证，委托凭证受作用域限制
+func (bc *BusinessContext) IsDelegated() bool {
+	return bc.IsAPIKey() || bc.OAuthClientID != ""
+}
+
+// HasScope 检查委托凭证是否拥有指定作用域；交互式登录的会话不受作用域限制
+func (bc *BusinessContext) HasScope(scope string) bool {
+	if !bc.IsDelegated() {
+		return true
+	}
+	for _, s := range bc.Scopes {
+		if s == scope {
+			return true
+		}
+	}
+	return false
+}
+
 // IsAuthenticated 检查是否已认证
 func (bc *BusinessContext) IsAuthenticated() bool {
-    return bc.UserUUID != "" && bc.Claims != nil
+	return bc.UserUUID != "" && bc.Claims != nil
 }
@@ -0,0 +1,138 @@
+package service
+
+import (
+	"context"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/json"
+	"fmt"
+	"go-one/internal/cache"
+	"go-one/util"
+	"net/url"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// DPoPConfig DPoP（RFC 9449）持有证明配置
+type DPoPConfig struct {
+	ProofLifetime time.Duration // 证明 iat 与服务器时间允许的最大偏差
+	BaseURL       string        // 对外访问地址（scheme://host），用于校验 htu；为空时按请求推断
+}
+
+var DPoP *DPoPConfig
+
+// dpopReplayKey 已使用的证明（公钥指纹 + jti 的哈希），在证明有效窗口内拒绝重放
+const dpopReplayKey = "dpop:jti:%s"
+
+// dpopSigningAlgs 证明允许的签名算法（仅非对称算法）
+var dpopSigningAlgs = []string{"RS256", "RS384", "RS512", "PS256", "PS384", "PS512", "ES256", "ES384", "ES512", "EdDSA"}
+
+// InitDPoP 初始化 DPoP 配置
+func InitDPoP() {
+	DPoP = &DPoPConfig{
+		ProofLifetime: time.Duration(envInt64("DPOP_PROOF_LIFETIME", 60)) * time.Second,
+		BaseURL:       strings.TrimRight(os.Getenv("DPOP_BASE_URL"), "/"),
+	}
+	util.Log().Info("DPoP 配置初始化完成")
+}
+
+// dpopProofClaims DPoP 证明声明
+type dpopProofClaims struct {
+	HTM string `json:"htm"`
+	HTU string `json:"htu"`
+	ATH string `json:"ath,omitempty"`
+	jwt.RegisteredClaims
+}
+
+// DPoPProofDTO 待校验的 DPoP 证明及其所在请求
+// AccessToken 非空时（访问受保护接口）要求证明携带该令牌的 ath
+type DPoPProofDTO struct {
+	Proof       string
+	Method      string
+	URL         string
+	AccessToken string
+}
+
+// VerifyDPoPProof 校验 DPoP 证明的类型、签名（证明自带的公钥）、htm、htu、iat、ath 与 jti，返回公钥指纹（RFC 7638）
+func VerifyDPoPProof(dto *DPoPProofDTO) (string, ServiceError) {
+	if dto.Proof == "" {
+		return "", &AuthError{Message: "缺少 DPoP 证明"}
+	}
+
+	var thumbprint string
+	claims := &dpopProofClaims{}
+	_, err := jwt.ParseWithClaims(dto.Proof, claims, func(token *jwt.Token) (interface{}, error) {
+		if typ, _ := token.Header["typ"].(string); typ != "dpop+jwt" {
+			return nil, fmt.Errorf("typ 必须为 dpop+jwt")
+		}
+		raw, ok := token.Header["jwk"].(map[string]interface{})
+		if !ok {
+			return nil, fmt.Errorf("缺少 jwk 头")
+		}
+		if _, private := raw["d"]; private {
+			return nil, fmt.Errorf("jwk 不得包含私钥")
+		}
+		encoded, _ := json.Marshal(raw)
+		var jwk JWK
+		if err := json.Unmarshal(encoded, &jwk); err != nil {
+			return nil, err
+		}
+		key, err := jwk.PublicKey()
+		if err != nil {
+			return nil, err
+		}
+		if thumbprint, err = jwk.Thumbprint(); err != nil {
+			return nil, err
+		}
+		return key, nil
+	}, jwt.WithValidMethods(dpopSigningAlgs))
+	if err != nil {
+		return "", &AuthError{Message: "DPoP 证明无效", Err: err}
+	}
+
+	if claims.ID == "" || claims.IssuedAt == nil {
+		return "", &AuthError{Message: "DPoP 证明缺少 jti 或 iat"}
+	}
+	if claims.HTM != dto.Method {
+		return "", &AuthError{Message: "DPoP 证明的 htm 与请求方法不一致"}
+	}
+	if !sameHTU(claims.HTU, dto.URL) {
+		return "", &AuthError{Message: "DPoP 证明的 htu 与请求地址不一致"}
+	}
+	if skew := time.Since(claims.IssuedAt.Time); skew > DPoP.ProofLifetime || skew < -DPoP.ProofLifetime {
+		return "", &AuthError{Message: "DPoP 证明已过期或时间不正确"}
+	}
+	if dto.AccessToken != "" {
+		sum := sha256.Sum256([]byte(dto.AccessToken))
+		if subtle.ConstantTimeCompare([]byte(claims.ATH), []byte(b64.EncodeToString(sum[:]))) != 1 {
+			return "", &AuthError{Message: "DPoP 证明的 ath 与访问令牌不一致"}
+		}
+	}
+
+	// 证明单次有效：有效窗口为 iat 前后各 ProofLifetime
+	key := fmt.Sprintf(dpopReplayKey, util.SHA256Hex(thumbprint+":"+claims.ID))
+	fresh, err := cache.RedisClient.SetNX(context.Background(), key, 1, 2*DPoP.ProofLifetime).Result()
+	if err != nil {
+		return "", &ExternalAPIError{Message: "校验 DPoP 证明失败", Err: err}
+	}
+	if !fresh {
+		return "", &AuthError{Message: "DPoP 证明已被使用"}
+	}
+	return thumbprint, nil
+}
+
+// sameHTU 比较 htu 与请求地址：忽略查询串与片段，scheme 与 host 不区分大小写（RFC 9449 4.3）
+func sameHTU(htu, requestURL string) bool {
+	a, err := url.Parse(htu)
+	if err != nil {
+		return false
+	}
+	b, err := url.Parse(requestURL)
+	if err != nil {
+		return false
+	}
+	return strings.EqualFold(a.Scheme, b.Scheme) && strings.EqualFold(a.Host, b.Host) && a.Path == b.Path
+}
@@ -0,0 +1,224 @@
+package service
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/json"
+	"go-one/internal/cache"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/google/uuid"
+	"github.com/redis/go-redis/v9"
+)
+
+// useTestRedis 将 cache.RedisClient 指向内存 Redis，测试结束后恢复
+func useTestRedis(t *testing.T) *miniredis.Miniredis {
+	t.Helper()
+	server := miniredis.RunT(t)
+	previous := cache.RedisClient
+	cache.RedisClient = redis.NewClient(&redis.Options{Addr: server.Addr()})
+	t.Cleanup(func() {
+		cache.RedisClient.Close()
+		cache.RedisClient = previous
+	})
+	return server
+}
+
+// dpopProver 持有 DPoP 私钥的测试客户端
+type dpopProver struct {
+	key *ecdsa.PrivateKey
+	jwk map[string]interface{}
+	jkt string
+}
+
+func newDPoPProver(t *testing.T) *dpopProver {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("生成 DPoP 密钥失败: %v", err)
+	}
+	jwk, err := NewJWK(key.Public())
+	if err != nil {
+		t.Fatalf("NewJWK: %v", err)
+	}
+	jkt, err := jwk.Thumbprint()
+	if err != nil {
+		t.Fatalf("Thumbprint: %v", err)
+	}
+	raw, _ := json.Marshal(jwk)
+	var header map[string]interface{}
+	_ = json.Unmarshal(raw, &header)
+	return &dpopProver{key: key, jwk: header, jkt: jkt}
+}
+
+// proof 签发 DPoP 证明，edit 可在签名前修改声明或头
+func (p *dpopProver) proof(t *testing.T, method, url, accessToken string, edit func(*jwt.Token, *dpopProofClaims)) string {
+	t.Helper()
+	claims := &dpopProofClaims{
+		HTM: method,
+		HTU: url,
+		RegisteredClaims: jwt.RegisteredClaims{
+			ID:       uuid.NewString(),
+			IssuedAt: jwt.NewNumericDate(time.Now()),
+		},
+	}
+	if accessToken != "" {
+		sum := sha256.Sum256([]byte(accessToken))
+		claims.ATH = b64.EncodeToString(sum[:])
+	}
+	token := jwt.NewWithClaims(jwt.SigningMethodES256, claims)
+	token.Header["typ"] = "dpop+jwt"
+	token.Header["jwk"] = p.jwk
+	if edit != nil {
+		edit(token, claims)
+	}
+	signed, err := token.SignedString(p.key)
+	if err != nil {
+		t.Fatalf("签发 DPoP 证明失败: %v", err)
+	}
+	return signed
+}
+
+func TestVerifyDPoPProof(t *testing.T) {
+	useTestRedis(t)
+	DPoP = &DPoPConfig{ProofLifetime: time.Minute}
+
+	const (
+		method      = "GET"
+		url         = "https://api.example.com/api/v1/user/profile"
+		accessToken = "access-token"
+	)
+	prover := newDPoPProver(t)
+	other := newDPoPProver(t)
+
+	tests := []struct {
+		name    string
+		proof   string
+		dto     DPoPProofDTO
+		wantErr bool
+	}{
+		{
+			name:  "有效证明",
+			proof: prover.proof(t, method, url, accessToken, nil),
+			dto:   DPoPProofDTO{Method: method, URL: url, AccessToken: accessToken},
+		},
+		{
+			name:  "htu 忽略查询串，scheme 与 host 不区分大小写",
+			proof: prover.proof(t, method, "HTTPS://API.example.com/api/v1/user/profile", "", nil),
+			dto:   DPoPProofDTO{Method: method, URL: url + "?page=2"},
+		},
+		{
+			name:    "htm 不一致",
+			proof:   prover.proof(t, "POST", url, accessToken, nil),
+			dto:     DPoPProofDTO{Method: method, URL: url, AccessToken: accessToken},
+			wantErr: true,
+		},
+		{
+			name:    "htu 路径不一致",
+			proof:   prover.proof(t, method, "https://api.example.com/api/v1/user/sessions", accessToken, nil),
+			dto:     DPoPProofDTO{Method: method, URL: url, AccessToken: accessToken},
+			wantErr: true,
+		},
+		{
+			name:    "htu 主机不一致",
+			proof:   prover.proof(t, method, "https://evil.example.com/api/v1/user/profile", accessToken, nil),
+			dto:     DPoPProofDTO{Method: method, URL: url, AccessToken: accessToken},
+			wantErr: true,
+		},
+		{
+			name:    "ath 与访问令牌不一致",
+			proof:   prover.proof(t, method, url, "another-token", nil),
+			dto:     DPoPProofDTO{Method: method, URL: url, AccessToken: accessToken},
+			wantErr: true,
+		},
+		{
+			name:    "访问受保护接口缺少 ath",
+			proof:   prover.proof(t, method, url, "", nil),
+			dto:     DPoPProofDTO{Method: method, URL: url, AccessToken: accessToken},
+			wantErr: true,
+		},
+		{
+			name: "iat 过旧",
+			proof: prover.proof(t, method, url, accessToken, func(_ *jwt.Token, c *dpopProofClaims) {
+				c.IssuedAt = jwt.NewNumericDate(time.Now().Add(-2 * time.Minute))
+			}),
+			dto:     DPoPProofDTO{Method: method, URL: url, AccessToken: accessToken},
+			wantErr: true,
+		},
+		{
+			name: "iat 在未来",
+			proof: prover.proof(t, method, url, accessToken, func(_ *jwt.Token, c *dpopProofClaims) {
+				c.IssuedAt = jwt.NewNumericDate(time.Now().Add(2 * time.Minute))
+			}),
+			dto:     DPoPProofDTO{Method: method, URL: url, AccessToken: accessToken},
+			wantErr: true,
+		},
+		{
+			name: "缺少 jti",
+			proof: prover.proof(t, method, url, accessToken, func(_ *jwt.Token, c *dpopProofClaims) {
+				c.ID = ""
+			}),
+			dto:     DPoPProofDTO{Method: method, URL: url, AccessToken: accessToken},
+			wantErr: true,
+		},
+		{
+			name: "typ 错误",
+			proof: prover.proof(t, method, url, accessToken, func(token *jwt.Token, _ *dpopProofClaims) {
+				token.Header["typ"] = "JWT"
+			}),
+			dto:     DPoPProofDTO{Method: method, URL: url, AccessToken: accessToken},
+			wantErr: true,
+		},
+		{
+			name: "jwk 与签名密钥不符",
+			proof: prover.proof(t, method, url, accessToken, func(token *jwt.Token, _ *dpopProofClaims) {
+				token.Header["jwk"] = other.jwk
+			}),
+			dto:     DPoPProofDTO{Method: method, URL: url, AccessToken: accessToken},
+			wantErr: true,
+		},
+		{
+			name:    "缺少证明",
+			dto:     DPoPProofDTO{Method: method, URL: url, AccessToken: accessToken},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dto := tt.dto
+			dto.Proof = tt.proof
+			jkt, serviceErr := VerifyDPoPProof(&dto)
+			if (serviceErr != nil) != tt.wantErr {
+				t.Fatalf("err = %v, wantErr %v", serviceErr, tt.wantErr)
+			}
+			if !tt.wantErr && jkt != prover.jkt {
+				t.Errorf("jkt = %s, want %s", jkt, prover.jkt)
+			}
+		})
+	}
+}
+
+func TestVerifyDPoPProofReplay(t *testing.T) {
+	useTestRedis(t)
+	DPoP = &DPoPConfig{ProofLifetime: time.Minute}
+
+	prover := newDPoPProver(t)
+	url := "https://api.example.com/api/v1/auth/login"
+	proof := prover.proof(t, "POST", url, "", nil)
+
+	if _, serviceErr := VerifyDPoPProof(&DPoPProofDTO{Proof: proof, Method: "POST", URL: url}); serviceErr != nil {
+		t.Fatalf("首次使用应通过: %v", serviceErr)
+	}
+	if _, serviceErr := VerifyDPoPProof(&DPoPProofDTO{Proof: proof, Method: "POST", URL: url}); serviceErr == nil {
+		t.Fatal("重放的证明应被拒绝")
+	}
+	// 不同证明（jti 不同）不受影响
+	if _, serviceErr := VerifyDPoPProof(&DPoPProofDTO{Proof: prover.proof(t, "POST", url, "", nil), Method: "POST", URL: url}); serviceErr != nil {
+		t.Errorf("新证明应通过: %v", serviceErr)
+	}
+}
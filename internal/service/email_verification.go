@@ -0,0 +1,185 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"go-one/internal/cache"
+	"go-one/internal/model"
+	"go-one/internal/repository"
+	"go-one/util"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// EmailConfig 邮箱验证配置
+type EmailConfig struct {
+	VerificationRequired bool          // 为 true 时未验证邮箱的用户不能登录
+	VerificationExpire   time.Duration // 验证链接有效期
+	VerifyURL            string        // 验证页面地址，令牌以 token 查询参数附加
+	ResendCooldown       time.Duration // 同一用户重发验证邮件的最小间隔
+}
+
+var Email *EmailConfig
+
+const (
+	// emailVerifyUsedKey 邮箱验证令牌已使用标记
+	emailVerifyUsedKey = "email_verify:used:%s"
+	// emailVerifyCooldownKey 重发验证邮件冷却
+	emailVerifyCooldownKey = "email_verify:cooldown:%d"
+)
+
+// InitEmail 初始化邮箱验证配置
+func InitEmail() {
+	expire := int64(86400) // 默认24小时
+	if v := os.Getenv("EMAIL_VERIFICATION_EXPIRE"); v != "" {
+		if parsed, err := strconv.ParseInt(v, 10, 64); err == nil {
+			expire = parsed
+		}
+	}
+
+	verifyURL := os.Getenv("EMAIL_VERIFY_URL")
+	if verifyURL == "" {
+		verifyURL = "http://localhost:8080/verify-email"
+	}
+
+	Email = &EmailConfig{
+		VerificationRequired: os.Getenv("EMAIL_VERIFICATION_REQUIRED") == "true",
+		VerificationExpire:   time.Duration(expire) * time.Second,
+		VerifyURL:            verifyURL,
+		ResendCooldown:       time.Minute,
+	}
+
+	util.Log().Info("邮箱验证配置初始化完成")
+}
+
+// EmailVerificationService 邮箱验证服务
+type EmailVerificationService struct {
+	userRepo repository.UserRepository
+	mailer   Mailer
+}
+
+// NewEmailVerificationService 创建邮箱验证服务实例
+func NewEmailVerificationService(userRepo repository.UserRepository, mailer Mailer) *EmailVerificationService {
+	return &EmailVerificationService{
+		userRepo: userRepo,
+		mailer:   mailer,
+	}
+}
+
+// SendVerification 向用户当前邮箱发送验证链接
+func (s *EmailVerificationService) SendVerification(ctx *BusinessContext, user *model.User) ServiceError {
+	if user.Email == "" || user.EmailVerified {
+		return nil
+	}
+
+	token, err := GenerateToken(JWTClaims{
+		UserID: strconv.FormatUint(uint64(user.ID), 10),
+		JTI:    uuid.NewString(),
+		Email:  user.Email,
+	}, EmailVerifyToken, Email.VerificationExpire)
+	if err != nil {
+		return &BusinessError{Message: "生成验证令牌失败", Code: 50000, Err: err}
+	}
+
+	link := linkWithToken(Email.VerifyURL, token)
+	msg := &MailMessage{
+		To:      user.Email,
+		Subject: "请验证您的邮箱",
+		Body: fmt.Sprintf("%s，您好：\n\n请点击以下链接完成邮箱验证（%d 小时内有效）：\n%s\n\n如果这不是您本人的操作，请忽略此邮件。\n",
+			user.Nickname, int(Email.VerificationExpire.Hours()), link),
+	}
+	if err := s.mailer.Send(msg); err != nil {
+		return &ExternalAPIError{Message: "发送验证邮件失败", Err: err}
+	}
+	return nil
+}
+
+// VerifyEmail 校验邮箱验证令牌（单次有效）并标记邮箱已验证
+func (s *EmailVerificationService) VerifyEmail(ctx *BusinessContext, token string) (*model.User, ServiceError) {
+	if token == "" {
+		return nil, &ValidationError{Message: "验证令牌不能为空", Code: 40000}
+	}
+	claims, err := ValidateToken(token, EmailVerifyToken)
+	if err != nil || claims.JTI == "" || claims.Email == "" {
+		return nil, &AuthError{Message: "验证链接无效或已过期"}
+	}
+
+	// 单次有效：首次使用时写入标记，有效期覆盖令牌剩余寿命
+	ttl := Email.VerificationExpire
+	if claims.ExpiresAt != nil {
+		ttl = time.Until(claims.ExpiresAt.Time) + time.Minute
+	}
+	ok, err := cache.RedisClient.SetNX(context.Background(), fmt.Sprintf(emailVerifyUsedKey, claims.JTI), 1, ttl).Result()
+	if err != nil {
+		return nil, &ExternalAPIError{Message: "邮箱验证服务异常", Err: err}
+	}
+	if !ok {
+		return nil, &AuthError{Message: "验证链接已使用"}
+	}
+
+	uid64, err := strconv.ParseUint(claims.UserID, 10, 64)
+	if err != nil || uid64 == 0 {
+		return nil, &AuthError{Message: "验证链接无效或已过期"}
+	}
+	user, err := s.userRepo.FindByID(uint(uid64))
+	if err != nil {
+		return nil, &NotFoundError{Message: "用户不存在"}
+	}
+	if user.Email != claims.Email {
+		return nil, &AuthError{Message: "邮箱已变更，请重新发送验证邮件"}
+	}
+	if user.EmailVerified {
+		return user, nil
+	}
+
+	// 邮箱已被其他账号验证占用
+	if owner, err := s.userRepo.FindByVerifiedEmail(user.Email); err == nil && owner.ID != user.ID {
+		return nil, &BusinessError{Message: "邮箱已被使用", Code: 40009}
+	}
+	if _, err := s.userRepo.MarkEmailVerified(user.ID, claims.Email); err != nil {
+		return nil, &DatabaseError{Message: "更新邮箱验证状态失败", Err: err}
+	}
+
+	now := time.Now()
+	user.EmailVerified = true
+	user.EmailVerifiedAt = &now
+
+	// 待激活账号在邮箱验证后激活
+	if user.Status == model.UserStatusPending {
+		if serviceErr := transitionUserStatus(s.userRepo, user, model.UserStatusActive, "邮箱验证通过", nil, 0); serviceErr != nil {
+			return nil, serviceErr
+		}
+	}
+	util.Log().Info("用户邮箱验证成功 user_id=%d", user.ID)
+	return user, nil
+}
+
+// ResendVerification 向填写了该邮箱且尚未验证的账号重发验证邮件
+// 为防止邮箱枚举，无论邮箱是否存在均视为成功
+func (s *EmailVerificationService) ResendVerification(ctx *BusinessContext, email string) ServiceError {
+	email = strings.TrimSpace(email)
+	if email == "" {
+		return &ValidationError{Message: "邮箱不能为空", Code: 40000}
+	}
+
+	users, err := s.userRepo.FindUnverifiedByEmail(email)
+	if err != nil {
+		return &DatabaseError{Message: "查询用户失败", Err: err}
+	}
+	for i := range users {
+		user := &users[i]
+		// 同一用户冷却期内不重复发送
+		key := fmt.Sprintf(emailVerifyCooldownKey, user.ID)
+		if ok, err := cache.RedisClient.SetNX(context.Background(), key, 1, Email.ResendCooldown).Result(); err != nil || !ok {
+			continue
+		}
+		if serviceErr := s.SendVerification(ctx, user); serviceErr != nil {
+			util.Log().Error("重发验证邮件失败 user_id=%d: %v", user.ID, serviceErr)
+		}
+	}
+	return nil
+}
@@ -1,6 +1,9 @@
 package service
 
-import "fmt"
+import (
+	"fmt"
+	"time"
+)
 
 // ServiceError 服务层错误接口
 type ServiceError interface {
@@ -128,3 +131,68 @@ func (e *BusinessError) GetCode() int {
 func (e *BusinessError) GetMessage() string {
 	return e.Message
 }
+
+// RateLimitError 请求频率或登录尝试受限错误，RetryAfter 为建议的重试等待时间
+type RateLimitError struct {
+	Message    string
+	Code       int // 42901 尝试过于频繁，42902 账号临时锁定
+	RetryAfter time.Duration
+}
+
+func (e *RateLimitError) Error() string {
+	return e.Message
+}
+
+func (e *RateLimitError) GetCode() int {
+	return e.Code
+}
+
+func (e *RateLimitError) GetMessage() string {
+	return e.Message
+}
+
+// RetryAfterSeconds 返回向上取整的重试等待秒数（至少 1 秒）
+func (e *RateLimitError) RetryAfterSeconds() int64 {
+	seconds := int64((e.RetryAfter + time.Second - 1) / time.Second)
+	if seconds < 1 {
+		seconds = 1
+	}
+	return seconds
+}
+
+// PasswordPolicyError 密码不符合安全策略，Violations 为全部违规项
+type PasswordPolicyError struct {
+	Message    string
+	Violations []PasswordViolation
+}
+
+func (e *PasswordPolicyError) Error() string {
+	return e.Message
+}
+
+func (e *PasswordPolicyError) GetCode() int {
+	return 40010 // 密码策略错误码
+}
+
+func (e *PasswordPolicyError) GetMessage() string {
+	return e.Message
+}
+
+// RegistrationError 注册被当前注册策略拒绝，Reason 为拒绝原因（见 RegistrationReject* 常量）
+type RegistrationError struct {
+	Message string
+	Code    int // 40310~40317，与 Reason 一一对应
+	Reason  string
+}
+
+func (e *RegistrationError) Error() string {
+	return e.Message
+}
+
+func (e *RegistrationError) GetCode() int {
+	return e.Code
+}
+
+func (e *RegistrationError) GetMessage() string {
+	return e.Message
+}
@@ -0,0 +1,238 @@
+package service
+
+import (
+	"go-one/internal/model"
+	"go-one/internal/repository"
+	"go-one/util"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// ImpersonationConfig 模拟登录配置
+type ImpersonationConfig struct {
+	TokenExpire time.Duration // 模拟令牌有效期（不签发刷新令牌，到期需重新发起）
+}
+
+var Impersonation *ImpersonationConfig
+
+// InitImpersonation 初始化模拟登录配置
+func InitImpersonation() {
+	Impersonation = &ImpersonationConfig{
+		TokenExpire: time.Duration(envInt64("IMPERSONATION_TOKEN_EXPIRE", 900)) * time.Second,
+	}
+	util.Log().Info("模拟登录配置初始化完成")
+}
+
+// denyImpersonation 模拟登录期间禁止执行账号安全操作
+func denyImpersonation(ctx *BusinessContext, action string) ServiceError {
+	if ctx != nil && ctx.IsImpersonated() {
+		return &BusinessError{Message: "模拟登录期间不能" + action, Code: 40307}
+	}
+	return nil
+}
+
+// ImpersonationService 管理员模拟登录服务
+type ImpersonationService struct {
+	userRepo  repository.UserRepository
+	auditRepo repository.AuditLogRepository
+	rbac      *RBACService
+}
+
+// NewImpersonationService 创建模拟登录服务实例
+func NewImpersonationService(userRepo repository.UserRepository, auditRepo repository.AuditLogRepository, rbac *RBACService) *ImpersonationService {
+	return &ImpersonationService{
+		userRepo:  userRepo,
+		auditRepo: auditRepo,
+		rbac:      rbac,
+	}
+}
+
+// ImpersonateDTO 开始模拟请求DTO
+type ImpersonateDTO struct {
+	Reason string
+}
+
+// ImpersonationResult 模拟登录结果
+type ImpersonationResult struct {
+	User            *model.User
+	AccessToken     string
+	ExpiresIn       int64
+	ImpersonationID string
+}
+
+// Start 以指定用户的身份签发短期访问令牌，令牌的 act 声明记录实际操作的管理员
+// 不能模拟自己、非正常状态的用户或同样拥有模拟权限的用户，模拟期间也不能再次发起模拟
+func (s *ImpersonationService) Start(ctx *BusinessContext, userID uint, dto *ImpersonateDTO) (*ImpersonationResult, ServiceError) {
+	if serviceErr := requirePermission(ctx, model.PermissionUsersImpersonate); serviceErr != nil {
+		return nil, serviceErr
+	}
+	if ctx.IsDelegated() {
+		return nil, &BusinessError{Message: "模拟登录仅限交互式登录的管理员", Code: 40003}
+	}
+	if serviceErr := denyImpersonation(ctx, "再次模拟其他用户"); serviceErr != nil {
+		return nil, serviceErr
+	}
+	actorID, serviceErr := currentUserID(ctx)
+	if serviceErr != nil {
+		return nil, serviceErr
+	}
+	if actorID == userID {
+		return nil, &BusinessError{Message: "不能模拟自己", Code: 40003}
+	}
+	dto.Reason = strings.TrimSpace(dto.Reason)
+	if dto.Reason == "" {
+		return nil, &ValidationError{Message: "请填写原因", Code: 40000}
+	}
+
+	user, err := s.userRepo.FindByID(userID)
+	if err != nil {
+		return nil, &NotFoundError{Message: "用户不存在"}
+	}
+	if serviceErr := checkUserStatus(user); serviceErr != nil {
+		return nil, serviceErr
+	}
+	access, err := s.rbac.Access(user.ID)
+	if err != nil {
+		return nil, &DatabaseError{Message: "获取用户权限失败", Err: err}
+	}
+	if util.ContainsString(access.Permissions, model.PermissionUsersImpersonate) {
+		return nil, &BusinessError{Message: "不能模拟拥有模拟权限的用户", Code: 40003}
+	}
+
+	impersonationID := uuid.NewString()
+	accessToken, err := GenerateToken(JWTClaims{
+		UserID:       strconv.FormatUint(uint64(user.ID), 10),
+		TenantID:     user.TenantID,
+		JTI:          impersonationID,
+		TokenVersion: user.TokenVersion,
+		Actor:        &Actor{Subject: ctx.UserUUID},
+	}, AccessToken, Impersonation.TokenExpire)
+	if err != nil {
+		return nil, &BusinessError{Message: "生成模拟令牌失败", Code: 50000, Err: err}
+	}
+
+	// 审计记录写入失败时不下发令牌
+	if err := s.auditRepo.Create(&model.AuditLog{
+		ActorID:         actorID,
+		UserID:          user.ID,
+		ImpersonationID: impersonationID,
+		Action:          model.AuditActionImpersonationStart,
+		Detail:          dto.Reason,
+		ClientIP:        ctx.ClientIP,
+		UserAgent:       ctx.UserAgent,
+	}); err != nil {
+		return nil, &DatabaseError{Message: "写入审计日志失败", Err: err}
+	}
+
+	util.Log().Info("管理员开始模拟用户 user_id=%d operator=%d impersonation_id=%s reason=%q",
+		user.ID, actorID, impersonationID, dto.Reason)
+	return &ImpersonationResult{
+		User:            user,
+		AccessToken:     accessToken,
+		ExpiresIn:       int64(Impersonation.TokenExpire.Seconds()),
+		ImpersonationID: impersonationID,
+	}, nil
+}
+
+// VerifyActor 校验模拟令牌的实际操作者仍拥有模拟权限，权限被撤销后模拟令牌随即失效
+func (s *ImpersonationService) VerifyActor(claims *JWTClaims) ServiceError {
+	actorID, err := strconv.ParseUint(claims.Actor.Subject, 10, 64)
+	if err != nil || actorID == 0 {
+		return &AuthError{Message: "无效的模拟令牌"}
+	}
+	access, err := s.rbac.Access(uint(actorID))
+	if err != nil {
+		return &DatabaseError{Message: "获取用户权限失败", Err: err}
+	}
+	if !util.ContainsString(access.Permissions, model.PermissionUsersImpersonate) {
+		return &AuthError{Message: "模拟登录已失效"}
+	}
+	return nil
+}
+
+// AuditRequestDTO 模拟期间的请求记录
+type AuditRequestDTO struct {
+	Method string
+	Path   string
+	Status int
+}
+
+// RecordRequest 记录模拟登录期间的一次请求
+func (s *ImpersonationService) RecordRequest(ctx *BusinessContext, dto *AuditRequestDTO) ServiceError {
+	if !ctx.IsImpersonated() {
+		return nil
+	}
+	actorID, err := strconv.ParseUint(ctx.ActorUUID, 10, 64)
+	if err != nil {
+		return &AuthError{Message: "无效的模拟令牌"}
+	}
+	userID, serviceErr := currentUserID(ctx)
+	if serviceErr != nil {
+		return serviceErr
+	}
+	if err := s.auditRepo.Create(&model.AuditLog{
+		ActorID:         uint(actorID),
+		UserID:          userID,
+		ImpersonationID: ctx.Claims.JTI,
+		Action:          model.AuditActionImpersonationRequest,
+		Method:          dto.Method,
+		Path:            dto.Path,
+		Status:          dto.Status,
+		ClientIP:        ctx.ClientIP,
+		UserAgent:       ctx.UserAgent,
+	}); err != nil {
+		return &DatabaseError{Message: "写入审计日志失败", Err: err}
+	}
+	return nil
+}
+
+// SearchAuditLogsQuery 审计日志搜索参数
+type SearchAuditLogsQuery struct {
+	ActorID         uint
+	UserID          uint
+	ImpersonationID string
+	Page            int
+	PageSize        int
+}
+
+// ListAuditLogsResult 审计日志列表结果
+type ListAuditLogsResult struct {
+	List     []model.AuditLog
+	Total    int64
+	Page     int
+	PageSize int
+}
+
+// SearchAuditLogs 搜索审计日志
+func (s *ImpersonationService) SearchAuditLogs(ctx *BusinessContext, query *SearchAuditLogsQuery) (*ListAuditLogsResult, ServiceError) {
+	if serviceErr := requirePermission(ctx, model.PermissionUsersManage); serviceErr != nil {
+		return nil, serviceErr
+	}
+	if query.Page < 1 {
+		query.Page = 1
+	}
+	if query.PageSize < 1 || query.PageSize > 100 {
+		query.PageSize = 20
+	}
+
+	logs, total, err := s.auditRepo.Search(repository.AuditLogSearch{
+		ActorID:         query.ActorID,
+		UserID:          query.UserID,
+		ImpersonationID: strings.TrimSpace(query.ImpersonationID),
+		Page:            query.Page,
+		PageSize:        query.PageSize,
+	})
+	if err != nil {
+		return nil, &DatabaseError{Message: "查询审计日志失败", Err: err}
+	}
+
+	return &ListAuditLogsResult{
+		List:     logs,
+		Total:    total,
+		Page:     query.Page,
+		PageSize: query.PageSize,
+	}, nil
+}
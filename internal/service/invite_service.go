@@ -0,0 +1,155 @@
+package service
+
+import (
+	"errors"
+	"go-one/internal/model"
+	"go-one/internal/repository"
+	"go-one/util"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// inviteMaxUses 单个邀请码可设置的最大使用次数
+const inviteMaxUses = 1000
+
+// InviteService 注册邀请码管理服务
+type InviteService struct {
+	inviteRepo repository.InviteRepository
+}
+
+// NewInviteService 创建邀请码管理服务实例
+func NewInviteService(inviteRepo repository.InviteRepository) *InviteService {
+	return &InviteService{
+		inviteRepo: inviteRepo,
+	}
+}
+
+// CreateInviteDTO 签发邀请码请求DTO
+type CreateInviteDTO struct {
+	Note      string
+	MaxUses   int // 为 0 时按单次邀请码处理
+	ExpiresAt *time.Time
+}
+
+// CreateInviteResult 签发结果，Code 为邀请码明文，仅在签发时返回一次
+type CreateInviteResult struct {
+	Invite *model.Invite
+	Code   string
+}
+
+// CreateInvite 签发邀请码
+func (s *InviteService) CreateInvite(ctx *BusinessContext, dto *CreateInviteDTO) (*CreateInviteResult, ServiceError) {
+	if serviceErr := requirePermission(ctx, model.PermissionInvitesManage); serviceErr != nil {
+		return nil, serviceErr
+	}
+	userID, serviceErr := currentUserID(ctx)
+	if serviceErr != nil {
+		return nil, serviceErr
+	}
+
+	if dto.MaxUses == 0 {
+		dto.MaxUses = 1
+	}
+	if dto.MaxUses < 0 || dto.MaxUses > inviteMaxUses {
+		return nil, &ValidationError{Message: "使用次数须在 1 到 1000 之间", Code: 40000}
+	}
+	if dto.ExpiresAt != nil && !dto.ExpiresAt.After(time.Now()) {
+		return nil, &ValidationError{Message: "过期时间必须晚于当前时间", Code: 40000}
+	}
+
+	code, err := newInviteCode()
+	if err != nil {
+		return nil, &BusinessError{Message: "生成邀请码失败", Code: 50000, Err: err}
+	}
+	invite := &model.Invite{
+		Prefix:    code[:4],
+		CodeHash:  util.SHA256Hex(code),
+		Note:      strings.TrimSpace(dto.Note),
+		MaxUses:   dto.MaxUses,
+		ExpiresAt: dto.ExpiresAt,
+		CreatedBy: userID,
+	}
+	if err := s.inviteRepo.Create(invite); err != nil {
+		return nil, &DatabaseError{Message: "保存邀请码失败", Err: err}
+	}
+
+	util.Log().Info("签发注册邀请码 invite_id=%d max_uses=%d by user_id=%d", invite.ID, invite.MaxUses, userID)
+	return &CreateInviteResult{Invite: invite, Code: code}, nil
+}
+
+// ListInvitesQuery 邀请码列表查询参数
+type ListInvitesQuery struct {
+	Page     int
+	PageSize int
+}
+
+// ListInvitesResult 邀请码列表结果
+type ListInvitesResult struct {
+	List     []model.Invite
+	Total    int64
+	Page     int
+	PageSize int
+}
+
+// ListInvites 分页获取邀请码
+func (s *InviteService) ListInvites(ctx *BusinessContext, query *ListInvitesQuery) (*ListInvitesResult, ServiceError) {
+	if serviceErr := requirePermission(ctx, model.PermissionInvitesManage); serviceErr != nil {
+		return nil, serviceErr
+	}
+	if query.Page < 1 {
+		query.Page = 1
+	}
+	if query.PageSize < 1 || query.PageSize > 100 {
+		query.PageSize = 20
+	}
+
+	invites, total, err := s.inviteRepo.List(query.Page, query.PageSize)
+	if err != nil {
+		return nil, &DatabaseError{Message: "查询邀请码失败", Err: err}
+	}
+
+	return &ListInvitesResult{
+		List:     invites,
+		Total:    total,
+		Page:     query.Page,
+		PageSize: query.PageSize,
+	}, nil
+}
+
+// RevokeInvite 撤销邀请码，已注册的用户不受影响
+func (s *InviteService) RevokeInvite(ctx *BusinessContext, id uint) ServiceError {
+	if serviceErr := requirePermission(ctx, model.PermissionInvitesManage); serviceErr != nil {
+		return serviceErr
+	}
+	affected, err := s.inviteRepo.Revoke(id)
+	if err != nil {
+		return &DatabaseError{Message: "撤销邀请码失败", Err: err}
+	}
+	if affected == 0 {
+		return &NotFoundError{Message: "邀请码不存在或已撤销"}
+	}
+	util.Log().Info("撤销注册邀请码 invite_id=%d by user_id=%s", id, ctx.UserUUID)
+	return nil
+}
+
+// ListRedemptions 获取邀请码的使用记录
+func (s *InviteService) ListRedemptions(ctx *BusinessContext, id uint) ([]repository.InviteRedemptionDetail, ServiceError) {
+	if serviceErr := requirePermission(ctx, model.PermissionInvitesManage); serviceErr != nil {
+		return nil, serviceErr
+	}
+	// 先按租户确认邀请码存在，避免查看其他租户的使用记录
+	if _, err := s.inviteRepo.FindByID(id); err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, &NotFoundError{Message: "邀请码不存在"}
+		}
+		return nil, &DatabaseError{Message: "查询邀请码失败", Err: err}
+	}
+
+	redemptions, err := s.inviteRepo.ListRedemptions(id)
+	if err != nil {
+		return nil, &DatabaseError{Message: "查询邀请码使用记录失败", Err: err}
+	}
+	return redemptions, nil
+}
@@ -0,0 +1,152 @@
+package service
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"math/big"
+)
+
+// JWK JSON Web Key（RFC 7517），仅承载公钥参数
+type JWK struct {
+	Kty string `json:"kty"`
+	Kid string `json:"kid,omitempty"`
+	Use string `json:"use,omitempty"`
+	Alg string `json:"alg,omitempty"`
+	Crv string `json:"crv,omitempty"`
+	N   string `json:"n,omitempty"`
+	E   string `json:"e,omitempty"`
+	X   string `json:"x,omitempty"`
+	Y   string `json:"y,omitempty"`
+}
+
+// JWKSet JWK 集合（/.well-known/jwks.json 的响应格式）
+type JWKSet struct {
+	Keys []JWK `json:"keys"`
+}
+
+var b64 = base64.RawURLEncoding
+
+// NewJWK 由公钥构建 JWK（支持 RSA、ECDSA P-256/384/521、Ed25519）
+func NewJWK(pub crypto.PublicKey) (JWK, error) {
+	switch key := pub.(type) {
+	case *rsa.PublicKey:
+		return JWK{
+			Kty: "RSA",
+			N:   b64.EncodeToString(key.N.Bytes()),
+			E:   b64.EncodeToString(big.NewInt(int64(key.E)).Bytes()),
+		}, nil
+	case *ecdsa.PublicKey:
+		size := (key.Curve.Params().BitSize + 7) / 8
+		return JWK{
+			Kty: "EC",
+			Crv: key.Curve.Params().Name,
+			X:   b64.EncodeToString(key.X.FillBytes(make([]byte, size))),
+			Y:   b64.EncodeToString(key.Y.FillBytes(make([]byte, size))),
+		}, nil
+	case ed25519.PublicKey:
+		return JWK{
+			Kty: "OKP",
+			Crv: "Ed25519",
+			X:   b64.EncodeToString(key),
+		}, nil
+	default:
+		return JWK{}, fmt.Errorf("不支持的公钥类型 %T", pub)
+	}
+}
+
+// PublicKey 将 JWK 还原为公钥
+func (k JWK) PublicKey() (crypto.PublicKey, error) {
+	switch k.Kty {
+	case "RSA":
+		n, err := b64.DecodeString(k.N)
+		if err != nil {
+			return nil, fmt.Errorf("无效的 RSA 模数: %w", err)
+		}
+		e, err := b64.DecodeString(k.E)
+		if err != nil {
+			return nil, fmt.Errorf("无效的 RSA 指数: %w", err)
+		}
+		return &rsa.PublicKey{
+			N: new(big.Int).SetBytes(n),
+			E: int(new(big.Int).SetBytes(e).Int64()),
+		}, nil
+	case "EC":
+		var curve elliptic.Curve
+		switch k.Crv {
+		case "P-256":
+			curve = elliptic.P256()
+		case "P-384":
+			curve = elliptic.P384()
+		case "P-521":
+			curve = elliptic.P521()
+		default:
+			return nil, fmt.Errorf("不支持的椭圆曲线 %s", k.Crv)
+		}
+		x, err := b64.DecodeString(k.X)
+		if err != nil {
+			return nil, fmt.Errorf("无效的 EC 坐标: %w", err)
+		}
+		y, err := b64.DecodeString(k.Y)
+		if err != nil {
+			return nil, fmt.Errorf("无效的 EC 坐标: %w", err)
+		}
+		pub := &ecdsa.PublicKey{Curve: curve, X: new(big.Int).SetBytes(x), Y: new(big.Int).SetBytes(y)}
+		if !curve.IsOnCurve(pub.X, pub.Y) {
+			return nil, fmt.Errorf("EC 公钥不在曲线上")
+		}
+		return pub, nil
+	case "OKP":
+		if k.Crv != "Ed25519" {
+			return nil, fmt.Errorf("不支持的 OKP 曲线 %s", k.Crv)
+		}
+		x, err := b64.DecodeString(k.X)
+		if err != nil || len(x) != ed25519.PublicKeySize {
+			return nil, fmt.Errorf("无效的 Ed25519 公钥")
+		}
+		return ed25519.PublicKey(x), nil
+	default:
+		return nil, fmt.Errorf("不支持的密钥类型 %s", k.Kty)
+	}
+}
+
+// Thumbprint 计算 JWK 指纹（RFC 7638，SHA-256，base64url）
+func (k JWK) Thumbprint() (string, error) {
+	// 仅包含必需成员，且按字典序排列
+	var members interface{}
+	switch k.Kty {
+	case "RSA":
+		members = struct {
+			E   string `json:"e"`
+			Kty string `json:"kty"`
+			N   string `json:"n"`
+		}{k.E, k.Kty, k.N}
+	case "EC":
+		members = struct {
+			Crv string `json:"crv"`
+			Kty string `json:"kty"`
+			X   string `json:"x"`
+			Y   string `json:"y"`
+		}{k.Crv, k.Kty, k.X, k.Y}
+	case "OKP":
+		members = struct {
+			Crv string `json:"crv"`
+			Kty string `json:"kty"`
+			X   string `json:"x"`
+		}{k.Crv, k.Kty, k.X}
+	default:
+		return "", fmt.Errorf("不支持的密钥类型 %s", k.Kty)
+	}
+	raw, err := json.Marshal(members)
+	if err != nil {
+		return "", err
+	}
+	sum := sha256.Sum256(raw)
+	return b64.EncodeToString(sum[:]), nil
+}
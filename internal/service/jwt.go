@@ -1,18 +1,21 @@
 package service
 
 import (
-    "fmt"
-    "go-one/util"
-    "os"
-    "strconv"
-    "time"
-
-    "github.com/golang-jwt/jwt/v5"
+	"fmt"
+	"go-one/util"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
 )
 
 // JWTConfig JWT配置
 type JWTConfig struct {
 	Secret             string
+	Algorithm          string
+	Keys               *KeyRing
 	AccessTokenExpire  time.Duration
 	RefreshTokenExpire time.Duration
 }
@@ -25,22 +28,101 @@ type TokenType string
 const (
 	AccessToken  TokenType = "access"
 	RefreshToken TokenType = "refresh"
+	// MFAPendingToken 第一因素通过、等待二次验证的临时令牌
+	MFAPendingToken TokenType = "mfa_pending"
+	// TrustedDeviceToken “记住此设备”令牌，持有者登录时可跳过二次验证
+	TrustedDeviceToken TokenType = "mfa_device"
+	// EmailVerifyToken 邮箱验证令牌
+	EmailVerifyToken TokenType = "email_verify"
+	// MagicLinkToken 邮件链接免密登录令牌
+	MagicLinkToken TokenType = "magic_link"
+	// DataExportToken 用户数据导出归档的下载令牌
+	DataExportToken TokenType = "data_export"
+	// ClientAccessToken OAuth client_credentials 授权签发的应用令牌（无用户，不能访问本服务接口）
+	ClientAccessToken TokenType = "client_access"
+	// APIKeyCredential 使用 API 密钥认证时 BusinessContext 中的凭证类型（不签发为 JWT）
+	APIKeyCredential TokenType = "api_key"
 )
 
 // JWTClaims JWT声明
 type JWTClaims struct {
-    UserID    string    `json:"user_id"`
-    TokenType TokenType `json:"token_type"`
-    JTI       string    `json:"jti,omitempty"`
-    jwt.RegisteredClaims
+	UserID       string        `json:"user_id"`
+	TenantID     uint          `json:"tid,omitempty"` // 用户所属租户
+	TokenType    TokenType     `json:"token_type"`
+	JTI          string        `json:"jti,omitempty"`
+	SessionID    string        `json:"sid,omitempty"`
+	TokenVersion int           `json:"ver"`                 // 签发时的用户令牌版本，与当前版本不一致即失效
+	Email        string        `json:"email,omitempty"`     // 邮箱验证令牌绑定的邮箱
+	Nonce        string        `json:"nonce,omitempty"`     // 登录链接绑定的客户端 Nonce 哈希
+	ClientID     string        `json:"client_id,omitempty"` // 签发给 OAuth 应用的令牌所属 client_id
+	Scope        string        `json:"scope,omitempty"`     // OAuth 授权范围（空格分隔）
+	Confirmation *Confirmation `json:"cnf,omitempty"`       // DPoP 绑定的公钥指纹
+	Actor        *Actor        `json:"act,omitempty"`       // 模拟登录时实际操作的管理员
+	jwt.RegisteredClaims
+}
+
+// Tenant 令牌所属租户；租户上线前签发、不含 tid 的令牌属于默认租户
+func (c *JWTClaims) Tenant() uint {
+	if c.TenantID != 0 {
+		return c.TenantID
+	}
+	return Tenancy.DefaultID
+}
+
+// Confirmation 令牌持有证明声明（RFC 9449 6.1）
+type Confirmation struct {
+	JKT string `json:"jkt"`
+}
+
+// Actor 代理声明（RFC 8693 4.1），Subject 为实际操作者的用户ID
+type Actor struct {
+	Subject string `json:"sub"`
+}
+
+// BoundKey 令牌绑定的 DPoP 公钥指纹，未绑定时为空
+func (c *JWTClaims) BoundKey() string {
+	if c.Confirmation == nil {
+		return ""
+	}
+	return c.Confirmation.JKT
+}
+
+// newConfirmation 按公钥指纹构建 cnf 声明，未绑定时为 nil
+func newConfirmation(jkt string) *Confirmation {
+	if jkt == "" {
+		return nil
+	}
+	return &Confirmation{JKT: jkt}
 }
 
 // InitJWT 初始化JWT配置
 func InitJWT() {
+	algorithm := strings.TrimSpace(os.Getenv("JWT_ALGORITHM"))
+	if algorithm == "" {
+		algorithm = jwt.SigningMethodHS256.Alg()
+	}
+
 	secret := os.Getenv("JWT_SECRET")
-	if secret == "" {
-		util.Log().Warning("JWT_SECRET 未配置，使用默认值（不安全）")
-		secret = "default_jwt_secret_key"
+	var keys *KeyRing
+	if algorithm == jwt.SigningMethodHS256.Alg() {
+		if secret == "" {
+			util.Log().Warning("JWT_SECRET 未配置，使用默认值（不安全）")
+			secret = "default_jwt_secret_key"
+		}
+		keys = NewHMACKeyRing(secret)
+	} else {
+		// 非对称算法：当前签名私钥 + 轮换期间仍需验签的历史密钥
+		var verifyKeyFiles []string
+		for _, file := range strings.Split(os.Getenv("JWT_VERIFY_KEY_FILES"), ",") {
+			if file = strings.TrimSpace(file); file != "" {
+				verifyKeyFiles = append(verifyKeyFiles, file)
+			}
+		}
+		loaded, err := LoadKeyRing(algorithm, os.Getenv("JWT_PRIVATE_KEY_FILE"), os.Getenv("JWT_KEY_ID"), verifyKeyFiles)
+		if err != nil {
+			util.Log().Panic("加载JWT密钥失败: %v", err)
+		}
+		keys = loaded
 	}
 
 	accessExpire := int64(3600) // 默认1小时
@@ -59,44 +141,42 @@ func InitJWT() {
 
 	JWT = &JWTConfig{
 		Secret:             secret,
+		Algorithm:          algorithm,
+		Keys:               keys,
 		AccessTokenExpire:  time.Duration(accessExpire) * time.Second,
 		RefreshTokenExpire: time.Duration(refreshExpire) * time.Second,
 	}
 
-	util.Log().Info("JWT配置初始化完成")
+	util.Log().Info("JWT配置初始化完成，签名算法: %s", algorithm)
 }
 
-// GenerateAccessToken 生成访问令牌（包含完整用户信息）
-func GenerateAccessToken(userID string) (string, error) {
-    claims := JWTClaims{
-        UserID:    userID,
-        TokenType: AccessToken,
-        RegisteredClaims: jwt.RegisteredClaims{
-            ExpiresAt: jwt.NewNumericDate(time.Now().Add(JWT.AccessTokenExpire)),
-            IssuedAt:  jwt.NewNumericDate(time.Now()),
-            NotBefore: jwt.NewNumericDate(time.Now()),
-        },
-    }
+// GenerateAccessToken 生成访问令牌
+// claims 中的用户、会话等业务字段由调用方填充，类型与时间字段在此统一设置
+func GenerateAccessToken(claims JWTClaims) (string, error) {
+	return GenerateToken(claims, AccessToken, JWT.AccessTokenExpire)
+}
 
-    token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
-    return token.SignedString([]byte(JWT.Secret))
+// GenerateRefreshToken 生成刷新令牌（用户ID、JTI与会话ID）
+func GenerateRefreshToken(claims JWTClaims) (string, error) {
+	return GenerateToken(claims, RefreshToken, JWT.RefreshTokenExpire)
 }
 
-// GenerateRefreshToken 生成刷新令牌（仅包含用户ID）
-func GenerateRefreshToken(userID string, jti string) (string, error) {
-    claims := JWTClaims{
-        UserID:    userID,
-        TokenType: RefreshToken,
-        JTI:       jti,
-        RegisteredClaims: jwt.RegisteredClaims{
-            ExpiresAt: jwt.NewNumericDate(time.Now().Add(JWT.RefreshTokenExpire)),
-            IssuedAt:  jwt.NewNumericDate(time.Now()),
-            NotBefore: jwt.NewNumericDate(time.Now()),
-        },
-    }
+// GenerateToken 生成指定类型与有效期的令牌
+func GenerateToken(claims JWTClaims, tokenType TokenType, expire time.Duration) (string, error) {
+	claims.TokenType = tokenType
+	claims.RegisteredClaims = newRegisteredClaims(expire)
+
+	return JWT.Keys.Sign(claims)
+}
 
-    token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
-    return token.SignedString([]byte(JWT.Secret))
+// newRegisteredClaims 构建以当前时间为起点的标准声明
+func newRegisteredClaims(expire time.Duration) jwt.RegisteredClaims {
+	now := time.Now()
+	return jwt.RegisteredClaims{
+		ExpiresAt: jwt.NewNumericDate(now.Add(expire)),
+		IssuedAt:  jwt.NewNumericDate(now),
+		NotBefore: jwt.NewNumericDate(now),
+	}
 }
 
 // GenerateTokenPair 生成token对（access + refresh）
@@ -104,9 +184,8 @@ func GenerateRefreshToken(userID string, jti string) (string, error) {
 
 // ParseJWT 解析JWT token
 func ParseJWT(tokenString string) (*JWTClaims, error) {
-	token, err := jwt.ParseWithClaims(tokenString, &JWTClaims{}, func(token *jwt.Token) (interface{}, error) {
-		return []byte(JWT.Secret), nil
-	})
+	token, err := jwt.ParseWithClaims(tokenString, &JWTClaims{}, JWT.Keys.Keyfunc,
+		jwt.WithValidMethods(JWT.Keys.ValidMethods()))
 
 	if err != nil {
 		return nil, err
@@ -121,27 +200,23 @@ func ParseJWT(tokenString string) (*JWTClaims, error) {
 
 // ValidateAccessToken 验证访问令牌
 func ValidateAccessToken(tokenString string) (*JWTClaims, error) {
-	claims, err := ParseJWT(tokenString)
-	if err != nil {
-		return nil, err
-	}
-
-	if claims.TokenType != AccessToken {
-		return nil, fmt.Errorf("invalid token type: expected access, got %s", claims.TokenType)
-	}
-
-	return claims, nil
+	return ValidateToken(tokenString, AccessToken)
 }
 
 // ValidateRefreshToken 验证刷新令牌
 func ValidateRefreshToken(tokenString string) (*JWTClaims, error) {
+	return ValidateToken(tokenString, RefreshToken)
+}
+
+// ValidateToken 验证令牌签名、有效期与类型
+func ValidateToken(tokenString string, expected TokenType) (*JWTClaims, error) {
 	claims, err := ParseJWT(tokenString)
 	if err != nil {
 		return nil, err
 	}
 
-	if claims.TokenType != RefreshToken {
-		return nil, fmt.Errorf("invalid token type: expected refresh, got %s", claims.TokenType)
+	if claims.TokenType != expected {
+		return nil, fmt.Errorf("invalid token type: expected %s, got %s", expected, claims.TokenType)
 	}
 
 	return claims, nil
@@ -0,0 +1,232 @@
+package service
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/pem"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// SigningKey 密钥环中的一把密钥
+type SigningKey struct {
+	ID      string
+	Method  jwt.SigningMethod
+	private interface{} // 签名密钥：HMAC 为 []byte，非对称为私钥；仅用于验签的密钥为 nil
+	public  interface{} // 验签密钥：HMAC 为 []byte，非对称为公钥
+}
+
+// KeyRing JWT 密钥环：一把当前签名密钥 + 多把可用于验签的密钥
+// 轮换时将旧密钥加入验签列表，待其签发的令牌全部过期后再移除，实现无停机轮换
+type KeyRing struct {
+	signing *SigningKey
+	keys    map[string]*SigningKey
+	methods []string
+}
+
+// NewHMACKeyRing 创建 HS256 密钥环（单一共享密钥，不对外发布）
+func NewHMACKeyRing(secret string) *KeyRing {
+	key := &SigningKey{
+		Method:  jwt.SigningMethodHS256,
+		private: []byte(secret),
+		public:  []byte(secret),
+	}
+	return &KeyRing{
+		signing: key,
+		keys:    map[string]*SigningKey{},
+		methods: []string{key.Method.Alg()},
+	}
+}
+
+// LoadKeyRing 从 PEM 文件加载非对称密钥环
+// privateKeyFile 为当前签名私钥；verifyKeyFiles 为仍需验签的历史密钥（公钥或私钥均可）
+// keyID 为空时使用 RFC 7638 指纹作为 kid；历史密钥可写作 kid=path，沿用其签名时配置的 kid
+func LoadKeyRing(algorithm, privateKeyFile, keyID string, verifyKeyFiles []string) (*KeyRing, error) {
+	signing, err := loadPEMKey(privateKeyFile)
+	if err != nil {
+		return nil, fmt.Errorf("加载签名私钥失败: %w", err)
+	}
+	if signing.private == nil {
+		return nil, fmt.Errorf("%s 不包含私钥", privateKeyFile)
+	}
+	if signing.Method.Alg() != algorithm {
+		return nil, fmt.Errorf("签名私钥类型与 JWT_ALGORITHM 不匹配: 期望 %s，实际 %s", algorithm, signing.Method.Alg())
+	}
+	if keyID != "" {
+		signing.ID = keyID
+	}
+
+	ring := &KeyRing{
+		signing: signing,
+		keys:    map[string]*SigningKey{signing.ID: signing},
+	}
+	for _, entry := range verifyKeyFiles {
+		file := entry
+		kid, path, hasKid := strings.Cut(entry, "=")
+		if hasKid {
+			file = path
+		}
+		key, err := loadPEMKey(file)
+		if err != nil {
+			return nil, fmt.Errorf("加载验签密钥 %s 失败: %w", file, err)
+		}
+		if hasKid && kid != "" {
+			key.ID = kid
+		}
+		// 历史密钥只用于验签
+		key.private = nil
+		if _, exists := ring.keys[key.ID]; !exists {
+			ring.keys[key.ID] = key
+		}
+	}
+	seen := map[string]bool{}
+	for _, key := range ring.keys {
+		if alg := key.Method.Alg(); !seen[alg] {
+			seen[alg] = true
+			ring.methods = append(ring.methods, alg)
+		}
+	}
+	return ring, nil
+}
+
+// Sign 使用当前签名密钥签发令牌，非对称密钥会写入 kid 头
+func (kr *KeyRing) Sign(claims jwt.Claims) (string, error) {
+	token := jwt.NewWithClaims(kr.signing.Method, claims)
+	if kr.signing.ID != "" {
+		token.Header["kid"] = kr.signing.ID
+	}
+	return token.SignedString(kr.signing.private)
+}
+
+// Keyfunc 根据令牌头中的 kid 与 alg 选择验签密钥
+func (kr *KeyRing) Keyfunc(token *jwt.Token) (interface{}, error) {
+	key := kr.signing
+	if kid, ok := token.Header["kid"].(string); ok && kid != "" {
+		found, exists := kr.keys[kid]
+		if !exists {
+			return nil, fmt.Errorf("未知的密钥ID: %s", kid)
+		}
+		key = found
+	} else if key.ID != "" {
+		return nil, fmt.Errorf("令牌缺少 kid")
+	}
+	// 防止算法混淆：令牌声明的算法必须与密钥一致
+	if token.Method.Alg() != key.Method.Alg() {
+		return nil, fmt.Errorf("令牌算法 %s 与密钥不匹配", token.Method.Alg())
+	}
+	return key.public, nil
+}
+
+// ValidMethods 返回密钥环接受的签名算法
+func (kr *KeyRing) ValidMethods() []string {
+	return kr.methods
+}
+
+// PublicJWKS 返回全部非对称验签公钥（HMAC 密钥不会发布）
+func (kr *KeyRing) PublicJWKS() JWKSet {
+	set := JWKSet{Keys: []JWK{}}
+	ordered := make([]*SigningKey, 0, len(kr.keys))
+	for _, key := range kr.keys {
+		ordered = append(ordered, key)
+	}
+	// 当前签名密钥在前，其余按 kid 排序，保证输出稳定
+	sort.Slice(ordered, func(i, j int) bool {
+		if (ordered[i] == kr.signing) != (ordered[j] == kr.signing) {
+			return ordered[i] == kr.signing
+		}
+		return ordered[i].ID < ordered[j].ID
+	})
+	for _, key := range ordered {
+		jwk, err := NewJWK(key.public)
+		if err != nil {
+			continue
+		}
+		jwk.Kid = key.ID
+		jwk.Use = "sig"
+		jwk.Alg = key.Method.Alg()
+		set.Keys = append(set.Keys, jwk)
+	}
+	return set
+}
+
+// loadPEMKey 读取 PEM 文件中的私钥或公钥，并推导签名算法与 kid
+func loadPEMKey(path string) (*SigningKey, error) {
+	data, err := os.ReadFile(strings.TrimSpace(path))
+	if err != nil {
+		return nil, err
+	}
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, fmt.Errorf("%s 不是有效的 PEM 文件", path)
+	}
+
+	var private interface{}
+	var public crypto.PublicKey
+	switch block.Type {
+	case "PRIVATE KEY":
+		private, err = x509.ParsePKCS8PrivateKey(block.Bytes)
+	case "RSA PRIVATE KEY":
+		private, err = x509.ParsePKCS1PrivateKey(block.Bytes)
+	case "EC PRIVATE KEY":
+		private, err = x509.ParseECPrivateKey(block.Bytes)
+	case "PUBLIC KEY":
+		public, err = x509.ParsePKIXPublicKey(block.Bytes)
+	case "RSA PUBLIC KEY":
+		public, err = x509.ParsePKCS1PublicKey(block.Bytes)
+	default:
+		return nil, fmt.Errorf("不支持的 PEM 类型 %s", block.Type)
+	}
+	if err != nil {
+		return nil, err
+	}
+	if private != nil {
+		signer, ok := private.(crypto.Signer)
+		if !ok {
+			return nil, fmt.Errorf("不支持的私钥类型 %T", private)
+		}
+		public = signer.Public()
+	}
+
+	method, err := signingMethodFor(public)
+	if err != nil {
+		return nil, err
+	}
+	jwk, err := NewJWK(public)
+	if err != nil {
+		return nil, err
+	}
+	kid, err := jwk.Thumbprint()
+	if err != nil {
+		return nil, err
+	}
+	return &SigningKey{ID: kid, Method: method, private: private, public: public}, nil
+}
+
+// signingMethodFor 根据公钥类型推导签名算法
+func signingMethodFor(pub crypto.PublicKey) (jwt.SigningMethod, error) {
+	switch key := pub.(type) {
+	case *rsa.PublicKey:
+		return jwt.SigningMethodRS256, nil
+	case *ecdsa.PublicKey:
+		switch key.Curve.Params().BitSize {
+		case 256:
+			return jwt.SigningMethodES256, nil
+		case 384:
+			return jwt.SigningMethodES384, nil
+		case 521:
+			return jwt.SigningMethodES512, nil
+		}
+		return nil, fmt.Errorf("不支持的椭圆曲线 %s", key.Curve.Params().Name)
+	case ed25519.PublicKey:
+		return jwt.SigningMethodEdDSA, nil
+	default:
+		return nil, fmt.Errorf("不支持的公钥类型 %T", pub)
+	}
+}
@@ -0,0 +1,302 @@
+package service
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/pem"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// writePrivateKey 将私钥以 PKCS#8 PEM 写入临时目录，返回文件路径
+func writePrivateKey(t *testing.T, name string, key crypto.Signer) string {
+	t.Helper()
+	der, err := x509.MarshalPKCS8PrivateKey(key)
+	if err != nil {
+		t.Fatalf("编码私钥失败: %v", err)
+	}
+	path := filepath.Join(t.TempDir(), name)
+	if err := os.WriteFile(path, pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: der}), 0o600); err != nil {
+		t.Fatalf("写入私钥失败: %v", err)
+	}
+	return path
+}
+
+// writePublicKey 将公钥以 PKIX PEM 写入临时目录，返回文件路径
+func writePublicKey(t *testing.T, name string, key crypto.PublicKey) string {
+	t.Helper()
+	der, err := x509.MarshalPKIXPublicKey(key)
+	if err != nil {
+		t.Fatalf("编码公钥失败: %v", err)
+	}
+	path := filepath.Join(t.TempDir(), name)
+	if err := os.WriteFile(path, pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: der}), 0o600); err != nil {
+		t.Fatalf("写入公钥失败: %v", err)
+	}
+	return path
+}
+
+func mustRSAKey(t *testing.T) *rsa.PrivateKey {
+	t.Helper()
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatalf("生成 RSA 密钥失败: %v", err)
+	}
+	return key
+}
+
+func mustECKey(t *testing.T, curve elliptic.Curve) *ecdsa.PrivateKey {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(curve, rand.Reader)
+	if err != nil {
+		t.Fatalf("生成 EC 密钥失败: %v", err)
+	}
+	return key
+}
+
+func mustEd25519Key(t *testing.T) ed25519.PrivateKey {
+	t.Helper()
+	_, key, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatalf("生成 Ed25519 密钥失败: %v", err)
+	}
+	return key
+}
+
+// parseWithRing 按服务端 ParseJWT 的方式用密钥环校验令牌
+func parseWithRing(ring *KeyRing, token string) (*JWTClaims, error) {
+	claims := &JWTClaims{}
+	_, err := jwt.ParseWithClaims(token, claims, ring.Keyfunc, jwt.WithValidMethods(ring.ValidMethods()))
+	return claims, err
+}
+
+func TestKeyRingSignVerify(t *testing.T) {
+	tests := []struct {
+		name string
+		alg  string
+		key  crypto.Signer
+	}{
+		{name: "RS256", alg: "RS256", key: mustRSAKey(t)},
+		{name: "ES256", alg: "ES256", key: mustECKey(t, elliptic.P256())},
+		{name: "ES384", alg: "ES384", key: mustECKey(t, elliptic.P384())},
+		{name: "ES512", alg: "ES512", key: mustECKey(t, elliptic.P521())},
+		{name: "EdDSA", alg: "EdDSA", key: mustEd25519Key(t)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ring, err := LoadKeyRing(tt.alg, writePrivateKey(t, "signing.pem", tt.key), "", nil)
+			if err != nil {
+				t.Fatalf("LoadKeyRing: %v", err)
+			}
+			signed, err := ring.Sign(JWTClaims{UserID: "42", TokenType: AccessToken, RegisteredClaims: newRegisteredClaims(time.Minute)})
+			if err != nil {
+				t.Fatalf("Sign: %v", err)
+			}
+
+			token, _, err := jwt.NewParser().ParseUnverified(signed, &JWTClaims{})
+			if err != nil {
+				t.Fatalf("ParseUnverified: %v", err)
+			}
+			if got := token.Method.Alg(); got != tt.alg {
+				t.Errorf("alg = %s, want %s", got, tt.alg)
+			}
+			if kid, _ := token.Header["kid"].(string); kid == "" {
+				t.Error("非对称密钥签发的令牌应携带 kid")
+			}
+
+			claims, err := parseWithRing(ring, signed)
+			if err != nil {
+				t.Fatalf("校验失败: %v", err)
+			}
+			if claims.UserID != "42" {
+				t.Errorf("UserID = %q, want 42", claims.UserID)
+			}
+
+			// JWKS 发布的公钥可独立验签
+			jwks := ring.PublicJWKS()
+			if len(jwks.Keys) != 1 || jwks.Keys[0].Alg != tt.alg || jwks.Keys[0].Use != "sig" {
+				t.Fatalf("PublicJWKS = %+v", jwks)
+			}
+			pub, err := jwks.Keys[0].PublicKey()
+			if err != nil {
+				t.Fatalf("JWK.PublicKey: %v", err)
+			}
+			if _, err := jwt.Parse(signed, func(*jwt.Token) (interface{}, error) { return pub, nil }); err != nil {
+				t.Errorf("JWKS 公钥验签失败: %v", err)
+			}
+		})
+	}
+}
+
+func TestKeyRingHMAC(t *testing.T) {
+	ring := NewHMACKeyRing("test-secret")
+	signed, err := ring.Sign(JWTClaims{UserID: "7", RegisteredClaims: newRegisteredClaims(time.Minute)})
+	if err != nil {
+		t.Fatalf("Sign: %v", err)
+	}
+	if _, err := parseWithRing(ring, signed); err != nil {
+		t.Fatalf("校验失败: %v", err)
+	}
+	if _, err := parseWithRing(NewHMACKeyRing("other-secret"), signed); err == nil {
+		t.Error("不同密钥签发的令牌应校验失败")
+	}
+	if keys := ring.PublicJWKS().Keys; len(keys) != 0 {
+		t.Errorf("HMAC 密钥不应发布到 JWKS: %+v", keys)
+	}
+}
+
+func TestKeyRingRotation(t *testing.T) {
+	oldKey := mustECKey(t, elliptic.P256())
+	customKey := mustECKey(t, elliptic.P256())
+	newKey := mustECKey(t, elliptic.P256())
+
+	oldRing, err := LoadKeyRing("ES256", writePrivateKey(t, "old.pem", oldKey), "", nil)
+	if err != nil {
+		t.Fatalf("LoadKeyRing(old): %v", err)
+	}
+	customRing, err := LoadKeyRing("ES256", writePrivateKey(t, "custom.pem", customKey), "2026-04", nil)
+	if err != nil {
+		t.Fatalf("LoadKeyRing(custom): %v", err)
+	}
+	// 轮换：新私钥签名，旧密钥（公钥即可）继续验签；曾以自定义 kid 签名的密钥以 kid=path 沿用原 kid
+	customPub := writePublicKey(t, "custom.pub.pem", customKey.Public())
+	rotated, err := LoadKeyRing("ES256", writePrivateKey(t, "new.pem", newKey), "2026-10",
+		[]string{writePublicKey(t, "old.pub.pem", oldKey.Public()), "2026-04=" + customPub})
+	if err != nil {
+		t.Fatalf("LoadKeyRing(rotated): %v", err)
+	}
+	// 未标注 kid 时历史密钥按指纹发布，与其签发时的 kid 不一致
+	unlabeled, err := LoadKeyRing("ES256", writePrivateKey(t, "new2.pem", newKey), "2026-10", []string{customPub})
+	if err != nil {
+		t.Fatalf("LoadKeyRing(unlabeled): %v", err)
+	}
+
+	claims := JWTClaims{UserID: "1", RegisteredClaims: newRegisteredClaims(time.Minute)}
+	issuedBefore, err := oldRing.Sign(claims)
+	if err != nil {
+		t.Fatalf("Sign(old): %v", err)
+	}
+	issuedCustom, err := customRing.Sign(claims)
+	if err != nil {
+		t.Fatalf("Sign(custom): %v", err)
+	}
+	issuedAfter, err := rotated.Sign(claims)
+	if err != nil {
+		t.Fatalf("Sign(rotated): %v", err)
+	}
+
+	tests := []struct {
+		name    string
+		ring    *KeyRing
+		token   string
+		wantErr bool
+	}{
+		{name: "轮换前签发的令牌在轮换后仍可验证", ring: rotated, token: issuedBefore},
+		{name: "自定义 kid 签发的令牌在轮换后仍可验证", ring: rotated, token: issuedCustom},
+		{name: "轮换后签发的令牌", ring: rotated, token: issuedAfter},
+		{name: "旧密钥环不认识新 kid", ring: oldRing, token: issuedAfter, wantErr: true},
+		{name: "历史密钥未标注自定义 kid", ring: unlabeled, token: issuedCustom, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := parseWithRing(tt.ring, tt.token)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("err = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+
+	token, _, _ := jwt.NewParser().ParseUnverified(issuedAfter, &JWTClaims{})
+	if kid := token.Header["kid"]; kid != "2026-10" {
+		t.Errorf("kid = %v, want 配置的 JWT_KEY_ID", kid)
+	}
+	keys := rotated.PublicJWKS().Keys
+	if len(keys) != 3 || keys[0].Kid != "2026-10" {
+		t.Fatalf("PublicJWKS 应先列出当前签名密钥: %+v", keys)
+	}
+	if keys[1].Kid != "2026-04" && keys[2].Kid != "2026-04" {
+		t.Errorf("自定义 kid 的历史密钥应按原 kid 发布: %+v", keys)
+	}
+}
+
+func TestKeyRingRejects(t *testing.T) {
+	key := mustRSAKey(t)
+	ring, err := LoadKeyRing("RS256", writePrivateKey(t, "signing.pem", key), "", nil)
+	if err != nil {
+		t.Fatalf("LoadKeyRing: %v", err)
+	}
+	claims := JWTClaims{UserID: "1", RegisteredClaims: newRegisteredClaims(time.Minute)}
+
+	noKid, err := jwt.NewWithClaims(jwt.SigningMethodRS256, claims).SignedString(key)
+	if err != nil {
+		t.Fatalf("签发无 kid 令牌失败: %v", err)
+	}
+	unknownKid := jwt.NewWithClaims(jwt.SigningMethodRS256, claims)
+	unknownKid.Header["kid"] = "unknown"
+	unknownKidToken, err := unknownKid.SignedString(key)
+	if err != nil {
+		t.Fatalf("签发未知 kid 令牌失败: %v", err)
+	}
+	// 算法混淆：以 RSA 公钥的 PEM 作为 HMAC 密钥
+	pubPEM, _ := os.ReadFile(writePublicKey(t, "signing.pub.pem", key.Public()))
+	confused := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
+	confused.Header["kid"] = ring.signing.ID
+	confusedToken, err := confused.SignedString(pubPEM)
+	if err != nil {
+		t.Fatalf("签发 HS256 令牌失败: %v", err)
+	}
+	noneToken, err := jwt.NewWithClaims(jwt.SigningMethodNone, claims).SignedString(jwt.UnsafeAllowNoneSignatureType)
+	if err != nil {
+		t.Fatalf("签发 none 令牌失败: %v", err)
+	}
+
+	tests := []struct {
+		name  string
+		token string
+	}{
+		{name: "缺少 kid", token: noKid},
+		{name: "未知 kid", token: unknownKidToken},
+		{name: "HS256 算法混淆", token: confusedToken},
+		{name: "alg none", token: noneToken},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := parseWithRing(ring, tt.token); err == nil {
+				t.Error("应拒绝该令牌")
+			}
+		})
+	}
+
+	if _, err := LoadKeyRing("ES256", writePrivateKey(t, "rsa.pem", key), "", nil); err == nil {
+		t.Error("私钥类型与 JWT_ALGORITHM 不匹配时应报错")
+	}
+}
+
+func TestJWKThumbprint(t *testing.T) {
+	// RFC 7638 3.1 示例
+	jwk := JWK{
+		Kty: "RSA",
+		N: "0vx7agoebGcQSuuPiLJXZptN9nndrQmbXEps2aiAFbWhM78LhWx4cbbfAAtVT86zwu1RK7aPFFxuhDR1L6tSoc_BJECPebWKRXjBZCiFV4n3oknjhMstn64tZ_2W" +
+			"-5JsGY4Hc5n9yBXArwl93lqt7_RN5w6Cf0h4QyQ5v-65YGjQR0_FDW2QvzqY368QQMicAtaSqzs8KJZgnYb9c7d0zgdAZHzu6qMQvRL5hajrn1n91CbOpbIS" +
+			"D08qNLyrdkt-bFTWhAI4vMQFh6WeZu0fM4lFd2NcRwr3XPksINHaQ-G_xBniIqbw0Ls1jF44-csFCur-kEgU8awapJzKnqDKgw",
+		E:   "AQAB",
+		Kid: "2011-04-29",
+		Alg: "RS256",
+	}
+	got, err := jwk.Thumbprint()
+	if err != nil {
+		t.Fatalf("Thumbprint: %v", err)
+	}
+	if want := "NzbLsXh8uDCcd-6MNwXF4W_7noWXFZAfHkxZsRGC9Xs"; got != want {
+		t.Errorf("Thumbprint = %s, want %s", got, want)
+	}
+}
@@ -0,0 +1,159 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"go-one/internal/cache"
+	"go-one/util"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// LoginGuardConfig 登录防爆破配置
+type LoginGuardConfig struct {
+	BackoffThreshold int64         // 同一 IP+用户名连续失败达到该次数后开始递增延迟
+	BaseDelay        time.Duration // 首次延迟，之后每次失败翻倍
+	MaxDelay         time.Duration // 延迟上限
+	LockThreshold    int64         // 同一用户名（不区分 IP）失败达到该次数后锁定
+	LockDuration     time.Duration // 锁定时长
+	Window           time.Duration // 失败计数的统计窗口（最后一次失败后开始计时）
+}
+
+var LoginProtection *LoginGuardConfig
+
+const (
+	// loginFailKey 用户名维度失败次数
+	loginFailKey = "login:fail:%s"
+	// loginFailIPKey IP+用户名维度失败次数（hash：ip -> 次数）
+	loginFailIPKey = "login:fail_ip:%s"
+	// loginDelayKey IP+用户名维度下次允许尝试的时间（hash：ip -> unix 毫秒）
+	loginDelayKey = "login:delay:%s"
+	// loginLockKey 用户名锁定标记
+	loginLockKey = "login:lock:%s"
+)
+
+// InitLoginGuard 初始化登录防爆破配置
+func InitLoginGuard() {
+	LoginProtection = &LoginGuardConfig{
+		BackoffThreshold: envInt64("LOGIN_BACKOFF_THRESHOLD", 3),
+		BaseDelay:        time.Second,
+		MaxDelay:         time.Minute,
+		LockThreshold:    envInt64("LOGIN_LOCK_THRESHOLD", 10),
+		LockDuration:     time.Duration(envInt64("LOGIN_LOCK_DURATION", 900)) * time.Second,
+		Window:           time.Duration(envInt64("LOGIN_FAILURE_WINDOW", 900)) * time.Second,
+	}
+
+	util.Log().Info("登录防爆破配置初始化完成")
+}
+
+// envInt64 读取整型环境变量，未配置或非法时返回默认值
+func envInt64(name string, def int64) int64 {
+	if v := os.Getenv(name); v != "" {
+		if parsed, err := strconv.ParseInt(v, 10, 64); err == nil && parsed > 0 {
+			return parsed
+		}
+	}
+	return def
+}
+
+// LoginGuard 基于 Redis 的登录失败计数：IP+用户名维度递增延迟，用户名维度临时锁定
+// Redis 不可用时放行并记录日志，避免因缓存故障导致全部用户无法登录
+type LoginGuard struct {
+	config *LoginGuardConfig
+}
+
+// NewLoginGuard 创建登录防爆破实例
+func NewLoginGuard(config *LoginGuardConfig) *LoginGuard {
+	return &LoginGuard{config: config}
+}
+
+// Check 登录前检查，账号锁定或处于延迟期时返回 RateLimitError
+func (g *LoginGuard) Check(ip, username string) ServiceError {
+	ctx := context.Background()
+	username = normalizeLoginName(username)
+
+	if ttl, err := cache.RedisClient.PTTL(ctx, fmt.Sprintf(loginLockKey, username)).Result(); err == nil && ttl > 0 {
+		return &RateLimitError{Message: "登录失败次数过多，账号已临时锁定", Code: 42902, RetryAfter: ttl}
+	} else if err != nil {
+		util.Log().Warning("读取登录锁定状态失败: %v", err)
+		return nil
+	}
+
+	next, err := cache.RedisClient.HGet(ctx, fmt.Sprintf(loginDelayKey, username), ip).Int64()
+	if err == nil {
+		if wait := time.Until(time.UnixMilli(next)); wait > 0 {
+			return &RateLimitError{Message: "登录尝试过于频繁，请稍后重试", Code: 42901, RetryAfter: wait}
+		}
+	}
+	return nil
+}
+
+// RecordFailure 记录一次登录失败，返回本次失败触发的锁定时长（未锁定为 0）
+func (g *LoginGuard) RecordFailure(ip, username string) time.Duration {
+	ctx := context.Background()
+	username = normalizeLoginName(username)
+	failKey := fmt.Sprintf(loginFailKey, username)
+	failIPKey := fmt.Sprintf(loginFailIPKey, username)
+	delayKey := fmt.Sprintf(loginDelayKey, username)
+
+	pipe := cache.RedisClient.TxPipeline()
+	userFails := pipe.Incr(ctx, failKey)
+	ipFails := pipe.HIncrBy(ctx, failIPKey, ip, 1)
+	pipe.Expire(ctx, failKey, g.config.Window)
+	pipe.Expire(ctx, failIPKey, g.config.Window)
+	if _, err := pipe.Exec(ctx); err != nil {
+		util.Log().Warning("记录登录失败次数失败: %v", err)
+		return 0
+	}
+
+	// IP+用户名维度：超过阈值后按指数递增延迟
+	if over := ipFails.Val() - g.config.BackoffThreshold; over >= 0 {
+		delay := g.config.BaseDelay << uint(min(over, 16))
+		if delay > g.config.MaxDelay {
+			delay = g.config.MaxDelay
+		}
+		cache.RedisClient.HSet(ctx, delayKey, ip, time.Now().Add(delay).UnixMilli())
+		cache.RedisClient.Expire(ctx, delayKey, g.config.Window)
+	}
+
+	// 用户名维度：达到阈值后锁定，并清零计数以便解锁后重新统计
+	if userFails.Val() >= g.config.LockThreshold {
+		lockKey := fmt.Sprintf(loginLockKey, username)
+		if ok, _ := cache.RedisClient.SetNX(ctx, lockKey, 1, g.config.LockDuration).Result(); ok {
+			cache.RedisClient.Del(ctx, failKey, failIPKey, delayKey)
+			return g.config.LockDuration
+		}
+	}
+	return 0
+}
+
+// Reset 登录成功后清除该用户名的失败计数与延迟
+func (g *LoginGuard) Reset(username string) {
+	username = normalizeLoginName(username)
+	err := cache.RedisClient.Del(context.Background(),
+		fmt.Sprintf(loginFailKey, username),
+		fmt.Sprintf(loginFailIPKey, username),
+		fmt.Sprintf(loginDelayKey, username),
+	).Err()
+	if err != nil {
+		util.Log().Warning("清除登录失败次数失败: %v", err)
+	}
+}
+
+// Unlock 解除用户名锁定并清除全部计数
+func (g *LoginGuard) Unlock(username string) error {
+	username = normalizeLoginName(username)
+	return cache.RedisClient.Del(context.Background(),
+		fmt.Sprintf(loginLockKey, username),
+		fmt.Sprintf(loginFailKey, username),
+		fmt.Sprintf(loginFailIPKey, username),
+		fmt.Sprintf(loginDelayKey, username),
+	).Err()
+}
+
+// normalizeLoginName 统一用户名大小写，避免通过大小写变体绕过计数
+func normalizeLoginName(username string) string {
+	return strings.ToLower(strings.TrimSpace(username))
+}
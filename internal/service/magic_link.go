@@ -0,0 +1,175 @@
+package service
+
+import (
+	"context"
+	"crypto/subtle"
+	"errors"
+	"fmt"
+	"go-one/internal/cache"
+	"go-one/internal/model"
+	"go-one/util"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// MagicLinkConfig 邮件链接免密登录配置
+type MagicLinkConfig struct {
+	Expire   time.Duration // 登录链接有效期
+	LoginURL string        // 前端登录页地址，令牌以 token 查询参数附加
+	Cooldown time.Duration // 同一用户两次申请的最小间隔
+}
+
+var MagicLink *MagicLinkConfig
+
+const (
+	// magicLinkUsedKey 登录链接已使用标记
+	magicLinkUsedKey = "magic_link:used:%s"
+	// magicLinkCooldownKey 登录链接申请冷却
+	magicLinkCooldownKey = "magic_link:cooldown:%d"
+)
+
+// InitMagicLink 初始化邮件链接登录配置
+func InitMagicLink() {
+	expire := int64(600) // 默认10分钟
+	if v := os.Getenv("MAGIC_LINK_EXPIRE"); v != "" {
+		if parsed, err := strconv.ParseInt(v, 10, 64); err == nil {
+			expire = parsed
+		}
+	}
+
+	loginURL := os.Getenv("MAGIC_LINK_URL")
+	if loginURL == "" {
+		loginURL = "http://localhost:8080/magic-login"
+	}
+
+	MagicLink = &MagicLinkConfig{
+		Expire:   time.Duration(expire) * time.Second,
+		LoginURL: loginURL,
+		Cooldown: time.Minute,
+	}
+
+	util.Log().Info("邮件链接登录配置初始化完成")
+}
+
+// MagicLinkDTO 申请登录链接请求DTO
+// Nonce 由接口层生成并写入发起请求的客户端（Cookie），链接只能在持有该 Nonce 的客户端上使用
+type MagicLinkDTO struct {
+	Email string
+	Nonce string
+}
+
+// RequestMagicLink 向已验证该邮箱的账号发送单次有效的登录链接
+// 为防止邮箱枚举，无论邮箱是否存在均返回成功，邮件异步发送
+func (s *UserService) RequestMagicLink(ctx *BusinessContext, dto *MagicLinkDTO) ServiceError {
+	email := strings.TrimSpace(dto.Email)
+	if email == "" {
+		return &ValidationError{Message: "邮箱不能为空", Code: 40000}
+	}
+	if dto.Nonce == "" {
+		return &ValidationError{Message: "缺少客户端标识", Code: 40000}
+	}
+
+	// 仅发送给已验证该邮箱的账号：未验证的邮箱不能证明归属
+	user, err := s.userRepo.FindByVerifiedEmail(email)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil
+		}
+		return &DatabaseError{Message: "查询用户失败", Err: err}
+	}
+	if checkUserStatus(user) != nil {
+		return nil
+	}
+	key := fmt.Sprintf(magicLinkCooldownKey, user.ID)
+	if ok, err := cache.RedisClient.SetNX(context.Background(), key, 1, MagicLink.Cooldown).Result(); err != nil || !ok {
+		return nil
+	}
+
+	token, err := GenerateToken(JWTClaims{
+		UserID:       strconv.FormatUint(uint64(user.ID), 10),
+		JTI:          uuid.NewString(),
+		Email:        user.Email,
+		Nonce:        util.SHA256Hex(dto.Nonce),
+		TokenVersion: user.TokenVersion,
+	}, MagicLinkToken, MagicLink.Expire)
+	if err != nil {
+		return &BusinessError{Message: "生成登录链接失败", Code: 50000, Err: err}
+	}
+
+	go s.sendMagicLinkEmail(user, token)
+	return nil
+}
+
+// MagicLinkConsumeDTO 使用登录链接请求DTO
+type MagicLinkConsumeDTO struct {
+	Token              string
+	Nonce              string
+	TrustedDeviceToken string
+}
+
+// ConsumeMagicLink 校验登录链接并完成登录，结果与 Login 一致（可能需要二次验证）
+func (s *UserService) ConsumeMagicLink(ctx *BusinessContext, dto *MagicLinkConsumeDTO) (*LoginResult, ServiceError) {
+	if dto.Token == "" {
+		return nil, &ValidationError{Message: "登录令牌不能为空", Code: 40000}
+	}
+	claims, err := ValidateToken(dto.Token, MagicLinkToken)
+	if err != nil || claims.JTI == "" || claims.Nonce == "" {
+		return nil, &AuthError{Message: "登录链接无效或已过期"}
+	}
+
+	// 绑定发起请求的客户端：在其他设备打开转发的邮件无法登录，且不会消耗该链接
+	if dto.Nonce == "" || subtle.ConstantTimeCompare([]byte(util.SHA256Hex(dto.Nonce)), []byte(claims.Nonce)) != 1 {
+		return nil, &AuthError{Message: "请在发起登录的设备和浏览器上打开此链接"}
+	}
+
+	// 单次有效：首次使用时写入标记，有效期覆盖令牌剩余寿命
+	ttl := MagicLink.Expire
+	if claims.ExpiresAt != nil {
+		ttl = time.Until(claims.ExpiresAt.Time) + time.Minute
+	}
+	ok, err := cache.RedisClient.SetNX(context.Background(), fmt.Sprintf(magicLinkUsedKey, claims.JTI), 1, ttl).Result()
+	if err != nil {
+		return nil, &ExternalAPIError{Message: "登录服务异常", Err: err}
+	}
+	if !ok {
+		return nil, &AuthError{Message: "登录链接已使用"}
+	}
+
+	uid64, err := strconv.ParseUint(claims.UserID, 10, 64)
+	if err != nil || uid64 == 0 {
+		return nil, &AuthError{Message: "登录链接无效或已过期"}
+	}
+	user, err := s.userRepo.FindByID(uint(uid64))
+	if err != nil {
+		return nil, &AuthError{Message: "登录链接无效或已过期"}
+	}
+	// 邮箱变更或令牌版本变化（改密、强制下线等）后链接失效
+	if !user.EmailVerified || user.Email != claims.Email || user.TokenVersion != claims.TokenVersion {
+		return nil, &AuthError{Message: "登录链接已失效，请重新申请"}
+	}
+	if serviceErr := checkUserStatus(user); serviceErr != nil {
+		return nil, serviceErr
+	}
+
+	util.Log().Info("用户通过邮件链接登录 user_id=%d ip=%s", user.ID, ctx.ClientIP)
+	return s.completeLogin(ctx, user, dto.TrustedDeviceToken)
+}
+
+// sendMagicLinkEmail 发送登录链接邮件
+func (s *UserService) sendMagicLinkEmail(user *model.User, token string) {
+	link := linkWithToken(MagicLink.LoginURL, token)
+	msg := &MailMessage{
+		To:      user.Email,
+		Subject: "您的登录链接",
+		Body: fmt.Sprintf("%s，您好：\n\n请点击以下链接登录账号 %s（%d 分钟内有效，仅可使用一次，且须在发起登录的设备和浏览器上打开）：\n%s\n\n如果这不是您本人的操作，请忽略此邮件。\n",
+			user.Nickname, user.Username, int(MagicLink.Expire.Minutes()), link),
+	}
+	if err := s.mailer.Send(msg); err != nil {
+		util.Log().Error("发送登录链接邮件失败 user_id=%d: %v", user.ID, err)
+	}
+}
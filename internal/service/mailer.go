@@ -0,0 +1,129 @@
+package service
+
+import (
+	"fmt"
+	"go-one/util"
+	"mime"
+	"net"
+	"net/smtp"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// MailMessage 邮件内容
+type MailMessage struct {
+	To      string
+	Subject string
+	Body    string // 纯文本正文
+}
+
+// Mailer 邮件发送接口
+type Mailer interface {
+	Send(msg *MailMessage) error
+}
+
+var Mail Mailer
+
+// InitMailer 根据 MAIL_DRIVER 初始化邮件发送器
+// smtp：通过 SMTP 发送；file（默认）：写入本地目录并记录日志，便于本地开发
+func InitMailer() {
+	driver := strings.ToLower(strings.TrimSpace(os.Getenv("MAIL_DRIVER")))
+	from := os.Getenv("MAIL_FROM")
+	if from == "" {
+		from = "no-reply@localhost"
+	}
+
+	switch driver {
+	case "smtp":
+		Mail = &SMTPMailer{
+			Host:     os.Getenv("SMTP_HOST"),
+			Port:     os.Getenv("SMTP_PORT"),
+			Username: os.Getenv("SMTP_USERNAME"),
+			Password: os.Getenv("SMTP_PASSWORD"),
+			From:     from,
+		}
+	case "", "file":
+		dir := os.Getenv("MAIL_FILE_DIR")
+		if dir == "" {
+			dir = "./logs/mail"
+		}
+		Mail = &FileMailer{Dir: dir, From: from}
+	default:
+		util.Log().Panic("不支持的 MAIL_DRIVER: %s", driver)
+	}
+
+	util.Log().Info("邮件发送器初始化完成，驱动: %T", Mail)
+}
+
+// SMTPMailer 通过 SMTP 服务器发送邮件（服务器支持时自动启用 STARTTLS）
+type SMTPMailer struct {
+	Host     string
+	Port     string
+	Username string
+	Password string
+	From     string
+}
+
+// Send 发送邮件
+func (m *SMTPMailer) Send(msg *MailMessage) error {
+	var auth smtp.Auth
+	if m.Username != "" {
+		auth = smtp.PlainAuth("", m.Username, m.Password, m.Host)
+	}
+	return smtp.SendMail(net.JoinHostPort(m.Host, m.Port), auth, m.From, []string{msg.To}, buildMIME(m.From, msg))
+}
+
+// FileMailer 将邮件写入本地目录（.eml）并记录日志，不实际发送
+type FileMailer struct {
+	Dir  string
+	From string
+}
+
+// Send 写入邮件文件
+func (m *FileMailer) Send(msg *MailMessage) error {
+	if err := os.MkdirAll(m.Dir, 0o755); err != nil {
+		return err
+	}
+	name := fmt.Sprintf("%s_%s.eml", time.Now().Format("20060102T150405.000000000"), sanitizeFileName(msg.To))
+	path := filepath.Join(m.Dir, name)
+	if err := os.WriteFile(path, buildMIME(m.From, msg), 0o600); err != nil {
+		return err
+	}
+	util.Log().Info("邮件已写入 %s to=%s subject=%q", path, msg.To, msg.Subject)
+	return nil
+}
+
+// buildMIME 构建纯文本邮件报文
+func buildMIME(from string, msg *MailMessage) []byte {
+	var b strings.Builder
+	b.WriteString("From: " + from + "\r\n")
+	b.WriteString("To: " + msg.To + "\r\n")
+	b.WriteString("Subject: " + mime.QEncoding.Encode("utf-8", msg.Subject) + "\r\n")
+	b.WriteString("Date: " + time.Now().Format(time.RFC1123Z) + "\r\n")
+	b.WriteString("MIME-Version: 1.0\r\n")
+	b.WriteString("Content-Type: text/plain; charset=UTF-8\r\n")
+	b.WriteString("Content-Transfer-Encoding: 8bit\r\n\r\n")
+	b.WriteString(strings.ReplaceAll(msg.Body, "\n", "\r\n"))
+	return []byte(b.String())
+}
+
+// linkWithToken 将令牌以 token 查询参数附加到邮件链接
+func linkWithToken(base, token string) string {
+	if strings.Contains(base, "?") {
+		return base + "&token=" + url.QueryEscape(token)
+	}
+	return base + "?token=" + url.QueryEscape(token)
+}
+
+// sanitizeFileName 将收件人地址转换为安全的文件名片段
+func sanitizeFileName(s string) string {
+	return strings.Map(func(r rune) rune {
+		if r == '@' || r == '.' || r == '-' || r == '_' || (r >= 'a' && r <= 'z') || (r >= 'A' && r <= 'Z') || (r >= '0' && r <= '9') {
+			return r
+		}
+		return '_'
+	}, s)
+}
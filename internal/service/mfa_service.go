@@ -0,0 +1,419 @@
+package service
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/base64"
+	"fmt"
+	"go-one/internal/cache"
+	"go-one/internal/model"
+	"go-one/internal/repository"
+	"go-one/util"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/skip2/go-qrcode"
+)
+
+// MFAConfig 二次验证配置
+type MFAConfig struct {
+	Issuer              string        // 认证器应用中显示的发行方
+	EncryptionKey       []byte        // TOTP 密钥落库加密使用的 AES-256 密钥
+	PendingTokenExpire  time.Duration // mfa_pending 令牌有效期
+	TrustedDeviceExpire time.Duration // “记住此设备”有效期
+	MaxAttempts         int64         // 单个 mfa_pending 令牌允许的最大校验次数
+}
+
+var MFA *MFAConfig
+
+const (
+	// recoveryCodeCount 每次生成的恢复码数量
+	recoveryCodeCount = 10
+	// totpSkew 允许的时钟偏差（时间步）
+	totpSkew = 1
+	// mfaAttemptsKey mfa_pending 令牌校验次数
+	mfaAttemptsKey = "mfa:attempts:%s"
+	// mfaUsedKey mfa_pending 令牌已使用标记
+	mfaUsedKey = "mfa:used:%s"
+)
+
+// InitMFA 初始化二次验证配置
+func InitMFA() {
+	issuer := os.Getenv("MFA_ISSUER")
+	if issuer == "" {
+		issuer = "go-one"
+	}
+
+	// 不从 JWT_SECRET 派生：非对称签名模式下 JWT_SECRET 为空，派生出的将是公开常量
+	v := os.Getenv("MFA_ENCRYPTION_KEY")
+	if v == "" {
+		util.Log().Panic("MFA_ENCRYPTION_KEY 未配置")
+	}
+	key, err := base64.StdEncoding.DecodeString(v)
+	if err != nil || len(key) != 32 {
+		util.Log().Panic("MFA_ENCRYPTION_KEY 必须是 base64 编码的 32 字节密钥")
+	}
+
+	pendingExpire := int64(300) // 默认5分钟
+	if v := os.Getenv("MFA_PENDING_TOKEN_EXPIRE"); v != "" {
+		if parsed, err := strconv.ParseInt(v, 10, 64); err == nil {
+			pendingExpire = parsed
+		}
+	}
+
+	trustedExpire := int64(2592000) // 默认30天
+	if v := os.Getenv("MFA_TRUSTED_DEVICE_EXPIRE"); v != "" {
+		if parsed, err := strconv.ParseInt(v, 10, 64); err == nil {
+			trustedExpire = parsed
+		}
+	}
+
+	MFA = &MFAConfig{
+		Issuer:              issuer,
+		EncryptionKey:       key,
+		PendingTokenExpire:  time.Duration(pendingExpire) * time.Second,
+		TrustedDeviceExpire: time.Duration(trustedExpire) * time.Second,
+		MaxAttempts:         5,
+	}
+
+	util.Log().Info("二次验证配置初始化完成")
+}
+
+// MFAService 二次验证服务（TOTP + 恢复码）
+type MFAService struct {
+	userRepo     repository.UserRepository
+	recoveryRepo repository.RecoveryCodeRepository
+	users        *UserService
+}
+
+// NewMFAService 创建二次验证服务实例
+func NewMFAService(userRepo repository.UserRepository, recoveryRepo repository.RecoveryCodeRepository, users *UserService) *MFAService {
+	return &MFAService{
+		userRepo:     userRepo,
+		recoveryRepo: recoveryRepo,
+		users:        users,
+	}
+}
+
+// TOTPEnrollResult TOTP 绑定结果
+type TOTPEnrollResult struct {
+	Secret    string
+	URI       string
+	QRCodePNG []byte
+}
+
+// EnrollTOTP 生成待确认的 TOTP 密钥，返回 otpauth:// URI 与二维码
+func (s *MFAService) EnrollTOTP(ctx *BusinessContext) (*TOTPEnrollResult, ServiceError) {
+	user, serviceErr := s.currentUser(ctx)
+	if serviceErr != nil {
+		return nil, serviceErr
+	}
+	if user.MFAEnabled {
+		return nil, &BusinessError{Message: "已启用二次验证", Code: 40009}
+	}
+
+	secret, err := util.GenerateTOTPSecret()
+	if err != nil {
+		return nil, &BusinessError{Message: "生成密钥失败", Code: 50000, Err: err}
+	}
+	encrypted, err := util.EncryptAESGCM(MFA.EncryptionKey, secret)
+	if err != nil {
+		return nil, &BusinessError{Message: "加密密钥失败", Code: 50000, Err: err}
+	}
+
+	user.TOTPSecret = encrypted
+	user.TOTPLastStep = 0
+	if err := s.userRepo.Update(user); err != nil {
+		return nil, &DatabaseError{Message: "保存密钥失败", Err: err}
+	}
+
+	account := user.Username
+	if user.Email != "" {
+		account = user.Email
+	}
+	uri := util.TOTPURI(MFA.Issuer, account, secret)
+	png, err := qrcode.Encode(uri, qrcode.Medium, 256)
+	if err != nil {
+		return nil, &BusinessError{Message: "生成二维码失败", Code: 50000, Err: err}
+	}
+
+	return &TOTPEnrollResult{
+		Secret:    secret,
+		URI:       uri,
+		QRCodePNG: png,
+	}, nil
+}
+
+// ConfirmTOTP 校验认证器生成的验证码以启用二次验证，返回一次性恢复码
+func (s *MFAService) ConfirmTOTP(ctx *BusinessContext, code string) ([]string, ServiceError) {
+	user, serviceErr := s.currentUser(ctx)
+	if serviceErr != nil {
+		return nil, serviceErr
+	}
+	if user.MFAEnabled {
+		return nil, &BusinessError{Message: "已启用二次验证", Code: 40009}
+	}
+	if user.TOTPSecret == "" {
+		return nil, &BusinessError{Message: "请先绑定认证器", Code: 40000}
+	}
+	if serviceErr := s.verifyTOTP(user, code); serviceErr != nil {
+		return nil, serviceErr
+	}
+
+	codes, serviceErr := s.generateRecoveryCodes(user.ID)
+	if serviceErr != nil {
+		return nil, serviceErr
+	}
+	user.MFAEnabled = true
+	if err := s.userRepo.Update(user); err != nil {
+		return nil, &DatabaseError{Message: "启用二次验证失败", Err: err}
+	}
+
+	util.Log().Info("用户启用二次验证 user_id=%d", user.ID)
+	return codes, nil
+}
+
+// MFACodeDTO 二次验证码（TOTP 验证码或恢复码二选一）
+type MFACodeDTO struct {
+	Code         string
+	RecoveryCode string
+}
+
+// DisableTOTP 关闭二次验证（需提供有效验证码或恢复码）
+func (s *MFAService) DisableTOTP(ctx *BusinessContext, dto *MFACodeDTO) ServiceError {
+	user, serviceErr := s.currentUser(ctx)
+	if serviceErr != nil {
+		return serviceErr
+	}
+	if !user.MFAEnabled {
+		return &BusinessError{Message: "未启用二次验证", Code: 40000}
+	}
+	if serviceErr := s.verifySecondFactor(user, dto); serviceErr != nil {
+		return serviceErr
+	}
+
+	user.MFAEnabled = false
+	user.TOTPSecret = ""
+	user.TOTPLastStep = 0
+	if err := s.userRepo.Update(user); err != nil {
+		return &DatabaseError{Message: "关闭二次验证失败", Err: err}
+	}
+	if err := s.recoveryRepo.DeleteByUser(user.ID); err != nil {
+		return &DatabaseError{Message: "删除恢复码失败", Err: err}
+	}
+
+	util.Log().Info("用户关闭二次验证 user_id=%d", user.ID)
+	return nil
+}
+
+// RegenerateRecoveryCodes 重新生成恢复码（旧恢复码全部作废）
+func (s *MFAService) RegenerateRecoveryCodes(ctx *BusinessContext, code string) ([]string, ServiceError) {
+	user, serviceErr := s.currentUser(ctx)
+	if serviceErr != nil {
+		return nil, serviceErr
+	}
+	if !user.MFAEnabled {
+		return nil, &BusinessError{Message: "未启用二次验证", Code: 40000}
+	}
+	if serviceErr := s.verifyTOTP(user, code); serviceErr != nil {
+		return nil, serviceErr
+	}
+	return s.generateRecoveryCodes(user.ID)
+}
+
+// MFAVerifyDTO 登录二次验证请求DTO
+type MFAVerifyDTO struct {
+	MFAToken       string
+	Code           string
+	RecoveryCode   string
+	RememberDevice bool
+}
+
+// VerifyLogin 校验 mfa_pending 令牌与第二因素，完成登录并签发令牌对
+func (s *MFAService) VerifyLogin(ctx *BusinessContext, dto *MFAVerifyDTO) (*LoginResult, ServiceError) {
+	if dto.MFAToken == "" {
+		return nil, &ValidationError{Message: "二次验证令牌不能为空", Code: 40000}
+	}
+	claims, err := ValidateToken(dto.MFAToken, MFAPendingToken)
+	if err != nil || claims.JTI == "" {
+		return nil, &AuthError{Message: "二次验证已过期，请重新登录"}
+	}
+
+	// 单个 mfa_pending 令牌限制校验次数，且只能成功使用一次
+	redisCtx := context.Background()
+	usedKey := fmt.Sprintf(mfaUsedKey, claims.JTI)
+	if exists, _ := cache.RedisClient.Exists(redisCtx, usedKey).Result(); exists > 0 {
+		return nil, &AuthError{Message: "二次验证已过期，请重新登录"}
+	}
+	attemptsKey := fmt.Sprintf(mfaAttemptsKey, claims.JTI)
+	attempts, err := cache.RedisClient.Incr(redisCtx, attemptsKey).Result()
+	if err != nil {
+		return nil, &ExternalAPIError{Message: "二次验证服务异常", Err: err}
+	}
+	cache.RedisClient.Expire(redisCtx, attemptsKey, MFA.PendingTokenExpire)
+	if attempts > MFA.MaxAttempts {
+		return nil, &AuthError{Message: "二次验证尝试次数过多，请重新登录"}
+	}
+
+	uid64, err := strconv.ParseUint(claims.UserID, 10, 64)
+	if err != nil || uid64 == 0 {
+		return nil, &AuthError{Message: "无效的用户ID"}
+	}
+	user, err := s.userRepo.FindByID(uint(uid64))
+	if err != nil {
+		return nil, &AuthError{Message: "二次验证已过期，请重新登录"}
+	}
+	if serviceErr := checkUserStatus(user); serviceErr != nil {
+		return nil, serviceErr
+	}
+	if !user.MFAEnabled || claims.TokenVersion != user.TokenVersion {
+		return nil, &AuthError{Message: "二次验证已过期，请重新登录"}
+	}
+
+	// 每次密码登录都会签发新的 mfa_pending 令牌，第二因素的失败须按用户计入登录防爆破计数
+	identity := loginIdentity(ctx.TenantID, user.Username)
+	if serviceErr := s.users.loginGuard.Check(ctx.ClientIP, identity); serviceErr != nil {
+		return nil, serviceErr
+	}
+	if serviceErr := s.verifySecondFactor(user, &MFACodeDTO{Code: dto.Code, RecoveryCode: dto.RecoveryCode}); serviceErr != nil {
+		if _, ok := serviceErr.(*AuthError); ok {
+			s.users.recordLoginFailure(ctx, user.Username, user.ID, loginFactorMFA)
+		}
+		return nil, serviceErr
+	}
+	if ok, _ := cache.RedisClient.SetNX(redisCtx, usedKey, 1, MFA.PendingTokenExpire).Result(); !ok {
+		return nil, &AuthError{Message: "二次验证已过期，请重新登录"}
+	}
+
+	accessToken, refreshToken, serviceErr := s.users.issueTokenPair(ctx, s.users.tokenRepo, user, nil)
+	if serviceErr != nil {
+		return nil, serviceErr
+	}
+	s.users.loginGuard.Reset(identity)
+	result := &LoginResult{
+		User:         user,
+		AccessToken:  accessToken,
+		RefreshToken: refreshToken,
+	}
+
+	if dto.RememberDevice {
+		deviceToken, err := GenerateToken(JWTClaims{
+			UserID:       claims.UserID,
+			JTI:          uuid.NewString(),
+			TokenVersion: user.TokenVersion,
+		}, TrustedDeviceToken, MFA.TrustedDeviceExpire)
+		if err != nil {
+			return nil, &BusinessError{Message: "生成设备令牌失败", Code: 50000, Err: err}
+		}
+		result.TrustedDeviceToken = deviceToken
+	}
+
+	return result, nil
+}
+
+// newMFAPendingToken 为通过第一因素的用户签发 mfa_pending 令牌
+func newMFAPendingToken(user *model.User) (string, error) {
+	return GenerateToken(JWTClaims{
+		UserID:       strconv.FormatUint(uint64(user.ID), 10),
+		JTI:          uuid.NewString(),
+		TokenVersion: user.TokenVersion,
+	}, MFAPendingToken, MFA.PendingTokenExpire)
+}
+
+// isTrustedDevice 校验“记住此设备”令牌属于该用户，且未因改密等操作失效
+func isTrustedDevice(token string, user *model.User) bool {
+	if token == "" {
+		return false
+	}
+	claims, err := ValidateToken(token, TrustedDeviceToken)
+	if err != nil {
+		return false
+	}
+	return claims.UserID == strconv.FormatUint(uint64(user.ID), 10) && claims.TokenVersion == user.TokenVersion
+}
+
+// verifySecondFactor 校验 TOTP 验证码或恢复码
+func (s *MFAService) verifySecondFactor(user *model.User, dto *MFACodeDTO) ServiceError {
+	if code := normalizeRecoveryCode(dto.RecoveryCode); code != "" {
+		ok, err := s.recoveryRepo.Consume(user.ID, util.SHA256Hex(code))
+		if err != nil {
+			return &DatabaseError{Message: "校验恢复码失败", Err: err}
+		}
+		if !ok {
+			return &AuthError{Message: "恢复码无效或已使用"}
+		}
+		util.Log().Info("用户使用恢复码完成二次验证 user_id=%d", user.ID)
+		return nil
+	}
+	return s.verifyTOTP(user, dto.Code)
+}
+
+// verifyTOTP 校验 TOTP 验证码，同一时间步的验证码只能使用一次
+func (s *MFAService) verifyTOTP(user *model.User, code string) ServiceError {
+	if strings.TrimSpace(code) == "" {
+		return &ValidationError{Message: "验证码不能为空", Code: 40000}
+	}
+	secret, err := util.DecryptAESGCM(MFA.EncryptionKey, user.TOTPSecret)
+	if err != nil {
+		return &BusinessError{Message: "读取二次验证密钥失败", Code: 50000, Err: err}
+	}
+	step, ok := util.ValidateTOTP(secret, code, time.Now(), totpSkew)
+	if !ok {
+		return &AuthError{Message: "验证码错误"}
+	}
+	advanced, err := s.userRepo.AdvanceTOTPStep(user.ID, step)
+	if err != nil {
+		return &DatabaseError{Message: "校验验证码失败", Err: err}
+	}
+	if !advanced {
+		return &AuthError{Message: "验证码已使用，请等待下一个验证码"}
+	}
+	user.TOTPLastStep = step
+	return nil
+}
+
+// generateRecoveryCodes 生成一组新的恢复码，仅保存哈希
+func (s *MFAService) generateRecoveryCodes(userID uint) ([]string, ServiceError) {
+	const alphabet = "abcdefghjkmnpqrstuvwxyz23456789"
+	codes := make([]string, recoveryCodeCount)
+	hashes := make([]string, recoveryCodeCount)
+	buf := make([]byte, 10)
+	for i := range codes {
+		if _, err := rand.Read(buf); err != nil {
+			return nil, &BusinessError{Message: "生成恢复码失败", Code: 50000, Err: err}
+		}
+		chars := make([]byte, len(buf))
+		for j, b := range buf {
+			chars[j] = alphabet[int(b)%len(alphabet)]
+		}
+		codes[i] = string(chars[:5]) + "-" + string(chars[5:])
+		hashes[i] = util.SHA256Hex(normalizeRecoveryCode(codes[i]))
+	}
+	if err := s.recoveryRepo.ReplaceForUser(userID, hashes); err != nil {
+		return nil, &DatabaseError{Message: "保存恢复码失败", Err: err}
+	}
+	return codes, nil
+}
+
+// normalizeRecoveryCode 去除分隔符与空白并转为小写
+func normalizeRecoveryCode(code string) string {
+	code = strings.ToLower(strings.TrimSpace(code))
+	code = strings.ReplaceAll(code, "-", "")
+	return strings.ReplaceAll(code, " ", "")
+}
+
+// currentUser 获取当前登录用户
+func (s *MFAService) currentUser(ctx *BusinessContext) (*model.User, ServiceError) {
+	userID, serviceErr := currentUserID(ctx)
+	if serviceErr != nil {
+		return nil, serviceErr
+	}
+	user, err := s.userRepo.FindByID(userID)
+	if err != nil {
+		return nil, &NotFoundError{Message: "用户不存在"}
+	}
+	return user, nil
+}
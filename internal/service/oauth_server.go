@@ -0,0 +1,768 @@
+package service
+
+import (
+	"context"
+	"crypto/subtle"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"go-one/internal/cache"
+	"go-one/internal/model"
+	"go-one/internal/oauth"
+	"go-one/internal/repository"
+	"go-one/util"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// OAuthServerConfig OAuth 授权服务配置（向内部应用签发令牌）
+type OAuthServerConfig struct {
+	CodeExpire        time.Duration // 授权码有效期
+	ClientTokenExpire time.Duration // client_credentials 应用令牌有效期
+}
+
+var OAuthServer *OAuthServerConfig
+
+const (
+	// oauthCodeKey 授权码（SHA256）对应的授权信息，换取令牌时一次性取出
+	oauthCodeKey = "oauth_server:code:%s"
+	// oauthRevokedKey 已吊销的应用访问令牌 jti，保留至访问令牌过期
+	oauthRevokedKey = "oauth_server:revoked:%s"
+
+	// 支持的授权类型
+	GrantAuthorizationCode = "authorization_code"
+	GrantRefreshToken      = "refresh_token"
+	GrantClientCredentials = "client_credentials"
+)
+
+// InitOAuthServer 初始化 OAuth 授权服务配置
+func InitOAuthServer() {
+	OAuthServer = &OAuthServerConfig{
+		CodeExpire:        time.Duration(envInt64("OAUTH_SERVER_CODE_EXPIRE", 60)) * time.Second,
+		ClientTokenExpire: time.Duration(envInt64("OAUTH_SERVER_CLIENT_TOKEN_EXPIRE", 3600)) * time.Second,
+	}
+	util.Log().Info("OAuth 授权服务配置初始化完成")
+}
+
+// OAuthProtocolError OAuth 协议错误，ErrorCode 为 RFC 6749 定义的 error 取值
+type OAuthProtocolError struct {
+	ErrorCode string
+	Message   string
+}
+
+func (e *OAuthProtocolError) Error() string {
+	return e.ErrorCode + ": " + e.Message
+}
+
+func (e *OAuthProtocolError) GetCode() int {
+	if e.ErrorCode == "invalid_client" {
+		return 40001
+	}
+	return 40000
+}
+
+func (e *OAuthProtocolError) GetMessage() string {
+	return e.Message
+}
+
+// oauthCode 授权码绑定的授权信息
+type oauthCode struct {
+	ClientID      string `json:"client_id"`
+	UserID        uint   `json:"user_id"`
+	RedirectURI   string `json:"redirect_uri"`
+	Scope         string `json:"scope"`
+	CodeChallenge string `json:"code_challenge"`
+}
+
+// OAuthServerService OAuth 授权服务：应用管理、授权同意、令牌签发、内省与吊销
+type OAuthServerService struct {
+	userRepo      repository.UserRepository
+	tokenRepo     repository.RefreshTokenRepository
+	oauthRepo     repository.OAuthRepository
+	users         *UserService
+	tokenVersions *TokenVersionService
+}
+
+// NewOAuthServerService 创建 OAuth 授权服务实例
+func NewOAuthServerService(userRepo repository.UserRepository, tokenRepo repository.RefreshTokenRepository,
+	oauthRepo repository.OAuthRepository, users *UserService, tokenVersions *TokenVersionService) *OAuthServerService {
+	return &OAuthServerService{
+		userRepo:      userRepo,
+		tokenRepo:     tokenRepo,
+		oauthRepo:     oauthRepo,
+		users:         users,
+		tokenVersions: tokenVersions,
+	}
+}
+
+// CreateOAuthClientDTO 注册 OAuth 应用请求DTO
+type CreateOAuthClientDTO struct {
+	Name         string
+	RedirectURIs []string
+	Scopes       []string
+	GrantTypes   []string
+	Public       bool
+}
+
+// CreateOAuthClientResult 注册结果，Secret 仅在创建时返回一次（公开应用为空）
+type CreateOAuthClientResult struct {
+	Client *model.OAuthClient
+	Secret string
+}
+
+// CreateClient 注册 OAuth 应用
+// 作用域只能是个人资料作用域或当前管理员已拥有的权限；公开应用不能使用 client_credentials
+func (s *OAuthServerService) CreateClient(ctx *BusinessContext, dto *CreateOAuthClientDTO) (*CreateOAuthClientResult, ServiceError) {
+	userID, serviceErr := currentUserID(ctx)
+	if serviceErr != nil {
+		return nil, serviceErr
+	}
+
+	dto.Name = strings.TrimSpace(dto.Name)
+	if dto.Name == "" {
+		return nil, &ValidationError{Message: "名称不能为空", Code: 40000}
+	}
+	if len(dto.Scopes) == 0 {
+		return nil, &ValidationError{Message: "至少需要一个作用域", Code: 40000}
+	}
+	scopes := make([]string, 0, len(dto.Scopes))
+	for _, scope := range dto.Scopes {
+		scope = strings.TrimSpace(scope)
+		if scope != ScopeProfileRead && scope != ScopeProfileWrite && !ctx.HasPermission(scope) {
+			return nil, &ValidationError{Message: "无效的作用域: " + scope, Code: 40000}
+		}
+		if !util.ContainsString(scopes, scope) {
+			scopes = append(scopes, scope)
+		}
+	}
+
+	if len(dto.GrantTypes) == 0 {
+		dto.GrantTypes = []string{GrantAuthorizationCode, GrantRefreshToken}
+	}
+	grantTypes := make([]string, 0, len(dto.GrantTypes))
+	for _, grant := range dto.GrantTypes {
+		switch grant {
+		case GrantAuthorizationCode, GrantRefreshToken:
+		case GrantClientCredentials:
+			if dto.Public {
+				return nil, &ValidationError{Message: "公开应用不支持 client_credentials 授权", Code: 40000}
+			}
+		default:
+			return nil, &ValidationError{Message: "不支持的授权类型: " + grant, Code: 40000}
+		}
+		if !util.ContainsString(grantTypes, grant) {
+			grantTypes = append(grantTypes, grant)
+		}
+	}
+
+	redirectURIs := make([]string, 0, len(dto.RedirectURIs))
+	for _, raw := range dto.RedirectURIs {
+		raw = strings.TrimSpace(raw)
+		u, err := url.Parse(raw)
+		if err != nil || u.Scheme == "" || u.Host == "" || u.Fragment != "" || strings.Contains(raw, ",") {
+			return nil, &ValidationError{Message: "无效的回调地址: " + raw, Code: 40000}
+		}
+		redirectURIs = append(redirectURIs, raw)
+	}
+	if util.ContainsString(grantTypes, GrantAuthorizationCode) && len(redirectURIs) == 0 {
+		return nil, &ValidationError{Message: "授权码模式至少需要一个回调地址", Code: 40000}
+	}
+
+	clientID, err := util.RandomToken(16)
+	if err != nil {
+		return nil, &BusinessError{Message: "生成应用标识失败", Code: 50000, Err: err}
+	}
+	client := &model.OAuthClient{
+		ClientID:     clientID,
+		Name:         dto.Name,
+		RedirectURIs: strings.Join(redirectURIs, ","),
+		Scopes:       strings.Join(scopes, ","),
+		GrantTypes:   strings.Join(grantTypes, ","),
+		Public:       dto.Public,
+		CreatedBy:    userID,
+	}
+	var secret string
+	if !dto.Public {
+		secret, err = util.RandomToken(32)
+		if err != nil {
+			return nil, &BusinessError{Message: "生成应用密钥失败", Code: 50000, Err: err}
+		}
+		client.SecretHash = util.SHA256Hex(secret)
+	}
+	if err := s.oauthRepo.CreateClient(client); err != nil {
+		return nil, &DatabaseError{Message: "保存 OAuth 应用失败", Err: err}
+	}
+
+	util.Log().Info("注册 OAuth 应用 client_id=%s name=%s by user_id=%d", client.ClientID, client.Name, userID)
+	return &CreateOAuthClientResult{Client: client, Secret: secret}, nil
+}
+
+// ListClients 获取全部 OAuth 应用
+func (s *OAuthServerService) ListClients(ctx *BusinessContext) ([]model.OAuthClient, ServiceError) {
+	clients, err := s.oauthRepo.ListClients()
+	if err != nil {
+		return nil, &DatabaseError{Message: "查询 OAuth 应用失败", Err: err}
+	}
+	return clients, nil
+}
+
+// RevokeClient 吊销 OAuth 应用，并撤销其全部授权会话（刷新令牌与已签发的访问令牌）
+func (s *OAuthServerService) RevokeClient(ctx *BusinessContext, id uint) ServiceError {
+	client, err := s.oauthRepo.FindClientByID(id)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return &NotFoundError{Message: "OAuth 应用不存在"}
+		}
+		return &DatabaseError{Message: "查询 OAuth 应用失败", Err: err}
+	}
+	affected, err := s.oauthRepo.RevokeClient(id)
+	if err != nil {
+		return &DatabaseError{Message: "吊销 OAuth 应用失败", Err: err}
+	}
+	if affected == 0 {
+		return &NotFoundError{Message: "OAuth 应用不存在或已吊销"}
+	}
+	revoked, serviceErr := s.revokeClientSessions(0, client.ClientID)
+	if serviceErr != nil {
+		return serviceErr
+	}
+	util.Log().Info("吊销 OAuth 应用 client_id=%s revoked_tokens=%d by user_id=%s", client.ClientID, revoked, ctx.UserUUID)
+	return nil
+}
+
+// AuthorizeDTO 授权请求DTO（参数与 RFC 6749 授权端点一致）
+type AuthorizeDTO struct {
+	ResponseType        string
+	ClientID            string
+	RedirectURI         string
+	Scope               string
+	State               string
+	CodeChallenge       string
+	CodeChallengeMethod string
+	Approve             bool
+}
+
+// AuthorizationInfo 授权确认页所需信息
+type AuthorizationInfo struct {
+	Client          *model.OAuthClient
+	Scopes          []string
+	ConsentRequired bool // 用户尚未同意过全部申请的作用域
+}
+
+// AuthorizeResult 授权结果，前端跳转到 RedirectURI（携带 code 或 error）
+type AuthorizeResult struct {
+	RedirectURI string
+}
+
+// PrepareAuthorization 校验授权请求并返回确认页信息
+func (s *OAuthServerService) PrepareAuthorization(ctx *BusinessContext, dto *AuthorizeDTO) (*AuthorizationInfo, ServiceError) {
+	userID, serviceErr := currentUserID(ctx)
+	if serviceErr != nil {
+		return nil, serviceErr
+	}
+	client, scopes, serviceErr := s.validateAuthorizeRequest(dto)
+	if serviceErr != nil {
+		return nil, serviceErr
+	}
+
+	consentRequired := true
+	consent, err := s.oauthRepo.FindConsent(userID, client.ClientID)
+	if err == nil {
+		consentRequired = !isSubset(scopes, splitList(consent.Scopes))
+	} else if !errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, &DatabaseError{Message: "查询授权记录失败", Err: err}
+	}
+
+	return &AuthorizationInfo{Client: client, Scopes: scopes, ConsentRequired: consentRequired}, nil
+}
+
+// Authorize 处理用户的授权决定：同意时记录授权并签发授权码，拒绝时返回 access_denied
+func (s *OAuthServerService) Authorize(ctx *BusinessContext, dto *AuthorizeDTO) (*AuthorizeResult, ServiceError) {
+	userID, serviceErr := currentUserID(ctx)
+	if serviceErr != nil {
+		return nil, serviceErr
+	}
+	client, scopes, serviceErr := s.validateAuthorizeRequest(dto)
+	if serviceErr != nil {
+		return nil, serviceErr
+	}
+
+	query := url.Values{}
+	if dto.State != "" {
+		query.Set("state", dto.State)
+	}
+	if !dto.Approve {
+		query.Set("error", "access_denied")
+		return &AuthorizeResult{RedirectURI: appendQuery(dto.RedirectURI, query)}, nil
+	}
+
+	// 合并记录已同意的作用域
+	granted := scopes
+	if consent, err := s.oauthRepo.FindConsent(userID, client.ClientID); err == nil {
+		granted = splitList(consent.Scopes)
+		for _, scope := range scopes {
+			if !util.ContainsString(granted, scope) {
+				granted = append(granted, scope)
+			}
+		}
+	}
+	if err := s.oauthRepo.SaveConsent(&model.OAuthConsent{
+		UserID:   userID,
+		ClientID: client.ClientID,
+		Scopes:   strings.Join(granted, ","),
+	}); err != nil {
+		return nil, &DatabaseError{Message: "保存授权记录失败", Err: err}
+	}
+
+	code, err := util.RandomToken(32)
+	if err != nil {
+		return nil, &BusinessError{Message: "生成授权码失败", Code: 50000, Err: err}
+	}
+	payload, _ := json.Marshal(oauthCode{
+		ClientID:      client.ClientID,
+		UserID:        userID,
+		RedirectURI:   dto.RedirectURI,
+		Scope:         strings.Join(scopes, " "),
+		CodeChallenge: dto.CodeChallenge,
+	})
+	key := fmt.Sprintf(oauthCodeKey, util.SHA256Hex(code))
+	if err := cache.RedisClient.Set(context.Background(), key, payload, OAuthServer.CodeExpire).Err(); err != nil {
+		return nil, &ExternalAPIError{Message: "保存授权码失败", Err: err}
+	}
+
+	util.Log().Info("用户授权 OAuth 应用 user_id=%d client_id=%s scope=%s", userID, client.ClientID, strings.Join(scopes, " "))
+	query.Set("code", code)
+	return &AuthorizeResult{RedirectURI: appendQuery(dto.RedirectURI, query)}, nil
+}
+
+// validateAuthorizeRequest 校验应用、回调地址（完全匹配）、作用域与 PKCE 参数
+func (s *OAuthServerService) validateAuthorizeRequest(dto *AuthorizeDTO) (*model.OAuthClient, []string, ServiceError) {
+	if dto.ResponseType != "code" {
+		return nil, nil, &ValidationError{Message: "仅支持 response_type=code", Code: 40000}
+	}
+	client, serviceErr := s.findClient(dto.ClientID)
+	if serviceErr != nil {
+		return nil, nil, serviceErr
+	}
+	if !util.ContainsString(splitList(client.GrantTypes), GrantAuthorizationCode) {
+		return nil, nil, &ValidationError{Message: "该应用不支持授权码模式", Code: 40000}
+	}
+	if !util.ContainsString(splitList(client.RedirectURIs), dto.RedirectURI) {
+		return nil, nil, &ValidationError{Message: "回调地址未注册", Code: 40000}
+	}
+	scopes, ok := resolveScopes(dto.Scope, splitList(client.Scopes))
+	if !ok {
+		return nil, nil, &ValidationError{Message: "申请的作用域超出应用允许范围", Code: 40000}
+	}
+	// 所有应用均强制 PKCE（S256）
+	if dto.CodeChallengeMethod != "S256" || len(dto.CodeChallenge) < 43 {
+		return nil, nil, &ValidationError{Message: "缺少 PKCE 参数或 code_challenge_method 不是 S256", Code: 40000}
+	}
+	return client, scopes, nil
+}
+
+// ListConsents 获取当前用户已授权的应用
+func (s *OAuthServerService) ListConsents(ctx *BusinessContext) ([]model.OAuthConsent, ServiceError) {
+	userID, serviceErr := currentUserID(ctx)
+	if serviceErr != nil {
+		return nil, serviceErr
+	}
+	consents, err := s.oauthRepo.ListConsents(userID)
+	if err != nil {
+		return nil, &DatabaseError{Message: "查询授权记录失败", Err: err}
+	}
+	return consents, nil
+}
+
+// RevokeConsent 撤销当前用户对应用的授权，并使该应用持有的刷新令牌与访问令牌失效
+func (s *OAuthServerService) RevokeConsent(ctx *BusinessContext, clientID string) ServiceError {
+	userID, serviceErr := currentUserID(ctx)
+	if serviceErr != nil {
+		return serviceErr
+	}
+	affected, err := s.oauthRepo.DeleteConsent(userID, clientID)
+	if err != nil {
+		return &DatabaseError{Message: "撤销授权失败", Err: err}
+	}
+	if affected == 0 {
+		return &NotFoundError{Message: "授权记录不存在"}
+	}
+	if _, serviceErr := s.revokeClientSessions(userID, clientID); serviceErr != nil {
+		return serviceErr
+	}
+	util.Log().Info("用户撤销 OAuth 授权 user_id=%d client_id=%s", userID, clientID)
+	return nil
+}
+
+// OAuthClientCredentials 令牌端点的应用认证信息（HTTP Basic 或表单参数）
+type OAuthClientCredentials struct {
+	ClientID     string
+	ClientSecret string
+}
+
+// OAuthTokenDTO 令牌请求DTO
+type OAuthTokenDTO struct {
+	OAuthClientCredentials
+	GrantType    string
+	Code         string
+	RedirectURI  string
+	CodeVerifier string
+	RefreshToken string
+	Scope        string
+}
+
+// OAuthTokenResult 令牌响应
+type OAuthTokenResult struct {
+	AccessToken  string
+	TokenType    string // Bearer 或 DPoP
+	ExpiresIn    int64
+	RefreshToken string
+	Scope        string
+}
+
+// Token 令牌端点：按 grant_type 签发令牌
+func (s *OAuthServerService) Token(ctx *BusinessContext, dto *OAuthTokenDTO) (*OAuthTokenResult, ServiceError) {
+	client, serviceErr := s.authenticateClient(dto.OAuthClientCredentials)
+	if serviceErr != nil {
+		return nil, serviceErr
+	}
+	if !util.ContainsString(splitList(client.GrantTypes), dto.GrantType) {
+		switch dto.GrantType {
+		case GrantAuthorizationCode, GrantRefreshToken, GrantClientCredentials:
+			return nil, &OAuthProtocolError{ErrorCode: "unauthorized_client", Message: "该应用不允许使用此授权类型"}
+		default:
+			return nil, &OAuthProtocolError{ErrorCode: "unsupported_grant_type", Message: "不支持的授权类型"}
+		}
+	}
+
+	switch dto.GrantType {
+	case GrantAuthorizationCode:
+		return s.exchangeCode(ctx, client, dto)
+	case GrantRefreshToken:
+		// 沿用用户登录的旋转与重放检测；scope 参数被忽略，沿用原授权范围
+		result, serviceErr := s.users.RefreshToken(ctx, &RefreshTokenDTO{RefreshToken: dto.RefreshToken, ClientID: client.ClientID})
+		if serviceErr != nil {
+			if serviceErr.GetCode() >= 50000 {
+				return nil, serviceErr
+			}
+			return nil, &OAuthProtocolError{ErrorCode: "invalid_grant", Message: serviceErr.GetMessage()}
+		}
+		return &OAuthTokenResult{
+			AccessToken:  result.AccessToken,
+			TokenType:    ctx.TokenScheme(),
+			ExpiresIn:    int64(JWT.AccessTokenExpire.Seconds()),
+			RefreshToken: result.RefreshToken,
+			Scope:        result.Scope,
+		}, nil
+	default:
+		return s.clientCredentials(ctx, client, dto)
+	}
+}
+
+// exchangeCode 授权码换取令牌：校验应用、回调地址与 PKCE code_verifier，授权码一次性使用
+func (s *OAuthServerService) exchangeCode(ctx *BusinessContext, client *model.OAuthClient, dto *OAuthTokenDTO) (*OAuthTokenResult, ServiceError) {
+	if dto.Code == "" || dto.CodeVerifier == "" {
+		return nil, &OAuthProtocolError{ErrorCode: "invalid_request", Message: "缺少 code 或 code_verifier"}
+	}
+	raw, err := cache.RedisClient.GetDel(context.Background(), fmt.Sprintf(oauthCodeKey, util.SHA256Hex(dto.Code))).Bytes()
+	if err != nil {
+		return nil, &OAuthProtocolError{ErrorCode: "invalid_grant", Message: "授权码无效或已过期"}
+	}
+	var code oauthCode
+	if err := json.Unmarshal(raw, &code); err != nil || code.ClientID != client.ClientID || code.RedirectURI != dto.RedirectURI {
+		return nil, &OAuthProtocolError{ErrorCode: "invalid_grant", Message: "授权码无效或已过期"}
+	}
+	if subtle.ConstantTimeCompare([]byte(oauth.CodeChallengeS256(dto.CodeVerifier)), []byte(code.CodeChallenge)) != 1 {
+		return nil, &OAuthProtocolError{ErrorCode: "invalid_grant", Message: "code_verifier 校验失败"}
+	}
+
+	user, err := s.userRepo.FindByID(code.UserID)
+	if err != nil {
+		return nil, &OAuthProtocolError{ErrorCode: "invalid_grant", Message: "用户不存在"}
+	}
+	if serviceErr := checkUserStatus(user); serviceErr != nil {
+		return nil, &OAuthProtocolError{ErrorCode: "invalid_grant", Message: serviceErr.GetMessage()}
+	}
+
+	accessToken, refreshToken, serviceErr := s.users.issueClientTokenPair(ctx, user, client, code.Scope)
+	if serviceErr != nil {
+		return nil, serviceErr
+	}
+	util.Log().Info("OAuth 应用换取令牌 user_id=%d client_id=%s", user.ID, client.ClientID)
+
+	result := &OAuthTokenResult{
+		AccessToken: accessToken,
+		TokenType:   ctx.TokenScheme(),
+		ExpiresIn:   int64(JWT.AccessTokenExpire.Seconds()),
+		Scope:       code.Scope,
+	}
+	// 未开通 refresh_token 授权的应用不下发刷新令牌，会话记录仍保留供用户在会话列表中查看与撤销
+	if util.ContainsString(splitList(client.GrantTypes), GrantRefreshToken) {
+		result.RefreshToken = refreshToken
+	}
+	return result, nil
+}
+
+// clientCredentials 以应用自身身份签发令牌（无用户、无刷新令牌）
+func (s *OAuthServerService) clientCredentials(ctx *BusinessContext, client *model.OAuthClient, dto *OAuthTokenDTO) (*OAuthTokenResult, ServiceError) {
+	scopes, ok := resolveScopes(dto.Scope, splitList(client.Scopes))
+	if !ok {
+		return nil, &OAuthProtocolError{ErrorCode: "invalid_scope", Message: "申请的作用域超出应用允许范围"}
+	}
+	scope := strings.Join(scopes, " ")
+	accessToken, err := GenerateToken(JWTClaims{
+		JTI:          uuid.NewString(),
+		ClientID:     client.ClientID,
+		Scope:        scope,
+		Confirmation: newConfirmation(ctx.DPoPKey),
+	}, ClientAccessToken, OAuthServer.ClientTokenExpire)
+	if err != nil {
+		return nil, &BusinessError{Message: "生成访问令牌失败", Code: 50000, Err: err}
+	}
+	return &OAuthTokenResult{
+		AccessToken: accessToken,
+		TokenType:   ctx.TokenScheme(),
+		ExpiresIn:   int64(OAuthServer.ClientTokenExpire.Seconds()),
+		Scope:       scope,
+	}, nil
+}
+
+// OAuthTokenRequestDTO 内省与吊销请求DTO
+type OAuthTokenRequestDTO struct {
+	OAuthClientCredentials
+	Token         string
+	TokenTypeHint string // 令牌自带类型声明，提示仅作参考
+}
+
+// IntrospectionResult 令牌内省结果（RFC 7662），Active 为 false 时其余字段为空
+type IntrospectionResult struct {
+	Active    bool
+	Scope     string
+	ClientID  string
+	Subject   string
+	TokenType string
+	ExpiresAt int64
+	IssuedAt  int64
+	JTI       string
+	DPoPKey   string // 令牌绑定的 DPoP 公钥指纹（cnf.jkt）
+}
+
+// Introspect 令牌内省：调用方须为已注册应用；刷新令牌仅对其所属应用可见
+func (s *OAuthServerService) Introspect(ctx *BusinessContext, dto *OAuthTokenRequestDTO) (*IntrospectionResult, ServiceError) {
+	caller, serviceErr := s.authenticateClient(dto.OAuthClientCredentials)
+	if serviceErr != nil {
+		return nil, serviceErr
+	}
+	inactive := &IntrospectionResult{Active: false}
+
+	claims, err := ParseJWT(dto.Token)
+	if err != nil {
+		return inactive, nil
+	}
+	switch claims.TokenType {
+	case AccessToken:
+		if s.tokenVersions.Verify(claims) != nil {
+			return inactive, nil
+		}
+	case ClientAccessToken:
+	case RefreshToken:
+		if claims.ClientID != caller.ClientID {
+			return inactive, nil
+		}
+		record, err := s.tokenRepo.FindByJTI(claims.JTI)
+		if err != nil || record.Revoked || time.Now().After(record.ExpiresAt) || s.tokenVersions.Verify(claims) != nil {
+			return inactive, nil
+		}
+	default:
+		return inactive, nil
+	}
+	if claims.ClientID != "" {
+		if _, serviceErr := s.findClient(claims.ClientID); serviceErr != nil {
+			return inactive, nil
+		}
+		if s.CheckRevoked(claims) != nil {
+			return inactive, nil
+		}
+	}
+
+	result := &IntrospectionResult{
+		Active:    true,
+		Scope:     claims.Scope,
+		ClientID:  claims.ClientID,
+		Subject:   claims.UserID,
+		TokenType: string(claims.TokenType),
+		JTI:       claims.JTI,
+		DPoPKey:   claims.BoundKey(),
+	}
+	if claims.ExpiresAt != nil {
+		result.ExpiresAt = claims.ExpiresAt.Unix()
+	}
+	if claims.IssuedAt != nil {
+		result.IssuedAt = claims.IssuedAt.Unix()
+	}
+	return result, nil
+}
+
+// Revoke 令牌吊销（RFC 7009）：仅能吊销签发给调用方应用的令牌，无效或不属于该应用的令牌直接忽略
+// 吊销刷新令牌会撤销整个授权会话，并使该会话已签发的访问令牌一并失效
+func (s *OAuthServerService) Revoke(ctx *BusinessContext, dto *OAuthTokenRequestDTO) ServiceError {
+	client, serviceErr := s.authenticateClient(dto.OAuthClientCredentials)
+	if serviceErr != nil {
+		return serviceErr
+	}
+	claims, err := ParseJWT(dto.Token)
+	if err != nil || claims.ClientID != client.ClientID {
+		return nil
+	}
+
+	switch claims.TokenType {
+	case RefreshToken:
+		userID, err := strconv.ParseUint(claims.UserID, 10, 64)
+		if err != nil {
+			return nil
+		}
+		if _, err := s.tokenRepo.RevokeSession(uint(userID), claims.SessionID); err != nil {
+			return &DatabaseError{Message: "撤销刷新令牌失败", Err: err}
+		}
+		if err := denySessions(claims.SessionID); err != nil {
+			return &ExternalAPIError{Message: "吊销令牌失败", Err: err}
+		}
+		return nil
+	case AccessToken, ClientAccessToken:
+		if claims.JTI == "" || claims.ExpiresAt == nil {
+			return nil
+		}
+		return s.denyToken(claims.JTI, claims.ExpiresAt.Time)
+	}
+	return nil
+}
+
+// CheckRevoked 检查应用令牌的 jti 是否已被吊销（所属会话的撤销由 SessionService.CheckRevoked 检查）
+func (s *OAuthServerService) CheckRevoked(claims *JWTClaims) ServiceError {
+	if claims.JTI == "" {
+		return nil
+	}
+	n, err := cache.RedisClient.Exists(context.Background(), fmt.Sprintf(oauthRevokedKey, claims.JTI)).Result()
+	if err != nil {
+		return &AuthError{Message: "认证令牌状态校验失败", Err: err}
+	}
+	if n > 0 {
+		return &AuthError{Message: "认证令牌已被吊销"}
+	}
+	return nil
+}
+
+// revokeClientSessions 撤销签发给应用的刷新令牌，并将其会话加入撤销列表使访问令牌立即失效
+// userID 为 0 时撤销该应用的全部授权，返回撤销的刷新令牌数量
+func (s *OAuthServerService) revokeClientSessions(userID uint, clientID string) (int64, ServiceError) {
+	sessionIDs, err := s.tokenRepo.ListSessionIDsByClient(userID, clientID)
+	if err != nil {
+		return 0, &DatabaseError{Message: "查询应用会话失败", Err: err}
+	}
+	revoked, err := s.tokenRepo.RevokeByClient(userID, clientID)
+	if err != nil {
+		return 0, &DatabaseError{Message: "撤销应用令牌失败", Err: err}
+	}
+	if err := denySessions(sessionIDs...); err != nil {
+		return 0, &ExternalAPIError{Message: "撤销应用令牌失败", Err: err}
+	}
+	return revoked, nil
+}
+
+// denyToken 将 jti 加入吊销列表直至 until
+func (s *OAuthServerService) denyToken(id string, until time.Time) ServiceError {
+	ttl := time.Until(until)
+	if id == "" || ttl <= 0 {
+		return nil
+	}
+	if err := cache.RedisClient.Set(context.Background(), fmt.Sprintf(oauthRevokedKey, id), 1, ttl).Err(); err != nil {
+		return &ExternalAPIError{Message: "吊销令牌失败", Err: err}
+	}
+	return nil
+}
+
+// authenticateClient 校验应用凭证：机密应用须提供正确的 client_secret，公开应用不得提供
+func (s *OAuthServerService) authenticateClient(creds OAuthClientCredentials) (*model.OAuthClient, ServiceError) {
+	invalid := &OAuthProtocolError{ErrorCode: "invalid_client", Message: "应用认证失败"}
+	if creds.ClientID == "" {
+		return nil, invalid
+	}
+	client, serviceErr := s.findClient(creds.ClientID)
+	if serviceErr != nil {
+		if _, ok := serviceErr.(*NotFoundError); ok {
+			return nil, invalid
+		}
+		return nil, serviceErr
+	}
+	if client.Public {
+		if creds.ClientSecret != "" {
+			return nil, invalid
+		}
+		return client, nil
+	}
+	if subtle.ConstantTimeCompare([]byte(util.SHA256Hex(creds.ClientSecret)), []byte(client.SecretHash)) != 1 {
+		return nil, invalid
+	}
+	return client, nil
+}
+
+// findClient 查找未吊销的应用
+func (s *OAuthServerService) findClient(clientID string) (*model.OAuthClient, ServiceError) {
+	client, err := s.oauthRepo.FindClient(clientID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, &NotFoundError{Message: "OAuth 应用不存在或已吊销"}
+		}
+		return nil, &DatabaseError{Message: "查询 OAuth 应用失败", Err: err}
+	}
+	return client, nil
+}
+
+// resolveScopes 解析空格分隔的作用域，须为 allowed 的子集；为空时授予全部允许的作用域
+func resolveScopes(requested string, allowed []string) ([]string, bool) {
+	fields := strings.Fields(requested)
+	if len(fields) == 0 {
+		return allowed, len(allowed) > 0
+	}
+	scopes := make([]string, 0, len(fields))
+	for _, scope := range fields {
+		if !util.ContainsString(allowed, scope) {
+			return nil, false
+		}
+		if !util.ContainsString(scopes, scope) {
+			scopes = append(scopes, scope)
+		}
+	}
+	return scopes, true
+}
+
+// isSubset 判断 items 是否全部包含在 set 中
+func isSubset(items, set []string) bool {
+	for _, item := range items {
+		if !util.ContainsString(set, item) {
+			return false
+		}
+	}
+	return true
+}
+
+// appendQuery 向回调地址追加查询参数（保留已注册地址中的参数）
+func appendQuery(rawURL string, values url.Values) string {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return rawURL
+	}
+	query := u.Query()
+	for key, vals := range values {
+		for _, v := range vals {
+			query.Add(key, v)
+		}
+	}
+	u.RawQuery = query.Encode()
+	return u.String()
+}
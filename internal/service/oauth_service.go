@@ -0,0 +1,313 @@
+package service
+
+import (
+	"context"
+	"crypto/subtle"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"go-one/internal/cache"
+	"go-one/internal/model"
+	"go-one/internal/oauth"
+	"go-one/internal/repository"
+	"go-one/util"
+	"net/http"
+	"os"
+	"regexp"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// OAuthConfig 第三方登录配置
+type OAuthConfig struct {
+	StateExpire time.Duration // 发起授权到回调的时限
+}
+
+var OAuth *OAuthConfig
+
+// OAuthProviders 已配置的 OIDC 身份提供方
+var OAuthProviders *oauth.Registry
+
+// oauthStateKey 授权流程状态（provider、nonce、PKCE code_verifier），回调时一次性取出
+const oauthStateKey = "oauth:state:%s"
+
+// InitOAuth 初始化第三方登录配置
+// OAUTH_PROVIDERS 为逗号分隔的提供方名称，每个提供方读取 OAUTH_<NAME>_ISSUER、_CLIENT_ID、_CLIENT_SECRET、
+// _SCOPES（空格或逗号分隔）、_REDIRECT_URL 与 _TRUST_EMAIL
+func InitOAuth() {
+	var configs []oauth.ProviderConfig
+	for _, name := range splitList(strings.ToLower(os.Getenv("OAUTH_PROVIDERS"))) {
+		prefix := "OAUTH_" + strings.ToUpper(name) + "_"
+		cfg := oauth.ProviderConfig{
+			Name:         name,
+			Issuer:       os.Getenv(prefix + "ISSUER"),
+			ClientID:     os.Getenv(prefix + "CLIENT_ID"),
+			ClientSecret: os.Getenv(prefix + "CLIENT_SECRET"),
+			Scopes:       strings.Fields(strings.ReplaceAll(os.Getenv(prefix+"SCOPES"), ",", " ")),
+			RedirectURL:  os.Getenv(prefix + "REDIRECT_URL"),
+			TrustEmail:   os.Getenv(prefix+"TRUST_EMAIL") == "true",
+		}
+		if cfg.Issuer == "" || cfg.ClientID == "" || cfg.RedirectURL == "" {
+			util.Log().Panic("OAuth 提供方 %s 缺少 ISSUER、CLIENT_ID 或 REDIRECT_URL 配置", name)
+		}
+		if len(cfg.Scopes) == 0 {
+			cfg.Scopes = []string{"openid", "email", "profile"}
+		}
+		configs = append(configs, cfg)
+	}
+
+	OAuth = &OAuthConfig{
+		StateExpire: time.Duration(envInt64("OAUTH_STATE_EXPIRE", 600)) * time.Second,
+	}
+	OAuthProviders = oauth.NewRegistry(configs, &http.Client{Timeout: 10 * time.Second})
+
+	util.Log().Info("第三方登录配置初始化完成，提供方: %v", OAuthProviders.Names())
+}
+
+// oauthState 授权流程状态
+type oauthState struct {
+	Provider     string `json:"provider"`
+	Nonce        string `json:"nonce"`
+	CodeVerifier string `json:"code_verifier"`
+}
+
+// OAuthService 第三方（OIDC）登录服务
+type OAuthService struct {
+	userRepo     repository.UserRepository
+	identityRepo repository.UserIdentityRepository
+	users        *UserService
+	providers    *oauth.Registry
+	hasher       PasswordHasher
+}
+
+// NewOAuthService 创建第三方登录服务实例
+func NewOAuthService(userRepo repository.UserRepository, identityRepo repository.UserIdentityRepository,
+	users *UserService, providers *oauth.Registry, hasher PasswordHasher) *OAuthService {
+	return &OAuthService{
+		userRepo:     userRepo,
+		identityRepo: identityRepo,
+		users:        users,
+		providers:    providers,
+		hasher:       hasher,
+	}
+}
+
+// Providers 已配置的身份提供方名称
+func (s *OAuthService) Providers() []string {
+	return s.providers.Names()
+}
+
+// OAuthStartResult 发起授权结果
+// State 需由接口层绑定到发起请求的客户端（Cookie），回调时一并校验
+type OAuthStartResult struct {
+	AuthorizationURL string
+	State            string
+}
+
+// StartLogin 生成 state、nonce 与 PKCE 参数并返回提供方授权地址
+func (s *OAuthService) StartLogin(ctx *BusinessContext, providerName string) (*OAuthStartResult, ServiceError) {
+	provider, ok := s.providers.Get(providerName)
+	if !ok {
+		return nil, &NotFoundError{Message: "不支持的登录方式"}
+	}
+
+	state, err := util.RandomToken(32)
+	if err != nil {
+		return nil, &BusinessError{Message: "生成授权参数失败", Code: 50000, Err: err}
+	}
+	nonce, err := util.RandomToken(32)
+	if err != nil {
+		return nil, &BusinessError{Message: "生成授权参数失败", Code: 50000, Err: err}
+	}
+	verifier, challenge, err := oauth.NewPKCE()
+	if err != nil {
+		return nil, &BusinessError{Message: "生成授权参数失败", Code: 50000, Err: err}
+	}
+
+	authURL, err := provider.AuthCodeURL(ctx.Context, state, nonce, challenge)
+	if err != nil {
+		return nil, &ExternalAPIError{Message: "获取身份提供方配置失败", Err: err}
+	}
+
+	payload, _ := json.Marshal(oauthState{Provider: providerName, Nonce: nonce, CodeVerifier: verifier})
+	if err := cache.RedisClient.Set(context.Background(), fmt.Sprintf(oauthStateKey, state), payload, OAuth.StateExpire).Err(); err != nil {
+		return nil, &ExternalAPIError{Message: "保存授权状态失败", Err: err}
+	}
+
+	return &OAuthStartResult{AuthorizationURL: authURL, State: state}, nil
+}
+
+// OAuthCallbackDTO 授权回调请求DTO
+type OAuthCallbackDTO struct {
+	Provider           string
+	Code               string
+	State              string
+	BoundState         string // 发起授权时写入客户端 Cookie 的 state
+	TrustedDeviceToken string
+}
+
+// CompleteLogin 校验回调 state，使用授权码换取并校验 ID Token，关联本地用户后完成登录
+// 结果与 Login 一致（可能需要二次验证）
+func (s *OAuthService) CompleteLogin(ctx *BusinessContext, dto *OAuthCallbackDTO) (*LoginResult, ServiceError) {
+	if dto.Code == "" || dto.State == "" {
+		return nil, &ValidationError{Message: "缺少授权码或state", Code: 40000}
+	}
+	provider, ok := s.providers.Get(dto.Provider)
+	if !ok {
+		return nil, &NotFoundError{Message: "不支持的登录方式"}
+	}
+	// state 必须与发起授权的客户端一致，防止登录 CSRF
+	if dto.BoundState == "" || subtle.ConstantTimeCompare([]byte(dto.State), []byte(dto.BoundState)) != 1 {
+		return nil, &AuthError{Message: "授权状态无效，请重新发起登录"}
+	}
+
+	// state 一次性使用
+	raw, err := cache.RedisClient.GetDel(context.Background(), fmt.Sprintf(oauthStateKey, dto.State)).Bytes()
+	if err != nil {
+		return nil, &AuthError{Message: "授权已过期，请重新发起登录"}
+	}
+	var state oauthState
+	if err := json.Unmarshal(raw, &state); err != nil || state.Provider != dto.Provider {
+		return nil, &AuthError{Message: "授权状态无效，请重新发起登录"}
+	}
+
+	token, err := provider.Exchange(ctx.Context, dto.Code, state.CodeVerifier)
+	if err != nil {
+		return nil, &ExternalAPIError{Message: "授权码换取令牌失败", Err: err}
+	}
+	claims, err := provider.VerifyIDToken(ctx.Context, token.IDToken, state.Nonce)
+	if err != nil {
+		util.Log().Warning("第三方登录 ID Token 校验失败 provider=%s ip=%s: %v", dto.Provider, ctx.ClientIP, err)
+		return nil, &AuthError{Message: "第三方身份校验失败"}
+	}
+
+	user, serviceErr := s.resolveUser(provider.Config(), claims)
+	if serviceErr != nil {
+		return nil, serviceErr
+	}
+	if serviceErr := checkUserStatus(user); serviceErr != nil {
+		return nil, serviceErr
+	}
+
+	util.Log().Info("用户通过第三方登录 user_id=%d provider=%s ip=%s", user.ID, dto.Provider, ctx.ClientIP)
+	return s.users.completeLogin(ctx, user, dto.TrustedDeviceToken)
+}
+
+// resolveUser 按外部身份查找本地用户；首次登录时关联已验证同一邮箱的账号（仅限受信任的提供方）或创建新用户
+func (s *OAuthService) resolveUser(cfg oauth.ProviderConfig, claims *oauth.IDTokenClaims) (*model.User, ServiceError) {
+	email := strings.TrimSpace(claims.Email)
+	emailVerified := email != "" && bool(claims.EmailVerified) && cfg.TrustEmail
+
+	identity, err := s.identityRepo.Find(cfg.Name, claims.Subject)
+	if err == nil {
+		if err := s.identityRepo.TouchLogin(identity.ID, email); err != nil {
+			util.Log().Warning("更新第三方身份登录时间失败 identity_id=%d: %v", identity.ID, err)
+		}
+		user, err := s.userRepo.FindByID(identity.UserID)
+		if err != nil {
+			return nil, &NotFoundError{Message: "用户不存在"}
+		}
+		return user, nil
+	}
+	if !errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, &DatabaseError{Message: "查询第三方身份失败", Err: err}
+	}
+
+	identity = &model.UserIdentity{Provider: cfg.Name, Subject: claims.Subject, Email: email}
+	now := time.Now()
+	identity.LastLoginAt = &now
+
+	// 邮箱已被本站账号验证占用
+	if email != "" {
+		owner, err := s.userRepo.FindByVerifiedEmail(email)
+		if err == nil {
+			if !emailVerified {
+				return nil, &BusinessError{Message: "该邮箱已注册，请使用原账号登录", Code: 40009}
+			}
+			identity.UserID = owner.ID
+			if err := s.identityRepo.Create(identity); err != nil {
+				return nil, &DatabaseError{Message: "关联第三方身份失败", Err: err}
+			}
+			util.Log().Info("第三方身份已按验证邮箱关联到已有账号 user_id=%d provider=%s", owner.ID, cfg.Name)
+			return owner, nil
+		}
+		if !errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, &DatabaseError{Message: "查询用户失败", Err: err}
+		}
+	}
+
+	// 创建新用户前按注册策略校验：密码为不可用的随机值，需要时可通过找回密码设置
+	if serviceErr := s.users.registration.CheckExternal(email, emailVerified); serviceErr != nil {
+		return nil, serviceErr
+	}
+	username, serviceErr := s.availableUsername(claims)
+	if serviceErr != nil {
+		return nil, serviceErr
+	}
+	randomPassword, err := util.RandomToken(32)
+	if err != nil {
+		return nil, &BusinessError{Message: "创建用户失败", Code: 50000, Err: err}
+	}
+	hashedPassword, err := s.hasher.Hash(randomPassword)
+	if err != nil {
+		return nil, &DatabaseError{Message: "密码加密失败", Err: err}
+	}
+	nickname := strings.TrimSpace(claims.Name)
+	if nickname == "" {
+		nickname = username
+	}
+	user := &model.User{
+		Username:      username,
+		Email:         email,
+		Password:      hashedPassword,
+		Nickname:      nickname,
+		Avatar:        claims.Picture,
+		Status:        model.UserStatusActive,
+		EmailVerified: emailVerified,
+	}
+	if emailVerified {
+		user.EmailVerifiedAt = &now
+	}
+	if err := s.identityRepo.CreateWithUser(user, identity); err != nil {
+		return nil, &DatabaseError{Message: "创建用户失败", Err: err}
+	}
+	util.Log().Info("通过第三方登录创建用户 user_id=%d provider=%s", user.ID, cfg.Name)
+	return user, nil
+}
+
+// usernameUnsafeChars 用户名中不允许的字符
+var usernameUnsafeChars = regexp.MustCompile(`[^a-zA-Z0-9_.-]+`)
+
+// availableUsername 从 preferred_username 或邮箱前缀派生未被占用的用户名
+func (s *OAuthService) availableUsername(claims *oauth.IDTokenClaims) (string, ServiceError) {
+	base := claims.PreferredUsername
+	if base == "" {
+		base, _, _ = strings.Cut(claims.Email, "@")
+	}
+	base = usernameUnsafeChars.ReplaceAllString(base, "")
+	if len(base) > 40 {
+		base = base[:40]
+	}
+	if len(base) < 3 {
+		base = "user"
+	}
+
+	candidate := base
+	for i := 0; i < 5; i++ {
+		_, err := s.userRepo.FindByUsername(candidate)
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return candidate, nil
+		}
+		if err != nil {
+			return "", &DatabaseError{Message: "查询用户失败", Err: err}
+		}
+		suffix, err := util.RandomToken(4)
+		if err != nil {
+			return "", &BusinessError{Message: "创建用户失败", Code: 50000, Err: err}
+		}
+		candidate = base + "_" + strings.ToLower(usernameUnsafeChars.ReplaceAllString(suffix, ""))
+	}
+	return "", &BusinessError{Message: "生成用户名失败，请重试", Code: 50000}
+}
@@ -0,0 +1,184 @@
+package service
+
+import (
+	"encoding/json"
+	"go-one/internal/model"
+	"go-one/internal/oauth"
+	"go-one/internal/repository"
+	"strings"
+	"testing"
+
+	"gorm.io/gorm"
+)
+
+// fakeUserRepo 内存用户仓储，仅实现第三方登录用到的查询
+type fakeUserRepo struct {
+	repository.UserRepository
+	users []*model.User
+}
+
+func (r *fakeUserRepo) FindByID(id uint) (*model.User, error) {
+	for _, u := range r.users {
+		if u.ID == id {
+			return u, nil
+		}
+	}
+	return nil, gorm.ErrRecordNotFound
+}
+
+func (r *fakeUserRepo) FindByUsername(username string) (*model.User, error) {
+	for _, u := range r.users {
+		if u.Username == username {
+			return u, nil
+		}
+	}
+	return nil, gorm.ErrRecordNotFound
+}
+
+func (r *fakeUserRepo) FindByVerifiedEmail(email string) (*model.User, error) {
+	for _, u := range r.users {
+		if u.EmailVerified && strings.EqualFold(u.Email, email) {
+			return u, nil
+		}
+	}
+	return nil, gorm.ErrRecordNotFound
+}
+
+// fakeIdentityRepo 内存外部身份仓储
+type fakeIdentityRepo struct {
+	users      *fakeUserRepo
+	identities []*model.UserIdentity
+}
+
+func (r *fakeIdentityRepo) Find(provider, subject string) (*model.UserIdentity, error) {
+	for _, identity := range r.identities {
+		if identity.Provider == provider && identity.Subject == subject {
+			return identity, nil
+		}
+	}
+	return nil, gorm.ErrRecordNotFound
+}
+
+func (r *fakeIdentityRepo) Create(identity *model.UserIdentity) error {
+	identity.ID = uint(len(r.identities) + 1)
+	r.identities = append(r.identities, identity)
+	return nil
+}
+
+func (r *fakeIdentityRepo) CreateWithUser(user *model.User, identity *model.UserIdentity) error {
+	user.ID = uint(len(r.users.users) + 1)
+	r.users.users = append(r.users.users, user)
+	identity.UserID = user.ID
+	return r.Create(identity)
+}
+
+func (r *fakeIdentityRepo) TouchLogin(id uint, email string) error {
+	return nil
+}
+
+// fakeHasher 测试用密码哈希器
+type fakeHasher struct{}
+
+func (fakeHasher) Hash(password string) (string, error)       { return "hashed:" + password, nil }
+func (fakeHasher) Verify(hash, password string) (bool, error) { return hash == "hashed:"+password, nil }
+func (fakeHasher) NeedsRehash(hash string) bool               { return false }
+
+func newTestOAuthService(existing ...*model.User) (*OAuthService, *fakeUserRepo, *fakeIdentityRepo) {
+	users := &fakeUserRepo{users: existing}
+	identities := &fakeIdentityRepo{users: users}
+	userService := &UserService{
+		registration: NewRegistrationPolicy(&RegistrationConfig{Mode: RegistrationModeOpen}, nil),
+	}
+	return NewOAuthService(users, identities, userService, nil, fakeHasher{}), users, identities
+}
+
+func idTokenClaims(t *testing.T, subject, email string, verified bool) *oauth.IDTokenClaims {
+	t.Helper()
+	raw, _ := json.Marshal(map[string]interface{}{
+		"sub":                subject,
+		"email":              email,
+		"email_verified":     verified,
+		"preferred_username": "alice",
+	})
+	claims := &oauth.IDTokenClaims{}
+	if err := json.Unmarshal(raw, claims); err != nil {
+		t.Fatalf("解析 ID Token 声明失败: %v", err)
+	}
+	return claims
+}
+
+func TestOAuthResolveUserLinksVerifiedEmail(t *testing.T) {
+	owner := &model.User{ID: 1, Username: "alice", Email: "alice@example.com", EmailVerified: true}
+	svc, users, identities := newTestOAuthService(owner)
+	cfg := oauth.ProviderConfig{Name: "stub", TrustEmail: true}
+
+	user, serviceErr := svc.resolveUser(cfg, idTokenClaims(t, "idp-1", "Alice@Example.com", true))
+	if serviceErr != nil {
+		t.Fatalf("resolveUser: %v", serviceErr)
+	}
+	if user.ID != owner.ID {
+		t.Fatalf("应关联到已验证同一邮箱的账号, got user_id=%d", user.ID)
+	}
+	if len(users.users) != 1 {
+		t.Errorf("不应创建新用户, users=%d", len(users.users))
+	}
+	if len(identities.identities) != 1 || identities.identities[0].UserID != owner.ID || identities.identities[0].Subject != "idp-1" {
+		t.Errorf("外部身份未正确关联: %+v", identities.identities)
+	}
+
+	// 再次登录按外部身份直接找到用户
+	again, serviceErr := svc.resolveUser(cfg, idTokenClaims(t, "idp-1", "alice@example.com", true))
+	if serviceErr != nil || again.ID != owner.ID {
+		t.Errorf("再次登录 = (%v, %v), want user_id=%d", again, serviceErr, owner.ID)
+	}
+	if len(identities.identities) != 1 {
+		t.Errorf("再次登录不应重复创建外部身份: %d", len(identities.identities))
+	}
+}
+
+func TestOAuthResolveUserEmailConflict(t *testing.T) {
+	tests := []struct {
+		name       string
+		trustEmail bool
+		verified   bool
+	}{
+		{name: "TrustEmail=false", trustEmail: false, verified: true},
+		{name: "提供方未验证邮箱", trustEmail: true, verified: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			owner := &model.User{ID: 1, Username: "alice", Email: "alice@example.com", EmailVerified: true}
+			svc, users, identities := newTestOAuthService(owner)
+			cfg := oauth.ProviderConfig{Name: "stub", TrustEmail: tt.trustEmail}
+
+			_, serviceErr := svc.resolveUser(cfg, idTokenClaims(t, "idp-1", "alice@example.com", tt.verified))
+			if serviceErr == nil || serviceErr.GetCode() != 40009 {
+				t.Fatalf("err = %v, want 40009 邮箱冲突", serviceErr)
+			}
+			if len(identities.identities) != 0 || len(users.users) != 1 {
+				t.Errorf("冲突时不应关联或创建账号: identities=%d users=%d", len(identities.identities), len(users.users))
+			}
+		})
+	}
+}
+
+func TestOAuthResolveUserCreatesUser(t *testing.T) {
+	// 已存在同名但邮箱未验证的账号：不关联，新建用户并派生不冲突的用户名
+	existing := &model.User{ID: 1, Username: "alice", Email: "alice@example.com"}
+	svc, users, identities := newTestOAuthService(existing)
+	cfg := oauth.ProviderConfig{Name: "stub", TrustEmail: false}
+
+	user, serviceErr := svc.resolveUser(cfg, idTokenClaims(t, "idp-2", "alice@example.com", true))
+	if serviceErr != nil {
+		t.Fatalf("resolveUser: %v", serviceErr)
+	}
+	if user.ID == existing.ID || user.Username == existing.Username {
+		t.Fatalf("应创建新用户, got %+v", user)
+	}
+	if user.EmailVerified {
+		t.Error("未信任提供方的邮箱时不应标记为已验证")
+	}
+	if len(users.users) != 2 || len(identities.identities) != 1 || identities.identities[0].UserID != user.ID {
+		t.Errorf("users=%d identities=%+v", len(users.users), identities.identities)
+	}
+}
@@ -0,0 +1,211 @@
+package service
+
+import (
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"go-one/util"
+	"os"
+	"strings"
+
+	"golang.org/x/crypto/argon2"
+	"golang.org/x/crypto/bcrypt"
+)
+
+const (
+	// PasswordAlgorithmBcrypt bcrypt 哈希（$2a$/$2b$/$2y$ 前缀）
+	PasswordAlgorithmBcrypt = "bcrypt"
+	// PasswordAlgorithmArgon2id argon2id 哈希（PHC 格式 $argon2id$v=19$m=...,t=...,p=...$salt$hash）
+	PasswordAlgorithmArgon2id = "argon2id"
+
+	// bcryptMaxPasswordBytes bcrypt 可处理的最大密码字节数，超出时 GenerateFromPassword 返回 ErrPasswordTooLong
+	bcryptMaxPasswordBytes = 72
+)
+
+// PasswordHashConfig 密码哈希配置
+// 新密码按 Algorithm 及对应参数生成哈希；已有哈希使用的算法或参数与之不一致时，登录成功后自动重新哈希
+type PasswordHashConfig struct {
+	Algorithm     string
+	BcryptCost    int
+	Argon2Time    uint32 // 迭代次数
+	Argon2Memory  uint32 // 内存（KiB）
+	Argon2Threads uint8
+	Argon2KeyLen  uint32
+	Argon2SaltLen uint32
+}
+
+var PasswordHashing *PasswordHashConfig
+
+// Passwords 全局密码哈希器，由 InitPasswordHasher 按配置创建
+var Passwords PasswordHasher
+
+// PasswordHasher 密码哈希接口
+// 哈希串自描述算法与参数，Verify 可校验任意受支持算法生成的哈希
+type PasswordHasher interface {
+	Hash(password string) (string, error)
+	Verify(hash, password string) (bool, error)
+	NeedsRehash(hash string) bool
+}
+
+// InitPasswordHasher 初始化密码哈希配置
+func InitPasswordHasher() {
+	algorithm := strings.ToLower(strings.TrimSpace(os.Getenv("PASSWORD_HASH_ALGORITHM")))
+	if algorithm == "" {
+		algorithm = PasswordAlgorithmArgon2id
+	}
+	if algorithm != PasswordAlgorithmBcrypt && algorithm != PasswordAlgorithmArgon2id {
+		util.Log().Panic("不支持的密码哈希算法: %s", algorithm)
+	}
+
+	bcryptCost := int(envInt64("PASSWORD_BCRYPT_COST", int64(bcrypt.DefaultCost)))
+	if bcryptCost < bcrypt.MinCost || bcryptCost > bcrypt.MaxCost {
+		util.Log().Panic("PASSWORD_BCRYPT_COST 超出范围: %d", bcryptCost)
+	}
+
+	PasswordHashing = &PasswordHashConfig{
+		Algorithm:     algorithm,
+		BcryptCost:    bcryptCost,
+		Argon2Time:    uint32(envInt64("PASSWORD_ARGON2_TIME", 3)),
+		Argon2Memory:  uint32(envInt64("PASSWORD_ARGON2_MEMORY", 64*1024)),
+		Argon2Threads: uint8(envInt64("PASSWORD_ARGON2_THREADS", 2)),
+		Argon2KeyLen:  32,
+		Argon2SaltLen: 16,
+	}
+	Passwords = NewPasswordHasher(PasswordHashing)
+
+	util.Log().Info("密码哈希配置初始化完成，算法: %s", algorithm)
+}
+
+// passwordHasher 支持 bcrypt 与 argon2id 的密码哈希器
+type passwordHasher struct {
+	cfg *PasswordHashConfig
+}
+
+// NewPasswordHasher 创建密码哈希器
+func NewPasswordHasher(cfg *PasswordHashConfig) PasswordHasher {
+	return &passwordHasher{cfg: cfg}
+}
+
+// Hash 按当前配置的算法生成哈希
+func (h *passwordHasher) Hash(password string) (string, error) {
+	if h.cfg.Algorithm == PasswordAlgorithmBcrypt {
+		hashed, err := bcrypt.GenerateFromPassword([]byte(password), h.cfg.BcryptCost)
+		if err != nil {
+			return "", err
+		}
+		return string(hashed), nil
+	}
+
+	salt := make([]byte, h.cfg.Argon2SaltLen)
+	if _, err := rand.Read(salt); err != nil {
+		return "", err
+	}
+	params := argon2Params{
+		Memory:  h.cfg.Argon2Memory,
+		Time:    h.cfg.Argon2Time,
+		Threads: h.cfg.Argon2Threads,
+	}
+	key := argon2.IDKey([]byte(password), salt, params.Time, params.Memory, params.Threads, h.cfg.Argon2KeyLen)
+	return encodeArgon2id(params, salt, key), nil
+}
+
+// Verify 按哈希串自身描述的算法与参数校验密码
+func (h *passwordHasher) Verify(hash, password string) (bool, error) {
+	switch passwordAlgorithmOf(hash) {
+	case PasswordAlgorithmBcrypt:
+		err := bcrypt.CompareHashAndPassword([]byte(hash), []byte(password))
+		if errors.Is(err, bcrypt.ErrMismatchedHashAndPassword) {
+			return false, nil
+		}
+		return err == nil, err
+	case PasswordAlgorithmArgon2id:
+		params, salt, key, err := decodeArgon2id(hash)
+		if err != nil {
+			return false, err
+		}
+		computed := argon2.IDKey([]byte(password), salt, params.Time, params.Memory, params.Threads, uint32(len(key)))
+		return subtle.ConstantTimeCompare(computed, key) == 1, nil
+	default:
+		return false, errors.New("unknown password hash format")
+	}
+}
+
+// NeedsRehash 哈希使用的算法或参数与当前配置不一致时返回 true
+func (h *passwordHasher) NeedsRehash(hash string) bool {
+	algorithm := passwordAlgorithmOf(hash)
+	if algorithm != h.cfg.Algorithm {
+		return true
+	}
+	if algorithm == PasswordAlgorithmBcrypt {
+		cost, err := bcrypt.Cost([]byte(hash))
+		return err != nil || cost != h.cfg.BcryptCost
+	}
+	params, salt, key, err := decodeArgon2id(hash)
+	if err != nil {
+		return true
+	}
+	return params.Memory != h.cfg.Argon2Memory || params.Time != h.cfg.Argon2Time ||
+		params.Threads != h.cfg.Argon2Threads || uint32(len(key)) != h.cfg.Argon2KeyLen ||
+		uint32(len(salt)) != h.cfg.Argon2SaltLen
+}
+
+// passwordAlgorithmOf 根据哈希前缀识别算法，无法识别时返回空串
+func passwordAlgorithmOf(hash string) string {
+	switch {
+	case strings.HasPrefix(hash, "$argon2id$"):
+		return PasswordAlgorithmArgon2id
+	case strings.HasPrefix(hash, "$2a$"), strings.HasPrefix(hash, "$2b$"), strings.HasPrefix(hash, "$2y$"):
+		return PasswordAlgorithmBcrypt
+	default:
+		return ""
+	}
+}
+
+// argon2Params argon2id 哈希参数
+type argon2Params struct {
+	Memory  uint32
+	Time    uint32
+	Threads uint8
+}
+
+// encodeArgon2id 编码为 PHC 字符串格式
+func encodeArgon2id(params argon2Params, salt, key []byte) string {
+	return fmt.Sprintf("$argon2id$v=%d$m=%d,t=%d,p=%d$%s$%s", argon2.Version,
+		params.Memory, params.Time, params.Threads,
+		base64.RawStdEncoding.EncodeToString(salt), base64.RawStdEncoding.EncodeToString(key))
+}
+
+// decodeArgon2id 解析 PHC 字符串格式的 argon2id 哈希
+func decodeArgon2id(hash string) (argon2Params, []byte, []byte, error) {
+	var params argon2Params
+	parts := strings.Split(hash, "$")
+	if len(parts) != 6 || parts[1] != PasswordAlgorithmArgon2id {
+		return params, nil, nil, errors.New("invalid argon2id hash")
+	}
+
+	var version int
+	if _, err := fmt.Sscanf(parts[2], "v=%d", &version); err != nil {
+		return params, nil, nil, err
+	}
+	if version != argon2.Version {
+		return params, nil, nil, fmt.Errorf("unsupported argon2 version: %d", version)
+	}
+	if _, err := fmt.Sscanf(parts[3], "m=%d,t=%d,p=%d", &params.Memory, &params.Time, &params.Threads); err != nil {
+		return params, nil, nil, err
+	}
+
+	salt, err := base64.RawStdEncoding.DecodeString(parts[4])
+	if err != nil {
+		return params, nil, nil, err
+	}
+	key, err := base64.RawStdEncoding.DecodeString(parts[5])
+	if err != nil {
+		return params, nil, nil, err
+	}
+	if len(key) == 0 {
+		return params, nil, nil, errors.New("invalid argon2id hash")
+	}
+	return params, salt, key, nil
+}
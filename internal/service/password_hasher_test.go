@@ -0,0 +1,150 @@
+package service
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"golang.org/x/crypto/bcrypt"
+)
+
+// 测试使用最低成本参数，避免拖慢测试
+var (
+	testBcryptConfig = &PasswordHashConfig{
+		Algorithm:  PasswordAlgorithmBcrypt,
+		BcryptCost: bcrypt.MinCost,
+	}
+	testArgon2Config = &PasswordHashConfig{
+		Algorithm:     PasswordAlgorithmArgon2id,
+		Argon2Time:    1,
+		Argon2Memory:  1024,
+		Argon2Threads: 1,
+		Argon2KeyLen:  32,
+		Argon2SaltLen: 16,
+	}
+)
+
+func TestPasswordHasherRoundTrip(t *testing.T) {
+	tests := []struct {
+		name   string
+		cfg    *PasswordHashConfig
+		prefix string
+	}{
+		{name: "bcrypt", cfg: testBcryptConfig, prefix: "$2a$"},
+		{name: "argon2id", cfg: testArgon2Config, prefix: "$argon2id$v=19$m=1024,t=1,p=1$"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			hasher := NewPasswordHasher(tt.cfg)
+			hash, err := hasher.Hash("correct horse 电池")
+			if err != nil {
+				t.Fatalf("Hash: %v", err)
+			}
+			if !strings.HasPrefix(hash, tt.prefix) {
+				t.Errorf("hash = %s, want prefix %s", hash, tt.prefix)
+			}
+			if ok, err := hasher.Verify(hash, "correct horse 电池"); !ok || err != nil {
+				t.Errorf("Verify(正确密码) = (%v, %v), want (true, nil)", ok, err)
+			}
+			if ok, err := hasher.Verify(hash, "correct horse 电"); ok || err != nil {
+				t.Errorf("Verify(错误密码) = (%v, %v), want (false, nil)", ok, err)
+			}
+			if hasher.NeedsRehash(hash) {
+				t.Error("当前配置生成的哈希不应需要重新哈希")
+			}
+			if again, _ := hasher.Hash("correct horse 电池"); again == hash {
+				t.Error("同一密码两次哈希应使用不同的盐")
+			}
+		})
+	}
+}
+
+func TestPasswordHasherVerifyOtherAlgorithm(t *testing.T) {
+	// 切换算法后，存量哈希仍可校验并被标记为需要重新哈希
+	legacy, err := NewPasswordHasher(testBcryptConfig).Hash("secret")
+	if err != nil {
+		t.Fatalf("Hash: %v", err)
+	}
+	hasher := NewPasswordHasher(testArgon2Config)
+	if ok, err := hasher.Verify(legacy, "secret"); !ok || err != nil {
+		t.Errorf("Verify(bcrypt 哈希) = (%v, %v), want (true, nil)", ok, err)
+	}
+	if !hasher.NeedsRehash(legacy) {
+		t.Error("算法变更后应需要重新哈希")
+	}
+}
+
+func TestPasswordHasherNeedsRehash(t *testing.T) {
+	bcryptHash, _ := NewPasswordHasher(testBcryptConfig).Hash("secret")
+	argon2Hash, _ := NewPasswordHasher(testArgon2Config).Hash("secret")
+
+	withBcryptCost := *testBcryptConfig
+	withBcryptCost.BcryptCost = bcrypt.MinCost + 1
+	withMemory := *testArgon2Config
+	withMemory.Argon2Memory = 2048
+	withTime := *testArgon2Config
+	withTime.Argon2Time = 2
+	withThreads := *testArgon2Config
+	withThreads.Argon2Threads = 2
+	withKeyLen := *testArgon2Config
+	withKeyLen.Argon2KeyLen = 64
+	withSaltLen := *testArgon2Config
+	withSaltLen.Argon2SaltLen = 32
+
+	tests := []struct {
+		name string
+		cfg  *PasswordHashConfig
+		hash string
+		want bool
+	}{
+		{name: "bcrypt 成本不变", cfg: testBcryptConfig, hash: bcryptHash},
+		{name: "bcrypt 成本变更", cfg: &withBcryptCost, hash: bcryptHash, want: true},
+		{name: "argon2id 参数不变", cfg: testArgon2Config, hash: argon2Hash},
+		{name: "argon2id 内存变更", cfg: &withMemory, hash: argon2Hash, want: true},
+		{name: "argon2id 迭代次数变更", cfg: &withTime, hash: argon2Hash, want: true},
+		{name: "argon2id 并行度变更", cfg: &withThreads, hash: argon2Hash, want: true},
+		{name: "argon2id 密钥长度变更", cfg: &withKeyLen, hash: argon2Hash, want: true},
+		{name: "argon2id 盐长度变更", cfg: &withSaltLen, hash: argon2Hash, want: true},
+		{name: "argon2id 哈希损坏", cfg: testArgon2Config, hash: "$argon2id$v=19$m=1024,t=1,p=1$", want: true},
+		{name: "无法识别的格式", cfg: testArgon2Config, hash: "plaintext", want: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := NewPasswordHasher(tt.cfg).NeedsRehash(tt.hash); got != tt.want {
+				t.Errorf("NeedsRehash = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestPasswordHasherVerifyRejectsMalformed(t *testing.T) {
+	hasher := NewPasswordHasher(testArgon2Config)
+	tests := []struct {
+		name string
+		hash string
+	}{
+		{name: "无法识别的格式", hash: "plaintext"},
+		{name: "argon2id 段数不足", hash: "$argon2id$v=19$m=1024,t=1,p=1$c2FsdA"},
+		{name: "argon2id 版本不支持", hash: "$argon2id$v=16$m=1024,t=1,p=1$c2FsdA$a2V5"},
+		{name: "argon2id 盐非 base64", hash: "$argon2id$v=19$m=1024,t=1,p=1$!!$a2V5"},
+		{name: "argon2id 哈希为空", hash: "$argon2id$v=19$m=1024,t=1,p=1$c2FsdA$"},
+		{name: "bcrypt 哈希截断", hash: "$2a$04$short"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if ok, err := hasher.Verify(tt.hash, "secret"); ok || err == nil {
+				t.Errorf("Verify = (%v, %v), want (false, error)", ok, err)
+			}
+		})
+	}
+}
+
+func TestPasswordHasherBcryptMaxBytes(t *testing.T) {
+	hasher := NewPasswordHasher(testBcryptConfig)
+	if _, err := hasher.Hash(strings.Repeat("a", bcryptMaxPasswordBytes)); err != nil {
+		t.Errorf("Hash(72 字节) 应成功: %v", err)
+	}
+	if _, err := hasher.Hash(strings.Repeat("a", bcryptMaxPasswordBytes+1)); !errors.Is(err, bcrypt.ErrPasswordTooLong) {
+		t.Errorf("Hash(73 字节) err = %v, want ErrPasswordTooLong", err)
+	}
+}
@@ -0,0 +1,266 @@
+package service
+
+import (
+	"bufio"
+	"fmt"
+	"go-one/util"
+	"hash/fnv"
+	"os"
+	"sort"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// 密码策略规则标识，随违规项返回给客户端用于定位提示
+const (
+	PasswordRuleMinLength = "min_length"
+	PasswordRuleMaxLength = "max_length"
+	PasswordRuleMaxBytes  = "max_bytes"
+	PasswordRuleLower     = "require_lower"
+	PasswordRuleUpper     = "require_upper"
+	PasswordRuleDigit     = "require_digit"
+	PasswordRuleSymbol    = "require_symbol"
+	PasswordRuleMaxRepeat = "max_repeat"
+	PasswordRuleUsername  = "contains_username"
+	PasswordRuleEmail     = "contains_email"
+	PasswordRuleBlocklist = "blocklist"
+)
+
+// 字符类别（PASSWORD_REQUIRED_CLASSES 取值）
+const (
+	passwordClassLower  = "lower"
+	passwordClassUpper  = "upper"
+	passwordClassDigit  = "digit"
+	passwordClassSymbol = "symbol"
+)
+
+// minUserInfoFragmentRunes 用户名或邮箱前缀短于该长度时不做包含检查，避免误伤
+const minUserInfoFragmentRunes = 3
+
+// PasswordPolicyConfig 密码策略配置
+type PasswordPolicyConfig struct {
+	MinLength       int      // 最小长度（字符数）
+	MaxLength       int      // 最大长度（字符数）
+	MaxBytes        int      // 最大长度（UTF-8 字节数），0 表示不限制；使用 bcrypt 时为 72
+	RequiredClasses []string // 必须包含的字符类别：lower/upper/digit/symbol
+	MaxRepeat       int      // 同一字符最多连续出现次数，0 表示不限制
+	RejectUserInfo  bool     // 拒绝包含用户名或邮箱前缀的密码
+	BlocklistFile   string   // 常见/泄露密码列表文件，每行一个
+}
+
+// PasswordViolation 密码策略违规项
+type PasswordViolation struct {
+	Rule    string
+	Message string
+}
+
+// PasswordPolicy 密码策略
+type PasswordPolicy struct {
+	cfg       *PasswordPolicyConfig
+	blocklist *PasswordBlocklist
+}
+
+var PasswordRules *PasswordPolicy
+
+// InitPasswordPolicy 初始化密码策略与泄露密码列表
+func InitPasswordPolicy() {
+	cfg := &PasswordPolicyConfig{
+		MinLength:       int(envInt64("PASSWORD_MIN_LENGTH", 6)),
+		MaxLength:       int(envInt64("PASSWORD_MAX_LENGTH", 128)),
+		RequiredClasses: splitList(strings.ToLower(os.Getenv("PASSWORD_REQUIRED_CLASSES"))),
+		MaxRepeat:       int(envInt64("PASSWORD_MAX_REPEAT", 0)),
+		RejectUserInfo:  os.Getenv("PASSWORD_REJECT_USER_INFO") != "false",
+		BlocklistFile:   strings.TrimSpace(os.Getenv("PASSWORD_BLOCKLIST_FILE")),
+	}
+	// bcrypt 只接受 72 字节以内的密码，超长密码须作为策略违规拒绝，而非在哈希时失败
+	if PasswordHashing != nil && PasswordHashing.Algorithm == PasswordAlgorithmBcrypt {
+		cfg.MaxBytes = bcryptMaxPasswordBytes
+	}
+	for _, class := range cfg.RequiredClasses {
+		switch class {
+		case passwordClassLower, passwordClassUpper, passwordClassDigit, passwordClassSymbol:
+		default:
+			util.Log().Panic("PASSWORD_REQUIRED_CLASSES 包含未知类别: %s", class)
+		}
+	}
+
+	blocklist := &PasswordBlocklist{}
+	if cfg.BlocklistFile != "" {
+		loaded, err := LoadPasswordBlocklist(cfg.BlocklistFile)
+		if err != nil {
+			util.Log().Panic("加载密码黑名单失败: %v", err)
+		}
+		blocklist = loaded
+	}
+	PasswordRules = NewPasswordPolicy(cfg, blocklist)
+
+	util.Log().Info("密码策略初始化完成，黑名单条目: %d", blocklist.Len())
+}
+
+// NewPasswordPolicy 创建密码策略
+func NewPasswordPolicy(cfg *PasswordPolicyConfig, blocklist *PasswordBlocklist) *PasswordPolicy {
+	if blocklist == nil {
+		blocklist = &PasswordBlocklist{}
+	}
+	return &PasswordPolicy{cfg: cfg, blocklist: blocklist}
+}
+
+// Validate 按全部规则校验密码，返回所有违规项（无违规时为空）
+// username、email 用于检查密码是否包含个人信息，可为空
+func (p *PasswordPolicy) Validate(password, username, email string) []PasswordViolation {
+	var violations []PasswordViolation
+	add := func(rule, format string, args ...interface{}) {
+		violations = append(violations, PasswordViolation{Rule: rule, Message: fmt.Sprintf(format, args...)})
+	}
+
+	length := utf8.RuneCountInString(password)
+	if length < p.cfg.MinLength {
+		add(PasswordRuleMinLength, "密码长度至少为%d个字符", p.cfg.MinLength)
+	}
+	if p.cfg.MaxLength > 0 && length > p.cfg.MaxLength {
+		add(PasswordRuleMaxLength, "密码长度不能超过%d个字符", p.cfg.MaxLength)
+	}
+	if p.cfg.MaxBytes > 0 && len(password) > p.cfg.MaxBytes {
+		add(PasswordRuleMaxBytes, "密码长度不能超过%d个字节（中文等字符每个占3个字节）", p.cfg.MaxBytes)
+	}
+
+	var hasLower, hasUpper, hasDigit, hasSymbol bool
+	for _, r := range password {
+		switch {
+		case unicode.IsLower(r):
+			hasLower = true
+		case unicode.IsUpper(r):
+			hasUpper = true
+		case unicode.IsDigit(r):
+			hasDigit = true
+		case !unicode.IsSpace(r):
+			hasSymbol = true
+		}
+	}
+	for _, class := range p.cfg.RequiredClasses {
+		switch {
+		case class == passwordClassLower && !hasLower:
+			add(PasswordRuleLower, "密码需包含小写字母")
+		case class == passwordClassUpper && !hasUpper:
+			add(PasswordRuleUpper, "密码需包含大写字母")
+		case class == passwordClassDigit && !hasDigit:
+			add(PasswordRuleDigit, "密码需包含数字")
+		case class == passwordClassSymbol && !hasSymbol:
+			add(PasswordRuleSymbol, "密码需包含特殊字符")
+		}
+	}
+
+	if p.cfg.MaxRepeat > 0 && maxConsecutiveRepeat(password) > p.cfg.MaxRepeat {
+		add(PasswordRuleMaxRepeat, "同一字符不能连续出现超过%d次", p.cfg.MaxRepeat)
+	}
+
+	if p.cfg.RejectUserInfo {
+		lower := strings.ToLower(password)
+		if containsFragment(lower, username) {
+			add(PasswordRuleUsername, "密码不能包含用户名")
+		}
+		if at := strings.LastIndex(email, "@"); at > 0 && containsFragment(lower, email[:at]) {
+			add(PasswordRuleEmail, "密码不能包含邮箱")
+		}
+	}
+
+	if p.blocklist.Contains(password) {
+		add(PasswordRuleBlocklist, "该密码过于常见或已出现在泄露数据中，请更换")
+	}
+	return violations
+}
+
+// Check 校验密码，不符合策略时返回包含全部违规项的 PasswordPolicyError
+func (p *PasswordPolicy) Check(password, username, email string) ServiceError {
+	if violations := p.Validate(password, username, email); len(violations) > 0 {
+		return &PasswordPolicyError{Message: violations[0].Message, Violations: violations}
+	}
+	return nil
+}
+
+// maxConsecutiveRepeat 返回同一字符的最长连续出现次数
+func maxConsecutiveRepeat(s string) int {
+	longest, run := 0, 0
+	var prev rune = -1
+	for _, r := range s {
+		if r == prev {
+			run++
+		} else {
+			prev, run = r, 1
+		}
+		if run > longest {
+			longest = run
+		}
+	}
+	return longest
+}
+
+// containsFragment 不区分大小写地检查 password（已转小写）是否包含 fragment
+func containsFragment(password, fragment string) bool {
+	fragment = strings.ToLower(strings.TrimSpace(fragment))
+	if utf8.RuneCountInString(fragment) < minUserInfoFragmentRunes {
+		return false
+	}
+	return strings.Contains(password, fragment)
+}
+
+// PasswordBlocklist 常见/泄露密码集合
+// 仅保存小写密码的 64 位 FNV-1a 哈希并排序，二分查找；误判概率可忽略，百万级条目约占 8MB
+type PasswordBlocklist struct {
+	hashes []uint64
+}
+
+// LoadPasswordBlocklist 从文件加载密码黑名单：每行一个密码，忽略空行与 # 开头的注释
+func LoadPasswordBlocklist(path string) (*PasswordBlocklist, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	var hashes []uint64
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		hashes = append(hashes, blocklistHash(line))
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	// 排序并去重
+	sort.Slice(hashes, func(i, j int) bool { return hashes[i] < hashes[j] })
+	unique := hashes[:0]
+	for i, h := range hashes {
+		if i == 0 || h != hashes[i-1] {
+			unique = append(unique, h)
+		}
+	}
+	return &PasswordBlocklist{hashes: unique}, nil
+}
+
+// Contains 不区分大小写地检查密码是否在黑名单中
+func (b *PasswordBlocklist) Contains(password string) bool {
+	if len(b.hashes) == 0 {
+		return false
+	}
+	h := blocklistHash(password)
+	i := sort.Search(len(b.hashes), func(i int) bool { return b.hashes[i] >= h })
+	return i < len(b.hashes) && b.hashes[i] == h
+}
+
+// Len 黑名单条目数
+func (b *PasswordBlocklist) Len() int {
+	return len(b.hashes)
+}
+
+// blocklistHash 计算小写密码的 FNV-1a 哈希
+func blocklistHash(password string) uint64 {
+	h := fnv.New64a()
+	h.Write([]byte(strings.ToLower(password)))
+	return h.Sum64()
+}
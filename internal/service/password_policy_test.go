@@ -0,0 +1,118 @@
+package service
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestPasswordPolicyValidate(t *testing.T) {
+	base := PasswordPolicyConfig{MinLength: 8, MaxLength: 64, RejectUserInfo: true}
+	withBcrypt := base
+	withBcrypt.MaxBytes = bcryptMaxPasswordBytes
+	withClasses := base
+	withClasses.RequiredClasses = []string{passwordClassLower, passwordClassUpper, passwordClassDigit, passwordClassSymbol}
+	withRepeat := base
+	withRepeat.MaxRepeat = 2
+	allowUserInfo := base
+	allowUserInfo.RejectUserInfo = false
+
+	tests := []struct {
+		name     string
+		cfg      PasswordPolicyConfig
+		password string
+		username string
+		email    string
+		want     []string
+	}{
+		{name: "符合策略", cfg: base, password: "correct horse"},
+		{name: "过短", cfg: base, password: "short", want: []string{PasswordRuleMinLength}},
+		{name: "长度按字符计", cfg: base, password: "密码密码密码密码"},
+		{name: "过长", cfg: base, password: strings.Repeat("ab", 33), want: []string{PasswordRuleMaxLength}},
+		{name: "bcrypt 72 字节以内", cfg: withBcrypt, password: strings.Repeat("密", 24)},
+		{name: "bcrypt 超过 72 字节", cfg: withBcrypt, password: strings.Repeat("密", 25), want: []string{PasswordRuleMaxBytes}},
+		{name: "未配置字节上限", cfg: base, password: strings.Repeat("密", 25)},
+		{name: "缺少全部字符类别", cfg: withClasses, password: "        ",
+			want: []string{PasswordRuleLower, PasswordRuleUpper, PasswordRuleDigit, PasswordRuleSymbol}},
+		{name: "满足全部字符类别", cfg: withClasses, password: "Abcdef1!"},
+		{name: "连续重复字符", cfg: withRepeat, password: "abcccdefg", want: []string{PasswordRuleMaxRepeat}},
+		{name: "重复次数在上限内", cfg: withRepeat, password: "abccdeffg"},
+		{name: "包含用户名（不区分大小写）", cfg: base, password: "xxAliceXX", username: "alice", want: []string{PasswordRuleUsername}},
+		{name: "包含邮箱前缀", cfg: base, password: "bob.smith2024", email: "Bob.Smith@example.com", want: []string{PasswordRuleEmail}},
+		{name: "过短的用户名不检查", cfg: base, password: "jo-password", username: "jo"},
+		{name: "关闭个人信息检查", cfg: allowUserInfo, password: "xxAliceXX", username: "alice"},
+		{name: "多项违规全部返回", cfg: withRepeat, password: "alll", username: "all",
+			want: []string{PasswordRuleMinLength, PasswordRuleMaxRepeat, PasswordRuleUsername}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := tt.cfg
+			var got []string
+			for _, v := range NewPasswordPolicy(&cfg, nil).Validate(tt.password, tt.username, tt.email) {
+				got = append(got, v.Rule)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("违规项 = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestPasswordPolicyCheck(t *testing.T) {
+	policy := NewPasswordPolicy(&PasswordPolicyConfig{MinLength: 8, MaxBytes: bcryptMaxPasswordBytes}, nil)
+	if serviceErr := policy.Check("correct horse", "", ""); serviceErr != nil {
+		t.Errorf("Check(合规密码) = %v", serviceErr)
+	}
+	serviceErr := policy.Check(strings.Repeat("a", bcryptMaxPasswordBytes+1), "", "")
+	policyErr, ok := serviceErr.(*PasswordPolicyError)
+	if !ok {
+		t.Fatalf("Check(超长密码) = %v, want *PasswordPolicyError", serviceErr)
+	}
+	if len(policyErr.Violations) != 1 || policyErr.Violations[0].Rule != PasswordRuleMaxBytes {
+		t.Errorf("Violations = %+v", policyErr.Violations)
+	}
+}
+
+func TestPasswordBlocklist(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "blocklist.txt")
+	content := "# 常见密码\n123456\n\nPassword1\n  qwerty  \npassword1\n"
+	if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+		t.Fatalf("写入黑名单失败: %v", err)
+	}
+	blocklist, err := LoadPasswordBlocklist(path)
+	if err != nil {
+		t.Fatalf("LoadPasswordBlocklist: %v", err)
+	}
+	if got := blocklist.Len(); got != 3 {
+		t.Errorf("Len = %d, want 3（忽略注释与空行，大小写变体去重）", got)
+	}
+
+	tests := []struct {
+		password string
+		want     bool
+	}{
+		{password: "123456", want: true},
+		{password: "PASSWORD1", want: true},
+		{password: "qwerty", want: true},
+		{password: "# 常见密码"},
+		{password: "1234567"},
+		{password: ""},
+	}
+	for _, tt := range tests {
+		if got := blocklist.Contains(tt.password); got != tt.want {
+			t.Errorf("Contains(%q) = %v, want %v", tt.password, got, tt.want)
+		}
+	}
+
+	policy := NewPasswordPolicy(&PasswordPolicyConfig{MinLength: 6}, blocklist)
+	violations := policy.Validate("Qwerty", "", "")
+	if len(violations) != 1 || violations[0].Rule != PasswordRuleBlocklist {
+		t.Errorf("Validate(黑名单密码) = %+v", violations)
+	}
+
+	if _, err := LoadPasswordBlocklist(filepath.Join(t.TempDir(), "missing.txt")); err == nil {
+		t.Error("黑名单文件不存在时应报错")
+	}
+}
@@ -0,0 +1,225 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"go-one/internal/cache"
+	"go-one/internal/model"
+	"go-one/internal/repository"
+	"go-one/util"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// PasswordResetConfig 找回密码配置
+type PasswordResetConfig struct {
+	Expire   time.Duration // 重置链接有效期
+	ResetURL string        // 重置页面地址，令牌以 token 查询参数附加
+	Cooldown time.Duration // 同一用户两次申请的最小间隔
+}
+
+var PasswordReset *PasswordResetConfig
+
+// passwordResetCooldownKey 找回密码申请冷却
+const passwordResetCooldownKey = "password_reset:cooldown:%d"
+
+// InitPasswordReset 初始化找回密码配置
+func InitPasswordReset() {
+	expire := int64(1800) // 默认30分钟
+	if v := os.Getenv("PASSWORD_RESET_EXPIRE"); v != "" {
+		if parsed, err := strconv.ParseInt(v, 10, 64); err == nil {
+			expire = parsed
+		}
+	}
+
+	resetURL := os.Getenv("PASSWORD_RESET_URL")
+	if resetURL == "" {
+		resetURL = "http://localhost:8080/reset-password"
+	}
+
+	PasswordReset = &PasswordResetConfig{
+		Expire:   time.Duration(expire) * time.Second,
+		ResetURL: resetURL,
+		Cooldown: time.Minute,
+	}
+
+	util.Log().Info("找回密码配置初始化完成")
+}
+
+// PasswordResetService 找回密码服务
+type PasswordResetService struct {
+	userRepo       repository.UserRepository
+	resetRepo      repository.PasswordResetRepository
+	tokenVersions  *TokenVersionService
+	mailer         Mailer
+	hasher         PasswordHasher
+	passwordPolicy *PasswordPolicy
+	securityEvents *SecurityEventService
+}
+
+// NewPasswordResetService 创建找回密码服务实例
+func NewPasswordResetService(userRepo repository.UserRepository, resetRepo repository.PasswordResetRepository,
+	tokenVersions *TokenVersionService, mailer Mailer, hasher PasswordHasher, passwordPolicy *PasswordPolicy,
+	securityEvents *SecurityEventService) *PasswordResetService {
+	return &PasswordResetService{
+		userRepo:       userRepo,
+		resetRepo:      resetRepo,
+		tokenVersions:  tokenVersions,
+		mailer:         mailer,
+		hasher:         hasher,
+		passwordPolicy: passwordPolicy,
+		securityEvents: securityEvents,
+	}
+}
+
+// ForgotPassword 向邮箱对应的账号发送重置链接
+// 为防止邮箱枚举，无论邮箱是否存在均返回成功，邮件异步发送
+func (s *PasswordResetService) ForgotPassword(ctx *BusinessContext, email string) ServiceError {
+	email = strings.TrimSpace(email)
+	if email == "" {
+		return &ValidationError{Message: "邮箱不能为空", Code: 40000}
+	}
+
+	// 优先发送给已验证该邮箱的账号；无人验证时发送给填写了该邮箱的账号（完成重置即证明邮箱归属）
+	var users []model.User
+	if owner, err := s.userRepo.FindByVerifiedEmail(email); err == nil {
+		users = []model.User{*owner}
+	} else if errors.Is(err, gorm.ErrRecordNotFound) {
+		unverified, err := s.userRepo.FindUnverifiedByEmail(email)
+		if err != nil {
+			return &DatabaseError{Message: "查询用户失败", Err: err}
+		}
+		users = unverified
+	} else {
+		return &DatabaseError{Message: "查询用户失败", Err: err}
+	}
+
+	for i := range users {
+		user := &users[i]
+		if checkUserStatus(user) != nil {
+			continue
+		}
+		key := fmt.Sprintf(passwordResetCooldownKey, user.ID)
+		if ok, err := cache.RedisClient.SetNX(context.Background(), key, 1, PasswordReset.Cooldown).Result(); err != nil || !ok {
+			continue
+		}
+		token, err := s.createResetToken(ctx, user)
+		if err != nil {
+			util.Log().Error("生成密码重置令牌失败 user_id=%d: %v", user.ID, err)
+			continue
+		}
+		go s.sendResetEmail(user, token)
+	}
+	return nil
+}
+
+// ResetPasswordDTO 重置密码请求DTO
+type ResetPasswordDTO struct {
+	Token       string
+	NewPassword string
+}
+
+// ResetPassword 使用重置令牌设置新密码，并使该用户所有已签发的令牌失效
+func (s *PasswordResetService) ResetPassword(ctx *BusinessContext, dto *ResetPasswordDTO) ServiceError {
+	if dto.Token == "" {
+		return &ValidationError{Message: "重置令牌不能为空", Code: 40000}
+	}
+	if dto.NewPassword == "" {
+		return &ValidationError{Message: "新密码不能为空", Code: 40000}
+	}
+
+	// 先校验新密码再消耗令牌，密码不合规时用户可用同一链接重试
+	tokenHash := util.SHA256Hex(dto.Token)
+	pending, err := s.resetRepo.FindValid(tokenHash)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return &AuthError{Message: "重置链接无效或已过期"}
+		}
+		return &DatabaseError{Message: "校验重置令牌失败", Err: err}
+	}
+	user, err := s.userRepo.FindByID(pending.UserID)
+	if err != nil {
+		return &NotFoundError{Message: "用户不存在"}
+	}
+	if serviceErr := s.passwordPolicy.Check(dto.NewPassword, user.Username, user.Email); serviceErr != nil {
+		return serviceErr
+	}
+
+	if _, err := s.resetRepo.Consume(tokenHash); err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return &AuthError{Message: "重置链接无效或已过期"}
+		}
+		return &DatabaseError{Message: "校验重置令牌失败", Err: err}
+	}
+
+	hashedPassword, err := s.hasher.Hash(dto.NewPassword)
+	if err != nil {
+		return &DatabaseError{Message: "密码加密失败", Err: err}
+	}
+	user.Password = hashedPassword
+	if err := s.userRepo.Update(user); err != nil {
+		return &DatabaseError{Message: "更新密码失败", Err: err}
+	}
+
+	// 作废其余重置链接，并注销全部已登录会话
+	if err := s.resetRepo.InvalidateByUser(user.ID); err != nil {
+		return &DatabaseError{Message: "作废重置令牌失败", Err: err}
+	}
+	if err := s.tokenVersions.InvalidateUserTokens(user.ID); err != nil {
+		return &DatabaseError{Message: "注销已登录会话失败", Err: err}
+	}
+
+	// 通过邮件完成重置即证明邮箱归属
+	if !user.EmailVerified && user.Email != "" {
+		if _, err := s.userRepo.FindByVerifiedEmail(user.Email); errors.Is(err, gorm.ErrRecordNotFound) {
+			if _, err := s.userRepo.MarkEmailVerified(user.ID, user.Email); err != nil {
+				util.Log().Warning("重置密码后标记邮箱已验证失败 user_id=%d: %v", user.ID, err)
+			}
+		}
+	}
+
+	util.Log().Info("用户通过找回密码重置了密码 user_id=%d ip=%s", user.ID, ctx.ClientIP)
+	s.securityEvents.Record(ctx, SecurityEvent{
+		Type:   SecurityEventPasswordChange,
+		UserID: user.ID,
+		Detail: map[string]interface{}{"method": "reset"},
+	})
+	return nil
+}
+
+// createResetToken 生成重置令牌并保存其哈希，返回明文令牌
+func (s *PasswordResetService) createResetToken(ctx *BusinessContext, user *model.User) (string, error) {
+	token, err := util.RandomToken(32)
+	if err != nil {
+		return "", err
+	}
+	record := &model.PasswordResetToken{
+		UserID:    user.ID,
+		TokenHash: util.SHA256Hex(token),
+		ExpiresAt: time.Now().Add(PasswordReset.Expire),
+		RequestIP: ctx.ClientIP,
+	}
+	if err := s.resetRepo.Create(record); err != nil {
+		return "", err
+	}
+	return token, nil
+}
+
+// sendResetEmail 发送重置链接邮件
+func (s *PasswordResetService) sendResetEmail(user *model.User, token string) {
+	link := linkWithToken(PasswordReset.ResetURL, token)
+	msg := &MailMessage{
+		To:      user.Email,
+		Subject: "重置您的密码",
+		Body: fmt.Sprintf("%s，您好：\n\n我们收到了重置账号 %s 密码的请求，请点击以下链接设置新密码（%d 分钟内有效）：\n%s\n\n如果这不是您本人的操作，请忽略此邮件，您的密码不会被修改。\n",
+			user.Nickname, user.Username, int(PasswordReset.Expire.Minutes()), link),
+	}
+	if err := s.mailer.Send(msg); err != nil {
+		util.Log().Error("发送密码重置邮件失败 user_id=%d: %v", user.ID, err)
+	}
+}
@@ -0,0 +1,223 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"go-one/internal/cache"
+	"go-one/internal/model"
+	"go-one/internal/repository"
+	"go-one/util"
+	"os"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+const (
+	// userAccessCacheKey 用户角色与权限缓存键
+	userAccessCacheKey = "rbac:user:%d"
+	// userAccessCacheTTL 用户角色与权限缓存时间（分配/撤销角色时主动删除）
+	userAccessCacheTTL = 10 * time.Minute
+)
+
+// InitRBAC 按 ADMIN_USERNAMES 为默认租户中已存在的用户授予 admin 角色（需在数据库与租户初始化之后调用）
+func InitRBAC() {
+	names := os.Getenv("ADMIN_USERNAMES")
+	if names == "" {
+		return
+	}
+	s := NewRBACService(repository.NewUserRepository(model.DB, Tenancy.DefaultID), repository.NewRoleRepository(model.DB))
+	admin, err := s.roleRepo.FindByName(model.RoleAdmin)
+	if err != nil {
+		util.Log().Error("初始化管理员失败，admin 角色不存在: %v", err)
+		return
+	}
+	for _, name := range strings.Split(names, ",") {
+		name = strings.TrimSpace(name)
+		if name == "" {
+			continue
+		}
+		user, err := s.userRepo.FindByUsername(name)
+		if err != nil {
+			util.Log().Warning("初始化管理员跳过，用户不存在: %s", name)
+			continue
+		}
+		if err := s.roleRepo.Assign(user.ID, admin.ID, 0); err != nil {
+			util.Log().Error("初始化管理员失败 username=%s: %v", name, err)
+			continue
+		}
+		s.invalidate(user.ID)
+	}
+	util.Log().Info("管理员角色初始化完成")
+}
+
+// UserAccess 用户的角色与权限
+type UserAccess struct {
+	Roles       []string `json:"roles"`
+	Permissions []string `json:"permissions"`
+}
+
+// RBACService 角色权限服务
+type RBACService struct {
+	userRepo repository.UserRepository
+	roleRepo repository.RoleRepository
+}
+
+// NewRBACService 创建角色权限服务实例
+func NewRBACService(userRepo repository.UserRepository, roleRepo repository.RoleRepository) *RBACService {
+	return &RBACService{
+		userRepo: userRepo,
+		roleRepo: roleRepo,
+	}
+}
+
+// Access 获取用户的角色与权限（优先读取 Redis 缓存）
+func (s *RBACService) Access(userID uint) (*UserAccess, error) {
+	ctx := context.Background()
+	key := fmt.Sprintf(userAccessCacheKey, userID)
+
+	if cached, err := cache.RedisClient.Get(ctx, key).Result(); err == nil {
+		var access UserAccess
+		if err := json.Unmarshal([]byte(cached), &access); err == nil {
+			return &access, nil
+		}
+	}
+
+	roles, err := s.roleRepo.RolesOfUser(userID)
+	if err != nil {
+		return nil, err
+	}
+	permissions, err := s.roleRepo.PermissionsOfUser(userID)
+	if err != nil {
+		return nil, err
+	}
+	access := &UserAccess{Roles: make([]string, len(roles)), Permissions: permissions}
+	for i := range roles {
+		access.Roles[i] = roles[i].Name
+	}
+	if data, err := json.Marshal(access); err == nil {
+		cache.RedisClient.Set(ctx, key, data, userAccessCacheTTL)
+	}
+	return access, nil
+}
+
+// ListRoles 获取全部角色及其权限
+func (s *RBACService) ListRoles(ctx *BusinessContext) ([]model.Role, ServiceError) {
+	if serviceErr := requirePermission(ctx, model.PermissionRolesManage); serviceErr != nil {
+		return nil, serviceErr
+	}
+	roles, err := s.roleRepo.List()
+	if err != nil {
+		return nil, &DatabaseError{Message: "查询角色失败", Err: err}
+	}
+	return roles, nil
+}
+
+// UserRoles 获取指定用户的角色
+func (s *RBACService) UserRoles(ctx *BusinessContext, userID uint) ([]model.Role, ServiceError) {
+	if serviceErr := requirePermission(ctx, model.PermissionRolesManage); serviceErr != nil {
+		return nil, serviceErr
+	}
+	if _, err := s.userRepo.FindByID(userID); err != nil {
+		return nil, &NotFoundError{Message: "用户不存在"}
+	}
+	roles, err := s.roleRepo.RolesOfUser(userID)
+	if err != nil {
+		return nil, &DatabaseError{Message: "查询用户角色失败", Err: err}
+	}
+	return roles, nil
+}
+
+// AssignRole 为用户分配角色
+func (s *RBACService) AssignRole(ctx *BusinessContext, userID uint, roleName string) ServiceError {
+	if serviceErr := requirePermission(ctx, model.PermissionRolesManage); serviceErr != nil {
+		return serviceErr
+	}
+	user, role, serviceErr := s.findUserAndRole(userID, roleName)
+	if serviceErr != nil {
+		return serviceErr
+	}
+
+	operatorID, _ := currentUserID(ctx)
+	if err := s.roleRepo.Assign(user.ID, role.ID, operatorID); err != nil {
+		return &DatabaseError{Message: "分配角色失败", Err: err}
+	}
+	s.invalidate(user.ID)
+
+	util.Log().Info("分配角色 user_id=%d role=%s operator=%s", user.ID, role.Name, ctx.UserUUID)
+	return nil
+}
+
+// RevokeRole 撤销用户的角色，不允许撤销最后一个管理员
+func (s *RBACService) RevokeRole(ctx *BusinessContext, userID uint, roleName string) ServiceError {
+	if serviceErr := requirePermission(ctx, model.PermissionRolesManage); serviceErr != nil {
+		return serviceErr
+	}
+	user, role, serviceErr := s.findUserAndRole(userID, roleName)
+	if serviceErr != nil {
+		return serviceErr
+	}
+
+	if role.Name == model.RoleAdmin {
+		count, err := s.roleRepo.CountUsers(role.ID)
+		if err != nil {
+			return &DatabaseError{Message: "查询角色成员失败", Err: err}
+		}
+		if count <= 1 {
+			return &BusinessError{Message: "不能撤销最后一个管理员", Code: 40009}
+		}
+	}
+
+	affected, err := s.roleRepo.Revoke(user.ID, role.ID)
+	if err != nil {
+		return &DatabaseError{Message: "撤销角色失败", Err: err}
+	}
+	if affected == 0 {
+		return &NotFoundError{Message: "用户未拥有该角色"}
+	}
+	s.invalidate(user.ID)
+
+	util.Log().Info("撤销角色 user_id=%d role=%s operator=%s", user.ID, role.Name, ctx.UserUUID)
+	return nil
+}
+
+// findUserAndRole 查找用户与角色
+func (s *RBACService) findUserAndRole(userID uint, roleName string) (*model.User, *model.Role, ServiceError) {
+	roleName = strings.TrimSpace(roleName)
+	if roleName == "" {
+		return nil, nil, &ValidationError{Message: "角色不能为空", Code: 40000}
+	}
+	user, err := s.userRepo.FindByID(userID)
+	if err != nil {
+		return nil, nil, &NotFoundError{Message: "用户不存在"}
+	}
+	role, err := s.roleRepo.FindByName(roleName)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil, &NotFoundError{Message: "角色不存在"}
+		}
+		return nil, nil, &DatabaseError{Message: "查询角色失败", Err: err}
+	}
+	return user, role, nil
+}
+
+// invalidate 删除用户角色权限缓存，使变更立即生效
+func (s *RBACService) invalidate(userID uint) {
+	if err := cache.RedisClient.Del(context.Background(), fmt.Sprintf(userAccessCacheKey, userID)).Err(); err != nil {
+		util.Log().Warning("删除用户权限缓存失败 user_id=%d: %v", userID, err)
+	}
+}
+
+// requirePermission 检查当前用户是否拥有指定权限
+func requirePermission(ctx *BusinessContext, permission string) ServiceError {
+	if ctx == nil || !ctx.IsAuthenticated() {
+		return &AuthError{Message: "未认证"}
+	}
+	if !ctx.HasPermission(permission) {
+		return &BusinessError{Message: "无权限执行该操作", Code: 40003}
+	}
+	return nil
+}
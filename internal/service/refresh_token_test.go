@@ -0,0 +1,207 @@
+package service
+
+import (
+	"go-one/internal/model"
+	"go-one/internal/repository"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// fakeTokenRepo 内存刷新令牌仓储：事务失败时回滚，记录加锁读取的 JTI
+type fakeTokenRepo struct {
+	repository.RefreshTokenRepository
+	tokens map[string]model.RefreshToken
+	inTx   bool
+	locked []string
+}
+
+func newFakeTokenRepo() *fakeTokenRepo {
+	return &fakeTokenRepo{tokens: map[string]model.RefreshToken{}}
+}
+
+func (r *fakeTokenRepo) Create(rt *model.RefreshToken) error {
+	r.tokens[rt.JTI] = *rt
+	return nil
+}
+
+func (r *fakeTokenRepo) FindByJTIForUpdate(jti string) (*model.RefreshToken, error) {
+	if r.inTx {
+		r.locked = append(r.locked, jti)
+	}
+	rt, ok := r.tokens[jti]
+	if !ok {
+		return nil, gorm.ErrRecordNotFound
+	}
+	return &rt, nil
+}
+
+func (r *fakeTokenRepo) RevokeByJTI(jti string) error {
+	rt := r.tokens[jti]
+	rt.Revoked = true
+	r.tokens[jti] = rt
+	return nil
+}
+
+// FindFamily 与 familyCTE 相同：从 jti 出发沿 rotated_from 向下遍历
+func (r *fakeTokenRepo) FindFamily(jti string) ([]model.RefreshToken, error) {
+	family := []model.RefreshToken{r.tokens[jti]}
+	for i := 0; i < len(family); i++ {
+		for _, rt := range r.tokens {
+			if rt.RotatedFrom == family[i].JTI {
+				family = append(family, rt)
+			}
+		}
+	}
+	return family, nil
+}
+
+func (r *fakeTokenRepo) RevokeFamily(jti string) (int64, error) {
+	family, _ := r.FindFamily(jti)
+	var revoked int64
+	for _, rt := range family {
+		if !rt.Revoked {
+			r.RevokeByJTI(rt.JTI)
+			revoked++
+		}
+	}
+	return revoked, nil
+}
+
+func (r *fakeTokenRepo) Transaction(fn func(repo repository.RefreshTokenRepository) error) error {
+	snapshot := make(map[string]model.RefreshToken, len(r.tokens))
+	for jti, rt := range r.tokens {
+		snapshot[jti] = rt
+	}
+	r.inTx = true
+	defer func() { r.inTx = false }()
+	if err := fn(r); err != nil {
+		r.tokens = snapshot
+		return err
+	}
+	return nil
+}
+
+// fakeEventRepo 内存安全事件仓储
+type fakeEventRepo struct {
+	repository.SecurityEventRepository
+	events []*model.SecurityEvent
+}
+
+func (r *fakeEventRepo) Create(event *model.SecurityEvent) error {
+	r.events = append(r.events, event)
+	return nil
+}
+
+// newTestSession 为用户新建一个会话，返回其刷新令牌与记录 JTI
+func newTestSession(t *testing.T, svc *UserService, repo *fakeTokenRepo, user *model.User) (string, string) {
+	t.Helper()
+	now := time.Now()
+	record := &model.RefreshToken{
+		JTI:             uuid.NewString(),
+		UserID:          user.ID,
+		SessionID:       uuid.NewString(),
+		ExpiresAt:       now.Add(time.Hour),
+		AuthenticatedAt: now,
+	}
+	_, refreshToken, serviceErr := svc.signTokenPair(repo, user, record)
+	if serviceErr != nil {
+		t.Fatalf("signTokenPair: %v", serviceErr)
+	}
+	return refreshToken, record.JTI
+}
+
+func TestRefreshTokenReuseRevokesFamily(t *testing.T) {
+	previous := JWT
+	JWT = &JWTConfig{Keys: NewHMACKeyRing("test-secret"), AccessTokenExpire: time.Minute, RefreshTokenExpire: time.Hour}
+	t.Cleanup(func() { JWT = previous })
+
+	user := &model.User{ID: 1, TenantID: 1, Username: "alice", Status: model.UserStatusActive}
+	users := &fakeUserRepo{users: []*model.User{user}}
+	tokens := newFakeTokenRepo()
+	events := &fakeEventRepo{}
+	svc := &UserService{userRepo: users, tokenRepo: tokens, securityEvents: NewSecurityEventService(events, users, nil)}
+	ctx := &BusinessContext{TenantID: 1, ClientIP: "127.0.0.1"}
+
+	first, rootJTI := newTestSession(t, svc, tokens, user)
+	_, otherJTI := newTestSession(t, svc, tokens, user)
+
+	// 正常旋转两次：每次在事务中加锁读取被旋转的令牌，新令牌沿用同一会话
+	second, serviceErr := svc.RefreshToken(ctx, &RefreshTokenDTO{RefreshToken: first})
+	if serviceErr != nil {
+		t.Fatalf("第一次刷新: %v", serviceErr)
+	}
+	third, serviceErr := svc.RefreshToken(ctx, &RefreshTokenDTO{RefreshToken: second.RefreshToken})
+	if serviceErr != nil {
+		t.Fatalf("第二次刷新: %v", serviceErr)
+	}
+	if len(tokens.locked) != 2 || tokens.locked[0] != rootJTI {
+		t.Errorf("旋转应在事务中以 FOR UPDATE 读取被旋转的令牌, locked=%v", tokens.locked)
+	}
+	family, _ := tokens.FindFamily(rootJTI)
+	if len(family) != 3 {
+		t.Fatalf("旋转链长度 = %d, want 3", len(family))
+	}
+	for _, rt := range family {
+		if rt.SessionID != tokens.tokens[rootJTI].SessionID {
+			t.Errorf("旋转后的令牌应沿用原会话: %+v", rt)
+		}
+	}
+
+	// 重放已旋转的令牌：拒绝并撤销整条旋转链，撤销结果须随事务提交
+	if _, serviceErr := svc.RefreshToken(ctx, &RefreshTokenDTO{RefreshToken: first}); serviceErr == nil {
+		t.Fatal("重放已旋转的刷新令牌应被拒绝")
+	}
+	family, _ = tokens.FindFamily(rootJTI)
+	for _, rt := range family {
+		if !rt.Revoked {
+			t.Errorf("重放后旋转链中的令牌应全部撤销: %s", rt.JTI)
+		}
+	}
+	if tokens.tokens[otherJTI].Revoked {
+		t.Error("其他会话不应受影响")
+	}
+	if _, serviceErr := svc.RefreshToken(ctx, &RefreshTokenDTO{RefreshToken: third.RefreshToken}); serviceErr == nil {
+		t.Error("旋转链被撤销后最新的刷新令牌也应失效")
+	}
+
+	// 首次重放撤销了链上仍有效的最新令牌
+	var reuse *model.SecurityEvent
+	for _, event := range events.events {
+		if event.Type == string(SecurityEventRefreshTokenReuse) {
+			reuse = event
+			break
+		}
+	}
+	if reuse == nil {
+		t.Fatal("应记录 refresh_token_reuse 安全事件")
+	}
+	if want := `"family_size":3`; !strings.Contains(reuse.Detail, want) {
+		t.Errorf("Detail = %s, want %s", reuse.Detail, want)
+	}
+	if want := `"revoked":1`; !strings.Contains(reuse.Detail, want) {
+		t.Errorf("Detail = %s, want %s", reuse.Detail, want)
+	}
+}
+
+func TestRefreshTokenRejectsOtherClient(t *testing.T) {
+	previous := JWT
+	JWT = &JWTConfig{Keys: NewHMACKeyRing("test-secret"), AccessTokenExpire: time.Minute, RefreshTokenExpire: time.Hour}
+	t.Cleanup(func() { JWT = previous })
+
+	user := &model.User{ID: 1, TenantID: 1, Username: "alice", Status: model.UserStatusActive}
+	users := &fakeUserRepo{users: []*model.User{user}}
+	tokens := newFakeTokenRepo()
+	svc := &UserService{userRepo: users, tokenRepo: tokens, securityEvents: NewSecurityEventService(&fakeEventRepo{}, users, nil)}
+
+	refreshToken, jti := newTestSession(t, svc, tokens, user)
+	if _, serviceErr := svc.RefreshToken(&BusinessContext{TenantID: 1}, &RefreshTokenDTO{RefreshToken: refreshToken, ClientID: "other-app"}); serviceErr == nil {
+		t.Fatal("其他应用不能刷新该会话的令牌")
+	}
+	if tokens.tokens[jti].Revoked {
+		t.Error("被拒绝的刷新不应撤销令牌")
+	}
+}
@@ -0,0 +1,187 @@
+package service
+
+import (
+	"crypto/rand"
+	"encoding/base32"
+	"errors"
+	"go-one/internal/model"
+	"go-one/internal/repository"
+	"go-one/util"
+	"os"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// 注册模式
+const (
+	RegistrationModeOpen   = "open"   // 开放注册
+	RegistrationModeInvite = "invite" // 凭邀请码注册
+	RegistrationModeDomain = "domain" // 仅允许指定域名的邮箱注册
+	RegistrationModeClosed = "closed" // 关闭注册
+)
+
+// 注册被拒绝的原因
+const (
+	RegistrationRejectClosed            = "registration_closed"
+	RegistrationRejectInviteRequired    = "invite_required"
+	RegistrationRejectInviteInvalid     = "invite_invalid"
+	RegistrationRejectInviteRevoked     = "invite_revoked"
+	RegistrationRejectInviteExpired     = "invite_expired"
+	RegistrationRejectInviteExhausted   = "invite_exhausted"
+	RegistrationRejectEmailDomain       = "email_domain_not_allowed"
+	RegistrationRejectPolicyNotAccepted = "policy_not_accepted"
+)
+
+// RegistrationConfig 注册策略配置
+type RegistrationConfig struct {
+	Mode           string   // 见 RegistrationMode* 常量
+	AllowedDomains []string // 域名白名单模式下允许的邮箱域名（小写），子域名需单独列出
+}
+
+var Registration *RegistrationConfig
+
+// InitRegistration 初始化注册策略配置
+func InitRegistration() {
+	mode := strings.ToLower(strings.TrimSpace(os.Getenv("REGISTRATION_MODE")))
+	if mode == "" {
+		mode = RegistrationModeOpen
+	}
+	switch mode {
+	case RegistrationModeOpen, RegistrationModeInvite, RegistrationModeDomain, RegistrationModeClosed:
+	default:
+		util.Log().Panic("不支持的 REGISTRATION_MODE: %s", mode)
+	}
+
+	Registration = &RegistrationConfig{
+		Mode:           mode,
+		AllowedDomains: splitList(strings.ToLower(os.Getenv("REGISTRATION_ALLOWED_DOMAINS"))),
+	}
+	if mode == RegistrationModeDomain && len(Registration.AllowedDomains) == 0 {
+		util.Log().Panic("域名白名单注册模式需要配置 REGISTRATION_ALLOWED_DOMAINS")
+	}
+
+	util.Log().Info("注册策略初始化完成，模式: %s", mode)
+}
+
+// RegistrationPolicy 按当前注册模式校验新用户注册
+type RegistrationPolicy struct {
+	config     *RegistrationConfig
+	inviteRepo repository.InviteRepository
+}
+
+// NewRegistrationPolicy 创建注册策略实例
+func NewRegistrationPolicy(config *RegistrationConfig, inviteRepo repository.InviteRepository) *RegistrationPolicy {
+	return &RegistrationPolicy{
+		config:     config,
+		inviteRepo: inviteRepo,
+	}
+}
+
+// Check 校验账号密码注册请求，邀请注册模式下返回待使用的邀请码
+func (p *RegistrationPolicy) Check(email, inviteCode string) (*model.Invite, ServiceError) {
+	switch p.config.Mode {
+	case RegistrationModeClosed:
+		return nil, registrationClosedError()
+	case RegistrationModeInvite:
+		return p.checkInvite(inviteCode)
+	case RegistrationModeDomain:
+		return nil, p.checkEmailDomain(email)
+	default:
+		return nil, nil
+	}
+}
+
+// CheckExternal 校验第三方登录首次创建账号
+// 第三方登录流程无法携带邀请码，邀请注册模式下只能登录已有账号；域名白名单模式要求提供方确认过邮箱
+func (p *RegistrationPolicy) CheckExternal(email string, emailVerified bool) ServiceError {
+	switch p.config.Mode {
+	case RegistrationModeClosed:
+		return registrationClosedError()
+	case RegistrationModeInvite:
+		return &RegistrationError{Message: "注册需要邀请码，请先使用邀请码注册账号", Code: 40311, Reason: RegistrationRejectInviteRequired}
+	case RegistrationModeDomain:
+		if !emailVerified {
+			return &RegistrationError{Message: "该邮箱域名不允许注册", Code: 40316, Reason: RegistrationRejectEmailDomain}
+		}
+		return p.checkEmailDomain(email)
+	default:
+		return nil
+	}
+}
+
+// RequiresVerifiedEmail 域名白名单模式下须验证邮箱归属后才能激活账号
+func (p *RegistrationPolicy) RequiresVerifiedEmail() bool {
+	return p.config.Mode == RegistrationModeDomain
+}
+
+// checkInvite 校验邀请码是否可用（实际占用在创建用户的事务中完成）
+func (p *RegistrationPolicy) checkInvite(code string) (*model.Invite, ServiceError) {
+	code = normalizeInviteCode(code)
+	if code == "" {
+		return nil, &RegistrationError{Message: "注册需要邀请码", Code: 40311, Reason: RegistrationRejectInviteRequired}
+	}
+	invite, err := p.inviteRepo.FindByCodeHash(util.SHA256Hex(code))
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, &RegistrationError{Message: "邀请码无效", Code: 40312, Reason: RegistrationRejectInviteInvalid}
+		}
+		return nil, &DatabaseError{Message: "查询邀请码失败", Err: err}
+	}
+	if serviceErr := inviteUnavailable(invite); serviceErr != nil {
+		return nil, serviceErr
+	}
+	return invite, nil
+}
+
+// checkEmailDomain 校验邮箱域名是否在白名单中
+func (p *RegistrationPolicy) checkEmailDomain(email string) ServiceError {
+	_, domain, ok := strings.Cut(strings.TrimSpace(email), "@")
+	if !ok || !util.ContainsString(p.config.AllowedDomains, strings.ToLower(domain)) {
+		return &RegistrationError{Message: "该邮箱域名不允许注册", Code: 40316, Reason: RegistrationRejectEmailDomain}
+	}
+	return nil
+}
+
+// inviteUnavailable 返回邀请码不可用的具体原因，可用时返回 nil
+func inviteUnavailable(invite *model.Invite) ServiceError {
+	switch {
+	case invite.RevokedAt != nil:
+		return &RegistrationError{Message: "邀请码已被撤销", Code: 40313, Reason: RegistrationRejectInviteRevoked}
+	case invite.ExpiresAt != nil && !invite.ExpiresAt.After(time.Now()):
+		return &RegistrationError{Message: "邀请码已过期", Code: 40314, Reason: RegistrationRejectInviteExpired}
+	case invite.UsedCount >= invite.MaxUses:
+		return &RegistrationError{Message: "邀请码使用次数已达上限", Code: 40315, Reason: RegistrationRejectInviteExhausted}
+	default:
+		return nil
+	}
+}
+
+// registrationClosedError 关闭注册
+func registrationClosedError() ServiceError {
+	return &RegistrationError{Message: "当前暂不开放注册", Code: 40310, Reason: RegistrationRejectClosed}
+}
+
+// inviteCodeEncoding 邀请码使用无填充的 Base32 大写字母与数字，便于手动输入
+var inviteCodeEncoding = base32.StdEncoding.WithPadding(base32.NoPadding)
+
+// newInviteCode 生成 16 位邀请码
+func newInviteCode() (string, error) {
+	buf := make([]byte, 10)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+	return inviteCodeEncoding.EncodeToString(buf), nil
+}
+
+// normalizeInviteCode 忽略大小写、空白与连字符
+func normalizeInviteCode(code string) string {
+	code = strings.ToUpper(code)
+	return strings.Map(func(r rune) rune {
+		if r == '-' || r == ' ' || r == '\t' {
+			return -1
+		}
+		return r
+	}, strings.TrimSpace(code))
+}
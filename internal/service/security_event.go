@@ -0,0 +1,304 @@
+package service
+
+import (
+	"encoding/json"
+	"fmt"
+	"go-one/internal/model"
+	"go-one/internal/repository"
+	"go-one/util"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/getsentry/sentry-go"
+)
+
+// SecurityEventType 安全事件类型
+type SecurityEventType string
+
+const (
+	// SecurityEventLoginSuccess 登录成功（新建会话）
+	SecurityEventLoginSuccess SecurityEventType = "login_success"
+	// SecurityEventLoginFailure 密码或二次验证错误导致的登录失败
+	SecurityEventLoginFailure SecurityEventType = "login_failure"
+	// SecurityEventTokenRefresh 刷新令牌旋转
+	SecurityEventTokenRefresh SecurityEventType = "token_refresh"
+	// SecurityEventLogout 登出（撤销刷新令牌）
+	SecurityEventLogout SecurityEventType = "logout"
+	// SecurityEventPasswordChange 修改或通过找回密码重置了密码
+	SecurityEventPasswordChange SecurityEventType = "password_change"
+	// SecurityEventRefreshTokenReuse 已撤销的刷新令牌被再次使用（疑似令牌泄露）
+	SecurityEventRefreshTokenReuse SecurityEventType = "refresh_token_reuse"
+	// SecurityEventAccountLocked 登录失败次数过多，账号被临时锁定
+	SecurityEventAccountLocked SecurityEventType = "account_locked"
+)
+
+// securityEventTypes 全部安全事件类型，用于校验查询条件
+var securityEventTypes = []SecurityEventType{
+	SecurityEventLoginSuccess,
+	SecurityEventLoginFailure,
+	SecurityEventTokenRefresh,
+	SecurityEventLogout,
+	SecurityEventPasswordChange,
+	SecurityEventRefreshTokenReuse,
+	SecurityEventAccountLocked,
+}
+
+// alerting 需要告警（告警日志与 Sentry）的事件类型，其余事件仅持久化
+func (t SecurityEventType) alerting() bool {
+	return t == SecurityEventRefreshTokenReuse || t == SecurityEventAccountLocked
+}
+
+// SecurityEvent 安全事件
+type SecurityEvent struct {
+	Type       SecurityEventType
+	UserID     uint
+	ClientIP   string
+	UserAgent  string
+	Detail     map[string]interface{}
+	OccurredAt time.Time
+}
+
+// emitSecurityEvent 上报安全事件：记录告警日志，并在启用 Sentry 时同步上报
+func emitSecurityEvent(ctx *BusinessContext, evt SecurityEvent) {
+	if ctx != nil {
+		if evt.ClientIP == "" {
+			evt.ClientIP = ctx.ClientIP
+		}
+		if evt.UserAgent == "" {
+			evt.UserAgent = ctx.UserAgent
+		}
+	}
+	if evt.OccurredAt.IsZero() {
+		evt.OccurredAt = time.Now()
+	}
+
+	util.Log().Warning("安全事件 type=%s user_id=%d ip=%s ua=%q detail=%v",
+		evt.Type, evt.UserID, evt.ClientIP, evt.UserAgent, evt.Detail)
+
+	sentry.WithScope(func(scope *sentry.Scope) {
+		scope.SetLevel(sentry.LevelWarning)
+		scope.SetTag("security_event", string(evt.Type))
+		scope.SetUser(sentry.User{ID: fmt.Sprint(evt.UserID), IPAddress: evt.ClientIP})
+		scope.SetContext("security_event", evt.Detail)
+		sentry.CaptureMessage("security event: " + string(evt.Type))
+	})
+}
+
+// SecurityNotifier 安全通知钩子，用户首次从新设备或新 IP 登录时调用
+type SecurityNotifier interface {
+	NotifyNewDevice(user *model.User, event *model.SecurityEvent) error
+}
+
+var SecurityNotify SecurityNotifier
+
+// InitSecurityEvents 根据 SECURITY_NOTIFY_DRIVER 初始化新设备登录通知（需在 InitMailer 之后调用）
+// mail（默认）：向已验证的邮箱发送提醒；log：仅记录日志；none：不通知
+func InitSecurityEvents() {
+	driver := strings.ToLower(strings.TrimSpace(os.Getenv("SECURITY_NOTIFY_DRIVER")))
+	switch driver {
+	case "", "mail":
+		SecurityNotify = &MailSecurityNotifier{Mailer: Mail}
+	case "log":
+		SecurityNotify = LogSecurityNotifier{}
+	case "none":
+		SecurityNotify = nil
+	default:
+		util.Log().Panic("不支持的 SECURITY_NOTIFY_DRIVER: %s", driver)
+	}
+
+	util.Log().Info("安全事件通知初始化完成，驱动: %T", SecurityNotify)
+}
+
+// MailSecurityNotifier 通过邮件提醒用户新设备登录，未验证邮箱的用户不发送
+type MailSecurityNotifier struct {
+	Mailer Mailer
+}
+
+// NotifyNewDevice 发送新设备登录提醒邮件
+func (n *MailSecurityNotifier) NotifyNewDevice(user *model.User, event *model.SecurityEvent) error {
+	if user.Email == "" || !user.EmailVerified {
+		return nil
+	}
+	return n.Mailer.Send(&MailMessage{
+		To:      user.Email,
+		Subject: "新设备登录提醒",
+		Body: fmt.Sprintf("%s，您好：\n\n您的账号 %s 于 %s 在新的设备或网络环境中登录：\nIP：%s\n设备：%s\n\n如果这不是您本人的操作，请立即修改密码并退出其他设备。\n",
+			user.Nickname, user.Username, event.CreatedAt.Format("2006-01-02 15:04:05"), event.ClientIP, event.UserAgent),
+	})
+}
+
+// LogSecurityNotifier 仅记录新设备登录日志，便于本地开发或由日志系统接管通知
+type LogSecurityNotifier struct{}
+
+// NotifyNewDevice 记录新设备登录日志
+func (LogSecurityNotifier) NotifyNewDevice(user *model.User, event *model.SecurityEvent) error {
+	util.Log().Info("新设备登录 user_id=%d ip=%s ua=%q", user.ID, event.ClientIP, event.UserAgent)
+	return nil
+}
+
+// SecurityEventService 安全事件记录与查询服务
+type SecurityEventService struct {
+	eventRepo repository.SecurityEventRepository
+	userRepo  repository.UserRepository
+	notifier  SecurityNotifier
+}
+
+// NewSecurityEventService 创建安全事件服务实例
+func NewSecurityEventService(eventRepo repository.SecurityEventRepository, userRepo repository.UserRepository, notifier SecurityNotifier) *SecurityEventService {
+	return &SecurityEventService{
+		eventRepo: eventRepo,
+		userRepo:  userRepo,
+		notifier:  notifier,
+	}
+}
+
+// Record 记录安全事件：告警类事件同时上报，关联到用户的事件写入安全事件表
+// 记录失败只写日志，不影响触发事件的业务流程
+func (s *SecurityEventService) Record(ctx *BusinessContext, evt SecurityEvent) *model.SecurityEvent {
+	return s.record(ctx, evt, false)
+}
+
+// RecordLogin 记录登录成功；用户此前登录过且本次的设备或 IP 未出现过时标记为新设备并触发通知钩子
+// 首次登录（如注册后自动登录）没有可比较的历史，不视为新设备
+func (s *SecurityEventService) RecordLogin(ctx *BusinessContext, user *model.User, sessionID string) {
+	newDevice := false
+	footprint, err := s.eventRepo.Footprint(user.ID, string(SecurityEventLoginSuccess), ctx.ClientIP, ctx.UserAgent)
+	if err != nil {
+		util.Log().Error("查询登录历史失败 user_id=%d: %v", user.ID, err)
+	} else {
+		newDevice = footprint.Total > 0 && (footprint.IPCount == 0 || footprint.AgentCount == 0)
+	}
+
+	event := s.record(ctx, SecurityEvent{
+		Type:   SecurityEventLoginSuccess,
+		UserID: user.ID,
+		Detail: map[string]interface{}{"session_id": sessionID},
+	}, newDevice)
+	if newDevice && s.notifier != nil {
+		go s.notifyNewDevice(user, event)
+	}
+}
+
+// record 持久化安全事件，返回写入的记录
+func (s *SecurityEventService) record(ctx *BusinessContext, evt SecurityEvent, newDevice bool) *model.SecurityEvent {
+	if ctx != nil {
+		if evt.ClientIP == "" {
+			evt.ClientIP = ctx.ClientIP
+		}
+		if evt.UserAgent == "" {
+			evt.UserAgent = ctx.UserAgent
+		}
+	}
+	if evt.OccurredAt.IsZero() {
+		evt.OccurredAt = time.Now()
+	}
+	if evt.Type.alerting() {
+		emitSecurityEvent(ctx, evt)
+	}
+
+	event := &model.SecurityEvent{
+		UserID:    evt.UserID,
+		Type:      string(evt.Type),
+		ClientIP:  evt.ClientIP,
+		UserAgent: evt.UserAgent,
+		NewDevice: newDevice,
+		CreatedAt: evt.OccurredAt,
+	}
+	// 未关联到用户的事件（如不存在的用户名登录失败）只记录日志
+	if evt.UserID == 0 {
+		return event
+	}
+	if len(evt.Detail) > 0 {
+		if detail, err := json.Marshal(evt.Detail); err == nil {
+			event.Detail = string(detail)
+		}
+	}
+	if err := s.eventRepo.Create(event); err != nil {
+		util.Log().Error("保存安全事件失败 type=%s user_id=%d: %v", evt.Type, evt.UserID, err)
+	}
+	return event
+}
+
+// notifyNewDevice 调用通知钩子，失败只记录日志
+func (s *SecurityEventService) notifyNewDevice(user *model.User, event *model.SecurityEvent) {
+	if err := s.notifier.NotifyNewDevice(user, event); err != nil {
+		util.Log().Error("发送新设备登录通知失败 user_id=%d: %v", user.ID, err)
+	}
+}
+
+// ListSecurityEventsQuery 安全事件查询参数
+type ListSecurityEventsQuery struct {
+	Type     string
+	Page     int
+	PageSize int
+}
+
+// ListSecurityEventsResult 安全事件列表结果
+type ListSecurityEventsResult struct {
+	List     []model.SecurityEvent
+	Total    int64
+	Page     int
+	PageSize int
+}
+
+// ListMine 获取当前用户的安全事件时间线
+func (s *SecurityEventService) ListMine(ctx *BusinessContext, query *ListSecurityEventsQuery) (*ListSecurityEventsResult, ServiceError) {
+	userID, serviceErr := currentUserID(ctx)
+	if serviceErr != nil {
+		return nil, serviceErr
+	}
+	return s.list(userID, query)
+}
+
+// ListUserEvents 获取指定用户的安全事件时间线（管理操作）
+func (s *SecurityEventService) ListUserEvents(ctx *BusinessContext, userID uint, query *ListSecurityEventsQuery) (*ListSecurityEventsResult, ServiceError) {
+	if serviceErr := requirePermission(ctx, model.PermissionUsersManage); serviceErr != nil {
+		return nil, serviceErr
+	}
+	if _, err := s.userRepo.FindByID(userID); err != nil {
+		return nil, &NotFoundError{Message: "用户不存在"}
+	}
+	return s.list(userID, query)
+}
+
+// list 分页查询用户的安全事件
+func (s *SecurityEventService) list(userID uint, query *ListSecurityEventsQuery) (*ListSecurityEventsResult, ServiceError) {
+	query.Type = strings.TrimSpace(query.Type)
+	if query.Type != "" && !isSecurityEventType(query.Type) {
+		return nil, &ValidationError{Message: "无效的安全事件类型: " + query.Type, Code: 40000}
+	}
+	if query.Page < 1 {
+		query.Page = 1
+	}
+	if query.PageSize < 1 || query.PageSize > 100 {
+		query.PageSize = 20
+	}
+
+	events, total, err := s.eventRepo.Search(repository.SecurityEventSearch{
+		UserID:   userID,
+		Type:     query.Type,
+		Page:     query.Page,
+		PageSize: query.PageSize,
+	})
+	if err != nil {
+		return nil, &DatabaseError{Message: "查询安全事件失败", Err: err}
+	}
+
+	return &ListSecurityEventsResult{
+		List:     events,
+		Total:    total,
+		Page:     query.Page,
+		PageSize: query.PageSize,
+	}, nil
+}
+
+// isSecurityEventType 判断是否为已知的安全事件类型
+func isSecurityEventType(value string) bool {
+	for _, t := range securityEventTypes {
+		if string(t) == value {
+			return true
+		}
+	}
+	return false
+}
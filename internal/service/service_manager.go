@@ -7,26 +7,147 @@ import (
 )
 
 // ServiceManager 统一管理所有服务的依赖注入
+// 用户、刷新令牌、外部身份、审计日志、邀请码、数据导出与安全事件仓储按租户隔离，处理请求时应通过 ForTenant 获取限定在请求租户的实例
 type ServiceManager struct {
-    // Repositories
-    userRepo repository.UserRepository
-    tokenRepo repository.RefreshTokenRepository
+	db *gorm.DB
+
+	// Repositories
+	userRepo     repository.UserRepository
+	tokenRepo    repository.RefreshTokenRepository
+	recoveryRepo repository.RecoveryCodeRepository
+	resetRepo    repository.PasswordResetRepository
+	roleRepo     repository.RoleRepository
+	apiKeyRepo   repository.APIKeyRepository
+	identityRepo repository.UserIdentityRepository
+	oauthRepo    repository.OAuthRepository
+	tenantRepo   repository.TenantRepository
+	auditRepo    repository.AuditLogRepository
+	inviteRepo   repository.InviteRepository
+	consentRepo  repository.ConsentRepository
+	exportRepo   repository.DataExportRepository
+	eventRepo    repository.SecurityEventRepository
 
 	// 可以在这里添加其他依赖
 	// 例如：缓存服务、消息队列、第三方API客户端等
 }
 
-// NewServiceManager 创建服务管理器
+// NewServiceManager 创建服务管理器（不限定租户，仅供跨租户的认证流程使用）
 func NewServiceManager(db *gorm.DB) *ServiceManager {
-    return &ServiceManager{
-        userRepo:  repository.NewUserRepository(db),
-        tokenRepo: repository.NewRefreshTokenRepository(db),
-    }
+	return &ServiceManager{
+		db:           db,
+		userRepo:     repository.NewUserRepository(db, 0),
+		tokenRepo:    repository.NewRefreshTokenRepository(db, 0),
+		recoveryRepo: repository.NewRecoveryCodeRepository(db),
+		resetRepo:    repository.NewPasswordResetRepository(db),
+		roleRepo:     repository.NewRoleRepository(db),
+		apiKeyRepo:   repository.NewAPIKeyRepository(db),
+		identityRepo: repository.NewUserIdentityRepository(db, 0),
+		oauthRepo:    repository.NewOAuthRepository(db),
+		tenantRepo:   repository.NewTenantRepository(db),
+		auditRepo:    repository.NewAuditLogRepository(db, 0),
+		inviteRepo:   repository.NewInviteRepository(db, 0),
+		consentRepo:  repository.NewConsentRepository(db),
+		exportRepo:   repository.NewDataExportRepository(db, 0),
+		eventRepo:    repository.NewSecurityEventRepository(db, 0),
+	}
+}
+
+// ForTenant 返回限定在指定租户的服务管理器，用户、刷新令牌、外部身份、审计日志、邀请码、数据导出与安全事件的读写自动附加租户条件
+func (sm *ServiceManager) ForTenant(tenantID uint) *ServiceManager {
+	scoped := *sm
+	scoped.userRepo = repository.NewUserRepository(sm.db, tenantID)
+	scoped.tokenRepo = repository.NewRefreshTokenRepository(sm.db, tenantID)
+	scoped.identityRepo = repository.NewUserIdentityRepository(sm.db, tenantID)
+	scoped.auditRepo = repository.NewAuditLogRepository(sm.db, tenantID)
+	scoped.inviteRepo = repository.NewInviteRepository(sm.db, tenantID)
+	scoped.exportRepo = repository.NewDataExportRepository(sm.db, tenantID)
+	scoped.eventRepo = repository.NewSecurityEventRepository(sm.db, tenantID)
+	return &scoped
+}
+
+// NewTenantService 创建租户服务
+func (sm *ServiceManager) NewTenantService() *TenantService {
+	return NewTenantService(sm.tenantRepo)
 }
 
 // NewUserService 创建用户服务
 func (sm *ServiceManager) NewUserService() *UserService {
-    return NewUserService(sm.userRepo, sm.tokenRepo)
+	return NewUserService(sm.userRepo, sm.tokenRepo, sm.inviteRepo, sm.consentRepo, sm.eventRepo)
+}
+
+// NewTokenVersionService 创建令牌版本服务
+func (sm *ServiceManager) NewTokenVersionService() *TokenVersionService {
+	return NewTokenVersionService(sm.userRepo, sm.tokenRepo)
+}
+
+// NewSessionService 创建会话服务
+func (sm *ServiceManager) NewSessionService() *SessionService {
+	return NewSessionService(sm.tokenRepo)
+}
+
+// NewMFAService 创建二次验证服务
+func (sm *ServiceManager) NewMFAService() *MFAService {
+	return NewMFAService(sm.userRepo, sm.recoveryRepo, sm.NewUserService())
+}
+
+// NewEmailVerificationService 创建邮箱验证服务
+func (sm *ServiceManager) NewEmailVerificationService() *EmailVerificationService {
+	return NewEmailVerificationService(sm.userRepo, Mail)
+}
+
+// NewPasswordResetService 创建找回密码服务
+func (sm *ServiceManager) NewPasswordResetService() *PasswordResetService {
+	return NewPasswordResetService(sm.userRepo, sm.resetRepo, sm.NewTokenVersionService(), Mail, Passwords, PasswordRules, sm.NewSecurityEventService())
+}
+
+// NewRBACService 创建角色权限服务
+func (sm *ServiceManager) NewRBACService() *RBACService {
+	return NewRBACService(sm.userRepo, sm.roleRepo)
+}
+
+// NewAPIKeyService 创建 API 密钥服务
+func (sm *ServiceManager) NewAPIKeyService() *APIKeyService {
+	return NewAPIKeyService(sm.userRepo, sm.apiKeyRepo, sm.NewRBACService())
+}
+
+// NewAdminUserService 创建用户管理服务
+func (sm *ServiceManager) NewAdminUserService() *AdminUserService {
+	return NewAdminUserService(sm.userRepo, sm.NewTokenVersionService())
+}
+
+// NewOAuthService 创建第三方登录服务
+func (sm *ServiceManager) NewOAuthService() *OAuthService {
+	return NewOAuthService(sm.userRepo, sm.identityRepo, sm.NewUserService(), OAuthProviders, Passwords)
+}
+
+// NewOAuthServerService 创建 OAuth 授权服务
+func (sm *ServiceManager) NewOAuthServerService() *OAuthServerService {
+	return NewOAuthServerService(sm.userRepo, sm.tokenRepo, sm.oauthRepo, sm.NewUserService(), sm.NewTokenVersionService())
+}
+
+// NewImpersonationService 创建模拟登录服务
+func (sm *ServiceManager) NewImpersonationService() *ImpersonationService {
+	return NewImpersonationService(sm.userRepo, sm.auditRepo, sm.NewRBACService())
+}
+
+// NewInviteService 创建邀请码管理服务
+func (sm *ServiceManager) NewInviteService() *InviteService {
+	return NewInviteService(sm.inviteRepo)
+}
+
+// NewConsentService 创建协议同意服务
+func (sm *ServiceManager) NewConsentService() *ConsentService {
+	return NewConsentService(sm.consentRepo)
+}
+
+// NewAccountDataService 创建用户数据导出与账号注销服务
+func (sm *ServiceManager) NewAccountDataService() *AccountDataService {
+	return NewAccountDataService(sm.userRepo, sm.exportRepo, sm.NewUserService(), sm.NewTokenVersionService(), Mail, AccountData)
+}
+
+// NewSecurityEventService 创建安全事件服务
+func (sm *ServiceManager) NewSecurityEventService() *SecurityEventService {
+	return NewSecurityEventService(sm.eventRepo, sm.userRepo, SecurityNotify)
 }
 
 // 可以在这里添加其他服务的工厂方法
@@ -0,0 +1,201 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"go-one/internal/cache"
+	"go-one/internal/model"
+	"go-one/internal/repository"
+	"go-one/util"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// SessionConfig 会话配置
+type SessionConfig struct {
+	MaxPerUser int // 每个用户的最大并发会话数，0 表示不限制
+}
+
+var Session *SessionConfig
+
+// sessionRevokedKey 已撤销会话的 sid，保留至该会话最后签发的访问令牌过期
+const sessionRevokedKey = "session:revoked:%s"
+
+// InitSession 初始化会话配置
+func InitSession() {
+	maxPerUser := 0
+	if v := os.Getenv("SESSION_MAX_PER_USER"); v != "" {
+		if parsed, err := strconv.Atoi(v); err == nil && parsed > 0 {
+			maxPerUser = parsed
+		}
+	}
+
+	Session = &SessionConfig{
+		MaxPerUser: maxPerUser,
+	}
+
+	util.Log().Info("会话配置初始化完成")
+}
+
+// evictExcessSessions 新建会话前按上限淘汰最早认证的会话，为新会话腾出位置
+func evictExcessSessions(repo repository.RefreshTokenRepository, userID uint) error {
+	if Session == nil || Session.MaxPerUser <= 0 {
+		return nil
+	}
+	active, err := repo.ListActiveByUser(userID)
+	if err != nil {
+		return err
+	}
+	// active 按认证时间倒序，保留最新的 MaxPerUser-1 个
+	for i := Session.MaxPerUser - 1; i < len(active); i++ {
+		if _, err := repo.RevokeSession(userID, active[i].SessionID); err != nil {
+			return err
+		}
+		if err := denySessions(active[i].SessionID); err != nil {
+			return err
+		}
+		util.Log().Info("会话数超出上限，淘汰会话 user_id=%d session_id=%s", userID, active[i].SessionID)
+	}
+	return nil
+}
+
+// SessionService 会话服务（基于刷新令牌记录）
+type SessionService struct {
+	tokenRepo repository.RefreshTokenRepository
+}
+
+// NewSessionService 创建会话服务实例
+func NewSessionService(tokenRepo repository.RefreshTokenRepository) *SessionService {
+	return &SessionService{
+		tokenRepo: tokenRepo,
+	}
+}
+
+// SessionListResult 会话列表结果
+type SessionListResult struct {
+	List             []model.RefreshToken
+	CurrentSessionID string
+}
+
+// ListSessions 获取当前用户的活跃会话
+func (s *SessionService) ListSessions(ctx *BusinessContext) (*SessionListResult, ServiceError) {
+	userID, serviceErr := currentUserID(ctx)
+	if serviceErr != nil {
+		return nil, serviceErr
+	}
+
+	tokens, err := s.tokenRepo.ListActiveByUser(userID)
+	if err != nil {
+		return nil, &DatabaseError{Message: "查询会话失败", Err: err}
+	}
+
+	return &SessionListResult{
+		List:             tokens,
+		CurrentSessionID: ctx.Claims.SessionID,
+	}, nil
+}
+
+// RevokeSession 撤销当前用户的指定会话
+func (s *SessionService) RevokeSession(ctx *BusinessContext, sessionID string) ServiceError {
+	if serviceErr := denyImpersonation(ctx, "管理会话"); serviceErr != nil {
+		return serviceErr
+	}
+	userID, serviceErr := currentUserID(ctx)
+	if serviceErr != nil {
+		return serviceErr
+	}
+	sessionID = strings.TrimSpace(sessionID)
+	if sessionID == "" {
+		return &ValidationError{Message: "会话ID不能为空", Code: 40000}
+	}
+
+	affected, err := s.tokenRepo.RevokeSession(userID, sessionID)
+	if err != nil {
+		return &DatabaseError{Message: "撤销会话失败", Err: err}
+	}
+	if affected == 0 {
+		return &NotFoundError{Message: "会话不存在或已失效"}
+	}
+	if err := denySessions(sessionID); err != nil {
+		return &ExternalAPIError{Message: "撤销会话失败", Err: err}
+	}
+	return nil
+}
+
+// RevokeOtherSessions 撤销当前会话以外的全部会话（退出其他设备），返回撤销数量
+// 被撤销会话已签发的访问令牌同时加入撤销列表，无需等待其过期
+func (s *SessionService) RevokeOtherSessions(ctx *BusinessContext) (int64, ServiceError) {
+	if serviceErr := denyImpersonation(ctx, "管理会话"); serviceErr != nil {
+		return 0, serviceErr
+	}
+	userID, serviceErr := currentUserID(ctx)
+	if serviceErr != nil {
+		return 0, serviceErr
+	}
+	if ctx.Claims.SessionID == "" {
+		return 0, &ValidationError{Message: "当前令牌未关联会话，请重新登录", Code: 40000}
+	}
+
+	active, err := s.tokenRepo.ListActiveByUser(userID)
+	if err != nil {
+		return 0, &DatabaseError{Message: "查询会话失败", Err: err}
+	}
+	affected, err := s.tokenRepo.RevokeAllByUser(userID, ctx.Claims.SessionID)
+	if err != nil {
+		return 0, &DatabaseError{Message: "撤销会话失败", Err: err}
+	}
+	sessionIDs := make([]string, 0, len(active))
+	for _, token := range active {
+		if token.SessionID != ctx.Claims.SessionID {
+			sessionIDs = append(sessionIDs, token.SessionID)
+		}
+	}
+	if err := denySessions(sessionIDs...); err != nil {
+		return 0, &ExternalAPIError{Message: "撤销会话失败", Err: err}
+	}
+	return affected, nil
+}
+
+// CheckRevoked 检查访问令牌所属会话是否已被撤销
+func (s *SessionService) CheckRevoked(claims *JWTClaims) ServiceError {
+	if claims.SessionID == "" {
+		return nil
+	}
+	n, err := cache.RedisClient.Exists(context.Background(), fmt.Sprintf(sessionRevokedKey, claims.SessionID)).Result()
+	if err != nil {
+		return &AuthError{Message: "认证令牌状态校验失败", Err: err}
+	}
+	if n > 0 {
+		return &AuthError{Message: "登录会话已被撤销，请重新登录"}
+	}
+	return nil
+}
+
+// denySessions 将会话加入撤销列表，使其已签发的访问令牌立即失效
+func denySessions(sessionIDs ...string) error {
+	ctx := context.Background()
+	pipe := cache.RedisClient.Pipeline()
+	for _, id := range sessionIDs {
+		if id != "" {
+			pipe.Set(ctx, fmt.Sprintf(sessionRevokedKey, id), 1, JWT.AccessTokenExpire)
+		}
+	}
+	if pipe.Len() == 0 {
+		return nil
+	}
+	_, err := pipe.Exec(ctx)
+	return err
+}
+
+// currentUserID 从业务上下文解析当前用户ID
+func currentUserID(ctx *BusinessContext) (uint, ServiceError) {
+	if ctx == nil || !ctx.IsAuthenticated() {
+		return 0, &AuthError{Message: "未认证"}
+	}
+	uid64, err := strconv.ParseUint(ctx.UserUUID, 10, 64)
+	if err != nil || uid64 == 0 {
+		return 0, &AuthError{Message: "无效的用户ID"}
+	}
+	return uint(uid64), nil
+}
@@ -0,0 +1,439 @@
+package service
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/subtle"
+	"errors"
+	"fmt"
+	"go-one/internal/cache"
+	"go-one/internal/model"
+	"go-one/util"
+	"math/big"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+	"gorm.io/gorm"
+)
+
+// SmsSender 短信发送接口，接入短信网关时实现该接口
+type SmsSender interface {
+	Send(phone, message string) error
+}
+
+var Sms SmsSender
+
+// SmsConfig 短信验证码配置
+type SmsConfig struct {
+	CodeExpire   time.Duration // 验证码有效期
+	MaxAttempts  int64         // 单个验证码允许的校验次数，超过后作废
+	SendCooldown time.Duration // 同一手机号两次发送的最小间隔
+	DailyLimit   int64         // 同一手机号每 24 小时最多发送次数
+}
+
+var SmsCode *SmsConfig
+
+const (
+	// smsCodeKey 验证码哈希（用途:租户:手机号）
+	smsCodeKey = "sms:code:%s:%d:%s"
+	// smsAttemptsKey 验证码已校验次数
+	smsAttemptsKey = "sms:attempts:%s:%d:%s"
+	// smsCooldownKey 手机号发送冷却
+	smsCooldownKey = "sms:cooldown:%d:%s"
+	// smsCountKey 手机号 24 小时内发送次数
+	smsCountKey = "sms:count:%d:%s"
+)
+
+// 验证码用途，不同用途的验证码互不通用
+const (
+	smsPurposeLogin = "login"
+	smsPurposeBind  = "bind"
+)
+
+// InitSMS 根据 SMS_DRIVER 初始化短信发送器与验证码配置
+// log（默认）：只记录日志；file：写入本地目录并记录日志，便于本地开发
+func InitSMS() {
+	driver := strings.ToLower(strings.TrimSpace(os.Getenv("SMS_DRIVER")))
+	switch driver {
+	case "", "log":
+		Sms = &LogSmsSender{}
+	case "file":
+		dir := os.Getenv("SMS_FILE_DIR")
+		if dir == "" {
+			dir = "./logs/sms"
+		}
+		Sms = &FileSmsSender{Dir: dir}
+	default:
+		util.Log().Panic("不支持的 SMS_DRIVER: %s", driver)
+	}
+
+	SmsCode = &SmsConfig{
+		CodeExpire:   time.Duration(envInt64("SMS_CODE_EXPIRE", 300)) * time.Second,
+		MaxAttempts:  envInt64("SMS_CODE_MAX_ATTEMPTS", 5),
+		SendCooldown: time.Duration(envInt64("SMS_SEND_COOLDOWN", 60)) * time.Second,
+		DailyLimit:   envInt64("SMS_DAILY_LIMIT", 10),
+	}
+
+	util.Log().Info("短信发送器初始化完成，驱动: %T", Sms)
+}
+
+// LogSmsSender 只将短信内容记录到日志，不实际发送
+type LogSmsSender struct{}
+
+// Send 记录短信日志
+func (s *LogSmsSender) Send(phone, message string) error {
+	util.Log().Info("短信 to=%s message=%q", phone, message)
+	return nil
+}
+
+// FileSmsSender 将短信写入本地目录并记录日志，不实际发送
+type FileSmsSender struct {
+	Dir string
+}
+
+// Send 写入短信文件
+func (s *FileSmsSender) Send(phone, message string) error {
+	if err := os.MkdirAll(s.Dir, 0o755); err != nil {
+		return err
+	}
+	name := fmt.Sprintf("%s_%s.txt", time.Now().Format("20060102T150405.000000000"), sanitizeFileName(phone))
+	path := filepath.Join(s.Dir, name)
+	if err := os.WriteFile(path, []byte(message+"\n"), 0o600); err != nil {
+		return err
+	}
+	util.Log().Info("短信已写入 %s to=%s", path, phone)
+	return nil
+}
+
+// normalizePhone 去除空白并校验手机号格式
+func normalizePhone(phone string) (string, ServiceError) {
+	phone = strings.TrimSpace(phone)
+	if phone == "" {
+		return "", &ValidationError{Message: "手机号不能为空", Code: 40000}
+	}
+	if !util.IsValidPhone(phone) {
+		return "", &ValidationError{Message: "手机号格式不正确", Code: 40000}
+	}
+	return phone, nil
+}
+
+// throttleSmsSend 按手机号限制发送频率：冷却期内或 24 小时内超过次数上限时拒绝
+func throttleSmsSend(tenantID uint, phone string) ServiceError {
+	redisCtx := context.Background()
+	cooldownKey := fmt.Sprintf(smsCooldownKey, tenantID, phone)
+	ok, err := cache.RedisClient.SetNX(redisCtx, cooldownKey, 1, SmsCode.SendCooldown).Result()
+	if err != nil {
+		return &ExternalAPIError{Message: "短信服务异常", Err: err}
+	}
+	if !ok {
+		retryAfter, _ := cache.RedisClient.TTL(redisCtx, cooldownKey).Result()
+		return &RateLimitError{Message: "验证码发送过于频繁，请稍后再试", Code: 42901, RetryAfter: retryAfter}
+	}
+
+	countKey := fmt.Sprintf(smsCountKey, tenantID, phone)
+	count, err := cache.RedisClient.Incr(redisCtx, countKey).Result()
+	if err != nil {
+		return &ExternalAPIError{Message: "短信服务异常", Err: err}
+	}
+	if count == 1 {
+		cache.RedisClient.Expire(redisCtx, countKey, 24*time.Hour)
+	}
+	if count > SmsCode.DailyLimit {
+		retryAfter, _ := cache.RedisClient.TTL(redisCtx, countKey).Result()
+		return &RateLimitError{Message: "该手机号今日验证码发送次数已达上限", Code: 42901, RetryAfter: retryAfter}
+	}
+	return nil
+}
+
+// issueSmsCode 生成六位验证码并保存其哈希，返回明文验证码用于发送
+// 重新发送会覆盖之前的验证码并清零校验次数
+func issueSmsCode(purpose string, tenantID uint, phone string) (string, ServiceError) {
+	n, err := rand.Int(rand.Reader, big.NewInt(1000000))
+	if err != nil {
+		return "", &BusinessError{Message: "生成验证码失败", Code: 50000, Err: err}
+	}
+	code := fmt.Sprintf("%06d", n.Int64())
+
+	redisCtx := context.Background()
+	pipe := cache.RedisClient.TxPipeline()
+	pipe.Set(redisCtx, fmt.Sprintf(smsCodeKey, purpose, tenantID, phone), hashSmsCode(purpose, phone, code), SmsCode.CodeExpire)
+	pipe.Del(redisCtx, fmt.Sprintf(smsAttemptsKey, purpose, tenantID, phone))
+	if _, err := pipe.Exec(redisCtx); err != nil {
+		return "", &ExternalAPIError{Message: "短信服务异常", Err: err}
+	}
+	return code, nil
+}
+
+// verifySmsCode 校验验证码，成功后验证码立即作废
+func verifySmsCode(purpose string, tenantID uint, phone, code string) ServiceError {
+	code = strings.TrimSpace(code)
+	if code == "" {
+		return &ValidationError{Message: "验证码不能为空", Code: 40000}
+	}
+
+	redisCtx := context.Background()
+	codeKey := fmt.Sprintf(smsCodeKey, purpose, tenantID, phone)
+	attemptsKey := fmt.Sprintf(smsAttemptsKey, purpose, tenantID, phone)
+	stored, err := cache.RedisClient.Get(redisCtx, codeKey).Result()
+	if err != nil {
+		if errors.Is(err, redis.Nil) {
+			return &AuthError{Message: "验证码无效或已过期"}
+		}
+		return &ExternalAPIError{Message: "短信服务异常", Err: err}
+	}
+
+	attempts, err := cache.RedisClient.Incr(redisCtx, attemptsKey).Result()
+	if err != nil {
+		return &ExternalAPIError{Message: "短信服务异常", Err: err}
+	}
+	cache.RedisClient.Expire(redisCtx, attemptsKey, SmsCode.CodeExpire)
+	if attempts > SmsCode.MaxAttempts {
+		cache.RedisClient.Del(redisCtx, codeKey, attemptsKey)
+		return &AuthError{Message: "验证码错误次数过多，请重新获取"}
+	}
+
+	if subtle.ConstantTimeCompare([]byte(hashSmsCode(purpose, phone, code)), []byte(stored)) != 1 {
+		return &AuthError{Message: "验证码错误"}
+	}
+	// 单次有效：并发提交同一验证码时只有删除成功的请求通过
+	if deleted, err := cache.RedisClient.Del(redisCtx, codeKey).Result(); err != nil || deleted == 0 {
+		return &AuthError{Message: "验证码无效或已过期"}
+	}
+	cache.RedisClient.Del(redisCtx, attemptsKey)
+	return nil
+}
+
+// hashSmsCode Redis 中只保存验证码的哈希
+func hashSmsCode(purpose, phone, code string) string {
+	return util.SHA256Hex(purpose + ":" + phone + ":" + code)
+}
+
+// sendSmsCode 发送验证码短信
+func sendSmsCode(phone, code, action string) {
+	message := fmt.Sprintf("您的%s验证码为 %s，%d 分钟内有效。如非本人操作，请忽略本短信。",
+		action, code, int(SmsCode.CodeExpire.Minutes()))
+	if err := Sms.Send(phone, message); err != nil {
+		util.Log().Error("发送短信验证码失败 phone=%s: %v", maskPhone(phone), err)
+	}
+}
+
+// maskPhone 日志中隐藏手机号中间四位
+func maskPhone(phone string) string {
+	if len(phone) < 7 {
+		return phone
+	}
+	return phone[:3] + "****" + phone[len(phone)-4:]
+}
+
+// SmsSendDTO 发送登录验证码请求DTO
+type SmsSendDTO struct {
+	Phone string
+}
+
+// SendSmsLoginCode 向已绑定该手机号的账号发送登录验证码
+// 为防止手机号枚举，无论手机号是否已绑定均返回成功（发送频率限制同样生效），短信异步发送
+func (s *UserService) SendSmsLoginCode(ctx *BusinessContext, dto *SmsSendDTO) ServiceError {
+	phone, serviceErr := normalizePhone(dto.Phone)
+	if serviceErr != nil {
+		return serviceErr
+	}
+	if serviceErr := throttleSmsSend(ctx.TenantID, phone); serviceErr != nil {
+		return serviceErr
+	}
+
+	user, err := s.userRepo.FindByVerifiedPhone(phone)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil
+		}
+		return &DatabaseError{Message: "查询用户失败", Err: err}
+	}
+	if checkUserStatus(user) != nil {
+		return nil
+	}
+
+	code, serviceErr := issueSmsCode(smsPurposeLogin, ctx.TenantID, phone)
+	if serviceErr != nil {
+		return serviceErr
+	}
+	go sendSmsCode(phone, code, "登录")
+	return nil
+}
+
+// SmsLoginDTO 短信验证码登录请求DTO
+type SmsLoginDTO struct {
+	Phone              string
+	Code               string
+	TrustedDeviceToken string
+}
+
+// LoginBySms 使用短信验证码登录，结果与 Login 一致（可能需要二次验证）
+func (s *UserService) LoginBySms(ctx *BusinessContext, dto *SmsLoginDTO) (*LoginResult, ServiceError) {
+	phone, serviceErr := normalizePhone(dto.Phone)
+	if serviceErr != nil {
+		return nil, serviceErr
+	}
+	if serviceErr := verifySmsCode(smsPurposeLogin, ctx.TenantID, phone, dto.Code); serviceErr != nil {
+		return nil, serviceErr
+	}
+
+	// 验证码发出后手机号可能已解绑或换绑
+	user, err := s.userRepo.FindByVerifiedPhone(phone)
+	if err != nil {
+		return nil, &AuthError{Message: "验证码无效或已过期"}
+	}
+	if serviceErr := checkUserStatus(user); serviceErr != nil {
+		return nil, serviceErr
+	}
+
+	util.Log().Info("用户通过短信验证码登录 user_id=%d ip=%s", user.ID, ctx.ClientIP)
+	return s.completeLogin(ctx, user, dto.TrustedDeviceToken)
+}
+
+// PhoneBindDTO 绑定手机号请求DTO（发送验证码时需验证当前密码）
+type PhoneBindDTO struct {
+	Phone    string
+	Password string
+	Code     string
+}
+
+// SendPhoneBindCode 验证当前密码后向待绑定的手机号发送验证码
+func (s *UserService) SendPhoneBindCode(ctx *BusinessContext, dto *PhoneBindDTO) ServiceError {
+	if serviceErr := denyImpersonation(ctx, "绑定手机号"); serviceErr != nil {
+		return serviceErr
+	}
+	phone, serviceErr := normalizePhone(dto.Phone)
+	if serviceErr != nil {
+		return serviceErr
+	}
+	user, serviceErr := s.reauthenticate(ctx, dto.Password)
+	if serviceErr != nil {
+		return serviceErr
+	}
+	if user.PhoneVerified && user.Phone == phone {
+		return &BusinessError{Message: "已绑定该手机号", Code: 40000}
+	}
+	if serviceErr := s.checkPhoneAvailable(user.ID, phone); serviceErr != nil {
+		return serviceErr
+	}
+	if serviceErr := throttleSmsSend(ctx.TenantID, phone); serviceErr != nil {
+		return serviceErr
+	}
+
+	code, serviceErr := issueSmsCode(bindPurpose(user.ID), ctx.TenantID, phone)
+	if serviceErr != nil {
+		return serviceErr
+	}
+	go sendSmsCode(phone, code, "绑定手机号")
+	return nil
+}
+
+// BindPhone 校验验证码并绑定手机号，已绑定其他手机号时替换
+// 验证码仅发给通过密码验证的本人，确认时无需再次输入密码
+func (s *UserService) BindPhone(ctx *BusinessContext, dto *PhoneBindDTO) ServiceError {
+	if serviceErr := denyImpersonation(ctx, "绑定手机号"); serviceErr != nil {
+		return serviceErr
+	}
+	phone, serviceErr := normalizePhone(dto.Phone)
+	if serviceErr != nil {
+		return serviceErr
+	}
+	userID, serviceErr := currentUserID(ctx)
+	if serviceErr != nil {
+		return serviceErr
+	}
+	if serviceErr := verifySmsCode(bindPurpose(userID), ctx.TenantID, phone, dto.Code); serviceErr != nil {
+		return serviceErr
+	}
+	if serviceErr := s.checkPhoneAvailable(userID, phone); serviceErr != nil {
+		return serviceErr
+	}
+
+	if err := s.userRepo.UpdatePhone(userID, phone); err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return &NotFoundError{Message: "用户不存在"}
+		}
+		// 并发绑定同一手机号时由唯一索引拒绝
+		return &DatabaseError{Message: "绑定手机号失败", Err: err}
+	}
+
+	util.Log().Info("用户绑定手机号 user_id=%d phone=%s", userID, maskPhone(phone))
+	return nil
+}
+
+// PhoneUnbindDTO 解绑手机号请求DTO
+type PhoneUnbindDTO struct {
+	Password string
+}
+
+// UnbindPhone 验证当前密码后解除手机号绑定
+func (s *UserService) UnbindPhone(ctx *BusinessContext, dto *PhoneUnbindDTO) ServiceError {
+	if serviceErr := denyImpersonation(ctx, "解绑手机号"); serviceErr != nil {
+		return serviceErr
+	}
+	user, serviceErr := s.reauthenticate(ctx, dto.Password)
+	if serviceErr != nil {
+		return serviceErr
+	}
+	if !user.PhoneVerified {
+		return &BusinessError{Message: "尚未绑定手机号", Code: 40000}
+	}
+
+	if err := s.userRepo.UpdatePhone(user.ID, ""); err != nil {
+		return &DatabaseError{Message: "解绑手机号失败", Err: err}
+	}
+
+	util.Log().Info("用户解绑手机号 user_id=%d phone=%s", user.ID, maskPhone(user.Phone))
+	return nil
+}
+
+// reauthenticate 账号安全操作前重新验证当前密码，返回当前用户
+// 密码错误计入登录失败次数，账号锁定期间直接拒绝
+func (s *UserService) reauthenticate(ctx *BusinessContext, password string) (*model.User, ServiceError) {
+	if password == "" {
+		return nil, &ValidationError{Message: "请输入当前密码", Code: 40000}
+	}
+	userID, serviceErr := currentUserID(ctx)
+	if serviceErr != nil {
+		return nil, serviceErr
+	}
+	user, err := s.userRepo.FindByID(userID)
+	if err != nil {
+		return nil, &NotFoundError{Message: "用户不存在"}
+	}
+	// 与登录共用防爆破计数，避免持有访问令牌者借此无限次猜测密码
+	identity := loginIdentity(ctx.TenantID, user.Username)
+	if serviceErr := s.loginGuard.Check(ctx.ClientIP, identity); serviceErr != nil {
+		return nil, serviceErr
+	}
+	if ok, _ := s.hasher.Verify(user.Password, password); !ok {
+		s.recordLoginFailure(ctx, user.Username, user.ID, loginFactorPassword)
+		return nil, &AuthError{Message: "密码错误"}
+	}
+	s.loginGuard.Reset(identity)
+	return user, nil
+}
+
+// checkPhoneAvailable 手机号未被租户内其他账号绑定
+func (s *UserService) checkPhoneAvailable(userID uint, phone string) ServiceError {
+	owner, err := s.userRepo.FindByVerifiedPhone(phone)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil
+		}
+		return &DatabaseError{Message: "查询用户失败", Err: err}
+	}
+	if owner.ID != userID {
+		return &BusinessError{Message: "该手机号已被其他账号绑定", Code: 40000}
+	}
+	return nil
+}
+
+// bindPurpose 绑定验证码按用户区分，其他账号无法使用
+func bindPurpose(userID uint) string {
+	return smsPurposeBind + ":" + strconv.FormatUint(uint64(userID), 10)
+}
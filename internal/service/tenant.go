@@ -0,0 +1,104 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"go-one/internal/cache"
+	"go-one/internal/model"
+	"go-one/internal/repository"
+	"go-one/util"
+	"net"
+	"os"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// TenantConfig 多租户配置
+type TenantConfig struct {
+	Header     string // 指定租户标识的请求头
+	BaseDomain string // 按子域名解析租户时的根域名（如 example.com 时 acme.example.com 解析为 acme），为空时不按子域名解析
+	DefaultID  uint   // 请求未指定租户时使用的默认租户
+}
+
+var Tenancy *TenantConfig
+
+const (
+	// tenantCacheKey 租户标识到租户信息的缓存键
+	tenantCacheKey = "tenant:slug:%s"
+	// tenantCacheTTL 租户信息缓存时间（停用租户最长在该时间后生效）
+	tenantCacheTTL = 5 * time.Minute
+)
+
+// InitTenant 初始化多租户配置（需在数据库初始化之后调用）
+func InitTenant() {
+	header := os.Getenv("TENANT_HEADER")
+	if header == "" {
+		header = "X-Tenant"
+	}
+	tenant, err := repository.NewTenantRepository(model.DB).FindBySlug(model.DefaultTenantSlug)
+	if err != nil {
+		util.Log().Panic("初始化租户失败，默认租户不存在: %v", err)
+	}
+	Tenancy = &TenantConfig{
+		Header:     header,
+		BaseDomain: strings.ToLower(strings.Trim(os.Getenv("TENANT_BASE_DOMAIN"), ".")),
+		DefaultID:  tenant.ID,
+	}
+	util.Log().Info("多租户配置初始化完成")
+}
+
+// TenantFromHost 从请求的 Host 中解析租户标识，未配置根域名或不是其一级子域名时返回空
+func TenantFromHost(host string) string {
+	if Tenancy.BaseDomain == "" {
+		return ""
+	}
+	if h, _, err := net.SplitHostPort(host); err == nil {
+		host = h
+	}
+	slug, ok := strings.CutSuffix(strings.ToLower(host), "."+Tenancy.BaseDomain)
+	if !ok || slug == "" || strings.Contains(slug, ".") {
+		return ""
+	}
+	return slug
+}
+
+// TenantService 租户服务
+type TenantService struct {
+	tenantRepo repository.TenantRepository
+}
+
+// NewTenantService 创建租户服务实例
+func NewTenantService(tenantRepo repository.TenantRepository) *TenantService {
+	return &TenantService{tenantRepo: tenantRepo}
+}
+
+// Resolve 按标识解析租户（Redis 缓存），返回租户ID；租户不存在或已停用时返回错误
+func (s *TenantService) Resolve(slug string) (uint, ServiceError) {
+	ctx := context.Background()
+	key := fmt.Sprintf(tenantCacheKey, strings.ToLower(slug))
+
+	var tenant model.Tenant
+	cached, err := cache.RedisClient.Get(ctx, key).Result()
+	if err != nil || json.Unmarshal([]byte(cached), &tenant) != nil {
+		found, err := s.tenantRepo.FindBySlug(strings.ToLower(slug))
+		if err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return 0, &NotFoundError{Message: "租户不存在"}
+			}
+			return 0, &DatabaseError{Message: "查询租户失败", Err: err}
+		}
+		tenant = *found
+		if data, err := json.Marshal(tenant); err == nil {
+			cache.RedisClient.Set(ctx, key, data, tenantCacheTTL)
+		}
+	}
+
+	if tenant.Status != model.TenantStatusActive {
+		return 0, &BusinessError{Message: "租户已停用", Code: 40306}
+	}
+	return tenant.ID, nil
+}
@@ -0,0 +1,90 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"go-one/internal/cache"
+	"go-one/internal/repository"
+	"go-one/util"
+	"strconv"
+	"time"
+)
+
+const (
+	// tokenVersionCacheKey 用户令牌版本缓存键
+	tokenVersionCacheKey = "user:token_version:%d"
+	// tokenVersionCacheTTL 令牌版本缓存时间（版本递增时主动覆盖，TTL 仅用于兜底）
+	tokenVersionCacheTTL = 10 * time.Minute
+)
+
+// TokenVersionService 用户令牌版本服务
+// 每个用户维护一个单调递增的版本号，签发 access token 时写入 ver 声明；
+// 版本号递增后，所有旧版本的 access token 立即失效
+type TokenVersionService struct {
+	userRepo  repository.UserRepository
+	tokenRepo repository.RefreshTokenRepository
+}
+
+// NewTokenVersionService 创建令牌版本服务实例
+func NewTokenVersionService(userRepo repository.UserRepository, tokenRepo repository.RefreshTokenRepository) *TokenVersionService {
+	return &TokenVersionService{
+		userRepo:  userRepo,
+		tokenRepo: tokenRepo,
+	}
+}
+
+// Current 获取用户当前的令牌版本（优先读取 Redis 缓存）
+func (s *TokenVersionService) Current(userID uint) (int, error) {
+	ctx := context.Background()
+	key := fmt.Sprintf(tokenVersionCacheKey, userID)
+
+	if cached, err := cache.RedisClient.Get(ctx, key).Result(); err == nil {
+		if version, err := strconv.Atoi(cached); err == nil {
+			return version, nil
+		}
+	}
+
+	user, err := s.userRepo.FindByID(userID)
+	if err != nil {
+		return 0, err
+	}
+	// 使用 SetNX：若期间版本已被递增并写入缓存，不覆盖为旧值
+	cache.RedisClient.SetNX(ctx, key, user.TokenVersion, tokenVersionCacheTTL)
+	return user.TokenVersion, nil
+}
+
+// Verify 校验 access token 中的版本号是否仍为用户当前版本
+func (s *TokenVersionService) Verify(claims *JWTClaims) ServiceError {
+	uid64, err := strconv.ParseUint(claims.UserID, 10, 64)
+	if err != nil || uid64 == 0 {
+		return &AuthError{Message: "无效的用户ID"}
+	}
+	current, err := s.Current(uint(uid64))
+	if err != nil {
+		return &AuthError{Message: "用户不存在", Err: err}
+	}
+	if claims.TokenVersion != current {
+		return &AuthError{Message: "认证令牌已失效，请重新登录"}
+	}
+	return nil
+}
+
+// InvalidateUserTokens 递增用户令牌版本并撤销其全部刷新令牌，使该用户所有已签发令牌立即失效
+func (s *TokenVersionService) InvalidateUserTokens(userID uint) error {
+	version, err := s.userRepo.IncrementTokenVersion(userID)
+	if err != nil {
+		return err
+	}
+	key := fmt.Sprintf(tokenVersionCacheKey, userID)
+	if err := cache.RedisClient.Set(context.Background(), key, version, tokenVersionCacheTTL).Err(); err != nil {
+		// 缓存写入失败时删除旧缓存，避免旧版本继续生效
+		util.Log().Warning("写入令牌版本缓存失败 user_id=%d: %v", userID, err)
+		cache.RedisClient.Del(context.Background(), key)
+	}
+
+	if _, err := s.tokenRepo.RevokeAllByUser(userID, ""); err != nil {
+		return err
+	}
+	util.Log().Info("用户令牌已全部失效 user_id=%d token_version=%d", userID, version)
+	return nil
+}
@@ -1,45 +1,74 @@
 package service
 
 import (
-    "go-one/internal/model"
-    "go-one/internal/repository"
-    "strconv"
-    "strings"
-    "time"
-
-    "github.com/google/uuid"
-    "golang.org/x/crypto/bcrypt"
+	"errors"
+	"go-one/internal/model"
+	"go-one/internal/repository"
+	"go-one/util"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
 )
 
 // UserService 用户服务
 type UserService struct {
-    userRepo repository.UserRepository
-    tokenRepo repository.RefreshTokenRepository
+	userRepo       repository.UserRepository
+	tokenRepo      repository.RefreshTokenRepository
+	inviteRepo     repository.InviteRepository
+	consents       *ConsentService
+	tokenVersions  *TokenVersionService
+	emailVerifier  *EmailVerificationService
+	loginGuard     *LoginGuard
+	mailer         Mailer
+	hasher         PasswordHasher
+	passwordPolicy *PasswordPolicy
+	registration   *RegistrationPolicy
+	securityEvents *SecurityEventService
 }
 
 // NewUserService 创建用户服务实例
-func NewUserService(userRepo repository.UserRepository, tokenRepo repository.RefreshTokenRepository) *UserService {
-    return &UserService{
-        userRepo:  userRepo,
-        tokenRepo: tokenRepo,
-    }
+func NewUserService(userRepo repository.UserRepository, tokenRepo repository.RefreshTokenRepository,
+	inviteRepo repository.InviteRepository, consentRepo repository.ConsentRepository, eventRepo repository.SecurityEventRepository) *UserService {
+	return &UserService{
+		userRepo:       userRepo,
+		tokenRepo:      tokenRepo,
+		inviteRepo:     inviteRepo,
+		consents:       NewConsentService(consentRepo),
+		tokenVersions:  NewTokenVersionService(userRepo, tokenRepo),
+		emailVerifier:  NewEmailVerificationService(userRepo, Mail),
+		loginGuard:     NewLoginGuard(LoginProtection),
+		mailer:         Mail,
+		hasher:         Passwords,
+		passwordPolicy: PasswordRules,
+		registration:   NewRegistrationPolicy(Registration, inviteRepo),
+		securityEvents: NewSecurityEventService(eventRepo, userRepo, SecurityNotify),
+	}
 }
 
 // RegisterDTO 注册请求DTO
 type RegisterDTO struct {
-	Username string
-	Email    string
-	Password string
+	Username   string
+	Email      string
+	Password   string
+	InviteCode string // 邀请注册模式下必填
+	// 同意的协议版本ID，须覆盖全部当前生效的协议版本
+	AcceptedPolicyVersions []uint
 }
 
 // RegisterResult 注册结果
+// VerificationRequired 为 true 时不签发令牌，需先完成邮箱验证再登录
 type RegisterResult struct {
-	User         *model.User
-	AccessToken  string
-	RefreshToken string
+	User                 *model.User
+	AccessToken          string
+	RefreshToken         string
+	VerificationRequired bool
 }
 
 // Register 用户注册
+// 注册前按当前注册策略校验（关闭注册、邀请码、邮箱域名白名单）并要求同意当前协议版本，被拒绝时返回 RegistrationError
 func (s *UserService) Register(ctx *BusinessContext, dto *RegisterDTO) (*RegisterResult, ServiceError) {
 	// 参数验证
 	if dto.Username == "" || len(dto.Username) < 3 {
@@ -48,12 +77,26 @@ func (s *UserService) Register(ctx *BusinessContext, dto *RegisterDTO) (*Registe
 			Code:    40000,
 		}
 	}
-	if dto.Password == "" || len(dto.Password) < 6 {
+	dto.Email = strings.TrimSpace(dto.Email)
+	// 域名白名单模式下注册邮箱即准入凭证，必须验证归属后才能激活
+	verificationRequired := Email.VerificationRequired || s.registration.RequiresVerifiedEmail()
+	if verificationRequired && dto.Email == "" {
 		return nil, &ValidationError{
-			Message: "密码长度至少为6个字符",
+			Message: "邮箱不能为空",
 			Code:    40000,
 		}
 	}
+	invite, serviceErr := s.registration.Check(dto.Email, dto.InviteCode)
+	if serviceErr != nil {
+		return nil, serviceErr
+	}
+	policies, serviceErr := s.consents.requireCurrent(dto.AcceptedPolicyVersions)
+	if serviceErr != nil {
+		return nil, serviceErr
+	}
+	if serviceErr := s.passwordPolicy.Check(dto.Password, dto.Username, dto.Email); serviceErr != nil {
+		return nil, serviceErr
+	}
 
 	// 检查用户名是否已存在
 	if _, err := s.userRepo.FindByUsername(dto.Username); err == nil {
@@ -63,9 +106,9 @@ func (s *UserService) Register(ctx *BusinessContext, dto *RegisterDTO) (*Registe
 		}
 	}
 
-	// 检查邮箱是否已存在
+	// 检查邮箱是否已被验证占用（未验证的邮箱不阻止注册，避免被抢注）
 	if dto.Email != "" {
-		if _, err := s.userRepo.FindByEmail(dto.Email); err == nil {
+		if _, err := s.userRepo.FindByVerifiedEmail(dto.Email); err == nil {
 			return nil, &BusinessError{
 				Message: "邮箱已被使用",
 				Code:    40009,
@@ -74,7 +117,7 @@ func (s *UserService) Register(ctx *BusinessContext, dto *RegisterDTO) (*Registe
 	}
 
 	// 密码加密
-	hashedPassword, err := bcrypt.GenerateFromPassword([]byte(dto.Password), bcrypt.DefaultCost)
+	hashedPassword, err := s.hasher.Hash(dto.Password)
 	if err != nil {
 		return nil, &DatabaseError{
 			Message: "密码加密失败",
@@ -85,37 +128,52 @@ func (s *UserService) Register(ctx *BusinessContext, dto *RegisterDTO) (*Registe
 	user := &model.User{
 		Username: dto.Username,
 		Email:    dto.Email,
-		Password: string(hashedPassword),
+		Password: hashedPassword,
 		Nickname: dto.Username,
-		Status:   1,
+		Status:   model.UserStatusActive,
+	}
+	// 要求邮箱验证时，验证通过前账号处于待激活状态
+	if verificationRequired {
+		user.Status = model.UserStatusPending
 	}
 
-	if err := s.userRepo.Create(user); err != nil {
+	if invite != nil {
+		// 邀请码在创建用户的同一事务中占用，并发注册不会超出可使用次数
+		if err := s.inviteRepo.CreateUserWithInvite(user, invite.ID); err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return nil, &RegistrationError{Message: "邀请码已失效", Code: 40315, Reason: RegistrationRejectInviteExhausted}
+			}
+			return nil, &DatabaseError{
+				Message: "创建用户失败",
+				Err:     err,
+			}
+		}
+		util.Log().Info("用户通过邀请码注册 user_id=%d invite_id=%d", user.ID, invite.ID)
+	} else if err := s.userRepo.Create(user); err != nil {
 		return nil, &DatabaseError{
 			Message: "创建用户失败",
 			Err:     err,
 		}
 	}
 
-    // 生成访问令牌
-    userIDStr := strconv.FormatUint(uint64(user.ID), 10)
-    accessToken, err := GenerateAccessToken(userIDStr)
-    if err != nil {
-        return nil, &BusinessError{
-            Message: "生成令牌失败",
-            Code:    50000,
-            Err:     err,
-        }
-    }
-    // 生成并持久化刷新令牌（旋转起点，无上游JTI）
-    jti := uuid.NewString()
-    if err := s.tokenRepo.Create(jti, user.ID, time.Now().Add(JWT.RefreshTokenExpire), ""); err != nil {
-        return nil, &DatabaseError{Message: "保存刷新令牌失败", Err: err}
-    }
-    refreshToken, err := GenerateRefreshToken(userIDStr, jti)
-    if err != nil {
-        return nil, &BusinessError{Message: "生成刷新令牌失败", Code: 50000, Err: err}
-    }
+	// 记录协议同意（失败不影响注册，登录后会被要求重新同意）
+	if serviceErr := s.consents.record(ctx, user.ID, policies); serviceErr != nil {
+		util.Log().Error("保存注册协议同意记录失败 user_id=%d: %v", user.ID, serviceErr)
+	}
+
+	// 发送验证邮件（失败不影响注册，用户可稍后重发）
+	if serviceErr := s.emailVerifier.SendVerification(ctx, user); serviceErr != nil {
+		util.Log().Error("发送验证邮件失败 user_id=%d: %v", user.ID, serviceErr)
+	}
+	if verificationRequired {
+		return &RegisterResult{User: user, VerificationRequired: true}, nil
+	}
+
+	// 生成令牌对（旋转起点，无上游JTI）
+	accessToken, refreshToken, serviceErr := s.issueTokenPair(ctx, s.tokenRepo, user, nil)
+	if serviceErr != nil {
+		return nil, serviceErr
+	}
 
 	return &RegisterResult{
 		User:         user,
@@ -126,15 +184,20 @@ func (s *UserService) Register(ctx *BusinessContext, dto *RegisterDTO) (*Registe
 
 // LoginDTO 登录请求DTO
 type LoginDTO struct {
-	Username string
-	Password string
+	Username           string
+	Password           string
+	TrustedDeviceToken string // “记住此设备”令牌，有效时跳过二次验证
 }
 
 // LoginResult 登录结果
+// MFARequired 为 true 时仅返回 MFAToken，需调用二次验证接口换取令牌对
 type LoginResult struct {
-	User         *model.User
-	AccessToken  string
-	RefreshToken string
+	User               *model.User
+	AccessToken        string
+	RefreshToken       string
+	MFARequired        bool
+	MFAToken           string
+	TrustedDeviceToken string
 }
 
 // Login 用户登录
@@ -156,48 +219,122 @@ func (s *UserService) Login(ctx *BusinessContext, dto *LoginDTO) (*LoginResult,
 		}
 	}
 
+	// 检查账号锁定与递增延迟
+	if serviceErr := s.loginGuard.Check(ctx.ClientIP, loginIdentity(ctx.TenantID, dto.Username)); serviceErr != nil {
+		return nil, serviceErr
+	}
+
 	user, err := s.userRepo.FindByUsername(dto.Username)
 	if err != nil {
+		// 用户不存在同样计入失败，避免通过锁定行为枚举用户名
+		s.recordLoginFailure(ctx, dto.Username, 0, loginFactorPassword)
 		return nil, &AuthError{
 			Message: "用户名或密码错误",
 		}
 	}
 
 	// 验证密码
-	if err := bcrypt.CompareHashAndPassword([]byte(user.Password), []byte(dto.Password)); err != nil {
+	if ok, err := s.hasher.Verify(user.Password, dto.Password); !ok {
+		if err != nil {
+			util.Log().Error("校验密码哈希失败 user_id=%d: %v", user.ID, err)
+		}
+		s.recordLoginFailure(ctx, dto.Username, user.ID, loginFactorPassword)
 		return nil, &AuthError{
 			Message: "用户名或密码错误",
 		}
 	}
+	s.rehashPassword(user, dto.Password)
 
 	// 检查用户状态
-	if user.Status != 1 {
-		return nil, &BusinessError{
-			Message: "账号已被禁用",
-			Code:    40003,
-		}
-	}
-
-    // 生成访问令牌
-    userIDStr := strconv.FormatUint(uint64(user.ID), 10)
-    accessToken, err := GenerateAccessToken(userIDStr)
-    if err != nil {
-        return nil, &BusinessError{
-            Message: "生成令牌失败",
-            Code:    50000,
-            Err:     err,
-        }
-    }
-
-    // 生成并持久化刷新令牌
-    jti := uuid.NewString()
-    if err := s.tokenRepo.Create(jti, user.ID, time.Now().Add(JWT.RefreshTokenExpire), ""); err != nil {
-        return nil, &DatabaseError{Message: "保存刷新令牌失败", Err: err}
-    }
-    refreshToken, err := GenerateRefreshToken(userIDStr, jti)
-    if err != nil {
-        return nil, &BusinessError{Message: "生成刷新令牌失败", Code: 50000, Err: err}
-    }
+	if serviceErr := checkUserStatus(user); serviceErr != nil {
+		return nil, serviceErr
+	}
+
+	return s.completeLogin(ctx, user, dto.TrustedDeviceToken)
+}
+
+// rehashPassword 存量哈希的算法或参数已过时时，用刚校验通过的明文重新哈希
+// 仅在哈希未被并发修改时写回；失败只记录日志，不影响本次登录
+func (s *UserService) rehashPassword(user *model.User, password string) {
+	if !s.hasher.NeedsRehash(user.Password) {
+		return
+	}
+	hashed, err := s.hasher.Hash(password)
+	if err != nil {
+		util.Log().Error("重新哈希密码失败 user_id=%d: %v", user.ID, err)
+		return
+	}
+	updated, err := s.userRepo.UpdatePasswordHash(user.ID, user.Password, hashed)
+	if err != nil {
+		util.Log().Error("保存升级后的密码哈希失败 user_id=%d: %v", user.ID, err)
+		return
+	}
+	if updated {
+		user.Password = hashed
+		util.Log().Info("已升级用户密码哈希 user_id=%d", user.ID)
+	}
+}
+
+// 登录失败的认证因素，写入登录失败事件详情
+const (
+	loginFactorPassword = "password"
+	loginFactorMFA      = "mfa"
+)
+
+// recordLoginFailure 记录登录失败（userID 为 0 表示用户不存在），触发锁定时记录日志并上报安全事件
+// 密码与二次验证的失败计入同一用户名维度的计数，任一因素被爆破都会锁定账号
+func (s *UserService) recordLoginFailure(ctx *BusinessContext, username string, userID uint, factor string) {
+	s.securityEvents.Record(ctx, SecurityEvent{
+		Type:   SecurityEventLoginFailure,
+		UserID: userID,
+		Detail: map[string]interface{}{"factor": factor},
+	})
+	lockedFor := s.loginGuard.RecordFailure(ctx.ClientIP, loginIdentity(ctx.TenantID, username))
+	if lockedFor <= 0 {
+		return
+	}
+	util.Log().Warning("登录失败次数过多，账号已锁定 username=%q user_id=%d ip=%s duration=%s",
+		username, userID, ctx.ClientIP, lockedFor)
+	s.securityEvents.Record(ctx, SecurityEvent{
+		Type:   SecurityEventAccountLocked,
+		UserID: userID,
+		Detail: map[string]interface{}{
+			"username": username,
+			"duration": lockedFor.String(),
+		},
+	})
+}
+
+// loginIdentity 登录防护按租户区分同名用户
+func loginIdentity(tenantID uint, username string) string {
+	return strconv.FormatUint(uint64(tenantID), 10) + ":" + username
+}
+
+// completeLogin 第一因素校验通过后完成登录
+// 已启用二次验证且不是受信任设备时返回 mfa_pending 令牌，否则直接签发令牌对
+// 登录失败计数只在签发令牌后清除，避免密码正确即清掉二次验证的失败次数
+func (s *UserService) completeLogin(ctx *BusinessContext, user *model.User, trustedDeviceToken string) (*LoginResult, ServiceError) {
+	if Email.VerificationRequired && !user.EmailVerified {
+		return nil, &BusinessError{Message: "邮箱尚未验证，请先完成邮箱验证", Code: 40301}
+	}
+	if user.MFAEnabled && !isTrustedDevice(trustedDeviceToken, user) {
+		mfaToken, err := newMFAPendingToken(user)
+		if err != nil {
+			return nil, &BusinessError{Message: "生成二次验证令牌失败", Code: 50000, Err: err}
+		}
+		return &LoginResult{
+			User:        user,
+			MFARequired: true,
+			MFAToken:    mfaToken,
+		}, nil
+	}
+
+	// 生成令牌对
+	accessToken, refreshToken, serviceErr := s.issueTokenPair(ctx, s.tokenRepo, user, nil)
+	if serviceErr != nil {
+		return nil, serviceErr
+	}
+	s.loginGuard.Reset(loginIdentity(ctx.TenantID, user.Username))
 
 	return &LoginResult{
 		User:         user,
@@ -206,19 +343,48 @@ func (s *UserService) Login(ctx *BusinessContext, dto *LoginDTO) (*LoginResult,
 	}, nil
 }
 
+// checkUserStatus 检查用户状态是否允许登录，不同状态返回不同错误码
+func checkUserStatus(user *model.User) ServiceError {
+	switch user.Status {
+	case model.UserStatusActive:
+		return nil
+	case model.UserStatusSuspended:
+		// 暂停到期后自动恢复
+		if user.SuspendedUntil != nil && time.Now().After(*user.SuspendedUntil) {
+			return nil
+		}
+		message := "账号已被暂停"
+		if user.SuspendedUntil != nil {
+			message += "，解除时间：" + user.SuspendedUntil.Format("2006-01-02 15:04:05")
+		}
+		if user.StatusReason != "" {
+			message += "，原因：" + user.StatusReason
+		}
+		return &BusinessError{Message: message, Code: 40302}
+	case model.UserStatusPending:
+		return &BusinessError{Message: "账号尚未激活，请先完成邮箱验证", Code: 40303}
+	case model.UserStatusBanned:
+		return &BusinessError{Message: "账号已被封禁", Code: 40304}
+	case model.UserStatusDeleted:
+		return &BusinessError{Message: "账号已注销", Code: 40305}
+	default:
+		return &BusinessError{Message: "账号状态异常", Code: 40003}
+	}
+}
+
 // GetUserByID 根据ID获取用户
 func (s *UserService) GetUserByID(ctx *BusinessContext) (*model.User, ServiceError) {
-    uid64, err := strconv.ParseUint(ctx.UserUUID, 10, 64)
-    if err != nil || uid64 == 0 {
-        return nil, &AuthError{Message: "无效的用户ID"}
-    }
-    user, err := s.userRepo.FindByID(uint(uid64))
-    if err != nil {
-        return nil, &NotFoundError{
-            Message: "用户不存在",
-        }
-    }
-    return user, nil
+	uid64, err := strconv.ParseUint(ctx.UserUUID, 10, 64)
+	if err != nil || uid64 == 0 {
+		return nil, &AuthError{Message: "无效的用户ID"}
+	}
+	user, err := s.userRepo.FindByID(uint(uid64))
+	if err != nil {
+		return nil, &NotFoundError{
+			Message: "用户不存在",
+		}
+	}
+	return user, nil
 }
 
 // UpdateProfileDTO 更新资料请求DTO
@@ -229,16 +395,16 @@ type UpdateProfileDTO struct {
 
 // UpdateProfile 更新用户资料
 func (s *UserService) UpdateProfile(ctx *BusinessContext, dto *UpdateProfileDTO) ServiceError {
-    uid64, err := strconv.ParseUint(ctx.UserUUID, 10, 64)
-    if err != nil || uid64 == 0 {
-        return &AuthError{Message: "无效的用户ID"}
-    }
-    user, err := s.userRepo.FindByID(uint(uid64))
-    if err != nil {
-        return &NotFoundError{
-            Message: "用户不存在",
-        }
-    }
+	uid64, err := strconv.ParseUint(ctx.UserUUID, 10, 64)
+	if err != nil || uid64 == 0 {
+		return &AuthError{Message: "无效的用户ID"}
+	}
+	user, err := s.userRepo.FindByID(uint(uid64))
+	if err != nil {
+		return &NotFoundError{
+			Message: "用户不存在",
+		}
+	}
 
 	// 只更新提供的字段
 	if dto.Nickname != "" {
@@ -266,6 +432,10 @@ type ChangePasswordDTO struct {
 
 // ChangePassword 修改密码
 func (s *UserService) ChangePassword(ctx *BusinessContext, dto *ChangePasswordDTO) ServiceError {
+	if serviceErr := denyImpersonation(ctx, "修改密码"); serviceErr != nil {
+		return serviceErr
+	}
+
 	// 参数验证
 	if dto.OldPassword == "" {
 		return &ValidationError{
@@ -273,33 +443,36 @@ func (s *UserService) ChangePassword(ctx *BusinessContext, dto *ChangePasswordDT
 			Code:    40000,
 		}
 	}
-	if dto.NewPassword == "" || len(dto.NewPassword) < 6 {
+	if dto.NewPassword == "" {
 		return &ValidationError{
-			Message: "新密码长度至少为6个字符",
+			Message: "新密码不能为空",
 			Code:    40000,
 		}
 	}
 
-    uid64, err := strconv.ParseUint(ctx.UserUUID, 10, 64)
-    if err != nil || uid64 == 0 {
-        return &AuthError{Message: "无效的用户ID"}
-    }
-    user, err := s.userRepo.FindByID(uint(uid64))
-    if err != nil {
-        return &NotFoundError{
-            Message: "用户不存在",
-        }
-    }
+	uid64, err := strconv.ParseUint(ctx.UserUUID, 10, 64)
+	if err != nil || uid64 == 0 {
+		return &AuthError{Message: "无效的用户ID"}
+	}
+	user, err := s.userRepo.FindByID(uint(uid64))
+	if err != nil {
+		return &NotFoundError{
+			Message: "用户不存在",
+		}
+	}
+	if serviceErr := s.passwordPolicy.Check(dto.NewPassword, user.Username, user.Email); serviceErr != nil {
+		return serviceErr
+	}
 
 	// 验证旧密码
-	if err := bcrypt.CompareHashAndPassword([]byte(user.Password), []byte(dto.OldPassword)); err != nil {
+	if ok, _ := s.hasher.Verify(user.Password, dto.OldPassword); !ok {
 		return &AuthError{
 			Message: "原密码错误",
 		}
 	}
 
 	// 加密新密码
-	hashedPassword, err := bcrypt.GenerateFromPassword([]byte(dto.NewPassword), bcrypt.DefaultCost)
+	hashedPassword, err := s.hasher.Hash(dto.NewPassword)
 	if err != nil {
 		return &DatabaseError{
 			Message: "密码加密失败",
@@ -307,7 +480,7 @@ func (s *UserService) ChangePassword(ctx *BusinessContext, dto *ChangePasswordDT
 		}
 	}
 
-	user.Password = string(hashedPassword)
+	user.Password = hashedPassword
 	if err := s.userRepo.Update(user); err != nil {
 		return &DatabaseError{
 			Message: "更新密码失败",
@@ -315,6 +488,41 @@ func (s *UserService) ChangePassword(ctx *BusinessContext, dto *ChangePasswordDT
 		}
 	}
 
+	// 密码变更后使该用户所有已签发的令牌失效
+	if err := s.tokenVersions.InvalidateUserTokens(user.ID); err != nil {
+		return &DatabaseError{
+			Message: "注销已登录会话失败",
+			Err:     err,
+		}
+	}
+
+	s.securityEvents.Record(ctx, SecurityEvent{
+		Type:   SecurityEventPasswordChange,
+		UserID: user.ID,
+		Detail: map[string]interface{}{"method": "change"},
+	})
+	return nil
+}
+
+// UnlockUser 解除用户的登录锁定（管理操作）
+func (s *UserService) UnlockUser(ctx *BusinessContext, userID uint) ServiceError {
+	if serviceErr := requirePermission(ctx, model.PermissionUsersManage); serviceErr != nil {
+		return serviceErr
+	}
+	user, err := s.userRepo.FindByID(userID)
+	if err != nil {
+		return &NotFoundError{
+			Message: "用户不存在",
+		}
+	}
+	if err := s.loginGuard.Unlock(loginIdentity(user.TenantID, user.Username)); err != nil {
+		return &ExternalAPIError{
+			Message: "解除登录锁定失败",
+			Err:     err,
+		}
+	}
+
+	util.Log().Info("用户登录锁定已解除 user_id=%d operator=%s", user.ID, ctx.UserUUID)
 	return nil
 }
 
@@ -334,6 +542,10 @@ type ListUsersResult struct {
 
 // ListUsers 获取用户列表
 func (s *UserService) ListUsers(ctx *BusinessContext, query *ListUsersQuery) (*ListUsersResult, ServiceError) {
+	if serviceErr := requirePermission(ctx, model.PermissionUsersList); serviceErr != nil {
+		return nil, serviceErr
+	}
+
 	// 参数校验和默认值
 	if query.Page < 1 {
 		query.Page = 1
@@ -359,14 +571,17 @@ func (s *UserService) ListUsers(ctx *BusinessContext, query *ListUsersQuery) (*L
 }
 
 // RefreshTokenDTO 刷新令牌请求DTO
+// ClientID 为已认证的 OAuth 应用（/oauth/token），自有登录的刷新为空；令牌必须属于该应用
 type RefreshTokenDTO struct {
 	RefreshToken string
+	ClientID     string
 }
 
 // RefreshTokenResult 刷新令牌结果
 type RefreshTokenResult struct {
-    AccessToken  string
-    RefreshToken string
+	AccessToken  string
+	RefreshToken string
+	Scope        string
 }
 
 // RefreshToken 刷新访问令牌
@@ -379,33 +594,28 @@ func (s *UserService) RefreshToken(ctx *BusinessContext, dto *RefreshTokenDTO) (
 		}
 	}
 
-    // 验证refresh token 签名与类型
-    claims, err := ValidateRefreshToken(dto.RefreshToken)
-    if err != nil {
-        return nil, &AuthError{
-            Message: "刷新令牌无效或已过期",
-        }
-    }
-
-    // 校验JTI是否存在、未撤销且未过期（持久化校验）
-    if claims.JTI == "" {
-        return nil, &AuthError{Message: "无效的刷新令牌标识"}
-    }
-    record, recErr := s.tokenRepo.FindByJTI(claims.JTI)
-    if recErr != nil || record == nil {
-        return nil, &AuthError{Message: "刷新令牌不存在或已撤销"}
-    }
-    if record.Revoked || time.Now().After(record.ExpiresAt) {
-        return nil, &AuthError{Message: "刷新令牌已失效"}
-    }
-
-    // 获取用户信息
-    userID, err := strconv.ParseUint(claims.UserID, 10, 64)
-    if err != nil {
-        return nil, &AuthError{
-            Message: "无效的用户ID",
-        }
-    }
+	// 验证refresh token 签名与类型
+	claims, err := ValidateRefreshToken(dto.RefreshToken)
+	if err != nil {
+		return nil, &AuthError{
+			Message: "刷新令牌无效或已过期",
+		}
+	}
+	if claims.Tenant() != ctx.TenantID {
+		return nil, &AuthError{Message: "刷新令牌不属于当前租户"}
+	}
+
+	if claims.JTI == "" {
+		return nil, &AuthError{Message: "无效的刷新令牌标识"}
+	}
+
+	// 获取用户信息
+	userID, err := strconv.ParseUint(claims.UserID, 10, 64)
+	if err != nil {
+		return nil, &AuthError{
+			Message: "无效的用户ID",
+		}
+	}
 
 	user, err := s.userRepo.FindByID(uint(userID))
 	if err != nil {
@@ -415,56 +625,230 @@ func (s *UserService) RefreshToken(ctx *BusinessContext, dto *RefreshTokenDTO) (
 	}
 
 	// 检查用户状态
-	if user.Status != 1 {
-		return nil, &BusinessError{
-			Message: "账号已被禁用",
-			Code:    40003,
+	if serviceErr := checkUserStatus(user); serviceErr != nil {
+		return nil, serviceErr
+	}
+
+	// 令牌版本已递增（如修改密码）则拒绝旋转
+	if claims.TokenVersion != user.TokenVersion {
+		return nil, &AuthError{Message: "刷新令牌已失效"}
+	}
+
+	// 在事务中对当前JTI加行锁后完成校验与旋转，避免同一令牌被并发刷新两次
+	var (
+		result      *RefreshTokenResult
+		sessionID   string
+		reused      bool
+		familySize  int
+		revokedSize int64
+	)
+	txErr := s.tokenRepo.Transaction(func(repo repository.RefreshTokenRepository) error {
+		record, err := repo.FindByJTIForUpdate(claims.JTI)
+		if err != nil || record == nil || record.UserID != user.ID || record.ClientID != dto.ClientID {
+			return &AuthError{Message: "刷新令牌不存在或已撤销"}
+		}
+		// 绑定 DPoP 的刷新令牌必须附带同一密钥的证明；未绑定的会话出示证明后，新令牌自此绑定该密钥
+		if record.DPoPKey != "" && record.DPoPKey != ctx.DPoPKey {
+			return &AuthError{Message: "缺少 DPoP 证明或与刷新令牌绑定的密钥不一致"}
+		}
+
+		// 已撤销的令牌再次出现：视为令牌泄露，撤销其后的整条旋转链
+		if record.Revoked {
+			family, err := repo.FindFamily(record.JTI)
+			if err != nil {
+				return &DatabaseError{Message: "查询令牌家族失败", Err: err}
+			}
+			revoked, err := repo.RevokeFamily(record.JTI)
+			if err != nil {
+				return &DatabaseError{Message: "撤销令牌家族失败", Err: err}
+			}
+			reused, familySize, revokedSize = true, len(family), revoked
+			// 返回 nil 以提交撤销结果
+			return nil
+		}
+		if time.Now().After(record.ExpiresAt) {
+			return &AuthError{Message: "刷新令牌已失效"}
+		}
+
+		// 撤销当前refresh token并旋转生成新的令牌对
+		if err := repo.RevokeByJTI(record.JTI); err != nil {
+			return &DatabaseError{Message: "撤销刷新令牌失败", Err: err}
 		}
+		accessToken, refreshToken, serviceErr := s.issueTokenPair(ctx, repo, user, record)
+		if serviceErr != nil {
+			return serviceErr
+		}
+		result = &RefreshTokenResult{
+			AccessToken:  accessToken,
+			RefreshToken: refreshToken,
+			Scope:        record.Scope,
+		}
+		sessionID = record.SessionID
+		return nil
+	})
+	if txErr != nil {
+		if serviceErr, ok := txErr.(ServiceError); ok {
+			return nil, serviceErr
+		}
+		return nil, &DatabaseError{Message: "刷新令牌失败", Err: txErr}
 	}
 
-    // 撤销当前refresh token并旋转生成新的refresh token
-    _ = s.tokenRepo.RevokeByJTI(claims.JTI)
-    newJTI := uuid.NewString()
-    if err := s.tokenRepo.Create(newJTI, user.ID, time.Now().Add(JWT.RefreshTokenExpire), claims.JTI); err != nil {
-        return nil, &DatabaseError{Message: "保存新刷新令牌失败", Err: err}
-    }
+	if reused {
+		s.securityEvents.Record(ctx, SecurityEvent{
+			Type:   SecurityEventRefreshTokenReuse,
+			UserID: user.ID,
+			Detail: map[string]interface{}{
+				"jti":         claims.JTI,
+				"family_size": familySize,
+				"revoked":     revokedSize,
+			},
+		})
+		return nil, &AuthError{Message: "刷新令牌已被使用，相关登录会话已全部失效"}
+	}
 
-    // 下发新的访问令牌与刷新令牌
-    accessToken, err := GenerateAccessToken(strconv.FormatUint(uint64(user.ID), 10))
-    if err != nil {
-        return nil, &BusinessError{Message: "生成访问令牌失败", Code: 50000, Err: err}
-    }
-    refreshToken, err := GenerateRefreshToken(strconv.FormatUint(uint64(user.ID), 10), newJTI)
-    if err != nil {
-        return nil, &BusinessError{Message: "生成刷新令牌失败", Code: 50000, Err: err}
-    }
+	detail := map[string]interface{}{"session_id": sessionID}
+	if dto.ClientID != "" {
+		detail["client_id"] = dto.ClientID
+	}
+	s.securityEvents.Record(ctx, SecurityEvent{Type: SecurityEventTokenRefresh, UserID: user.ID, Detail: detail})
+	return result, nil
+}
 
-	return &RefreshTokenResult{
-		AccessToken:  accessToken,
-		RefreshToken: refreshToken,
-	}, nil
+// issueTokenPair 生成访问令牌，并通过 repo 持久化新的刷新令牌
+// parent 为被旋转的上游刷新令牌；为 nil 时表示登录/注册新建会话，记录登录成功事件
+func (s *UserService) issueTokenPair(ctx *BusinessContext, repo repository.RefreshTokenRepository, user *model.User, parent *model.RefreshToken) (string, string, ServiceError) {
+	now := time.Now()
+	record := &model.RefreshToken{
+		JTI:             uuid.NewString(),
+		UserID:          user.ID,
+		SessionID:       uuid.NewString(),
+		ExpiresAt:       now.Add(JWT.RefreshTokenExpire),
+		DeviceName:      ctx.DeviceName,
+		ClientIP:        ctx.ClientIP,
+		UserAgent:       ctx.UserAgent,
+		LastUsedAt:      now,
+		AuthenticatedAt: now,
+		DPoPKey:         ctx.DPoPKey,
+	}
+	if parent != nil {
+		// 旋转沿用原会话的标识、设备名与认证时间
+		record.RotatedFrom = parent.JTI
+		if parent.SessionID != "" {
+			record.SessionID = parent.SessionID
+		}
+		if parent.DeviceName != "" {
+			record.DeviceName = parent.DeviceName
+		}
+		if !parent.AuthenticatedAt.IsZero() {
+			record.AuthenticatedAt = parent.AuthenticatedAt
+		} else {
+			record.AuthenticatedAt = parent.CreatedAt
+		}
+		// OAuth 应用的授权范围随旋转沿用
+		record.ClientID = parent.ClientID
+		record.Scope = parent.Scope
+	} else if err := evictExcessSessions(repo, user.ID); err != nil {
+		return "", "", &DatabaseError{Message: "清理超额会话失败", Err: err}
+	}
+
+	accessToken, refreshToken, serviceErr := s.signTokenPair(repo, user, record)
+	if serviceErr != nil {
+		return "", "", serviceErr
+	}
+	if parent == nil {
+		s.securityEvents.RecordLogin(ctx, user, record.SessionID)
+	}
+	return accessToken, refreshToken, nil
+}
+
+// issueClientTokenPair 为 OAuth 应用新建授权会话并签发令牌对，会话中记录应用与授权范围
+func (s *UserService) issueClientTokenPair(ctx *BusinessContext, user *model.User, client *model.OAuthClient, scope string) (string, string, ServiceError) {
+	if err := evictExcessSessions(s.tokenRepo, user.ID); err != nil {
+		return "", "", &DatabaseError{Message: "清理超额会话失败", Err: err}
+	}
+	now := time.Now()
+	return s.signTokenPair(s.tokenRepo, user, &model.RefreshToken{
+		JTI:             uuid.NewString(),
+		UserID:          user.ID,
+		SessionID:       uuid.NewString(),
+		ExpiresAt:       now.Add(JWT.RefreshTokenExpire),
+		DeviceName:      client.Name,
+		ClientIP:        ctx.ClientIP,
+		UserAgent:       ctx.UserAgent,
+		LastUsedAt:      now,
+		AuthenticatedAt: now,
+		ClientID:        client.ClientID,
+		Scope:           scope,
+		DPoPKey:         ctx.DPoPKey,
+	})
+}
+
+// signTokenPair 持久化刷新令牌记录并签发对应的令牌对
+// 签发给 OAuth 应用的访问令牌额外携带 client_id、scope 与 jti（供吊销与内省）
+func (s *UserService) signTokenPair(repo repository.RefreshTokenRepository, user *model.User, record *model.RefreshToken) (string, string, ServiceError) {
+	userIDStr := strconv.FormatUint(uint64(user.ID), 10)
+	accessClaims := JWTClaims{
+		UserID:       userIDStr,
+		TenantID:     user.TenantID,
+		SessionID:    record.SessionID,
+		TokenVersion: user.TokenVersion,
+		ClientID:     record.ClientID,
+		Scope:        record.Scope,
+		Confirmation: newConfirmation(record.DPoPKey),
+	}
+	if record.ClientID != "" {
+		accessClaims.JTI = uuid.NewString()
+	}
+	accessToken, err := GenerateAccessToken(accessClaims)
+	if err != nil {
+		return "", "", &BusinessError{Message: "生成访问令牌失败", Code: 50000, Err: err}
+	}
+
+	if err := repo.Create(record); err != nil {
+		return "", "", &DatabaseError{Message: "保存刷新令牌失败", Err: err}
+	}
+	refreshToken, err := GenerateRefreshToken(JWTClaims{
+		UserID:       userIDStr,
+		TenantID:     user.TenantID,
+		JTI:          record.JTI,
+		SessionID:    record.SessionID,
+		TokenVersion: user.TokenVersion,
+		ClientID:     record.ClientID,
+		Confirmation: newConfirmation(record.DPoPKey),
+	})
+	if err != nil {
+		return "", "", &BusinessError{Message: "生成刷新令牌失败", Code: 50000, Err: err}
+	}
+	return accessToken, refreshToken, nil
 }
 
 // LogoutDTO 登出请求DTO（撤销刷新令牌）
 type LogoutDTO struct {
-    RefreshToken string
+	RefreshToken string
 }
 
 // Logout 撤销刷新令牌（登出）
 func (s *UserService) Logout(ctx *BusinessContext, dto *LogoutDTO) ServiceError {
-    if dto.RefreshToken == "" {
-        return &ValidationError{Message: "刷新令牌不能为空", Code: 40000}
-    }
-    claims, err := ValidateRefreshToken(dto.RefreshToken)
-    if err != nil {
-        return &AuthError{Message: "刷新令牌无效或已过期"}
-    }
-    if claims.JTI == "" {
-        return &AuthError{Message: "无效的刷新令牌标识"}
-    }
-    // 标记撤销（幂等）
-    if err := s.tokenRepo.RevokeByJTI(claims.JTI); err != nil {
-        return &DatabaseError{Message: "撤销刷新令牌失败", Err: err}
-    }
-    return nil
+	if dto.RefreshToken == "" {
+		return &ValidationError{Message: "刷新令牌不能为空", Code: 40000}
+	}
+	claims, err := ValidateRefreshToken(dto.RefreshToken)
+	if err != nil {
+		return &AuthError{Message: "刷新令牌无效或已过期"}
+	}
+	if claims.JTI == "" {
+		return &AuthError{Message: "无效的刷新令牌标识"}
+	}
+	// 标记撤销（幂等）
+	if err := s.tokenRepo.RevokeByJTI(claims.JTI); err != nil {
+		return &DatabaseError{Message: "撤销刷新令牌失败", Err: err}
+	}
+	if userID, err := strconv.ParseUint(claims.UserID, 10, 64); err == nil {
+		s.securityEvents.Record(ctx, SecurityEvent{
+			Type:   SecurityEventLogout,
+			UserID: uint(userID),
+			Detail: map[string]interface{}{"session_id": claims.SessionID},
+		})
+	}
+	return nil
 }
@@ -0,0 +1,72 @@
+package util
+
+import (
+	"bytes"
+	"encoding/base64"
+	"testing"
+)
+
+func TestAESGCMRoundTrip(t *testing.T) {
+	tests := []struct {
+		name      string
+		keySize   int
+		plaintext string
+	}{
+		{name: "AES-128", keySize: 16, plaintext: "JBSWY3DPEHPK3PXP"},
+		{name: "AES-192", keySize: 24, plaintext: "二次验证密钥"},
+		{name: "AES-256", keySize: 32, plaintext: ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			key := bytes.Repeat([]byte{0x42}, tt.keySize)
+			first, err := EncryptAESGCM(key, tt.plaintext)
+			if err != nil {
+				t.Fatalf("EncryptAESGCM: %v", err)
+			}
+			second, err := EncryptAESGCM(key, tt.plaintext)
+			if err != nil {
+				t.Fatalf("EncryptAESGCM: %v", err)
+			}
+			if first == second {
+				t.Error("相同明文两次加密应使用不同的 nonce")
+			}
+			got, err := DecryptAESGCM(key, first)
+			if err != nil {
+				t.Fatalf("DecryptAESGCM: %v", err)
+			}
+			if got != tt.plaintext {
+				t.Errorf("DecryptAESGCM = %q, want %q", got, tt.plaintext)
+			}
+		})
+	}
+}
+
+func TestDecryptAESGCMRejects(t *testing.T) {
+	key := bytes.Repeat([]byte{0x42}, 32)
+	encrypted, err := EncryptAESGCM(key, "secret")
+	if err != nil {
+		t.Fatalf("EncryptAESGCM: %v", err)
+	}
+	raw, _ := base64.StdEncoding.DecodeString(encrypted)
+	raw[len(raw)-1] ^= 0x01
+	tampered := base64.StdEncoding.EncodeToString(raw)
+
+	tests := []struct {
+		name    string
+		key     []byte
+		encoded string
+	}{
+		{name: "密钥错误", key: bytes.Repeat([]byte{0x24}, 32), encoded: encrypted},
+		{name: "密文被篡改", key: key, encoded: tampered},
+		{name: "密文过短", key: key, encoded: base64.StdEncoding.EncodeToString([]byte("short"))},
+		{name: "非 base64", key: key, encoded: "%%%"},
+		{name: "密钥长度非法", key: []byte("short"), encoded: encrypted},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := DecryptAESGCM(tt.key, tt.encoded); err == nil {
+				t.Error("应解密失败")
+			}
+		})
+	}
+}
@@ -0,0 +1,64 @@
+package util
+
+import (
+	"testing"
+	"time"
+)
+
+// rfc6238Secret RFC 6238 附录 B 的 SHA1 测试密钥 "12345678901234567890"（Base32）
+const rfc6238Secret = "GEZDGNBVGY3TQOJQGEZDGNBVGY3TQOJQ"
+
+func TestTOTPCodeRFC6238(t *testing.T) {
+	// RFC 6238 附录 B 的 8 位验证码取后 6 位
+	tests := []struct {
+		unix int64
+		want string
+	}{
+		{unix: 59, want: "287082"},
+		{unix: 1111111109, want: "081804"},
+		{unix: 1111111111, want: "050471"},
+		{unix: 1234567890, want: "005924"},
+		{unix: 2000000000, want: "279037"},
+		{unix: 20000000000, want: "353130"},
+	}
+	for _, tt := range tests {
+		got, err := TOTPCode(rfc6238Secret, tt.unix/TOTPPeriod)
+		if err != nil {
+			t.Fatalf("TOTPCode(%d): %v", tt.unix, err)
+		}
+		if got != tt.want {
+			t.Errorf("TOTPCode(%d) = %s, want %s", tt.unix, got, tt.want)
+		}
+	}
+}
+
+func TestValidateTOTP(t *testing.T) {
+	at := time.Unix(1111111111, 0)
+	step := at.Unix() / TOTPPeriod
+	prev, _ := TOTPCode(rfc6238Secret, step-1)
+	next2, _ := TOTPCode(rfc6238Secret, step+2)
+
+	tests := []struct {
+		name     string
+		secret   string
+		code     string
+		wantStep int64
+		wantOK   bool
+	}{
+		{name: "当前时间步", secret: rfc6238Secret, code: "050471", wantStep: step, wantOK: true},
+		{name: "小写密钥与空白", secret: " gezdgnbvgy3tqojqgezdgnbvgy3tqojq ", code: " 050471 ", wantStep: step, wantOK: true},
+		{name: "允许的时钟偏差", secret: rfc6238Secret, code: prev, wantStep: step - 1, wantOK: true},
+		{name: "超出时钟偏差", secret: rfc6238Secret, code: next2},
+		{name: "错误验证码", secret: rfc6238Secret, code: "000000"},
+		{name: "位数不符", secret: rfc6238Secret, code: "50471"},
+		{name: "无效密钥", secret: "not base32!", code: "050471"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			gotStep, ok := ValidateTOTP(tt.secret, tt.code, at, 1)
+			if ok != tt.wantOK || gotStep != tt.wantStep {
+				t.Errorf("ValidateTOTP = (%d, %v), want (%d, %v)", gotStep, ok, tt.wantStep, tt.wantOK)
+			}
+		})
+	}
+}